@@ -0,0 +1,585 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	bsm "github.com/tpltnt/go-bsm"
+	"github.com/tpltnt/go-bsm/decode"
+	"github.com/tpltnt/go-bsm/filter"
+	"github.com/tpltnt/go-bsm/output"
+	"github.com/tpltnt/go-bsm/output/auditbeat"
+	"github.com/tpltnt/go-bsm/output/compact"
+	"github.com/tpltnt/go-bsm/spool"
+	"github.com/tpltnt/go-bsm/token"
+)
+
+// source is a trail read by the agent.
+type source interface {
+	Next() (decode.BsmRecord, error)
+	Offset() int64 // offset of the next record
+	Close() error
+}
+
+// fileSource reads a trail once.
+type fileSource struct {
+	file    *os.File
+	start   int64
+	decoder *decode.Decoder
+}
+
+func (src *fileSource) Next() (decode.BsmRecord, error) {
+	return src.decoder.Decode()
+}
+
+func (src *fileSource) Offset() int64 {
+	return src.start + int64(src.decoder.Stats().BytesRead)
+}
+
+func (src *fileSource) Close() error {
+	return src.file.Close()
+}
+
+// tailSource follows a trail until the agent is stopped.
+type tailSource struct {
+	*bsm.TailSource
+	file *os.File
+}
+
+func (src tailSource) Close() error {
+	src.TailSource.Close()
+	return src.file.Close()
+}
+
+// Agent is a pipeline reading records from trails and writing those
+// passing the filter to all sinks.
+type Agent struct {
+	config     *Config
+	dialect    token.Dialect
+	transforms []func(*decode.BsmRecord) error
+	keep       filter.Filter
+	sinks      []output.Sink
+	closers    []io.Closer
+	dlq        *spool.DeadLetterQueue
+	latency    bsm.LatencyStats // see the latency enrichment
+	users      *bsm.UserCache   // see the privilege enrichment
+	dedupe     *filter.Deduper  // see FilterConfig.Dedupe
+
+	mutex   sync.Mutex
+	offsets map[string]int64 // checkpointed offsets by source path
+	count   int              // records since the last checkpoint
+	saving  sync.Mutex       // serializes writing the checkpoint
+}
+
+// FromConfig returns an agent built from the given YAML configuration
+// (see Config). The sinks are opened right away, the sources by Run.
+func FromConfig(data []byte) (*Agent, error) {
+	config, err := ParseConfig(data)
+	if err != nil {
+		return nil, err
+	}
+	return New(config)
+}
+
+// New returns an agent built from the given configuration.
+func New(config *Config) (*Agent, error) {
+	dialect, err := parseDialect(config.Dialect)
+	if err != nil {
+		return nil, err
+	}
+	a := &Agent{config: config, dialect: dialect, offsets: map[string]int64{}}
+
+	for _, name := range config.Enrich {
+		switch name {
+		case "attack":
+			a.transforms = append(a.transforms, bsm.EnrichAttack(nil))
+		case "privilege":
+			a.users = bsm.NewUserCache(nil, config.Limits.Users)
+			a.transforms = append(a.transforms, bsm.FlagPrivilegeTransitions(a.users.Lookup))
+		case "latency":
+			a.transforms = append(a.transforms, bsm.MeasureLatency(&a.latency))
+		case "args":
+			a.transforms = append(a.transforms, bsm.FlattenExecArgs)
+		default:
+			return nil, fmt.Errorf("unknown enrichment %q", name)
+		}
+	}
+
+	if config.Filter.Dedupe > 0 {
+		a.dedupe = filter.NewDeduper(config.Filter.Dedupe)
+	}
+	if a.keep, err = buildFilter(config.Filter, a.dedupe); err != nil {
+		return nil, err
+	}
+
+	for _, sc := range config.Sinks {
+		sink, err := a.openSink(sc)
+		if err != nil {
+			a.Close()
+			return nil, err
+		}
+		a.sinks = append(a.sinks, sink)
+	}
+
+	if config.DeadLetters != "" {
+		if a.dlq, err = spool.OpenDeadLetters(config.DeadLetters); err != nil {
+			a.Close()
+			return nil, fmt.Errorf("dead letters: %w", err)
+		}
+		a.closers = append(a.closers, a.dlq)
+	}
+
+	if config.Checkpoint != "" {
+		data, err := os.ReadFile(config.Checkpoint)
+		if err == nil {
+			err = json.Unmarshal(data, &a.offsets)
+		}
+		if err != nil && !os.IsNotExist(err) {
+			a.Close()
+			return nil, fmt.Errorf("checkpoint: %w", err)
+		}
+	}
+	return a, nil
+}
+
+// buildFilter combines the criteria of the configuration and the
+// deduplication (if any).
+func buildFilter(config FilterConfig, dedupe *filter.Deduper) (filter.Filter, error) {
+	var filters []filter.Filter
+	if len(config.Events) > 0 {
+		events := map[uint16]bool{}
+		for _, e := range config.Events {
+			events[e] = true
+		}
+		filters = append(filters, func(rec *decode.BsmRecord) bool { return events[rec.EventType] })
+	}
+	if len(config.Paths) > 0 || len(config.Globs) > 0 {
+		m := filter.NewPathMatcher()
+		for _, p := range config.Paths {
+			m.AddPrefix(p)
+		}
+		for _, g := range config.Globs {
+			if err := m.AddGlob(g); err != nil {
+				return nil, fmt.Errorf("glob %q: %w", g, err)
+			}
+		}
+		filters = append(filters, m.Filter())
+	}
+	if dedupe != nil {
+		filters = append(filters, dedupe.Keep)
+	}
+	return func(rec *decode.BsmRecord) bool {
+		for _, keep := range filters {
+			if !keep(rec) {
+				return false
+			}
+		}
+		return true
+	}, nil
+}
+
+// openSink opens the sink of the given configuration.
+func (a *Agent) openSink(config SinkConfig) (output.Sink, error) {
+	for _, name := range config.Compression {
+		if _, err := output.LookupCodec(name); err != nil {
+			return nil, err
+		}
+	}
+
+	if config.Type == "spool" {
+		q, err := spool.Open(config.Path)
+		if err != nil {
+			return nil, err
+		}
+		a.closers = append(a.closers, q)
+		return q, nil
+	}
+
+	if config.Type == "ndjson" {
+		w := output.NewNDJSONWriter(config.Path)
+		w.Gzip, w.MaxBytes = config.Gzip, config.MaxBytes
+		if len(config.Compression) > 0 {
+			w.Codec = output.NegotiateCodec(config.Compression, nil).Name
+		}
+		w.Policy = decode.JSONPolicy{Nulls: config.Nulls, StringInt64: config.StringInt64}
+		a.closers = append(a.closers, w)
+		return w, nil
+	}
+
+	if config.Type == "http" {
+		sink := output.NewHTTPSink(config.Path, config.Compression)
+		sink.Policy = decode.JSONPolicy{Nulls: config.Nulls, StringInt64: config.StringInt64}
+		return sink, nil
+	}
+
+	if config.Type == "plugin" {
+		sink, err := openPlugin(config)
+		if err != nil {
+			return nil, fmt.Errorf("plugin %s: %w", config.Path, err)
+		}
+		if closer, ok := sink.(io.Closer); ok {
+			a.closers = append(a.closers, closer)
+		}
+		return sink, nil
+	}
+
+	var w io.Writer = os.Stdout
+	var closer io.Closer
+	if strings.HasPrefix(config.Path, output.ExecScheme) {
+		cmd, err := output.StartCommand(strings.TrimPrefix(config.Path, output.ExecScheme))
+		if err != nil {
+			return nil, err
+		}
+		w, closer = cmd, cmd
+	} else if config.Path != "-" && config.Path != "" {
+		file, err := os.OpenFile(config.Path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+		if err != nil {
+			return nil, err
+		}
+		w, closer = file, file
+	}
+	if codec := output.NegotiateCodec(config.Compression, nil); codec.Name != output.CodecIdentity {
+		enc, err := output.CompressStream(w, codec)
+		if err != nil {
+			if closer != nil {
+				closer.Close()
+			}
+			return nil, err
+		}
+		a.closers = append(a.closers, enc) // before the file or command
+		w = enc
+	}
+	if closer != nil {
+		a.closers = append(a.closers, closer)
+	}
+	policy := decode.JSONPolicy{Nulls: config.Nulls, StringInt64: config.StringInt64}
+	switch config.Type {
+	case "json":
+		return output.SinkFunc(func(rec *decode.BsmRecord) error {
+			data, err := policy.Marshal(rec)
+			if err != nil {
+				return err
+			}
+			_, err = w.Write(append(data, '\n'))
+			return err
+		}), nil
+	case "compact":
+		writer := compact.NewWriter(w)
+		writer.Policy = policy
+		return writer, nil
+	case "auditbeat":
+		writer := auditbeat.NewWriter(w)
+		writer.Policy = policy
+		return writer, nil
+	case "praudit":
+		return output.NewPrauditWriter(w), nil
+	}
+	return nil, fmt.Errorf("unknown sink type %q", config.Type)
+}
+
+// openSource opens the source of the given configuration at the
+// checkpointed offset.
+func (a *Agent) openSource(config SourceConfig) (source, error) {
+	file, err := os.Open(config.Path)
+	if err != nil {
+		return nil, err
+	}
+	offset := a.offset(config.Path)
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		file.Close()
+		return nil, err
+	}
+	switch config.Type {
+	case "file":
+		decoder := decode.NewDecoder(file)
+		decoder.Dialect = a.dialect
+		decoder.Interner = a.interner()
+		return &fileSource{file: file, start: offset, decoder: decoder}, nil
+	case "tail":
+		src := bsm.NewTailSource(file)
+		src.Dialect = a.dialect
+		src.Interner = a.interner()
+		return tailSource{TailSource: src, file: file}, nil
+	}
+	file.Close()
+	return nil, fmt.Errorf("unknown source type %q", config.Type)
+}
+
+// Run reads all sources until they are exhausted (file sources) or the
+// context is cancelled (tail sources). Records which can't be decoded
+// stop the source they were read from, as do records which can't be
+// transformed or written unless there is a dead letter queue.
+func (a *Agent) Run(ctx context.Context) error {
+	var sources []source
+	for _, sc := range a.config.Sources {
+		src, err := a.openSource(sc)
+		if err != nil {
+			for _, s := range sources {
+				s.Close()
+			}
+			return fmt.Errorf("source %s: %w", sc.Path, err)
+		}
+		sources = append(sources, src)
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(sources))
+	for i, src := range sources {
+		wg.Add(1)
+		go func(i int, src source) {
+			defer wg.Done()
+			errs[i] = a.runSource(a.config.Sources[i].Path, src)
+		}(i, src)
+	}
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			for _, src := range sources {
+				if tail, ok := src.(tailSource); ok {
+					tail.TailSource.Close()
+				}
+			}
+		case <-done:
+		}
+	}()
+	wg.Wait()
+	close(done)
+	for _, src := range sources {
+		src.Close()
+	}
+
+	if err := a.checkpoint(); err != nil {
+		return err
+	}
+	for i, err := range errs {
+		if err != nil {
+			return fmt.Errorf("source %s: %w", a.config.Sources[i].Path, err)
+		}
+	}
+	return nil
+}
+
+// runSource ships the records of a single source. Partial records of
+// followed trails are skipped.
+func (a *Agent) runSource(path string, src source) error {
+	for {
+		rec, err := src.Next()
+		if err == io.EOF {
+			return nil
+		}
+		var partial *bsm.PartialRecord
+		if errors.As(err, &partial) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := a.process(&rec); err != nil {
+			return err
+		}
+		if err := a.advance(path, src.Offset()); err != nil {
+			return err
+		}
+	}
+}
+
+// process passes a record through the pipeline. Failures are kept in
+// the dead letter queue, if there is one.
+func (a *Agent) process(rec *decode.BsmRecord) error {
+	for _, fn := range a.transforms {
+		if err := fn(rec); err != nil {
+			return a.deadLetter(rec, "transform", err)
+		}
+	}
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	if !a.keep(rec) {
+		return nil
+	}
+	for i, sink := range a.sinks {
+		if err := sink.WriteRecord(rec); err != nil {
+			if err := a.deadLetter(rec, fmt.Sprintf("sink:%d", i), err); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Latency returns the processing latencies of the records measured by
+// the latency enrichment.
+func (a *Agent) Latency() bsm.LatencySnapshot {
+	return a.latency.Snapshot()
+}
+
+// interner returns the interner of a new source, nil if interning is
+// off.
+func (a *Agent) interner() *decode.Interner {
+	if a.config.Limits.Strings <= 0 {
+		return nil
+	}
+	in := decode.NewInterner()
+	in.MaxStrings = a.config.Limits.Strings
+	in.MaxLength = a.config.Limits.StringLength
+	return in
+}
+
+// CacheStats returns the state of the caches by name ("users" and
+// "dedupe"), only those in use are included.
+func (a *Agent) CacheStats() map[string]bsm.CacheStats {
+	stats := map[string]bsm.CacheStats{}
+	if a.users != nil {
+		stats["users"] = a.users.Stats()
+	}
+	if a.dedupe != nil {
+		a.mutex.Lock()
+		stats["dedupe"] = bsm.CacheStats{
+			Entries:  a.dedupe.Len(),
+			Capacity: a.dedupe.Cap(),
+			Hits:     a.dedupe.Dropped,
+		}
+		a.mutex.Unlock()
+	}
+	return stats
+}
+
+// MemoryBudget is the estimated worst-case memory in bytes held by the
+// caches of an agent, by cache.
+type MemoryBudget map[string]int64
+
+// Total returns the sum of the budget.
+func (b MemoryBudget) Total() int64 {
+	var total int64
+	for _, n := range b {
+		total += n
+	}
+	return total
+}
+
+// MemoryBudget returns the memory the caches of the agent hold at most,
+// however long it runs: the dedupe window ("dedupe"), the user names of
+// the privilege enrichment ("users") and the interned strings of all
+// sources ("strings"). Everything else the agent holds is bounded by
+// the records in flight (one per source and batch of a sink).
+func (a *Agent) MemoryBudget() MemoryBudget {
+	budget := MemoryBudget{}
+	if a.dedupe != nil {
+		budget["dedupe"] = a.dedupe.MaxBytes()
+	}
+	if a.users != nil {
+		budget["users"] = a.users.MaxBytes()
+	}
+	if in := a.interner(); in != nil {
+		budget["strings"] = int64(len(a.config.Sources)) * in.MaxBytes()
+	}
+	return budget
+}
+
+// deadLetter adds a record which failed in the given stage of the
+// pipeline to the dead letter queue. Without queue the error is
+// returned, which stops the source.
+func (a *Agent) deadLetter(rec *decode.BsmRecord, stage string, err error) error {
+	if a.dlq == nil {
+		return err
+	}
+	return a.dlq.Add(rec, stage, err)
+}
+
+// ReprocessDeadLetters passes the records of the dead letter queue
+// through the pipeline again, e.g. once a sink is reachable again.
+// Records which failed in a sink are only written to that sink, the
+// others pass the complete pipeline. Records failing again are kept. It
+// returns the number of records processed successfully.
+func (a *Agent) ReprocessDeadLetters() (int, error) {
+	if a.dlq == nil {
+		return 0, errors.New("no dead letter queue configured")
+	}
+	return a.dlq.Reprocess(func(letter *spool.DeadLetter) error {
+		rec := &letter.Record
+		for _, fn := range a.transforms { // annotations aren't kept
+			if err := fn(rec); err != nil {
+				return err
+			}
+		}
+
+		a.mutex.Lock()
+		defer a.mutex.Unlock()
+		sinks := a.sinks
+		if index, ok := strings.CutPrefix(letter.Stage, "sink:"); ok {
+			i, err := strconv.Atoi(index)
+			if err != nil || i < 0 || i >= len(a.sinks) {
+				return fmt.Errorf("invalid stage %q", letter.Stage)
+			}
+			sinks = a.sinks[i : i+1]
+		} else if !a.keep(rec) {
+			return nil
+		}
+		for _, sink := range sinks {
+			if err := sink.WriteRecord(rec); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (a *Agent) offset(path string) int64 {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	return a.offsets[path]
+}
+
+// advance records the offset of a source and saves the checkpoint every
+// CheckpointEvery records.
+func (a *Agent) advance(path string, offset int64) error {
+	a.mutex.Lock()
+	a.offsets[path] = offset
+	a.count += 1
+	due := a.count >= a.config.CheckpointEvery
+	a.mutex.Unlock()
+	if due {
+		return a.checkpoint()
+	}
+	return nil
+}
+
+// checkpoint atomically saves the offsets of all sources.
+func (a *Agent) checkpoint() error {
+	if a.config.Checkpoint == "" {
+		return nil
+	}
+	a.saving.Lock()
+	defer a.saving.Unlock()
+	a.mutex.Lock()
+	data, err := json.Marshal(a.offsets)
+	a.count = 0
+	a.mutex.Unlock()
+	if err != nil {
+		return err
+	}
+	tmp := a.config.Checkpoint + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, a.config.Checkpoint)
+}
+
+// Close closes the sinks of the agent.
+func (a *Agent) Close() error {
+	var first error
+	for _, c := range a.closers {
+		if err := c.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
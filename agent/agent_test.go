@@ -0,0 +1,326 @@
+package agent
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tpltnt/go-bsm/decode"
+	"github.com/tpltnt/go-bsm/output"
+)
+
+// The configurations are written in the JSON subset of YAML.
+
+func readLines(t *testing.T, name string) []map[string]interface{} {
+	file, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	var lines []map[string]interface{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var line map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatal(err)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func TestFromConfig(t *testing.T) {
+	dir := t.TempDir()
+	trail, _ := filepath.Abs("../start_stop.bsm")
+	out := filepath.Join(dir, "out.json")
+	strict := filepath.Join(dir, "strict.json")
+	checkpoint := filepath.Join(dir, "checkpoint")
+	config := fmt.Sprintf(`{
+		"dialect": "freebsd",
+		"sources": [{"type": "file", "path": %q}],
+		"filter": {"events": [45001]},
+		"enrich": ["attack", "latency"],
+		"sinks": [{"type": "json", "path": %q}, {"type": "json", "path": %q, "nulls": true, "string_int64": true}],
+		"checkpoint": %q
+	}`, trail, out, strict, checkpoint)
+
+	a, err := FromConfig([]byte(config))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	a.Close()
+	lines := readLines(t, out)
+	if len(lines) != 1 || lines[0]["event_type"] != 45001.0 {
+		t.Fatal("unexpected output:", lines)
+	}
+	annotations, _ := lines[0]["annotations"].(map[string]interface{})
+	if annotations["attack.technique"] != "T1562.012" || annotations["processing_latency"] == nil {
+		t.Error("unexpected annotations:", annotations)
+	}
+	if lines := readLines(t, strict); len(lines) != 1 {
+		t.Error("unexpected output:", lines)
+	} else if _, ok := lines[0]["seconds"].(string); !ok || lines[0]["event_type"] != 45001.0 {
+		t.Error("unexpected serialization:", lines[0])
+	} else if privilege, ok := lines[0]["privilege"]; !ok || privilege != nil {
+		t.Error("privilege not null:", lines[0])
+	}
+	if latency := a.Latency(); latency.Count != 2 {
+		t.Errorf("got %d latencies, expected 2", latency.Count)
+	}
+
+	// the checkpoint prevents shipping the records again
+	a, err = FromConfig([]byte(config))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	a.Close()
+	if lines := readLines(t, out); len(lines) != 1 {
+		t.Error("records shipped twice:", lines)
+	}
+}
+
+func TestFromConfigTail(t *testing.T) {
+	dir := t.TempDir()
+	trail, _ := filepath.Abs("../start_stop.bsm")
+	out := filepath.Join(dir, "out.json")
+	a, err := FromConfig([]byte(fmt.Sprintf(`{
+		"sources": [{"type": "tail", "path": %q}],
+		"sinks": [{"type": "compact", "path": %q}]
+	}`, trail, out)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	if err := a.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if lines := readLines(t, out); len(lines) != 2 {
+		t.Error("unexpected output:", lines)
+	}
+}
+
+func TestFromConfigCompression(t *testing.T) {
+	dir := t.TempDir()
+	trail, _ := filepath.Abs("../start_stop.bsm")
+	out := filepath.Join(dir, "out.json.gz")
+	a, err := FromConfig([]byte(fmt.Sprintf(`{
+		"sources": [{"type": "file", "path": %q}],
+		"sinks": [{"type": "compact", "path": %q, "compression": ["gzip", "deflate"]}]
+	}`, trail, out)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	a.Close()
+
+	file, err := os.Open(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(gz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(string(data), "\n"); lines != 2 {
+		t.Errorf("got %d records, expected 2", lines)
+	}
+}
+
+func TestFromConfigHTTP(t *testing.T) {
+	var records int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Encoding") != "gzip" {
+			t.Error("unexpected encoding", r.Header.Get("Content-Encoding"))
+		}
+		records += 1
+	}))
+	defer server.Close()
+
+	trail, _ := filepath.Abs("../start_stop.bsm")
+	a, err := FromConfig([]byte(fmt.Sprintf(`{
+		"sources": [{"type": "file", "path": %q}],
+		"sinks": [{"type": "http", "path": %q, "compression": ["gzip"]}]
+	}`, trail, server.URL)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	a.Close()
+	if records != 2 {
+		t.Errorf("got %d records, expected 2", records)
+	}
+}
+
+func TestMemoryBudget(t *testing.T) {
+	dir := t.TempDir()
+	trail, _ := filepath.Abs("../start_stop.bsm")
+	a, err := FromConfig([]byte(fmt.Sprintf(`{
+		"sources": [{"type": "file", "path": %q}, {"type": "file", "path": %q}],
+		"filter": {"dedupe": 100},
+		"enrich": ["privilege"],
+		"sinks": [{"type": "compact", "path": %q}],
+		"limits": {"users": 10, "strings": 1000, "string_length": 100}
+	}`, trail, trail, filepath.Join(dir, "out.json"))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+	if err := a.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	budget := a.MemoryBudget()
+	if budget["dedupe"] == 0 || budget["users"] == 0 || budget["strings"] != 2*1000*(100+64) {
+		t.Errorf("unexpected budget %v", budget)
+	}
+	if budget.Total() != budget["dedupe"]+budget["users"]+budget["strings"] {
+		t.Error("unexpected total:", budget.Total())
+	}
+	stats := a.CacheStats()
+	// both sources hold the same records
+	if dedupe := stats["dedupe"]; dedupe.Entries != 2 || dedupe.Capacity != 100 || dedupe.Hits != 2 {
+		t.Errorf("unexpected dedupe stats %+v", dedupe)
+	}
+	if users := stats["users"]; users.Capacity != 10 {
+		t.Errorf("unexpected user cache stats %+v", users)
+	}
+}
+
+// TestHelperProcess is the command of the exec sink: it copies its
+// standard input to the file named by BSM_HELPER_OUTPUT.
+func TestHelperProcess(t *testing.T) {
+	path := os.Getenv("BSM_HELPER_OUTPUT")
+	if path == "" {
+		return
+	}
+	data, err := io.ReadAll(os.Stdin)
+	if err == nil {
+		err = os.WriteFile(path, data, 0o600)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+func TestFromConfigExec(t *testing.T) {
+	trail, _ := filepath.Abs("../start_stop.bsm")
+	out := filepath.Join(t.TempDir(), "out.json")
+	t.Setenv("BSM_HELPER_OUTPUT", out)
+	a, err := FromConfig([]byte(fmt.Sprintf(`{
+		"sources": [{"type": "file", "path": %q}],
+		"sinks": [{"type": "json", "path": %q}]
+	}`, trail, "exec://"+os.Args[0]+" -test.run=^TestHelperProcess$")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if lines := readLines(t, out); len(lines) != 2 {
+		t.Error("unexpected output:", lines)
+	}
+}
+
+func TestFromConfigNDJSON(t *testing.T) {
+	trail, _ := filepath.Abs("../start_stop.bsm")
+	dir := t.TempDir()
+	a, err := FromConfig([]byte(fmt.Sprintf(`{
+		"sources": [{"type": "file", "path": %q}],
+		"sinks": [{"type": "ndjson", "path": %q, "max_bytes": 1}]
+	}`, trail, dir)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Close(); err != nil {
+		t.Fatal(err)
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "records-*.ndjson"))
+	if len(files) != 2 {
+		t.Errorf("expected a file per record, got %v", files)
+	}
+}
+
+func TestDeadLetters(t *testing.T) {
+	dir := t.TempDir()
+	trail, _ := filepath.Abs("../start_stop.bsm")
+	out := filepath.Join(dir, "out.json")
+	a, err := FromConfig([]byte(fmt.Sprintf(`{
+		"sources": [{"type": "file", "path": %q}],
+		"sinks": [{"type": "json", "path": %q}],
+		"dead_letters": %q
+	}`, trail, out, filepath.Join(dir, "dlq"))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+	sink := a.sinks[0]
+	a.sinks[0] = output.SinkFunc(func(*decode.BsmRecord) error { return errors.New("unreachable") })
+	if err := a.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	a.sinks[0] = sink
+	n, err := a.ReprocessDeadLetters()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("reprocessed %d records, expected 2", n)
+	}
+	if lines := readLines(t, out); len(lines) != 2 {
+		t.Error("unexpected output:", lines)
+	}
+}
+
+func TestFromConfigErrors(t *testing.T) {
+	for _, config := range []string{
+		`{"sinks": [{"type": "json"}]}`,
+		`{"sources": [{"type": "file", "path": "x"}]}`,
+		`{"sources": [{"type": "file", "path": "x"}], "sinks": [{"type": "xml"}]}`,
+		`{"sources": [{"type": "file", "path": "x"}], "sinks": [{"type": "json"}], "dialect": "plan9"}`,
+		`{"sources": [{"type": "file", "path": "x"}], "sinks": [{"type": "json"}], "enrich": ["magic"]}`,
+		`{"sources": [{"type": "file", "path": "x"}], "sinks": [{"type": "json", "path": "exec://"}]}`,
+		`{"sources": [{"type": "file", "path": "x"}], "sinks": [{"type": "plugin", "path": "missing.so"}]}`,
+		`{"sources": [{"type": "file", "path": "x"}], "sinks": [{"type": "json", "compression": ["lz4", "gzip"]}]}`,
+	} {
+		if _, err := FromConfig([]byte(config)); err == nil {
+			t.Error("expected error for", config)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/tpltnt/go-bsm/decode"
+)
+
+// BackfillOptions controls Backfill.
+type BackfillOptions struct {
+	// Rate limits the records processed per second (unlimited if 0),
+	// e.g. to spare the sinks.
+	Rate float64
+	// Progress (if not nil) is called every ProgressEvery (default 10s)
+	// and once all trails are processed.
+	Progress      func(BackfillProgress)
+	ProgressEvery time.Duration
+}
+
+// BackfillProgress describes the state of a Backfill.
+type BackfillProgress struct {
+	Files      int    // number of trails
+	FilesDone  int    // number of trails processed completely
+	File       string // trail being processed
+	Records    uint64 // number of records processed
+	Bytes      int64  // number of bytes read
+	TotalBytes int64  // size of all trails
+	Elapsed    time.Duration
+}
+
+// Percent returns the progress in percent of the bytes.
+func (p BackfillProgress) Percent() float64 {
+	if p.TotalBytes == 0 {
+		return 100
+	}
+	return 100 * float64(p.Bytes) / float64(p.TotalBytes)
+}
+
+// Backfill replays the given (archived) trails, in order, through the
+// enrichments, filter and sinks of the agent, e.g. after the detection
+// rules or schemas changed. The sources of the configuration and the
+// checkpoint aren't used. It stops at the first error or when the
+// context is cancelled.
+func (a *Agent) Backfill(ctx context.Context, paths []string, options BackfillOptions) (BackfillProgress, error) {
+	progress := BackfillProgress{Files: len(paths)}
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return progress, err
+		}
+		progress.TotalBytes += info.Size()
+	}
+	every := options.ProgressEvery
+	if every <= 0 {
+		every = 10 * time.Second
+	}
+
+	start := time.Now()
+	lastReport := start
+	for _, path := range paths {
+		progress.File = path
+		err := a.backfillTrail(path, func(rec *decode.BsmRecord, bytes int64) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if options.Rate > 0 {
+				due := start.Add(time.Duration(float64(progress.Records) / options.Rate * float64(time.Second)))
+				if wait := time.Until(due); wait > 0 {
+					select {
+					case <-ctx.Done():
+						return ctx.Err()
+					case <-time.After(wait):
+					}
+				}
+			}
+			if err := a.process(rec); err != nil {
+				return err
+			}
+			progress.Records += 1
+			progress.Bytes += bytes
+			if options.Progress != nil && time.Since(lastReport) >= every {
+				lastReport = time.Now()
+				progress.Elapsed = lastReport.Sub(start)
+				options.Progress(progress)
+			}
+			return nil
+		})
+		if err != nil {
+			return progress, fmt.Errorf("%s: %w", path, err)
+		}
+		progress.FilesDone += 1
+	}
+	progress.File = ""
+	progress.Bytes = progress.TotalBytes // incl. trailing file tokens
+	progress.Elapsed = time.Since(start)
+	if options.Progress != nil {
+		options.Progress(progress)
+	}
+	return progress, nil
+}
+
+// backfillTrail calls fn for every record of the trail along with its
+// size.
+func (a *Agent) backfillTrail(path string, fn func(rec *decode.BsmRecord, bytes int64) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	decoder := decode.NewDecoder(file)
+	decoder.Dialect = a.dialect
+	var offset uint64
+	for {
+		rec, err := decoder.Decode()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		read := decoder.Stats().BytesRead
+		if err := fn(&rec, int64(read-offset)); err != nil {
+			return err
+		}
+		offset = read
+	}
+}
@@ -0,0 +1,59 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBackfill(t *testing.T) {
+	dir := t.TempDir()
+	trail, _ := filepath.Abs("../start_stop.bsm")
+	out := filepath.Join(dir, "out.json")
+	checkpoint := filepath.Join(dir, "checkpoint")
+	a, err := FromConfig([]byte(fmt.Sprintf(`{
+		"sources": [{"type": "tail", "path": "/nonexistent"}],
+		"sinks": [{"type": "json", "path": %q}],
+		"checkpoint": %q
+	}`, out, checkpoint)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+
+	var reports []BackfillProgress
+	start := time.Now()
+	progress, err := a.Backfill(context.Background(), []string{trail, trail}, BackfillOptions{
+		Rate:          20,
+		Progress:      func(p BackfillProgress) { reports = append(reports, p) },
+		ProgressEvery: time.Nanosecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 4 records at 20 per second take 150ms at least
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+		t.Errorf("rate not limited: %v", elapsed)
+	}
+	if progress.Records != 4 || progress.FilesDone != 2 || progress.Percent() != 100 {
+		t.Errorf("unexpected progress %+v", progress)
+	}
+	if len(reports) != 5 || reports[0].Records != 1 || reports[0].File != trail {
+		t.Errorf("unexpected progress reports %+v", reports)
+	}
+	if lines := readLines(t, out); len(lines) != 4 {
+		t.Error("unexpected output:", lines)
+	}
+	if _, err := os.Stat(checkpoint); err == nil {
+		t.Error("checkpoint written by backfill")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := a.Backfill(ctx, []string{trail}, BackfillOptions{}); err == nil {
+		t.Error("expected error for cancelled backfill")
+	}
+}
@@ -0,0 +1,128 @@
+// Package agent builds a complete BSM shipping pipeline (sources,
+// filters, enrichments and sinks) from a declarative configuration, e.g.
+//
+//	dialect: freebsd
+//	sources:
+//	  - type: tail
+//	    path: /var/audit/current
+//	filter:
+//	  events: [7, 23]
+//	  paths: [/etc]
+//	enrich: [attack, privilege]
+//	sinks:
+//	  - type: compact
+//	    path: /var/log/bsm.json
+//	  - type: json
+//	    path: exec:///usr/local/bin/forward --host siem
+//	checkpoint: /var/db/bsm-agent.checkpoint
+//	dead_letters: /var/db/bsm-agent.dlq
+package agent
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tpltnt/go-bsm/token"
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the declarative description of an agent.
+type Config struct {
+	Sources    []SourceConfig `yaml:"sources" json:"sources"`
+	Dialect    string         `yaml:"dialect" json:"dialect"` // darwin, freebsd, solaris or linux
+	Filter     FilterConfig   `yaml:"filter" json:"filter"`
+	Enrich     []string       `yaml:"enrich" json:"enrich"` // attack, privilege, latency, args
+	Sinks      []SinkConfig   `yaml:"sinks" json:"sinks"`
+	Checkpoint string         `yaml:"checkpoint" json:"checkpoint"` // file keeping the source offsets (optional)
+	// CheckpointEvery is the number of records after which the offsets
+	// are saved (default 1000). They are saved on exit in any case.
+	CheckpointEvery int `yaml:"checkpoint_every" json:"checkpoint_every"`
+	// DeadLetters is the directory of a dead letter queue (optional,
+	// see spool.DeadLetterQueue). Records which can't be transformed or
+	// written to a sink are kept there instead of stopping the source.
+	DeadLetters string `yaml:"dead_letters" json:"dead_letters"`
+	// Limits bounds the caches of the agent, see Agent.MemoryBudget.
+	Limits LimitsConfig `yaml:"limits" json:"limits"`
+}
+
+// LimitsConfig bounds the memory held by the agent over time.
+type LimitsConfig struct {
+	Users        int `yaml:"users" json:"users"`                 // users cached by the privilege enrichment (default bsm.DefaultUserCacheSize)
+	Strings      int `yaml:"strings" json:"strings"`             // strings interned per source (interning off if 0)
+	StringLength int `yaml:"string_length" json:"string_length"` // longest string interned (default decode.DefaultMaxLength)
+}
+
+// SourceConfig describes a trail to read.
+type SourceConfig struct {
+	Type string `yaml:"type" json:"type"` // file (read once) or tail (follow)
+	Path string `yaml:"path" json:"path"`
+}
+
+// FilterConfig selects the records to ship. All given criteria have to
+// match.
+type FilterConfig struct {
+	Events []uint16 `yaml:"events" json:"events"` // event types (all if empty)
+	Paths  []string `yaml:"paths" json:"paths"`   // watched directories (see filter.PathMatcher)
+	Globs  []string `yaml:"globs" json:"globs"`   // watched glob patterns
+	Dedupe int      `yaml:"dedupe" json:"dedupe"` // window of the deduplication (off if 0)
+}
+
+// SinkConfig describes a destination of the records. The json, compact
+// and auditbeat sinks write one record per line to a file or, with a
+// path like "exec:///usr/local/bin/forward --host siem", to the standard
+// input of a command, the praudit sink writes `praudit -r` lines the
+// same way. The ndjson sink writes rotated files to a directory, the
+// http sink posts every record as JSON to a URL (see output.HTTPSink).
+type SinkConfig struct {
+	Type    string            `yaml:"type" json:"type"`       // json, compact, auditbeat, praudit, ndjson, http, spool or plugin
+	Path    string            `yaml:"path" json:"path"`       // file ("-" for stdout), exec:// command, ndjson or spool directory, URL or plugin
+	Options map[string]string `yaml:"options" json:"options"` // passed to plugins
+
+	// serialization policy of json, compact, auditbeat and ndjson sinks (see decode.JSONPolicy)
+	Nulls       bool `yaml:"nulls" json:"nulls"`               // write unset fields as null
+	StringInt64 bool `yaml:"string_int64" json:"string_int64"` // write 64-bit integers as strings
+
+	// Compression lists codecs (see output.RegisterCodec) in order of
+	// preference, e.g. [zstd, gzip]. Codecs which aren't registered are
+	// an error. The output of json, compact, auditbeat and praudit
+	// sinks is compressed as a stream with the first codec, the files
+	// of ndjson sinks one by one. http sinks use the first codec the
+	// server accepts (see output.NegotiateCodec).
+	Compression []string `yaml:"compression" json:"compression"`
+
+	// files of ndjson sinks (see output.NDJSONWriter)
+	Gzip     bool  `yaml:"gzip" json:"gzip"`           // compress the files
+	MaxBytes int64 `yaml:"max_bytes" json:"max_bytes"` // rotate once a file reaches this size
+}
+
+// ParseConfig parses a YAML configuration.
+func ParseConfig(data []byte) (*Config, error) {
+	var config Config
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, err
+	}
+	if len(config.Sources) == 0 {
+		return nil, fmt.Errorf("no sources configured")
+	}
+	if len(config.Sinks) == 0 {
+		return nil, fmt.Errorf("no sinks configured")
+	}
+	if config.CheckpointEvery <= 0 {
+		config.CheckpointEvery = 1000
+	}
+	return &config, nil
+}
+
+// parseDialect returns the dialect of the given name (see
+// token.Dialect.String).
+func parseDialect(name string) (token.Dialect, error) {
+	if name == "" {
+		return token.DialectUnknown, nil
+	}
+	for _, d := range []token.Dialect{token.DialectDarwin, token.DialectFreeBSD, token.DialectSolaris, token.DialectLinux} {
+		if strings.EqualFold(d.String(), name) {
+			return d, nil
+		}
+	}
+	return token.DialectUnknown, fmt.Errorf("unknown dialect %q", name)
+}
@@ -0,0 +1,45 @@
+package agent
+
+import (
+	"fmt"
+	"plugin"
+
+	"github.com/tpltnt/go-bsm/output"
+)
+
+// PluginSymbol is the name of the function a sink plugin exports, of
+// type PluginFunc. Sinks implementing io.Closer are closed along with
+// the agent.
+const PluginSymbol = "NewSink"
+
+// PluginFunc creates the sink of a plugin, which is a Go plugin (see
+// package plugin) built against the same version of this module, e.g.
+//
+//	go build -buildmode=plugin -o mysink.so ./mysink
+//
+// It is called with the options of the sink configuration.
+type PluginFunc = func(options map[string]string) (output.Sink, error)
+
+// openPlugin loads the plugin of the sink configuration and creates its
+// sink. Plugins are only supported on some platforms, e.g. Linux and
+// macOS with cgo enabled.
+func openPlugin(config SinkConfig) (output.Sink, error) {
+	p, err := plugin.Open(config.Path)
+	if err != nil {
+		return nil, err
+	}
+	symbol, err := p.Lookup(PluginSymbol)
+	if err != nil {
+		return nil, err
+	}
+	newSink, ok := symbol.(PluginFunc)
+	if !ok {
+		if ptr, isPtr := symbol.(*PluginFunc); isPtr {
+			newSink, ok = *ptr, true
+		}
+	}
+	if !ok {
+		return nil, fmt.Errorf("%s is of type %T, not %T", PluginSymbol, symbol, newSink)
+	}
+	return newSink(config.Options)
+}
@@ -0,0 +1,125 @@
+package bsm
+
+import (
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Dimension extracts a value to group records by.
+type Dimension struct {
+	Name  string
+	Value func(rec *BsmRecord) string
+}
+
+// Predefined dimensions.
+var (
+	// DimEventType groups by event type (as decimal number).
+	DimEventType = Dimension{Name: "event_type", Value: func(rec *BsmRecord) string {
+		return strconv.Itoa(int(rec.EventType))
+	}}
+	// DimUser groups by audit user ID ("" without subject).
+	DimUser = Dimension{Name: "user", Value: func(rec *BsmRecord) string {
+		if subject, ok := rec.Subject(); ok {
+			return strconv.FormatUint(uint64(subject.AuditID), 10)
+		}
+		return ""
+	}}
+	// DimZone groups by zone ("" for the global zone).
+	DimZone = Dimension{Name: "zone", Value: func(rec *BsmRecord) string {
+		zone, _ := rec.Zone()
+		return zone
+	}}
+	// DimPath groups by the first path of the record.
+	DimPath = Dimension{Name: "path", Value: func(rec *BsmRecord) string {
+		path, _ := rec.Path()
+		return path
+	}}
+	// DimExecPath groups by the executed program (first exec argument).
+	DimExecPath = Dimension{Name: "exec_path", Value: func(rec *BsmRecord) string {
+		for _, token := range rec.Tokens {
+			if v, ok := token.(ExecArgsToken); ok && len(v.Text) > 0 {
+				return v.Text[0]
+			}
+		}
+		return ""
+	}}
+	// DimSourceIP groups by the terminal machine address of the subject.
+	DimSourceIP = Dimension{Name: "source_ip", Value: func(rec *BsmRecord) string {
+		if subject, ok := rec.Subject(); ok && subject.TerminalMachineAddress != nil {
+			return subject.TerminalMachineAddress.String()
+		}
+		return ""
+	}}
+)
+
+// Bucket holds the aggregated values of all records within a time
+// bucket sharing the same dimension values.
+type Bucket struct {
+	Start       time.Time // beginning of the time bucket
+	Key         []string  // values of the dimensions (in order)
+	Count       int       // number of records
+	UniqueUsers int       // number of distinct audit user IDs
+	Failures    int       // number of failed operations
+}
+
+// FailureRate returns the fraction of failed operations.
+func (b Bucket) FailureRate() float64 {
+	if b.Count == 0 {
+		return 0
+	}
+	return float64(b.Failures) / float64(b.Count)
+}
+
+// Aggregate groups the records into time buckets of the given size and
+// by the given dimensions. The result is ordered by time and key.
+func Aggregate(records []BsmRecord, bucket time.Duration, dims ...Dimension) []Bucket {
+	type group struct {
+		bucket *Bucket
+		users  map[uint32]bool
+	}
+	groups := map[string]*group{}
+	for i := range records {
+		rec := &records[i]
+		start := rec.Time().Truncate(bucket)
+		key := make([]string, len(dims))
+		id := strconv.FormatInt(start.UnixNano(), 10)
+		for j, dim := range dims {
+			key[j] = dim.Value(rec)
+			id += "\x00" + key[j]
+		}
+		g, ok := groups[id]
+		if !ok {
+			g = &group{
+				bucket: &Bucket{Start: start, Key: key},
+				users:  map[uint32]bool{},
+			}
+			groups[id] = g
+		}
+		g.bucket.Count += 1
+		if rec.Failed() {
+			g.bucket.Failures += 1
+		}
+		if subject, ok := rec.Subject(); ok {
+			g.users[subject.AuditID] = true
+		}
+	}
+
+	result := make([]Bucket, 0, len(groups))
+	for _, g := range groups {
+		g.bucket.UniqueUsers = len(g.users)
+		result = append(result, *g.bucket)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].Start.Equal(result[j].Start) {
+			return result[i].Start.Before(result[j].Start)
+		}
+		for k := range result[i].Key {
+			if result[i].Key[k] != result[j].Key[k] {
+				return result[i].Key[k] < result[j].Key[k]
+			}
+		}
+		return false
+	})
+	return result
+}
@@ -0,0 +1,48 @@
+package bsm
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAggregate(t *testing.T) {
+	rec := func(seconds uint64, eventType uint16, auditID uint32, errno uint8) BsmRecord {
+		return BsmRecord{Seconds: seconds, EventType: eventType, Tokens: []Token{
+			SubjectToken32bit{TokenID: 0x24, AuditID: auditID},
+			ReturnToken32bit{TokenID: 0x27, ErrorNumber: errno},
+		}}
+	}
+	records := []BsmRecord{
+		rec(3600, 72, 1001, 0),
+		rec(3700, 72, 1002, 13),
+		rec(3800, 23, 1001, 0),
+		rec(7300, 72, 1001, 2),
+	}
+
+	buckets := Aggregate(records, time.Hour)
+	if len(buckets) != 2 {
+		t.Fatal("unexpected number of buckets:", len(buckets))
+	}
+	first := buckets[0]
+	if first.Start.Unix() != 3600 || first.Count != 3 || first.UniqueUsers != 2 || first.Failures != 1 {
+		t.Error("unexpected bucket:", first)
+	}
+	if rate := buckets[1].FailureRate(); rate != 1 {
+		t.Error("unexpected failure rate:", rate)
+	}
+
+	buckets = Aggregate(records, time.Hour, DimEventType)
+	if len(buckets) != 3 {
+		t.Fatal("unexpected number of buckets:", len(buckets))
+	}
+	if buckets[0].Key[0] != "23" || buckets[1].Key[0] != "72" || buckets[1].Count != 2 {
+		t.Error("unexpected buckets:", buckets)
+	}
+
+	if len(Aggregate(nil, time.Minute)) != 0 {
+		t.Error("expected no buckets")
+	}
+	if (Bucket{}).FailureRate() != 0 {
+		t.Error("unexpected failure rate for Token bucket")
+	}
+}
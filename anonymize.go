@@ -0,0 +1,355 @@
+package bsm
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"path"
+	"strings"
+
+	"github.com/tpltnt/go-bsm/decode"
+	"github.com/tpltnt/go-bsm/encode"
+	"github.com/tpltnt/go-bsm/token"
+)
+
+// anonymizedIDBase is the first ID assigned to pseudonymized users and
+// groups.
+const anonymizedIDBase = 10000
+
+// AnonymizePolicy selects the data replaced by Anonymize.
+type AnonymizePolicy struct {
+	IDs       bool     // user and group IDs (0 and DefaultAuditID are kept)
+	Addresses bool     // IP addresses of hosts and terminals (loopback and unspecified addresses are kept)
+	Paths     bool     // path names and exec arguments which are absolute paths
+	KeepPaths []string // directories whose paths are kept verbatim, e.g. "/usr"
+	Hostnames []string // host names replaced within all strings
+	Dialect   Dialect  // operating system which wrote the trail
+}
+
+// DefaultAnonymizePolicy replaces IDs, addresses and the paths outside
+// of the usual system directories.
+var DefaultAnonymizePolicy = AnonymizePolicy{
+	IDs:       true,
+	Addresses: true,
+	Paths:     true,
+	KeepPaths: []string{"/bin", "/sbin", "/usr", "/lib", "/libexec", "/etc", "/dev", "/System", "/Library"},
+}
+
+// AnonymizeMapping holds the pseudonyms assigned by Anonymize, e.g. to
+// translate findings back to the original trail. It must not be shared
+// along with the anonymized trail.
+type AnonymizeMapping struct {
+	IDs        map[uint32]uint32 `json:"ids"`        // user and group IDs
+	Addresses  map[string]string `json:"addresses"`  // IP addresses
+	Components map[string]string `json:"components"` // path components
+	Hostnames  map[string]string `json:"hostnames"`
+}
+
+// Anonymize copies the trail read from in to out, replacing the data
+// selected by the policy with consistent pseudonyms: an ID, address or
+// path component is replaced by the same pseudonym wherever it occurs.
+// The format is preserved, IDs stay numbers, addresses keep their family
+// and paths their depth and file name extensions. Tokens without such
+// data (e.g. return tokens) are copied unchanged. Tokens which can't be
+// parsed or anonymized (e.g. System V IPC tokens) make it fail rather
+// than leak their data. It returns the pseudonyms assigned.
+func Anonymize(in io.Reader, out io.Writer, policy AnonymizePolicy) (*AnonymizeMapping, error) {
+	a := &anonymizer{
+		policy: policy,
+		mapping: &AnonymizeMapping{
+			IDs:        map[uint32]uint32{},
+			Addresses:  map[string]string{},
+			Components: map[string]string{},
+			Hostnames:  map[string]string{},
+		},
+	}
+	err := rewriteTrail(in, out, policy.Dialect, a.token)
+	return a.mapping, err
+}
+
+// rewriteTrail copies the trail read from in to out, replacing every raw
+// token with the result of fn, which drops the token if nil. The byte
+// counts of header and trailer are corrected if the size of a record
+// changes.
+func rewriteTrail(in io.Reader, out io.Writer, dialect Dialect, fn func(data []byte) ([]byte, error)) error {
+	var record []byte // pending record, starting with its header
+	var offset int
+	tokens := decode.NewTokenReader(in, dialect)
+	for {
+		data, err := tokens.ReadToken()
+		if err == io.EOF {
+			if len(record) > 0 {
+				return io.ErrUnexpectedEOF
+			}
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("token at offset %d: %w", offset, err)
+		}
+		start := offset
+		offset += len(data)
+		if data, err = fn(data); err != nil {
+			return fmt.Errorf("token at offset %d: %w", start, err)
+		}
+		if len(data) == 0 {
+			continue
+		}
+
+		switch data[0] {
+		case 0x14, 0x15, 0x74, 0x79: // header
+			record = append(record[:0], data...)
+			continue
+		case 0x13: // trailer
+			if len(record) == 0 {
+				break
+			}
+			record = append(record, data...)
+			binary.BigEndian.PutUint32(record[1:5], uint32(len(record)))
+			binary.BigEndian.PutUint32(record[len(record)-4:], uint32(len(record)))
+			data, record = record, record[len(record):]
+		default:
+			if len(record) > 0 {
+				record = append(record, data...)
+				continue
+			}
+		}
+		if _, err := out.Write(data); err != nil {
+			return err
+		}
+	}
+}
+
+// anonymizer keeps the pseudonyms of a run of Anonymize.
+type anonymizer struct {
+	policy  AnonymizePolicy
+	mapping *AnonymizeMapping
+	ipv4    uint32 // number of IPv4 addresses assigned
+	ipv6    uint32 // number of IPv6 addresses assigned
+}
+
+// token returns the anonymized form of the given raw token. Tokens which
+// can't be parsed or anonymized are an error.
+func (a *anonymizer) token(data []byte) ([]byte, error) {
+	tok, err := token.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	switch v := tok.(type) {
+	case ExpandedHeaderToken32bit:
+		return a.patchAddress(data, 14, v.MachineAddress), nil
+	case ExpandedHeaderToken64bit:
+		return a.patchAddress(data, 14, v.MachineAddress), nil
+	case SubjectToken32bit:
+		a.ids(&v.AuditID, &v.EffectiveUserID, &v.EffectiveGroupID, &v.RealUserID, &v.RealGroupID)
+		v.TerminalMachineAddress = a.address(v.TerminalMachineAddress)
+		tok = v
+	case SubjectToken64bit:
+		a.ids(&v.AuditID, &v.EffectiveUserID, &v.EffectiveGroupID, &v.RealUserID, &v.RealGroupID)
+		v.TerminalMachineAddress = a.address(v.TerminalMachineAddress)
+		tok = v
+	case ExpandedSubjectToken64bit:
+		a.ids(&v.AuditID, &v.EffectiveUserID, &v.EffectiveGroupID, &v.RealUserID, &v.RealGroupID)
+		v.TerminalMachineAddress = a.address(v.TerminalMachineAddress)
+		tok = v
+	case ExpandedSubjectToken32bit:
+		a.ids(&v.AuditID, &v.EffectiveUserID, &v.EffectiveGroupID, &v.RealUserID, &v.RealGroupID)
+		v.TerminalMachineAddress = a.address(v.TerminalMachineAddress)
+		tok = v
+	case ProcessToken32bit:
+		a.ids(&v.AuditID, &v.EffectiveUserID, &v.EffectiveGroupID, &v.RealUserID, &v.RealGroupID)
+		v.TerminalMachineAddress = a.address(v.TerminalMachineAddress)
+		tok = v
+	case ProcessToken64bit:
+		a.ids(&v.AuditID, &v.EffectiveUserID, &v.EffectiveGroupID, &v.RealUserID, &v.RealGroupID)
+		v.TerminalMachineAddress = a.address(v.TerminalMachineAddress)
+		tok = v
+	case ExpandedProcessToken32bit:
+		a.ids(&v.AuditID, &v.EffectiveUserID, &v.EffectiveGroupID, &v.RealUserID, &v.RealGroupID)
+		v.TerminalMachineAddress = a.address(v.TerminalMachineAddress)
+		tok = v
+	case ExpandedProcessToken64bit:
+		a.ids(&v.AuditID, &v.EffectiveUserID, &v.EffectiveGroupID, &v.RealUserID, &v.RealGroupID)
+		v.TerminalMachineAddress = a.address(v.TerminalMachineAddress)
+		tok = v
+	case SocketToken:
+		v.SocketAddress = a.address(v.SocketAddress)
+		tok = v
+	case ExpandedSocketToken:
+		v.LocalIpAddress = a.address(v.LocalIpAddress)
+		v.RemoteIpAddress = a.address(v.RemoteIpAddress)
+		tok = v
+	case IpToken:
+		v.SourceAddress = a.address(v.SourceAddress)
+		v.DestinationAddress = a.address(v.DestinationAddress)
+		tok = v
+	case InAddrToken:
+		v.IpAddress = a.address(v.IpAddress)
+		tok = v
+	case ExpandedInAddrToken:
+		v.IpAddress = a.address(v.IpAddress)
+		tok = v
+	case AttributeToken32bit:
+		a.ids(&v.OwnerUserID, &v.OwnerGroupID)
+		tok = v
+	case AttributeToken64bit:
+		a.ids(&v.OwnerUserID, &v.OwnerGroupID)
+		tok = v
+	case GroupsToken:
+		groups := make([]uint32, len(v.GroupList))
+		for i, g := range v.GroupList {
+			groups[i] = a.id(g)
+		}
+		v.GroupList = groups
+		tok = v
+	case PathToken:
+		v.Path = a.path(v.Path)
+		tok = v
+	case FileToken:
+		v.PathName = a.path(v.PathName)
+		tok = v
+	case TextToken:
+		v.Text = a.hostnames(v.Text)
+		tok = v
+	case ExecArgsToken:
+		args := make([]string, len(v.Text))
+		for i, arg := range v.Text {
+			if strings.HasPrefix(arg, "/") {
+				arg = a.path(arg)
+			}
+			args[i] = a.hostnames(arg)
+		}
+		v.Text = args
+		tok = v
+	case ExecEnvToken:
+		env := make([]string, len(v.Text))
+		for i, s := range v.Text {
+			env[i] = a.hostnames(s)
+		}
+		v.Text = env
+		tok = v
+	case ZonenameToken:
+		v.Zonename = a.hostnames(v.Zonename)
+		tok = v
+	case HeaderToken32bit, HeaderToken64bit, TrailerToken, ReturnToken32bit, ReturnToken64bit,
+		ExitToken, SeqToken, IPortToken, ArgToken32bit, ArgToken64bit:
+		return data, nil // no identifying data
+	default:
+		return nil, fmt.Errorf("can't anonymize token of type %T", tok)
+	}
+	return encode.Token(tok)
+}
+
+// ids replaces the given IDs.
+func (a *anonymizer) ids(ids ...*uint32) {
+	for _, id := range ids {
+		*id = a.id(*id)
+	}
+}
+
+// id returns the pseudonym of a user or group ID.
+func (a *anonymizer) id(id uint32) uint32 {
+	if !a.policy.IDs || id == 0 || id == DefaultAuditID {
+		return id
+	}
+	if pseudonym, ok := a.mapping.IDs[id]; ok {
+		return pseudonym
+	}
+	pseudonym := anonymizedIDBase + uint32(len(a.mapping.IDs))
+	a.mapping.IDs[id] = pseudonym
+	return pseudonym
+}
+
+// address returns the pseudonym of an IP address, an address from
+// 10.0.0.0/8 for IPv4 and fd00::/8 for IPv6 addresses.
+func (a *anonymizer) address(ip net.IP) net.IP {
+	if !a.policy.Addresses || ip == nil || ip.IsLoopback() || ip.IsUnspecified() {
+		return ip
+	}
+	pseudonym, ok := a.mapping.Addresses[ip.String()]
+	if !ok {
+		if ip.To4() != nil {
+			a.ipv4 += 1
+			pseudonym = net.IPv4(10, byte(a.ipv4>>16), byte(a.ipv4>>8), byte(a.ipv4)).String()
+		} else {
+			a.ipv6 += 1
+			v6 := make(net.IP, net.IPv6len)
+			v6[0] = 0xfd
+			binary.BigEndian.PutUint32(v6[12:], a.ipv6)
+			pseudonym = v6.String()
+		}
+		a.mapping.Addresses[ip.String()] = pseudonym
+	}
+	if len(ip) == net.IPv4len {
+		return net.ParseIP(pseudonym).To4()
+	}
+	return net.ParseIP(pseudonym)
+}
+
+// patchAddress replaces the address at the given offset of a raw token.
+func (a *anonymizer) patchAddress(data []byte, offset int, ip net.IP) []byte {
+	pseudonym := a.address(ip)
+	if ip.To4() != nil {
+		pseudonym = pseudonym.To4()
+	}
+	if pseudonym == nil || len(data) < offset+len(pseudonym) {
+		return data
+	}
+	data = append([]byte(nil), data...)
+	copy(data[offset:], pseudonym)
+	return data
+}
+
+// path returns the pseudonym of a path. Paths within KeepPaths are
+// kept, the components of all others are replaced one by one.
+func (a *anonymizer) path(p string) string {
+	p = a.hostnames(p)
+	if !a.policy.Paths || p == "" {
+		return p
+	}
+	for _, prefix := range a.policy.KeepPaths {
+		prefix = strings.TrimSuffix(prefix, "/")
+		if p == prefix || strings.HasPrefix(p, prefix+"/") {
+			return p
+		}
+	}
+	components := strings.Split(p, "/")
+	for i, c := range components {
+		components[i] = a.component(c)
+	}
+	return strings.Join(components, "/")
+}
+
+// component returns the pseudonym of a path component, keeping its
+// extension.
+func (a *anonymizer) component(c string) string {
+	if c == "" || c == "." || c == ".." {
+		return c
+	}
+	if pseudonym, ok := a.mapping.Components[c]; ok {
+		return pseudonym
+	}
+	ext := path.Ext(c)
+	if ext == c || len(ext) > 6 {
+		ext = ""
+	}
+	pseudonym := fmt.Sprintf("p%d%s", len(a.mapping.Components)+1, ext)
+	a.mapping.Components[c] = pseudonym
+	return pseudonym
+}
+
+// hostnames replaces the host names of the policy within s.
+func (a *anonymizer) hostnames(s string) string {
+	for _, name := range a.policy.Hostnames {
+		if name == "" || !strings.Contains(s, name) {
+			continue
+		}
+		pseudonym, ok := a.mapping.Hostnames[name]
+		if !ok {
+			pseudonym = fmt.Sprintf("host%d", len(a.mapping.Hostnames)+1)
+			a.mapping.Hostnames[name] = pseudonym
+		}
+		s = strings.ReplaceAll(s, name, pseudonym)
+	}
+	return s
+}
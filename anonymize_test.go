@@ -0,0 +1,171 @@
+package bsm
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/tpltnt/go-bsm/encode"
+)
+
+func TestAnonymize(t *testing.T) {
+	var trail bytes.Buffer
+	encoder := encode.NewEncoder(&trail)
+	for _, p := range []string{"/home/alice/notes.txt", "/usr/bin/vi", "/home/alice"} {
+		rec := BsmRecord{
+			Version:   11,
+			EventType: 72,
+			Tokens: []Token{
+				SubjectToken32bit{AuditID: 1001, EffectiveUserID: 0, RealUserID: 1001, RealGroupID: 20,
+					TerminalMachineAddress: net.IPv4(192, 0, 2, 1).To4()},
+				PathToken{Path: p},
+				TextToken{Text: "login from alice.example.com"},
+				ReturnToken32bit{},
+			},
+		}
+		if err := encoder.Encode(&rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	policy := DefaultAnonymizePolicy
+	policy.Hostnames = []string{"alice.example.com"}
+	var out bytes.Buffer
+	mapping, err := Anonymize(&trail, &out, policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := out.Bytes()
+	decoder := NewDecoder(bytes.NewReader(data))
+	var paths []string
+	for {
+		offset := decoder.Stats().BytesRead
+		rec, err := decoder.Decode()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if size := binary.BigEndian.Uint32(data[offset+1:]); uint64(size) != decoder.Stats().BytesRead-offset {
+			t.Errorf("record at offset %d: byte count %d doesn't match", offset, size)
+		}
+		subject, _ := rec.Subject()
+		if subject.AuditID != 10000 || subject.RealUserID != 10000 || subject.RealGroupID != 10001 || subject.EffectiveUserID != 0 {
+			t.Errorf("IDs not replaced: %+v", subject)
+		}
+		if !subject.TerminalMachineAddress.Equal(net.IPv4(10, 0, 0, 1)) {
+			t.Errorf("address not replaced: %v", subject.TerminalMachineAddress)
+		}
+		for _, tok := range rec.Tokens {
+			if text, ok := tok.(TextToken); ok && text.Text != "login from host1" {
+				t.Errorf("host name not replaced: %q", text.Text)
+			}
+		}
+		p, _ := rec.Path()
+		paths = append(paths, p)
+	}
+	expected := []string{"/p1/p2/p3.txt", "/usr/bin/vi", "/p1/p2"}
+	if len(paths) != len(expected) {
+		t.Fatalf("got %d records, expected %d", len(paths), len(expected))
+	}
+	for i := range expected {
+		if paths[i] != expected[i] {
+			t.Errorf("path %d: got %q, expected %q", i, paths[i], expected[i])
+		}
+	}
+	if mapping.IDs[1001] != 10000 || mapping.Components["alice"] != "p2" || mapping.Addresses["192.0.2.1"] != "10.0.0.1" {
+		t.Errorf("unexpected mapping %+v", mapping)
+	}
+}
+
+func TestAnonymizeUnchanged(t *testing.T) {
+	data, err := os.ReadFile("start_stop.bsm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if _, err := Anonymize(bytes.NewReader(data), &out, AnonymizePolicy{}); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(out.Bytes(), data) {
+		t.Error("trail changed by empty policy")
+	}
+}
+
+func TestAnonymizeLinux(t *testing.T) {
+	policy := DefaultAnonymizePolicy
+	policy.KeepPaths = nil
+	policy.Dialect = DialectLinux
+	var out bytes.Buffer
+	if _, err := Anonymize(bytes.NewReader(linuxTrail), &out, policy); err != nil {
+		t.Fatal(err)
+	}
+	decoder := NewDecoder(&out)
+	decoder.Dialect = DialectLinux
+	rec, err := decoder.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rec.Tokens) != 1 || rec.Tokens[0].(PathToken).Path == "/etc" {
+		t.Error("unexpected tokens:", rec.Tokens)
+	}
+}
+
+func TestAnonymizeTokens(t *testing.T) {
+	v4, v6 := net.IPv4(192, 0, 2, 1).To4(), net.ParseIP("2001:db8::1")
+	for _, tok := range []Token{
+		ExpandedSubjectToken64bit{TokenID: 0x7c, AuditID: 1001, RealUserID: 1001, TerminalMachineAddress: v6},
+		ExpandedProcessToken64bit{TokenID: 0x7d, AuditID: 1001, RealUserID: 1001, TerminalMachineAddress: v4},
+		ProcessToken32bit{TokenID: 0x26, AuditID: 1001, RealUserID: 1001, TerminalMachineAddress: v4},
+		SocketToken{TokenID: 0x80, SocketFamily: 2, LocalPort: 22, SocketAddress: v4},
+		SocketToken{TokenID: 0x81, SocketFamily: 26, LocalPort: 22, SocketAddress: v6},
+		ExpandedSocketToken{TokenID: 0x7f, SocketDomain: 2, SocketType: 1, LocalPort: 22, LocalIpAddress: v4,
+			RemotePort: 4242, RemoteIpAddress: net.IPv4(198, 51, 100, 7).To4()},
+		ExpandedSocketToken{TokenID: 0x7f, SocketDomain: 28, SocketType: 1, LocalPort: 22, LocalIpAddress: v6,
+			RemotePort: 4242, RemoteIpAddress: net.ParseIP("2001:db8::2")},
+		IpToken{TokenID: 0x2b, VersionAndIHL: 0x45, TTL: 64, Protocol: 6, SourceAddress: v4,
+			DestinationAddress: net.IPv4(198, 51, 100, 7).To4()},
+		InAddrToken{TokenID: 0x2a, IpAddress: v4},
+		ExpandedInAddrToken{TokenID: 0x7e, IpAddressType: 16, IpAddress: v6},
+	} {
+		var trail bytes.Buffer
+		rec := BsmRecord{Version: 11, EventType: 42, Tokens: []Token{tok, ReturnToken32bit{}}}
+		if err := encode.NewEncoder(&trail).Encode(&rec); err != nil {
+			t.Fatalf("%T: %v", tok, err)
+		}
+		var out bytes.Buffer
+		if _, err := Anonymize(&trail, &out, DefaultAnonymizePolicy); err != nil {
+			t.Errorf("%T: %v", tok, err)
+			continue
+		}
+		for _, leak := range [][]byte{v4, v6, {198, 51, 100, 7}, {0, 0, 3, 0xe9}} {
+			if bytes.Contains(out.Bytes(), leak) {
+				t.Errorf("%T: % x not replaced", tok, leak)
+			}
+		}
+		anonymized, err := NewDecoder(&out).Decode()
+		if err != nil {
+			t.Errorf("%T: %v", tok, err)
+			continue
+		}
+		if got := anonymized.Tokens[0]; reflect.TypeOf(got) != reflect.TypeOf(tok) {
+			t.Errorf("%T: got token %+v", tok, got)
+		}
+	}
+
+	// tokens which can't be anonymized aren't passed through
+	perm := append([]byte{0x32}, make([]byte, 28)...)
+	var trail bytes.Buffer
+	trail.Write([]byte{0x14, 0, 0, 0, 56, 11, 0, 42, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0})
+	trail.Write(perm)
+	trail.Write([]byte{0x13, 0xb1, 0x05, 0, 0, 0, 56})
+	if _, err := Anonymize(&trail, io.Discard, DefaultAnonymizePolicy); err == nil {
+		t.Error("expected an error for System V IPC permission token")
+	}
+}
@@ -0,0 +1,102 @@
+package bsm
+
+import (
+	"path"
+	"sort"
+	"strings"
+)
+
+// AttackRule maps records to a MITRE ATT&CK technique.
+type AttackRule struct {
+	Technique  string                // technique ID, e.g. "T1059.004"
+	EventTypes []uint16              // event types the rule applies to
+	Match      func(*BsmRecord) bool // additional predicate (may be nil)
+}
+
+// shells are the programs considered command interpreters.
+var shells = map[string]bool{
+	"sh": true, "bash": true, "csh": true, "tcsh": true, "ksh": true,
+	"zsh": true, "dash": true, "fish": true,
+}
+
+// execsShell reports whether the record executes a shell.
+func execsShell(rec *BsmRecord) bool {
+	return shells[path.Base(DimExecPath.Value(rec))]
+}
+
+// touchesLogs reports whether the path of the record lies within a log
+// or audit trail directory.
+func touchesLogs(rec *BsmRecord) bool {
+	p, ok := rec.Path()
+	return ok && (strings.HasPrefix(p, "/var/log/") || strings.HasPrefix(p, "/var/audit/"))
+}
+
+// setsSetuidBit reports whether the record sets the setuid or setgid
+// bit, i.e. if an argument named "new file mode" carries one of them.
+func setsSetuidBit(rec *BsmRecord) bool {
+	for _, token := range rec.Tokens {
+		switch v := token.(type) {
+		case ArgToken32bit:
+			if v.Text == "new file mode" && v.ArgumentValue&0o6000 != 0 {
+				return true
+			}
+		case ArgToken64bit:
+			if v.Text == "new file mode" && v.ArgumentValue&0o6000 != 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// DefaultAttackRules is a small mapping of common audit events of
+// OpenBSM to ATT&CK techniques. It is a starting point, not a complete
+// detection rule set.
+var DefaultAttackRules = []AttackRule{
+	// Command and Scripting Interpreter: Unix Shell
+	{Technique: "T1059.004", EventTypes: []uint16{7, 23}, Match: execsShell}, // AUE_EXEC, AUE_EXECVE
+	// File and Directory Permissions Modification
+	{Technique: "T1222.002", EventTypes: []uint16{10, 11, 38, 39}}, // AUE_CHMOD, AUE_CHOWN, AUE_FCHOWN, AUE_FCHMOD
+	// Abuse Elevation Control Mechanism: Setuid and Setgid
+	{Technique: "T1548.001", EventTypes: []uint16{10, 39}, Match: setsSetuidBit},
+	// Indicator Removal: Clear Linux or Mac System Logs
+	{Technique: "T1070.002", EventTypes: []uint16{6, 42, 43}, Match: touchesLogs}, // AUE_UNLINK, AUE_RENAME, AUE_TRUNCATE
+	// Indicator Removal: Timestomp
+	{Technique: "T1070.006", EventTypes: []uint16{49}}, // AUE_UTIMES
+	// System Shutdown/Reboot
+	{Technique: "T1529", EventTypes: []uint16{20}}, // AUE_REBOOT
+	// Remote Services: SSH
+	{Technique: "T1021.004", EventTypes: []uint16{6172, 32800}}, // AUE_ssh, AUE_openssh
+	// Impair Defenses: Disable or Modify Linux Audit System
+	{Technique: "T1562.012", EventTypes: []uint16{45001}}, // AUE_audit_shutdown
+}
+
+// EnrichAttack returns a transformation (see Transform) which annotates
+// records matching any of the given rules (DefaultAttackRules if nil)
+// with the sorted, comma separated technique IDs as "attack.technique".
+func EnrichAttack(rules []AttackRule) func(*BsmRecord) error {
+	if rules == nil {
+		rules = DefaultAttackRules
+	}
+	byEvent := map[uint16][]AttackRule{}
+	for _, rule := range rules {
+		for _, eventType := range rule.EventTypes {
+			byEvent[eventType] = append(byEvent[eventType], rule)
+		}
+	}
+
+	return func(rec *BsmRecord) error {
+		var techniques []string
+		for _, rule := range byEvent[rec.EventType] {
+			if rule.Match != nil && !rule.Match(rec) {
+				continue
+			}
+			techniques = append(techniques, rule.Technique)
+		}
+		if len(techniques) > 0 {
+			sort.Strings(techniques)
+			rec.Annotate("attack.technique", strings.Join(techniques, ","))
+		}
+		return nil
+	}
+}
@@ -0,0 +1,39 @@
+package bsm
+
+import "testing"
+
+func TestEnrichAttack(t *testing.T) {
+	enrich := EnrichAttack(nil)
+
+	exec := BsmRecord{EventType: 23, Tokens: []Token{
+		ExecArgsToken{TokenID: 0x3c, Count: 2, Text: []string{"/bin/bash", "-i"}},
+	}}
+	enrich(&exec)
+	if technique, _ := exec.Annotation("attack.technique"); technique != "T1059.004" {
+		t.Error("unexpected technique for shell exec:", technique)
+	}
+
+	ls := BsmRecord{EventType: 23, Tokens: []Token{
+		ExecArgsToken{TokenID: 0x3c, Count: 1, Text: []string{"/bin/ls"}},
+	}}
+	enrich(&ls)
+	if technique, ok := ls.Annotation("attack.technique"); ok {
+		t.Error("unexpected technique for ls:", technique)
+	}
+
+	chmod := BsmRecord{EventType: 10, Tokens: []Token{
+		ArgToken32bit{TokenID: 0x2d, ArgumentID: 2, ArgumentValue: 0o4755, Text: "new file mode"},
+		PathToken{TokenID: 0x23, Path: "/tmp/x"},
+	}}
+	enrich(&chmod)
+	if technique, _ := chmod.Annotation("attack.technique"); technique != "T1222.002,T1548.001" {
+		t.Error("unexpected technique for chmod:", technique)
+	}
+
+	custom := EnrichAttack([]AttackRule{{Technique: "T0000", EventTypes: []uint16{45000}}})
+	startup := BsmRecord{EventType: 45000}
+	custom(&startup)
+	if technique, _ := startup.Annotation("attack.technique"); technique != "T0000" {
+		t.Error("unexpected technique for custom rule:", technique)
+	}
+}
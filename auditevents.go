@@ -0,0 +1,237 @@
+package bsm
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/tpltnt/go-bsm/token"
+)
+
+// DefaultAuditEventPath is the audit_event(5) file of macOS and FreeBSD.
+const DefaultAuditEventPath = "/etc/security/audit_event"
+
+// EmbeddedAuditEvents returns the events named by package token for the
+// given dialect (all dialects for DialectUnknown). Only Number and Name
+// are set.
+func EmbeddedAuditEvents(dialect Dialect) map[uint16]AuditEvent {
+	events := map[uint16]AuditEvent{}
+	for _, e := range token.EventTypes(dialect) {
+		events[uint16(e)] = AuditEvent{Number: uint16(e), Name: e.Name(dialect)}
+	}
+	return events
+}
+
+// MergeAuditEvents returns the events of base and extra. The entries of
+// extra replace those of base with the same number, but inherit the
+// name, description and classes they don't set.
+func MergeAuditEvents(base, extra map[uint16]AuditEvent) map[uint16]AuditEvent {
+	merged := make(map[uint16]AuditEvent, len(base)+len(extra))
+	for number, event := range base {
+		merged[number] = event
+	}
+	for number, event := range extra {
+		if prev, ok := merged[number]; ok {
+			if event.Name == "" {
+				event.Name = prev.Name
+			}
+			if event.Description == "" {
+				event.Description = prev.Description
+			}
+			if event.Classes == nil {
+				event.Classes = prev.Classes
+			}
+		}
+		merged[number] = event
+	}
+	return merged
+}
+
+// LoadAuditEvents reads the event definitions at path (see
+// ParseAuditEvents, DefaultAuditEventPath if empty) merged over the
+// embedded ones of the dialect (see EmbeddedAuditEvents), so event
+// names resolve even if the file lacks entries, e.g. the additional
+// ones of macOS, or doesn't exist at all.
+func LoadAuditEvents(path string, dialect Dialect) (map[uint16]AuditEvent, error) {
+	if path == "" {
+		path = DefaultAuditEventPath
+	}
+	embedded := EmbeddedAuditEvents(dialect)
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return embedded, nil
+	}
+	if err != nil {
+		return embedded, err
+	}
+	defer file.Close()
+	events, err := ParseAuditEvents(file)
+	if err != nil {
+		return embedded, fmt.Errorf("%s: %w", path, err)
+	}
+	return MergeAuditEvents(embedded, events), nil
+}
+
+// isPlist reports whether the data starts like an XML property list.
+func isPlist(r *bufio.Reader) bool {
+	start, _ := r.Peek(64)
+	start = bytes.TrimSpace(start)
+	return bytes.HasPrefix(start, []byte("<?xml")) || bytes.HasPrefix(start, []byte("<plist"))
+}
+
+// parseAuditEventsPlist reads event definitions from a property list,
+// either a dictionary keyed by event number whose values are the event
+// name or a dictionary with the keys "name", "description" and
+// "classes", or an array of such dictionaries with an additional
+// "number" key.
+func parseAuditEventsPlist(r io.Reader) (map[uint16]AuditEvent, error) {
+	root, err := parsePlist(xml.NewDecoder(r))
+	if err != nil {
+		return nil, err
+	}
+	events := map[uint16]AuditEvent{}
+	add := func(key string, value interface{}) error {
+		number, err := strconv.ParseUint(strings.TrimSpace(key), 10, 16)
+		if err != nil {
+			return fmt.Errorf("invalid event number %q", key)
+		}
+		event := AuditEvent{Number: uint16(number)}
+		switch v := value.(type) {
+		case string:
+			event.Name = v
+		case map[string]interface{}:
+			event.Name, _ = v["name"].(string)
+			event.Description, _ = v["description"].(string)
+			switch classes := v["classes"].(type) {
+			case string:
+				event.Classes = splitList(classes)
+			case []interface{}:
+				for _, class := range classes {
+					if s, ok := class.(string); ok {
+						event.Classes = append(event.Classes, s)
+					}
+				}
+			}
+		default:
+			return fmt.Errorf("event %d: unexpected value %v", number, value)
+		}
+		events[event.Number] = event
+		return nil
+	}
+
+	switch v := root.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if err := add(key, v[key]); err != nil {
+				return nil, err
+			}
+		}
+	case []interface{}:
+		for i, entry := range v {
+			dict, ok := entry.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("entry %d: expected a dictionary", i)
+			}
+			number, _ := dict["number"].(string)
+			if err := add(number, dict); err != nil {
+				return nil, fmt.Errorf("entry %d: %w", i, err)
+			}
+		}
+	default:
+		return nil, errors.New("expected a dictionary or an array of events")
+	}
+	return events, nil
+}
+
+// parsePlist returns the first value of a property list: dictionaries
+// as map[string]interface{}, arrays as []interface{} and all other
+// values as their text.
+func parsePlist(d *xml.Decoder) (interface{}, error) {
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if t.Name.Local == "plist" {
+				continue
+			}
+			return parsePlistValue(d, t)
+		case xml.EndElement:
+			return nil, errors.New("empty property list")
+		}
+	}
+}
+
+// parsePlistValue parses the value started by the given element.
+func parsePlistValue(d *xml.Decoder, start xml.StartElement) (interface{}, error) {
+	switch start.Name.Local {
+	case "dict":
+		dict := map[string]interface{}{}
+		var key *string
+		for {
+			tok, err := d.Token()
+			if err != nil {
+				return nil, err
+			}
+			switch t := tok.(type) {
+			case xml.StartElement:
+				value, err := parsePlistValue(d, t)
+				if err != nil {
+					return nil, err
+				}
+				if t.Name.Local == "key" {
+					s := value.(string)
+					key = &s
+					continue
+				}
+				if key == nil {
+					return nil, errors.New("dictionary value without key")
+				}
+				dict[*key] = value
+				key = nil
+			case xml.EndElement:
+				return dict, nil
+			}
+		}
+	case "array":
+		var array []interface{}
+		for {
+			tok, err := d.Token()
+			if err != nil {
+				return nil, err
+			}
+			switch t := tok.(type) {
+			case xml.StartElement:
+				value, err := parsePlistValue(d, t)
+				if err != nil {
+					return nil, err
+				}
+				array = append(array, value)
+			case xml.EndElement:
+				return array, nil
+			}
+		}
+	case "true", "false":
+		return start.Name.Local, d.Skip()
+	}
+	var text string
+	if err := d.DecodeElement(&text, &start); err != nil {
+		return nil, err
+	}
+	return strings.TrimSpace(text), nil
+}
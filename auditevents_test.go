@@ -0,0 +1,98 @@
+package bsm
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testAuditEventsPlist = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>23</key>
+	<dict>
+		<key>name</key>
+		<string>AUE_EXECVE</string>
+		<key>description</key>
+		<string>execve(2)</string>
+		<key>classes</key>
+		<array><string>pc</string><string>ex</string></array>
+	</dict>
+	<key>45028</key>
+	<string>AUE_sudo</string>
+</dict>
+</plist>
+`
+
+func TestParseAuditEventsVariations(t *testing.T) {
+	events, err := ParseAuditEvents(strings.NewReader("43190:AUE_MAC_GET_PROC:__mac_get_proc(2): MAC:pc\n44901:AUE_SESSION_START:session start\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e := events[43190]; e.Description != "__mac_get_proc(2): MAC" || len(e.Classes) != 1 || e.Classes[0] != "pc" {
+		t.Errorf("unexpected event %+v", e)
+	}
+	if e := events[44901]; e.Name != "AUE_SESSION_START" || e.Classes != nil {
+		t.Errorf("unexpected event %+v", e)
+	}
+
+	events, err = ParseAuditEvents(strings.NewReader(testAuditEventsPlist))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e := events[23]; e.Name != "AUE_EXECVE" || e.Description != "execve(2)" || len(e.Classes) != 2 {
+		t.Errorf("unexpected event %+v", e)
+	}
+	if e := events[45028]; e.Name != "AUE_sudo" {
+		t.Errorf("unexpected event %+v", e)
+	}
+
+	array := `<plist><array><dict><key>number</key><integer>6152</integer><key>name</key><string>AUE_login</string><key>classes</key><string>lo</string></dict></array></plist>`
+	events, err = ParseAuditEvents(strings.NewReader(array))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e := events[6152]; e.Name != "AUE_login" || len(e.Classes) != 1 {
+		t.Errorf("unexpected events %+v", events)
+	}
+}
+
+func TestLoadAuditEvents(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit_event")
+	if err := os.WriteFile(path, []byte("23:AUE_EXECVE:execve(2):pc,ex\n50000:AUE_custom:custom:ot\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	events, err := LoadAuditEvents(path, DialectDarwin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e := events[23]; e.Description != "execve(2)" || len(e.Classes) != 2 {
+		t.Errorf("file entry not used: %+v", e)
+	}
+	if events[50000].Name != "AUE_custom" || events[uint16(AUE_SESSION_START)].Name != "AUE_SESSION_START" {
+		t.Error("entries not merged")
+	}
+
+	events, err = LoadAuditEvents(filepath.Join(t.TempDir(), "missing"), DialectFreeBSD)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if events[uint16(AUE_EXECVE)].Name != "AUE_EXECVE" {
+		t.Error("embedded events missing")
+	}
+	if _, ok := events[uint16(AUE_SESSION_START)]; ok {
+		t.Error("macOS events for FreeBSD")
+	}
+}
+
+func TestMergeAuditEvents(t *testing.T) {
+	merged := MergeAuditEvents(
+		map[uint16]AuditEvent{1: {Number: 1, Name: "AUE_EXIT", Classes: []string{"pc"}}},
+		map[uint16]AuditEvent{1: {Number: 1, Description: "exit(2)"}},
+	)
+	if e := merged[1]; e.Name != "AUE_EXIT" || e.Description != "exit(2)" || len(e.Classes) != 1 {
+		t.Errorf("unexpected event %+v", e)
+	}
+}
@@ -0,0 +1,19 @@
+//go:build darwin || freebsd
+
+package auditsys
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+func (bsd) Audit(record []byte) error {
+	if len(record) == 0 {
+		return syscall.EINVAL
+	}
+	_, _, errno := syscall.Syscall(syscall.SYS_AUDIT, uintptr(unsafe.Pointer(&record[0])), uintptr(len(record)), 0)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,23 @@
+//go:build !(darwin || freebsd)
+
+package auditsys
+
+import "testing"
+
+func TestAuditNotSupported(t *testing.T) {
+	if err := Audit([]byte{0x14}); err != ErrNotSupported {
+		t.Errorf("got %v, expected ErrNotSupported", err)
+	}
+}
+
+func TestAuditonNotSupported(t *testing.T) {
+	if _, err := GetPolicy(); err != ErrNotSupported {
+		t.Errorf("got %v, expected ErrNotSupported", err)
+	}
+}
+
+func TestSessionNotSupported(t *testing.T) {
+	if _, err := GetSession(); err != ErrNotSupported {
+		t.Errorf("got %v, expected ErrNotSupported", err)
+	}
+}
@@ -0,0 +1,109 @@
+package auditsys
+
+import "strings"
+
+// auditon(2) commands of <bsm/audit.h>.
+const (
+	aGetStat      = 12
+	aGetPolicy    = 33
+	aGetQueueCtrl = 35
+	aGetCond      = 37
+)
+
+// Policy holds the audit policy flags (AUDIT_*).
+type Policy uint32
+
+// Audit policy flags.
+const (
+	PolicyCnt      Policy = 0x0001 // continue without auditing if out of space
+	PolicyAhlt     Policy = 0x0002 // halt the system if records can't be written
+	PolicyArgv     Policy = 0x0004 // audit command line arguments of execve
+	PolicyArge     Policy = 0x0008 // audit the environment of execve
+	PolicySeq      Policy = 0x0010 // add a sequence token to every record
+	PolicyWindata  Policy = 0x0020
+	PolicyUser     Policy = 0x0040
+	PolicyGroup    Policy = 0x0080 // add a groups token to every record
+	PolicyTrail    Policy = 0x0100 // add a trailer token to every record
+	PolicyPath     Policy = 0x0200 // add a path token to every record
+	PolicyScnt     Policy = 0x0400
+	PolicyPublic   Policy = 0x0800
+	PolicyZonename Policy = 0x1000 // add a zonename token to every record
+	PolicyPerzone  Policy = 0x2000
+)
+
+var policyNames = []struct {
+	flag Policy
+	name string
+}{
+	{PolicyCnt, "cnt"}, {PolicyAhlt, "ahlt"}, {PolicyArgv, "argv"},
+	{PolicyArge, "arge"}, {PolicySeq, "seq"}, {PolicyWindata, "windata"},
+	{PolicyUser, "user"}, {PolicyGroup, "group"}, {PolicyTrail, "trail"},
+	{PolicyPath, "path"}, {PolicyScnt, "scnt"}, {PolicyPublic, "public"},
+	{PolicyZonename, "zonename"}, {PolicyPerzone, "perzone"},
+}
+
+// String returns the comma separated names of the flags as used by the
+// policy setting of audit_control(5), e.g. "cnt,argv".
+func (p Policy) String() string {
+	var names []string
+	for _, n := range policyNames {
+		if p&n.flag != 0 {
+			names = append(names, n.name)
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+// QueueControl holds the parameters of the kernel record queue
+// (struct au_qctrl).
+type QueueControl struct {
+	HighWater  int32 // number of queued records blocking writers
+	LowWater   int32 // number of queued records unblocking writers
+	BufferSize int32 // maximum size of a record
+	Delay      int32 // unused
+	MinFree    int32 // minimum percentage of free space of the trail file system
+}
+
+// Condition is the state of the audit subsystem (AUC_*).
+type Condition int32
+
+// Audit conditions.
+const (
+	ConditionDisabled Condition = -1
+	ConditionUnset    Condition = 0
+	ConditionAuditing Condition = 1
+	ConditionNoAudit  Condition = 2
+)
+
+func (c Condition) String() string {
+	switch c {
+	case ConditionDisabled:
+		return "disabled"
+	case ConditionUnset:
+		return "unset"
+	case ConditionAuditing:
+		return "auditing"
+	case ConditionNoAudit:
+		return "noaudit"
+	}
+	return "unknown"
+}
+
+// Stats holds the statistics of the audit subsystem (struct
+// audit_stat). Not all kernels keep them, e.g. FreeBSD doesn't.
+type Stats struct {
+	Version    uint32 // version of the audit subsystem
+	NumEvents  uint32 // number of events
+	Generated  int32  // number of records generated
+	NonAttrib  int32  // number of non-attributable records
+	Kernel     int32  // number of kernel records
+	Audit      int32  // number of records submitted by audit(2)
+	AuditCtl   int32  // number of auditctl(2) calls
+	Enqueued   int32  // number of records put on the queue
+	Written    int32  // number of records written
+	WriteBlock int32  // number of times writing was blocked
+	ReadBlock  int32  // number of times reading was blocked
+	Dropped    int32  // number of records dropped
+	TotalSize  int32  // total number of bytes of the records
+	MemoryUsed uint32 // number of bytes of memory in use
+}
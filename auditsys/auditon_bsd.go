@@ -0,0 +1,41 @@
+//go:build darwin || freebsd
+
+package auditsys
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// auditon calls auditon(2) with the given command and data.
+func auditon(cmd int, data unsafe.Pointer, length uintptr) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_AUDITON, uintptr(cmd), uintptr(data), length)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+func (bsd) GetPolicy() (Policy, error) {
+	var policy int32
+	err := auditon(aGetPolicy, unsafe.Pointer(&policy), unsafe.Sizeof(policy))
+	return Policy(policy), err
+}
+
+func (bsd) GetQueueControl() (QueueControl, error) {
+	var qctrl QueueControl
+	err := auditon(aGetQueueCtrl, unsafe.Pointer(&qctrl), unsafe.Sizeof(qctrl))
+	return qctrl, err
+}
+
+func (bsd) GetCondition() (Condition, error) {
+	var cond int32
+	err := auditon(aGetCond, unsafe.Pointer(&cond), unsafe.Sizeof(cond))
+	return Condition(cond), err
+}
+
+func (bsd) GetStats() (Stats, error) {
+	var stats Stats
+	err := auditon(aGetStat, unsafe.Pointer(&stats), unsafe.Sizeof(stats))
+	return stats, err
+}
@@ -0,0 +1,21 @@
+package auditsys
+
+import "testing"
+
+func TestPolicyString(t *testing.T) {
+	if s := (PolicyCnt | PolicyArgv | PolicyZonename).String(); s != "cnt,argv,zonename" {
+		t.Errorf("got %q", s)
+	}
+	if s := Policy(0).String(); s != "" {
+		t.Errorf("got %q for no flags", s)
+	}
+}
+
+func TestConditionString(t *testing.T) {
+	if s := ConditionAuditing.String(); s != "auditing" {
+		t.Errorf("got %q", s)
+	}
+	if s := Condition(42).String(); s != "unknown" {
+		t.Errorf("got %q", s)
+	}
+}
@@ -0,0 +1,78 @@
+// Package auditsys wraps the system calls and devices of the BSD audit
+// subsystem, e.g. to commit records to the system audit trail. They are
+// available on FreeBSD and macOS, elsewhere all functions return
+// ErrNotSupported. The package is pure Go on all other platforms, so
+// programs using it can be cross-compiled.
+package auditsys
+
+import (
+	"errors"
+	"io"
+)
+
+// ErrNotSupported is returned on platforms without BSD audit.
+var ErrNotSupported = errors.New("audit system calls not supported on this platform")
+
+// Platform provides the native audit features of a system.
+type Platform interface {
+	// Supported reports whether the features are available at all.
+	Supported() bool
+
+	Audit(record []byte) error
+	GetPolicy() (Policy, error)
+	GetQueueControl() (QueueControl, error)
+	GetCondition() (Condition, error)
+	GetStats() (Stats, error)
+	GetAuditID() (uint32, error)
+	SetAuditID(auid uint32) error
+	GetSession() (Session, error)
+	SetSession(s Session) error
+	OpenPipe() (io.ReadCloser, error)
+	GetPipeStats(pipe io.ReadCloser) (PipeStats, error)
+}
+
+// Native is the platform used by the functions of this package. It is
+// the running system, or Unsupported where BSD audit isn't available.
+// Tests may replace it.
+var Native Platform = native
+
+// Supported reports whether BSD audit is available.
+func Supported() bool { return Native.Supported() }
+
+// Audit commits the given record, in its binary form including header
+// and trailer, to the system audit trail (audit(2)). The caller needs
+// the audit privilege, i.e. usually has to run as root.
+func Audit(record []byte) error { return Native.Audit(record) }
+
+// GetPolicy returns the audit policy flags.
+func GetPolicy() (Policy, error) { return Native.GetPolicy() }
+
+// GetQueueControl returns the parameters of the kernel record queue.
+func GetQueueControl() (QueueControl, error) { return Native.GetQueueControl() }
+
+// GetCondition returns the state of the audit subsystem.
+func GetCondition() (Condition, error) { return Native.GetCondition() }
+
+// GetStats returns the statistics of the audit subsystem.
+func GetStats() (Stats, error) { return Native.GetStats() }
+
+// GetAuditID returns the audit user ID of the calling process
+// (getauid(2)).
+func GetAuditID() (uint32, error) { return Native.GetAuditID() }
+
+// SetAuditID sets the audit user ID of the calling process (setauid(2)),
+// e.g. after authenticating a user. It requires the audit privilege.
+func SetAuditID(auid uint32) error { return Native.SetAuditID(auid) }
+
+// GetSession returns the audit state of the calling process
+// (getaudit_addr(2)).
+func GetSession() (Session, error) { return Native.GetSession() }
+
+// SetSession sets the audit state of the calling process
+// (setaudit_addr(2)), e.g. before starting a user session. It requires
+// the audit privilege.
+func SetSession(s Session) error { return Native.SetSession(s) }
+
+// OpenPipe opens an audit pipe (auditpipe(4)), a live copy of the
+// records written to the trail. It can be read with a decoder.
+func OpenPipe() (io.ReadCloser, error) { return Native.OpenPipe() }
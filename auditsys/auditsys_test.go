@@ -0,0 +1,49 @@
+package auditsys
+
+import "testing"
+
+// fakePlatform records the records submitted with Audit.
+type fakePlatform struct {
+	Unsupported
+	records [][]byte
+}
+
+func (p *fakePlatform) Supported() bool { return true }
+
+func (p *fakePlatform) Audit(record []byte) error {
+	p.records = append(p.records, record)
+	return nil
+}
+
+func TestNative(t *testing.T) {
+	saved := Native
+	defer func() { Native = saved }()
+
+	fake := &fakePlatform{}
+	Native = fake
+	if !Supported() {
+		t.Error("fake platform not supported")
+	}
+	if err := Audit([]byte{0x14}); err != nil {
+		t.Error(err)
+	}
+	if len(fake.records) != 1 {
+		t.Errorf("got %d records, expected 1", len(fake.records))
+	}
+	if _, err := GetPolicy(); err != ErrNotSupported {
+		t.Errorf("got %v, expected ErrNotSupported", err)
+	}
+}
+
+func TestUnsupported(t *testing.T) {
+	var p Platform = Unsupported{}
+	if p.Supported() {
+		t.Error("Unsupported is supported")
+	}
+	if _, err := p.OpenPipe(); err != ErrNotSupported {
+		t.Errorf("got %v, expected ErrNotSupported", err)
+	}
+	if _, err := p.GetPipeStats(nil); err != ErrNotSupported {
+		t.Errorf("got %v, expected ErrNotSupported", err)
+	}
+}
@@ -0,0 +1,22 @@
+//go:build darwin || freebsd
+
+package auditsys
+
+import (
+	"io"
+	"os"
+)
+
+// pipeDevice is the clone device of auditpipe(4).
+const pipeDevice = "/dev/auditpipe"
+
+// bsd is the Platform of FreeBSD and macOS.
+type bsd struct{}
+
+var native Platform = bsd{}
+
+func (bsd) Supported() bool { return true }
+
+func (bsd) OpenPipe() (io.ReadCloser, error) {
+	return os.Open(pipeDevice)
+}
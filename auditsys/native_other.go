@@ -0,0 +1,5 @@
+//go:build !(darwin || freebsd)
+
+package auditsys
+
+var native Platform = Unsupported{}
@@ -0,0 +1,24 @@
+package auditsys
+
+import "io"
+
+// Requests of auditpipe(4) reading its counters (_IOR('A', 100..103, u_int64_t)).
+const (
+	pipeGetInserts   = 0x40084164
+	pipeGetReads     = 0x40084165
+	pipeGetDrops     = 0x40084166
+	pipeGetTruncates = 0x40084167
+)
+
+// PipeStats holds the counters of an audit pipe. Records are dropped
+// when the queue of the pipe is full, i.e. the reader is too slow.
+type PipeStats struct {
+	Inserts   uint64 // number of records put on the queue
+	Reads     uint64 // number of records read
+	Drops     uint64 // number of records dropped
+	Truncates uint64 // number of records truncated (too large for the read buffer)
+}
+
+// GetPipeStats returns the counters of an audit pipe opened with
+// OpenPipe.
+func GetPipeStats(pipe io.ReadCloser) (PipeStats, error) { return Native.GetPipeStats(pipe) }
@@ -0,0 +1,47 @@
+//go:build darwin || freebsd
+
+package auditsys
+
+import (
+	"errors"
+	"io"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+func (bsd) GetPipeStats(pipe io.ReadCloser) (PipeStats, error) {
+	file, ok := pipe.(*os.File)
+	if !ok {
+		return PipeStats{}, errors.New("audit pipe is not a file")
+	}
+	conn, err := file.SyscallConn()
+	if err != nil {
+		return PipeStats{}, err
+	}
+	var stats PipeStats
+	var errno syscall.Errno
+	err = conn.Control(func(fd uintptr) {
+		for _, req := range []struct {
+			request uintptr
+			value   *uint64
+		}{
+			{pipeGetInserts, &stats.Inserts},
+			{pipeGetReads, &stats.Reads},
+			{pipeGetDrops, &stats.Drops},
+			{pipeGetTruncates, &stats.Truncates},
+		} {
+			_, _, errno = syscall.Syscall(syscall.SYS_IOCTL, fd, req.request, uintptr(unsafe.Pointer(req.value)))
+			if errno != 0 {
+				return
+			}
+		}
+	})
+	if err != nil {
+		return stats, err
+	}
+	if errno != 0 {
+		return stats, errno
+	}
+	return stats, nil
+}
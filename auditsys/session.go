@@ -0,0 +1,55 @@
+package auditsys
+
+import "net"
+
+// Terminal address types of struct au_tid_addr (at_type).
+const (
+	auIPv4 = 4
+	auIPv6 = 16
+)
+
+// Mask selects the audit classes of the events to record, separately
+// for successful and failed events (struct au_mask).
+type Mask struct {
+	Success uint32
+	Failure uint32
+}
+
+// TerminalID identifies the terminal of a session (struct au_tid_addr).
+type TerminalID struct {
+	Port    uint64 // device or remote port
+	Address net.IP // local or remote IPv4/IPv6 address
+}
+
+// Session is the audit state of a process (struct auditinfo_addr), which
+// is inherited by its children.
+type Session struct {
+	AuditID   uint32 // audit user ID, e.g. bsm.DefaultAuditID
+	Mask      Mask
+	Terminal  TerminalID
+	SessionID int32  // audit session ID
+	Flags     uint64 // session flags
+}
+
+// terminalAddress returns the terminal address of the given type.
+func terminalAddress(addrType uint32, addr [16]byte) net.IP {
+	switch addrType {
+	case auIPv4:
+		return net.IP(append([]byte(nil), addr[:4]...))
+	case auIPv6:
+		return net.IP(append([]byte(nil), addr[:]...))
+	}
+	return net.IPv4zero.To4()
+}
+
+// terminalType returns the type and the raw form of a terminal address.
+// Unset addresses are stored as 0.0.0.0.
+func terminalType(ip net.IP) (uint32, [16]byte) {
+	var addr [16]byte
+	if ip4 := ip.To4(); ip4 != nil || ip == nil {
+		copy(addr[:], ip4)
+		return auIPv4, addr
+	}
+	copy(addr[:], ip.To16())
+	return auIPv6, addr
+}
@@ -0,0 +1,59 @@
+//go:build darwin || freebsd
+
+package auditsys
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+func (bsd) GetAuditID() (uint32, error) {
+	var auid uint32
+	_, _, errno := syscall.Syscall(syscall.SYS_GETAUID, uintptr(unsafe.Pointer(&auid)), 0, 0)
+	if errno != 0 {
+		return 0, errno
+	}
+	return auid, nil
+}
+
+func (bsd) SetAuditID(auid uint32) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_SETAUID, uintptr(unsafe.Pointer(&auid)), 0, 0)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+func (bsd) GetSession() (Session, error) {
+	var ai auditinfoAddr
+	_, _, errno := syscall.Syscall(syscall.SYS_GETAUDIT_ADDR, uintptr(unsafe.Pointer(&ai)), unsafe.Sizeof(ai), 0)
+	if errno != 0 {
+		return Session{}, errno
+	}
+	return Session{
+		AuditID: ai.auid,
+		Mask:    ai.mask,
+		Terminal: TerminalID{
+			Port:    uint64(ai.port),
+			Address: terminalAddress(ai.addrType, ai.addr),
+		},
+		SessionID: ai.asid,
+		Flags:     ai.flags,
+	}, nil
+}
+
+func (bsd) SetSession(s Session) error {
+	ai := auditinfoAddr{
+		auid:  s.AuditID,
+		mask:  s.Mask,
+		port:  devT(s.Terminal.Port),
+		asid:  s.SessionID,
+		flags: s.Flags,
+	}
+	ai.addrType, ai.addr = terminalType(s.Terminal.Address)
+	_, _, errno := syscall.Syscall(syscall.SYS_SETAUDIT_ADDR, uintptr(unsafe.Pointer(&ai)), unsafe.Sizeof(ai), 0)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,15 @@
+package auditsys
+
+// devT is dev_t.
+type devT = int32
+
+// auditinfoAddr is struct auditinfo_addr of <bsm/audit.h>.
+type auditinfoAddr struct {
+	auid     uint32
+	mask     Mask
+	port     devT
+	addrType uint32
+	addr     [16]byte
+	asid     int32
+	flags    uint64
+}
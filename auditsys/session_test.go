@@ -0,0 +1,26 @@
+package auditsys
+
+import (
+	"net"
+	"testing"
+)
+
+func TestTerminalAddress(t *testing.T) {
+	for _, ip := range []net.IP{net.ParseIP("192.0.2.1").To4(), net.ParseIP("2001:db8::1")} {
+		addrType, addr := terminalType(ip)
+		if int(addrType) != len(ip) {
+			t.Errorf("%v: got type %d", ip, addrType)
+		}
+		if got := terminalAddress(addrType, addr); !got.Equal(ip) || len(got) != len(ip) {
+			t.Errorf("%v: got %v back", ip, got)
+		}
+	}
+
+	addrType, addr := terminalType(nil)
+	if addrType != auIPv4 || !terminalAddress(addrType, addr).Equal(net.IPv4zero) {
+		t.Error("unset address isn't stored as 0.0.0.0")
+	}
+	if got := terminalAddress(0, addr); !got.Equal(net.IPv4zero) {
+		t.Errorf("unknown type: got %v", got)
+	}
+}
@@ -0,0 +1,22 @@
+package auditsys
+
+import "io"
+
+// Unsupported is the Platform of systems without BSD audit. All its
+// methods return ErrNotSupported.
+type Unsupported struct{}
+
+func (Unsupported) Supported() bool                        { return false }
+func (Unsupported) Audit(record []byte) error              { return ErrNotSupported }
+func (Unsupported) GetPolicy() (Policy, error)             { return 0, ErrNotSupported }
+func (Unsupported) GetQueueControl() (QueueControl, error) { return QueueControl{}, ErrNotSupported }
+func (Unsupported) GetCondition() (Condition, error)       { return ConditionUnset, ErrNotSupported }
+func (Unsupported) GetStats() (Stats, error)               { return Stats{}, ErrNotSupported }
+func (Unsupported) GetAuditID() (uint32, error)            { return 0, ErrNotSupported }
+func (Unsupported) SetAuditID(auid uint32) error           { return ErrNotSupported }
+func (Unsupported) GetSession() (Session, error)           { return Session{}, ErrNotSupported }
+func (Unsupported) SetSession(s Session) error             { return ErrNotSupported }
+func (Unsupported) OpenPipe() (io.ReadCloser, error)       { return nil, ErrNotSupported }
+func (Unsupported) GetPipeStats(io.ReadCloser) (PipeStats, error) {
+	return PipeStats{}, ErrNotSupported
+}
@@ -0,0 +1,157 @@
+// Package baseline learns the typical behaviour of users or hosts from
+// historical trails and scores live records for deviations from it.
+package baseline
+
+import (
+	"fmt"
+	"io"
+	"math"
+
+	bsm "github.com/tpltnt/go-bsm"
+)
+
+// RateThreshold is the number of standard deviations above the mean
+// events per hour which is scored as 1 (i.e. anomalous).
+const RateThreshold = 3
+
+// profile holds the baseline of a single entity.
+type profile struct {
+	hours       map[int64]uint64  // events per hour (learning)
+	executables map[string]uint64 // executed programs
+	mean        float64           // mean events per active hour
+	stddev      float64           // standard deviation of events per hour
+	dirty       bool              // mean and stddev need an update
+
+	liveHour  int64  // hour of the live counter
+	liveCount uint64 // events seen in liveHour while scoring
+}
+
+func (p *profile) update() {
+	if !p.dirty {
+		return
+	}
+	p.dirty = false
+	var sum, sqsum float64
+	for _, n := range p.hours {
+		sum += float64(n)
+		sqsum += float64(n) * float64(n)
+	}
+	count := float64(len(p.hours))
+	p.mean = sum / count
+	p.stddev = math.Sqrt(math.Max(sqsum/count-p.mean*p.mean, 0))
+}
+
+// Score is the deviation of a record from the baseline. A Value of 1
+// or more marks the record as anomalous, Reasons explains why.
+type Score struct {
+	Value   float64
+	Reasons []string
+}
+
+// ScoredRecord is a parsing result with its score.
+type ScoredRecord struct {
+	bsm.ParsingResult
+	Score Score
+}
+
+// Model holds the baselines of all entities. Entities are the values
+// of the Dimensions, e.g. bsm.DimUser for per-user baselines. A Model
+// is not safe for concurrent use.
+type Model struct {
+	Dimensions []bsm.Dimension
+	profiles   map[string]*profile
+}
+
+// NewModel returns an empty model for the given dimensions.
+func NewModel(dims ...bsm.Dimension) *Model {
+	return &Model{
+		Dimensions: dims,
+		profiles:   map[string]*profile{},
+	}
+}
+
+func hour(rec *bsm.BsmRecord) int64 {
+	return int64(rec.Seconds / 3600)
+}
+
+// Learn adds the record to the baselines.
+func (m *Model) Learn(rec *bsm.BsmRecord) {
+	exe := bsm.DimExecPath.Value(rec)
+	for _, dim := range m.Dimensions {
+		key := dim.Name + "=" + dim.Value(rec)
+		p, ok := m.profiles[key]
+		if !ok {
+			p = &profile{hours: map[int64]uint64{}, executables: map[string]uint64{}}
+			m.profiles[key] = p
+		}
+		p.hours[hour(rec)] += 1
+		if exe != "" {
+			p.executables[exe] += 1
+		}
+		p.dirty = true
+	}
+}
+
+// LearnFrom adds all records of a historical trail to the baselines.
+func (m *Model) LearnFrom(input io.Reader) error {
+	return bsm.ForEachRecord(input, func(rec *bsm.BsmRecord) error {
+		m.Learn(rec)
+		return nil
+	})
+}
+
+// Score rates how much the record deviates from the baselines. Live
+// records are expected in chronological order, as the events per hour
+// are counted while scoring.
+func (m *Model) Score(rec *bsm.BsmRecord) Score {
+	score := Score{}
+	add := func(value float64, reason string) {
+		if value > score.Value {
+			score.Value = value
+		}
+		if value >= 1 {
+			score.Reasons = append(score.Reasons, reason)
+		}
+	}
+
+	exe := bsm.DimExecPath.Value(rec)
+	for _, dim := range m.Dimensions {
+		value := dim.Value(rec)
+		p, ok := m.profiles[dim.Name+"="+value]
+		if !ok {
+			add(1, fmt.Sprintf("unknown %s %q", dim.Name, value))
+			continue
+		}
+		p.update()
+
+		if p.liveHour != hour(rec) {
+			p.liveHour = hour(rec)
+			p.liveCount = 0
+		}
+		p.liveCount += 1
+		z := (float64(p.liveCount) - p.mean) / math.Max(p.stddev, 1)
+		add(z/RateThreshold, fmt.Sprintf("%d events per hour for %s %q (typically %.1f)", p.liveCount, dim.Name, value, p.mean))
+
+		if exe != "" && p.executables[exe] == 0 {
+			add(1, fmt.Sprintf("new executable %q for %s %q", exe, dim.Name, value))
+		}
+	}
+	return score
+}
+
+// Run scores all records of the input stream. Parsing errors are
+// passed through without a score.
+func (m *Model) Run(in chan bsm.ParsingResult) chan ScoredRecord {
+	out := make(chan ScoredRecord)
+	go func() {
+		defer close(out)
+		for res := range in {
+			scored := ScoredRecord{ParsingResult: res}
+			if res.Error == nil {
+				scored.Score = m.Score(&res.Record)
+			}
+			out <- scored
+		}
+	}()
+	return out
+}
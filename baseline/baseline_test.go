@@ -0,0 +1,83 @@
+package baseline
+
+import (
+	"errors"
+	"testing"
+
+	bsm "github.com/tpltnt/go-bsm"
+)
+
+func record(seconds uint64, auditID uint32, exe string) bsm.BsmRecord {
+	rec := bsm.BsmRecord{Seconds: seconds}
+	rec.Tokens = append(rec.Tokens, bsm.SubjectToken32bit{TokenID: 0x24, AuditID: auditID})
+	if exe != "" {
+		rec.Tokens = append(rec.Tokens, bsm.ExecArgsToken{TokenID: 0x3c, Count: 1, Text: []string{exe}})
+	}
+	return rec
+}
+
+func TestModel(t *testing.T) {
+	model := NewModel(bsm.DimUser)
+	// two events per hour for a day
+	for h := uint64(0); h < 24; h++ {
+		for _, exe := range []string{"/bin/ls", "/usr/bin/vi"} {
+			rec := record(h*3600, 1001, exe)
+			model.Learn(&rec)
+		}
+	}
+
+	live := uint64(48 * 3600)
+	rec := record(live, 1001, "/bin/ls")
+	if score := model.Score(&rec); score.Value >= 1 || len(score.Reasons) != 0 {
+		t.Error("typical record scored as anomalous:", score)
+	}
+
+	rec = record(live+1, 1001, "/usr/bin/nc")
+	score := model.Score(&rec)
+	if score.Value < 1 || len(score.Reasons) != 1 {
+		t.Error("new executable not detected:", score)
+	}
+
+	rec = record(live, 1002, "/bin/ls")
+	if score := model.Score(&rec); score.Value < 1 {
+		t.Error("unknown user not detected:", score)
+	}
+
+	// burst of events
+	var burst Score
+	for i := 0; i < 10; i++ {
+		rec = record(live+2, 1001, "/bin/ls")
+		burst = model.Score(&rec)
+	}
+	if burst.Value < 1 {
+		t.Error("burst not detected:", burst)
+	}
+	// the counter starts over in the next hour
+	rec = record(live+3600, 1001, "/bin/ls")
+	if score := model.Score(&rec); score.Value >= 1 {
+		t.Error("unexpected score in next hour:", score)
+	}
+}
+
+func TestModelRun(t *testing.T) {
+	model := NewModel(bsm.DimUser)
+	in := make(chan bsm.ParsingResult)
+	go func() {
+		in <- bsm.ParsingResult{Record: record(0, 1001, "")}
+		in <- bsm.ParsingResult{Error: errors.New("broken record")}
+		close(in)
+	}()
+	results := []ScoredRecord{}
+	for res := range model.Run(in) {
+		results = append(results, res)
+	}
+	if len(results) != 2 {
+		t.Fatal("unexpected number of results")
+	}
+	if results[0].Score.Value != 1 {
+		t.Error("unexpected score:", results[0].Score)
+	}
+	if results[1].Error == nil || results[1].Score.Value != 0 {
+		t.Error("parsing error not passed through")
+	}
+}
@@ -0,0 +1,149 @@
+// Package clock abstracts the current time and timers, so tests and
+// simulations can control the time seen by encoders, sources and sinks.
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock tells the time and waits for it to pass.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer is the time.Timer of a Clock.
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+	Reset(d time.Duration) bool
+}
+
+// Real is the clock of the system.
+var Real Clock = realClock{}
+
+// Or returns c, or Real if c is nil.
+func Or(c Clock) Clock {
+	if c == nil {
+		return Real
+	}
+	return c
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+func (realClock) NewTimer(d time.Duration) Timer         { return realTimer{time.NewTimer(d)} }
+
+type realTimer struct{ *time.Timer }
+
+func (t realTimer) C() <-chan time.Time { return t.Timer.C }
+
+// Fake is a Clock whose time only changes with Set and Advance, which
+// fire the timers that are due.
+type Fake struct {
+	mutex  sync.Mutex
+	now    time.Time
+	timers []*fakeTimer // pending timers
+}
+
+// NewFake returns a fake clock set to the given time.
+func NewFake(now time.Time) *Fake {
+	return &Fake{now: now}
+}
+
+// Now returns the time of the clock.
+func (f *Fake) Now() time.Time {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.now
+}
+
+// Set sets the time of the clock.
+func (f *Fake) Set(now time.Time) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.now = now
+	pending := f.timers[:0]
+	for _, t := range f.timers {
+		if t.when.After(now) {
+			pending = append(pending, t)
+			continue
+		}
+		select {
+		case t.c <- now:
+		default:
+		}
+	}
+	f.timers = pending
+}
+
+// Advance moves the time of the clock forward.
+func (f *Fake) Advance(d time.Duration) {
+	f.Set(f.Now().Add(d))
+}
+
+// Waiters returns the number of pending timers, e.g. to wait until the
+// code under test blocks on the clock.
+func (f *Fake) Waiters() int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return len(f.timers)
+}
+
+// After returns a channel receiving the time once the clock was
+// advanced by d.
+func (f *Fake) After(d time.Duration) <-chan time.Time {
+	return f.NewTimer(d).C()
+}
+
+// NewTimer returns a timer firing once the clock was advanced by d.
+func (f *Fake) NewTimer(d time.Duration) Timer {
+	t := &fakeTimer{clock: f, c: make(chan time.Time, 1)}
+	t.Reset(d)
+	return t
+}
+
+type fakeTimer struct {
+	clock *Fake
+	c     chan time.Time
+	when  time.Time
+}
+
+func (t *fakeTimer) C() <-chan time.Time { return t.c }
+
+// Stop removes the timer from the pending timers of the clock.
+func (t *fakeTimer) Stop() bool {
+	t.clock.mutex.Lock()
+	defer t.clock.mutex.Unlock()
+	return t.remove()
+}
+
+// remove removes the timer from the pending timers and reports whether
+// it was pending. The mutex of the clock is held.
+func (t *fakeTimer) remove() bool {
+	for i, pending := range t.clock.timers {
+		if pending == t {
+			t.clock.timers = append(t.clock.timers[:i], t.clock.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Reset makes the timer fire once the clock was advanced by d from now.
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	t.clock.mutex.Lock()
+	active := t.remove()
+	t.when = t.clock.now.Add(d)
+	t.clock.timers = append(t.clock.timers, t)
+	now := t.clock.now
+	t.clock.mutex.Unlock()
+	if d <= 0 {
+		t.clock.Set(now)
+	}
+	return active
+}
@@ -0,0 +1,54 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFake(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	f := NewFake(start)
+	after := f.After(time.Second)
+	timer := f.NewTimer(2 * time.Second)
+	stopped := f.NewTimer(time.Second)
+	if !stopped.Stop() || f.Waiters() != 2 {
+		t.Fatalf("got %d waiters, expected 2", f.Waiters())
+	}
+
+	f.Advance(999 * time.Millisecond)
+	select {
+	case <-after:
+		t.Fatal("timer fired early")
+	default:
+	}
+	f.Advance(time.Millisecond)
+	if now := <-after; !now.Equal(start.Add(time.Second)) {
+		t.Error("unexpected time", now)
+	}
+	if f.Waiters() != 1 {
+		t.Errorf("got %d waiters, expected 1", f.Waiters())
+	}
+
+	timer.Reset(time.Second)
+	f.Advance(time.Second)
+	select {
+	case <-timer.C():
+	default:
+		t.Error("reset timer didn't fire")
+	}
+	select {
+	case <-stopped.C():
+		t.Error("stopped timer fired")
+	default:
+	}
+}
+
+func TestOr(t *testing.T) {
+	if Or(nil) != Real {
+		t.Error("nil is not the real clock")
+	}
+	f := NewFake(time.Time{})
+	if Or(f) != f {
+		t.Error("clock not kept")
+	}
+}
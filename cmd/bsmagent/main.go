@@ -0,0 +1,64 @@
+// Command bsmagent ships BSM audit trails as configured by an agent
+// configuration (see package agent): it reads the sources, applies the
+// enrichments and the filter and writes the records to the sinks,
+// including exec:// and plugin sinks.
+//
+//	bsmagent -config agent.yaml
+//	bsmagent -completion bash|zsh|fish
+//
+// It runs until all file sources are exhausted or it is interrupted
+// (SIGINT or SIGTERM), checkpointing the offsets of the sources.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/tpltnt/go-bsm/agent"
+	"github.com/tpltnt/go-bsm/cmd/internal/cli"
+)
+
+func main() {
+	configPath := flag.String("config", "", "agent configuration (YAML)")
+	completion := flag.String("completion", "", "print the completion script for the shell: bash, zsh or fish")
+	flag.Parse()
+	if *completion != "" {
+		values := map[string][]string{"completion": cli.Shells}
+		if err := cli.Completion(os.Stdout, *completion, "bsmagent", flag.CommandLine, values); err != nil {
+			fmt.Fprintln(os.Stderr, "bsmagent:", err)
+			os.Exit(2)
+		}
+		return
+	}
+	if *configPath == "" || flag.NArg() > 0 {
+		fmt.Fprintln(os.Stderr, "usage: bsmagent -config agent.yaml")
+		flag.PrintDefaults()
+		os.Exit(2)
+	}
+	if err := run(*configPath); err != nil {
+		fmt.Fprintln(os.Stderr, "bsmagent:", err)
+		os.Exit(1)
+	}
+}
+
+func run(configPath string) error {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return err
+	}
+	a, err := agent.FromConfig(data)
+	if err != nil {
+		return err
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	err = a.Run(ctx)
+	if cerr := a.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
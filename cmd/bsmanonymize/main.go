@@ -0,0 +1,85 @@
+// Command bsmanonymize pseudonymizes a BSM audit trail (see
+// bsm.Anonymize) so it can be shared, e.g. to report a parsing problem.
+//
+//	bsmanonymize [-map mapping.json] [-host name]... in.bsm out.bsm
+//	bsmanonymize -completion bash|zsh|fish
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	bsm "github.com/tpltnt/go-bsm"
+	"github.com/tpltnt/go-bsm/cmd/internal/cli"
+)
+
+// listFlag collects the values of a repeated flag.
+type listFlag []string
+
+func (l *listFlag) String() string { return fmt.Sprint(*l) }
+
+func (l *listFlag) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+func main() {
+	mapPath := flag.String("map", "", "file to write the mapping of the pseudonyms to (keep it private)")
+	var hosts, keep listFlag
+	flag.Var(&hosts, "host", "host name to replace (repeatable)")
+	flag.Var(&keep, "keep", "directory whose paths are kept (repeatable, replaces the defaults)")
+	completion := flag.String("completion", "", "print the completion script for the shell: bash, zsh or fish")
+	flag.Parse()
+	if *completion != "" {
+		values := map[string][]string{"completion": cli.Shells}
+		if err := cli.Completion(os.Stdout, *completion, "bsmanonymize", flag.CommandLine, values); err != nil {
+			fmt.Fprintln(os.Stderr, "bsmanonymize:", err)
+			os.Exit(2)
+		}
+		return
+	}
+	if flag.NArg() != 2 {
+		fmt.Fprintln(os.Stderr, "usage: bsmanonymize [flags] in.bsm out.bsm")
+		flag.PrintDefaults()
+		os.Exit(2)
+	}
+
+	policy := bsm.DefaultAnonymizePolicy
+	policy.Hostnames = hosts
+	if len(keep) > 0 {
+		policy.KeepPaths = keep
+	}
+	if err := run(flag.Arg(0), flag.Arg(1), *mapPath, policy); err != nil {
+		fmt.Fprintln(os.Stderr, "bsmanonymize:", err)
+		os.Exit(1)
+	}
+}
+
+func run(inPath, outPath, mapPath string, policy bsm.AnonymizePolicy) error {
+	in, err := os.Open(inPath)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(outPath)
+	if err != nil {
+		return err
+	}
+	mapping, err := bsm.Anonymize(in, out, policy)
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	if mapPath == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(mapping, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(mapPath, data, 0600)
+}
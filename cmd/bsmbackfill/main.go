@@ -0,0 +1,80 @@
+// Command bsmbackfill replays archived BSM audit trails through the
+// enrichments, filter and sinks of an agent configuration (see package
+// agent), e.g. after detection rules or schemas changed. The sources and
+// the checkpoint of the configuration are ignored.
+//
+//	bsmbackfill -config agent.yaml [-rate n] [-progress 10s] trail...
+//	bsmbackfill -completion bash|zsh|fish
+//
+// The trails are processed in the given order. Progress is reported on
+// the standard error.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/tpltnt/go-bsm/agent"
+	"github.com/tpltnt/go-bsm/cmd/internal/cli"
+)
+
+func main() {
+	configPath := flag.String("config", "", "agent configuration (YAML)")
+	rate := flag.Float64("rate", 0, "maximum number of records per second (0 for unlimited)")
+	every := flag.Duration("progress", 10*time.Second, "interval of the progress reports")
+	completion := flag.String("completion", "", "print the completion script for the shell: bash, zsh or fish")
+	flag.Parse()
+	if *completion != "" {
+		values := map[string][]string{"completion": cli.Shells}
+		if err := cli.Completion(os.Stdout, *completion, "bsmbackfill", flag.CommandLine, values); err != nil {
+			fmt.Fprintln(os.Stderr, "bsmbackfill:", err)
+			os.Exit(2)
+		}
+		return
+	}
+	if *configPath == "" || flag.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "usage: bsmbackfill -config agent.yaml [flags] trail...")
+		flag.PrintDefaults()
+		os.Exit(2)
+	}
+	if err := run(*configPath, flag.Args(), *rate, *every); err != nil {
+		fmt.Fprintln(os.Stderr, "bsmbackfill:", err)
+		os.Exit(1)
+	}
+}
+
+func run(configPath string, trails []string, rate float64, every time.Duration) error {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return err
+	}
+	a, err := agent.FromConfig(data)
+	if err != nil {
+		return err
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	_, err = a.Backfill(ctx, trails, agent.BackfillOptions{
+		Rate:          rate,
+		Progress:      report,
+		ProgressEvery: every,
+	})
+	if cerr := a.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// report prints the progress.
+func report(p agent.BackfillProgress) {
+	if p.File == "" {
+		fmt.Fprintf(os.Stderr, "done: %d trails, %d records in %v\n", p.FilesDone, p.Records, p.Elapsed.Round(time.Millisecond))
+		return
+	}
+	fmt.Fprintf(os.Stderr, "%5.1f%% trail %d/%d, %d records (%.0f/s), %s\n", p.Percent(), p.FilesDone+1, p.Files,
+		p.Records, float64(p.Records)/p.Elapsed.Seconds(), p.File)
+}
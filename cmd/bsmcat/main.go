@@ -0,0 +1,150 @@
+// Command bsmcat prints the records of BSM audit trails.
+//
+//	bsmcat [-output json|ndjson|table] [-nulls] [-string-int64] [-color auto|always|never] [-events file] [trail]...
+//	bsmcat -follow [-output ndjson|table] trail
+//	bsmcat -completion bash|zsh|fish
+//
+// Without trails the standard input is read. The output formats json (a
+// single array) and ndjson (one record per line, the default) are stable:
+// the records follow the JSON schema of the schema package. The table
+// format (or -pretty) prints aligned and colored columns for the terminal
+// and may change. -nulls and -string-int64 select the serialization
+// policy of the JSON formats (see decode.JSONPolicy). With -follow the trail is followed as it is written
+// (like tail -f) and failures are highlighted.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	bsm "github.com/tpltnt/go-bsm"
+	"github.com/tpltnt/go-bsm/cmd/internal/cli"
+	"github.com/tpltnt/go-bsm/decode"
+)
+
+func main() {
+	output := flag.String("output", "ndjson", "output format: json, ndjson or table")
+	pretty := flag.Bool("pretty", false, "shorthand for -output table")
+	nulls := flag.Bool("nulls", false, "write unset fields of json and ndjson output as null")
+	stringInt64 := flag.Bool("string-int64", false, "write 64-bit integers of json and ndjson output as strings")
+	follow := flag.Bool("follow", false, "follow the trail as it is written")
+	color := flag.String("color", "auto", "colorize table output: auto, always or never")
+	eventsPath := flag.String("events", bsm.DefaultAuditEventPath, "audit_event(5) file naming the events (in addition to the built-in names)")
+	completion := flag.String("completion", "", "print the completion script for the shell: bash, zsh or fish")
+	flag.Parse()
+	if *completion != "" {
+		values := map[string][]string{
+			"output":     {"json", "ndjson", "table"},
+			"color":      {"auto", "always", "never"},
+			"completion": cli.Shells,
+		}
+		if err := cli.Completion(os.Stdout, *completion, "bsmcat", flag.CommandLine, values); err != nil {
+			fmt.Fprintln(os.Stderr, "bsmcat:", err)
+			os.Exit(2)
+		}
+		return
+	}
+	format, err := cli.ParseFormat(*output)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "bsmcat:", err)
+		os.Exit(2)
+	}
+	if *pretty {
+		format = cli.FormatTable
+	}
+	if *follow && (flag.NArg() != 1 || format == cli.FormatJSON) {
+		fmt.Fprintln(os.Stderr, "usage: bsmcat -follow [-output ndjson|table] [flags] trail")
+		os.Exit(2)
+	}
+
+	var print func(rec *bsm.BsmRecord) error
+	encoder := cli.NewEncoder(os.Stdout, format)
+	encoder.Policy = decode.JSONPolicy{Nulls: *nulls, StringInt64: *stringInt64}
+	if format == cli.FormatTable {
+		p := &prettyPrinter{w: os.Stdout, highlight: *follow}
+		switch *color {
+		case "always":
+			p.color = true
+		case "auto":
+			p.color = isTerminal(os.Stdout) && os.Getenv("NO_COLOR") == ""
+		case "never":
+		default:
+			fmt.Fprintln(os.Stderr, "bsmcat: invalid -color value:", *color)
+			os.Exit(2)
+		}
+		p.events, _ = bsm.LoadAuditEvents(*eventsPath, bsm.DialectUnknown)
+		print = p.print
+	} else {
+		print = func(rec *bsm.BsmRecord) error { return encoder.Encode(rec) }
+	}
+
+	switch {
+	case *follow:
+		err = followTrail(flag.Arg(0), print)
+	case flag.NArg() == 0:
+		err = bsm.ForEachRecord(os.Stdin, print)
+	default:
+		for _, path := range flag.Args() {
+			if err = catTrail(path, print); err != nil {
+				break
+			}
+		}
+	}
+	if cerr := encoder.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "bsmcat:", err)
+		os.Exit(1)
+	}
+}
+
+// catTrail prints all records of a trail file.
+func catTrail(path string, print func(rec *bsm.BsmRecord) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if err := bsm.ForEachRecord(file, print); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// followTrail prints the records of a trail file as they are written.
+// Records which are never completed are reported, but don't stop it.
+func followTrail(path string, print func(rec *bsm.BsmRecord) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	src := bsm.NewTailSource(file)
+	for {
+		rec, err := src.Next()
+		if err == io.EOF {
+			return nil
+		}
+		var partial *bsm.PartialRecord
+		if errors.As(err, &partial) {
+			fmt.Fprintln(os.Stderr, "bsmcat:", err)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := print(&rec); err != nil {
+			return err
+		}
+	}
+}
+
+// isTerminal reports whether the file is a terminal.
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	bsm "github.com/tpltnt/go-bsm"
+	"github.com/tpltnt/go-bsm/decode"
+)
+
+// ANSI escape sequences used by the prettyPrinter.
+const (
+	colorReset   = "\x1b[0m"
+	colorDim     = "\x1b[2m"
+	colorRed     = "\x1b[31m"
+	colorCyan    = "\x1b[36m"
+	colorBoldRed = "\x1b[1;31m"
+)
+
+// prettyPrinter prints records as aligned columns: time, event, result,
+// subject and details. Subjects equal to the one of the previous record
+// are collapsed.
+type prettyPrinter struct {
+	w         io.Writer
+	color     bool                      // use ANSI colors
+	highlight bool                      // highlight complete lines of failures
+	events    map[uint16]bsm.AuditEvent // event names (optional)
+	last      string                    // subject of the previous record
+}
+
+// print writes a single record.
+func (p *prettyPrinter) print(rec *bsm.BsmRecord) error {
+	result := "ok"
+	failed := rec.Failed()
+	if failed {
+		result = "failed"
+		if errno, ok := errorNumber(rec); ok {
+			result = fmt.Sprintf("err %d", errno)
+		}
+	}
+	subject := ""
+	if s, ok := rec.Subject(); ok {
+		subject = fmt.Sprintf("auid=%d euid=%d pid=%d", s.AuditID, s.EffectiveUserID, s.ProcessID)
+	}
+	shown := subject
+	if subject != "" && subject == p.last {
+		shown = `"`
+	}
+	p.last = subject
+
+	columns := []string{
+		rec.Time().Format("2006-01-02 15:04:05.000"),
+		p.paint(colorCyan, fmt.Sprintf("%-20s", p.eventName(rec.EventType))),
+		p.paint(colorRed, fmt.Sprintf("%-7s", result), failed),
+		p.paint(colorDim, fmt.Sprintf("%-30s", shown)),
+		details(rec),
+	}
+	line := strings.TrimRight(strings.Join(columns, " "), " ")
+	if failed && p.highlight && p.color {
+		// the colors of the columns are replaced by the highlight
+		line = colorBoldRed + strings.ReplaceAll(line, colorReset, colorReset+colorBoldRed) + colorReset
+	}
+	_, err := fmt.Fprintln(p.w, line)
+	return err
+}
+
+// paint wraps s in the given color if colors are enabled and all
+// conditions hold.
+func (p *prettyPrinter) paint(color, s string, conditions ...bool) string {
+	for _, c := range conditions {
+		if !c {
+			return s
+		}
+	}
+	if !p.color {
+		return s
+	}
+	return color + s + colorReset
+}
+
+// eventName returns the name of the event type, e.g. "execve".
+func (p *prettyPrinter) eventName(eventType uint16) string {
+	if event, ok := p.events[eventType]; ok {
+		return strings.ToLower(strings.TrimPrefix(event.Name, "AUE_"))
+	}
+	return fmt.Sprintf("event %d", eventType)
+}
+
+// errorNumber returns the errno of the return token of the record.
+func errorNumber(rec *bsm.BsmRecord) (uint8, bool) {
+	for _, tok := range rec.Tokens {
+		switch v := tok.(type) {
+		case bsm.ReturnToken32bit:
+			return v.ErrorNumber, true
+		case bsm.ReturnToken64bit:
+			return v.ErrorNumber, true
+		}
+	}
+	return 0, false
+}
+
+// details returns the most telling data of the record: the executed
+// command line, the path or the text. Control characters are escaped to
+// keep them from reaching the terminal.
+func details(rec *bsm.BsmRecord) string {
+	for _, tok := range rec.Tokens {
+		if v, ok := tok.(bsm.ExecArgsToken); ok {
+			return decode.EscapeString(strings.Join(v.Text, " "))
+		}
+	}
+	if path, ok := rec.Path(); ok {
+		return decode.EscapeString(path)
+	}
+	for _, tok := range rec.Tokens {
+		if v, ok := tok.(bsm.TextToken); ok {
+			return decode.EscapeString(v.Text)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	bsm "github.com/tpltnt/go-bsm"
+)
+
+func TestPrettyPrinter(t *testing.T) {
+	subject := bsm.SubjectToken32bit{AuditID: 1001, EffectiveUserID: 0, ProcessID: 42}
+	records := []bsm.BsmRecord{
+		{EventType: 23, Tokens: []bsm.Token{subject, bsm.ExecArgsToken{Text: []string{"/bin/sh", "-c", "echo\x1b"}}, bsm.ReturnToken32bit{}}},
+		{EventType: 72, Tokens: []bsm.Token{subject, bsm.PathToken{Path: "/etc/master.passwd"}, bsm.ReturnToken32bit{ErrorNumber: 13}}},
+	}
+	var buf bytes.Buffer
+	p := &prettyPrinter{w: &buf, events: map[uint16]bsm.AuditEvent{23: {Name: "AUE_EXECVE"}}}
+	for i := range records {
+		if err := p.print(&records[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, expected 2", len(lines))
+	}
+	if !strings.Contains(lines[0], "execve ") || !strings.Contains(lines[0], "auid=1001 euid=0 pid=42") ||
+		!strings.HasSuffix(lines[0], `/bin/sh -c echo\x1b`) {
+		t.Errorf("unexpected line %q", lines[0])
+	}
+	if !strings.Contains(lines[1], "event 72") || !strings.Contains(lines[1], "err 13") ||
+		strings.Contains(lines[1], "auid=") || !strings.HasSuffix(lines[1], "/etc/master.passwd") {
+		t.Errorf("unexpected line %q", lines[1])
+	}
+	// columns are aligned
+	if strings.Index(lines[0], "/bin/sh") != strings.Index(lines[1], "/etc") {
+		t.Error("columns not aligned")
+	}
+	if strings.Contains(buf.String(), "\x1b") {
+		t.Error("colors without terminal")
+	}
+
+	buf.Reset()
+	p = &prettyPrinter{w: &buf, color: true, highlight: true}
+	p.print(&records[1])
+	if !strings.HasPrefix(buf.String(), colorBoldRed) {
+		t.Errorf("failure not highlighted: %q", buf.String())
+	}
+}
@@ -0,0 +1,86 @@
+// Package cli holds the conventions shared by the commands: the output
+// formats and the generation of shell completions.
+package cli
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/tpltnt/go-bsm/decode"
+)
+
+// Format is an output format selected by the -output flag.
+type Format string
+
+// Output formats. JSON and NDJSON are stable contracts for scripts, the
+// records follow the JSON schema of the schema package. Table is meant
+// for humans and may change at any time.
+const (
+	FormatJSON   Format = "json"   // a single JSON array
+	FormatNDJSON Format = "ndjson" // one JSON value per line
+	FormatTable  Format = "table"  // aligned columns
+)
+
+// Formats lists all output formats.
+var Formats = []Format{FormatJSON, FormatNDJSON, FormatTable}
+
+// ParseFormat returns the format of the given name.
+func ParseFormat(name string) (Format, error) {
+	for _, f := range Formats {
+		if string(f) == name {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("unknown output format %q (json, ndjson or table)", name)
+}
+
+// Encoder writes values as JSON array (FormatJSON) or as JSON lines
+// (FormatNDJSON). The array is completed by Close.
+type Encoder struct {
+	Policy decode.JSONPolicy // serialization policy, e.g. set by -nulls
+
+	w      io.Writer
+	format Format
+	count  int
+}
+
+// NewEncoder returns an encoder writing to w in the given format, which
+// must be FormatJSON or FormatNDJSON.
+func NewEncoder(w io.Writer, format Format) *Encoder {
+	return &Encoder{w: w, format: format}
+}
+
+// Encode writes a value.
+func (e *Encoder) Encode(v interface{}) error {
+	data, err := e.Policy.Marshal(v)
+	if err != nil {
+		return err
+	}
+	prefix := ""
+	if e.format == FormatJSON {
+		prefix = ",\n"
+		if e.count == 0 {
+			prefix = "[\n"
+		}
+	}
+	e.count += 1
+	_, err = fmt.Fprintf(e.w, "%s%s", prefix, data)
+	if err == nil && e.format == FormatNDJSON {
+		_, err = io.WriteString(e.w, "\n")
+	}
+	return err
+}
+
+// Close completes the output. An empty array is written if no value was
+// encoded in FormatJSON.
+func (e *Encoder) Close() error {
+	if e.format != FormatJSON {
+		return nil
+	}
+	end := "\n]\n"
+	if e.count == 0 {
+		end = "[]\n"
+	}
+	_, err := io.WriteString(e.w, end)
+	return err
+}
@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"strings"
+	"testing"
+
+	"github.com/tpltnt/go-bsm/decode"
+)
+
+func TestEncoder(t *testing.T) {
+	values := []map[string]int{{"a": 1}, {"b": 2}}
+	for _, format := range []Format{FormatJSON, FormatNDJSON} {
+		var buf bytes.Buffer
+		encoder := NewEncoder(&buf, format)
+		for _, v := range values {
+			if err := encoder.Encode(v); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := encoder.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		var decoded []map[string]int
+		if format == FormatJSON {
+			if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+				t.Fatalf("%s: %v", format, err)
+			}
+		} else {
+			for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n") {
+				var v map[string]int
+				if err := json.Unmarshal([]byte(line), &v); err != nil {
+					t.Fatalf("%s: %v", format, err)
+				}
+				decoded = append(decoded, v)
+			}
+		}
+		if len(decoded) != 2 || decoded[0]["a"] != 1 || decoded[1]["b"] != 2 {
+			t.Errorf("%s: unexpected output %q", format, buf.String())
+		}
+	}
+
+	var buf bytes.Buffer
+	NewEncoder(&buf, FormatJSON).Close()
+	if buf.String() != "[]\n" {
+		t.Errorf("unexpected empty array %q", buf.String())
+	}
+}
+
+func TestEncoderPolicy(t *testing.T) {
+	var buf bytes.Buffer
+	encoder := NewEncoder(&buf, FormatNDJSON)
+	encoder.Policy = decode.JSONPolicy{Nulls: true, StringInt64: true}
+	if err := encoder.Encode(&decode.BsmRecord{Seconds: 1 << 60}); err != nil {
+		t.Fatal(err)
+	}
+	if out := buf.String(); !strings.Contains(out, `"annotations":null`) || !strings.Contains(out, `"1152921504606846976"`) {
+		t.Errorf("policy not applied: %s", out)
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	if f, err := ParseFormat("ndjson"); err != nil || f != FormatNDJSON {
+		t.Errorf("got %q, %v", f, err)
+	}
+	if _, err := ParseFormat("xml"); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
+
+func TestCompletion(t *testing.T) {
+	flags := flag.NewFlagSet("tool", flag.ContinueOnError)
+	flags.Bool("pretty", false, "pretty output")
+	flags.String("output", "ndjson", "output format")
+	flags.String("events", "", "event file")
+	values := map[string][]string{"output": {"json", "ndjson", "table"}}
+
+	expected := map[string][]string{
+		"bash": {"complete -F _tool tool", `-output) COMPREPLY=($(compgen -W "json ndjson table"`, `"-events -output -pretty"`},
+		"zsh":  {"#compdef tool", "'-pretty[pretty output]'", "'-output[output format]:value:(json ndjson table)'", "'-events[event file]:file:_files'"},
+		"fish": {"complete -c tool -o pretty -d 'pretty output'\n", "-o output -d 'output format' -x -a 'json ndjson table'", "-o events -d 'event file' -r -F"},
+	}
+	for _, shell := range Shells {
+		var buf bytes.Buffer
+		if err := Completion(&buf, shell, "tool", flags, values); err != nil {
+			t.Fatal(err)
+		}
+		for _, s := range expected[shell] {
+			if !strings.Contains(buf.String(), s) {
+				t.Errorf("%s: %q missing in\n%s", shell, s, buf.String())
+			}
+		}
+	}
+	if err := Completion(&bytes.Buffer{}, "csh", "tool", flags, nil); err == nil {
+		t.Error("expected error for unsupported shell")
+	}
+}
@@ -0,0 +1,108 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Shells lists the shells supported by Completion.
+var Shells = []string{"bash", "zsh", "fish"}
+
+// completionFlag describes a flag for the completion scripts.
+type completionFlag struct {
+	name   string
+	usage  string
+	isBool bool
+	values []string // possible values (if known)
+}
+
+// Completion writes a completion script of the command for the given
+// shell, generated from its flags. Values lists the possible values of
+// flags (e.g. of -output), all other arguments are completed as files.
+func Completion(w io.Writer, shell, command string, flags *flag.FlagSet, values map[string][]string) error {
+	var list []completionFlag
+	flags.VisitAll(func(f *flag.Flag) {
+		b, ok := f.Value.(interface{ IsBoolFlag() bool })
+		list = append(list, completionFlag{
+			name:   f.Name,
+			usage:  f.Usage,
+			isBool: ok && b.IsBoolFlag(),
+			values: values[f.Name],
+		})
+	})
+	sort.Slice(list, func(i, j int) bool { return list[i].name < list[j].name })
+
+	var script string
+	switch shell {
+	case "bash":
+		script = bashCompletion(command, list)
+	case "zsh":
+		script = zshCompletion(command, list)
+	case "fish":
+		script = fishCompletion(command, list)
+	default:
+		return fmt.Errorf("unsupported shell %q (%s)", shell, strings.Join(Shells, ", "))
+	}
+	_, err := io.WriteString(w, script)
+	return err
+}
+
+func bashCompletion(command string, flags []completionFlag) string {
+	fn := "_" + strings.ReplaceAll(command, "-", "_")
+	var names []string
+	var cases strings.Builder
+	for _, f := range flags {
+		names = append(names, "-"+f.name)
+		if len(f.values) > 0 {
+			fmt.Fprintf(&cases, "\t-%s) COMPREPLY=($(compgen -W %q -- \"$cur\")); return ;;\n", f.name, strings.Join(f.values, " "))
+		}
+	}
+	return fmt.Sprintf(`%s() {
+	local cur=${COMP_WORDS[COMP_CWORD]} prev=${COMP_WORDS[COMP_CWORD-1]}
+	case $prev in
+%s	esac
+	case $cur in
+	-*) COMPREPLY=($(compgen -W %q -- "$cur")) ;;
+	*) COMPREPLY=($(compgen -f -- "$cur")) ;;
+	esac
+}
+complete -F %s %s
+`, fn, cases.String(), strings.Join(names, " "), fn, command)
+}
+
+func zshCompletion(command string, flags []completionFlag) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "#compdef %s\n\n_arguments \\\n", command)
+	for _, f := range flags {
+		usage := strings.NewReplacer("[", `\[`, "]", `\]`, "'", "").Replace(f.usage)
+		switch {
+		case f.isBool:
+			fmt.Fprintf(&b, "\t'-%s[%s]' \\\n", f.name, usage)
+		case len(f.values) > 0:
+			fmt.Fprintf(&b, "\t'-%s[%s]:value:(%s)' \\\n", f.name, usage, strings.Join(f.values, " "))
+		default:
+			fmt.Fprintf(&b, "\t'-%s[%s]:file:_files' \\\n", f.name, usage)
+		}
+	}
+	b.WriteString("\t'*:file:_files'\n")
+	return b.String()
+}
+
+func fishCompletion(command string, flags []completionFlag) string {
+	var b strings.Builder
+	for _, f := range flags {
+		usage := strings.ReplaceAll(f.usage, "'", "")
+		switch {
+		case f.isBool:
+			fmt.Fprintf(&b, "complete -c %s -o %s -d '%s'\n", command, f.name, usage)
+		case len(f.values) > 0:
+			fmt.Fprintf(&b, "complete -c %s -o %s -d '%s' -x -a '%s'\n", command, f.name, usage, strings.Join(f.values, " "))
+		default:
+			fmt.Fprintf(&b, "complete -c %s -o %s -d '%s' -r -F\n", command, f.name, usage)
+		}
+	}
+	return b.String()
+}
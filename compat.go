@@ -0,0 +1,262 @@
+// Compatibility aliases of the API which moved into the subpackages
+// token, decode, filter and output. New code should use the subpackages
+// directly.
+
+package bsm
+
+import (
+	"io"
+
+	"github.com/tpltnt/go-bsm/decode"
+	"github.com/tpltnt/go-bsm/filter"
+	"github.com/tpltnt/go-bsm/output"
+	"github.com/tpltnt/go-bsm/token"
+)
+
+// Tokens, see package token.
+type (
+	ArbitraryDataToken        = token.ArbitraryDataToken
+	ArgToken32bit             = token.ArgToken32bit
+	ArgToken64bit             = token.ArgToken64bit
+	AttributeFileInfo         = token.AttributeFileInfo
+	AttributeToken32bit       = token.AttributeToken32bit
+	AttributeToken64bit       = token.AttributeToken64bit
+	Device                    = token.Device
+	Dialect                   = token.Dialect
+	ExecArgsToken             = token.ExecArgsToken
+	ExecEnvToken              = token.ExecEnvToken
+	ExitInfo                  = token.ExitInfo
+	ExitToken                 = token.ExitToken
+	ExpandedHeaderToken32bit  = token.ExpandedHeaderToken32bit
+	ExpandedHeaderToken64bit  = token.ExpandedHeaderToken64bit
+	ExpandedInAddrToken       = token.ExpandedInAddrToken
+	ExpandedProcessToken32bit = token.ExpandedProcessToken32bit
+	ExpandedProcessToken64bit = token.ExpandedProcessToken64bit
+	ExpandedSocketToken       = token.ExpandedSocketToken
+	ExpandedSubjectToken32bit = token.ExpandedSubjectToken32bit
+	ExpandedSubjectToken64bit = token.ExpandedSubjectToken64bit
+	FileToken                 = token.FileToken
+	GroupsToken               = token.GroupsToken
+	HeaderToken32bit          = token.HeaderToken32bit
+	HeaderToken64bit          = token.HeaderToken64bit
+	IPortToken                = token.IPortToken
+	InAddrToken               = token.InAddrToken
+	IpToken                   = token.IpToken
+	IpcObjectType             = token.IpcObjectType
+	PathAttrToken             = token.PathAttrToken
+	PathToken                 = token.PathToken
+	ProcessToken32bit         = token.ProcessToken32bit
+	ProcessToken64bit         = token.ProcessToken64bit
+	ReturnToken32bit          = token.ReturnToken32bit
+	ReturnToken64bit          = token.ReturnToken64bit
+	SeqToken                  = token.SeqToken
+	SocketToken               = token.SocketToken
+	SubjectToken32bit         = token.SubjectToken32bit
+	SubjectToken64bit         = token.SubjectToken64bit
+	SystemVIpcPermissionToken = token.SystemVIpcPermissionToken
+	SystemVIpcToken           = token.SystemVIpcToken
+	TextToken                 = token.TextToken
+	Token                     = token.Token
+	TrailerToken              = token.TrailerToken
+	ZonenameToken             = token.ZonenameToken
+)
+
+const (
+	DialectDarwin         = token.DialectDarwin
+	DialectFreeBSD        = token.DialectFreeBSD
+	DialectLinux          = token.DialectLinux
+	DialectSolaris        = token.DialectSolaris
+	DialectUnknown        = token.DialectUnknown
+	IPFlagDontFragment    = token.IPFlagDontFragment
+	IPFlagMoreFragments   = token.IPFlagMoreFragments
+	IpcMessageQueue       = token.IpcMessageQueue
+	IpcSemaphore          = token.IpcSemaphore
+	IpcSharedMemory       = token.IpcSharedMemory
+	SocketDomainAppleTalk = token.SocketDomainAppleTalk
+	SocketDomainInet      = token.SocketDomainInet
+	SocketDomainInet6     = token.SocketDomainInet6
+	SocketDomainKey       = token.SocketDomainKey
+	SocketDomainLink      = token.SocketDomainLink
+	SocketDomainLocal     = token.SocketDomainLocal
+	SocketDomainRoute     = token.SocketDomainRoute
+	SocketDomainUnspec    = token.SocketDomainUnspec
+	SocketTypeDgram       = token.SocketTypeDgram
+	SocketTypeRaw         = token.SocketTypeRaw
+	SocketTypeRdm         = token.SocketTypeRdm
+	SocketTypeSeqPacket   = token.SocketTypeSeqPacket
+	SocketTypeStream      = token.SocketTypeStream
+)
+
+// ParseExpandedHeaderToken32bit calls token.ParseExpandedHeaderToken32bit.
+func ParseExpandedHeaderToken32bit(input []byte) (ExpandedHeaderToken32bit, error) {
+	return token.ParseExpandedHeaderToken32bit(input)
+}
+
+// ParseExpandedHeaderToken64bit calls token.ParseExpandedHeaderToken64bit.
+func ParseExpandedHeaderToken64bit(input []byte) (ExpandedHeaderToken64bit, error) {
+	return token.ParseExpandedHeaderToken64bit(input)
+}
+
+// ParseHeaderToken32bit calls token.ParseHeaderToken32bit.
+func ParseHeaderToken32bit(input []byte) (HeaderToken32bit, error) {
+	return token.ParseHeaderToken32bit(input)
+}
+
+// ParseHeaderToken64bit calls token.ParseHeaderToken64bit.
+func ParseHeaderToken64bit(input []byte) (HeaderToken64bit, error) {
+	return token.ParseHeaderToken64bit(input)
+}
+
+// TTYName calls token.TTYName.
+func TTYName(dev uint64) (string, bool) {
+	return token.TTYName(dev)
+}
+
+// Decoding, see package decode.
+type (
+	BsmRecord           = decode.BsmRecord
+	Cursor              = decode.Cursor
+	Decoder             = decode.Decoder
+	DecoderStats        = decode.DecoderStats
+	Interner            = decode.Interner
+	ParsingResult       = decode.ParsingResult
+	PrivilegeTransition = decode.PrivilegeTransition
+	RecordRef           = decode.RecordRef
+	Subject             = decode.Subject
+	TrailIndex          = decode.TrailIndex
+	UnknownVersionError = decode.UnknownVersionError
+)
+
+const (
+	DefaultAuditID    = decode.DefaultAuditID
+	SchemaVersion     = decode.SchemaVersion
+	VersionOldDarwin  = decode.VersionOldDarwin
+	VersionOpenBSM10  = decode.VersionOpenBSM10
+	VersionOpenBSM11  = decode.VersionOpenBSM11
+	VersionSolaris    = decode.VersionSolaris
+	VersionTSolaris   = decode.VersionTSolaris
+	VersionTSolaris25 = decode.VersionTSolaris25
+)
+
+var (
+	KnownVersions = decode.KnownVersions
+)
+
+// Canonicalize calls decode.Canonicalize.
+func Canonicalize(rec *BsmRecord) {
+	decode.Canonicalize(rec)
+}
+
+// Equal calls decode.Equal.
+func Equal(a, b *BsmRecord) bool {
+	return decode.Equal(a, b)
+}
+
+// ExportChunk calls decode.ExportChunk.
+func ExportChunk(index *TrailIndex, cursor Cursor, limit int) ([]BsmRecord, Cursor, error) {
+	return decode.ExportChunk(index, cursor, limit)
+}
+
+// ForEachRecord calls decode.ForEachRecord.
+func ForEachRecord(input io.Reader, fn func(*BsmRecord) error) error {
+	return decode.ForEachRecord(input, fn)
+}
+
+// NewDecoder calls decode.NewDecoder.
+func NewDecoder(input io.Reader) *Decoder {
+	return decode.NewDecoder(input)
+}
+
+// NewInterner calls decode.NewInterner.
+func NewInterner() *Interner {
+	return decode.NewInterner()
+}
+
+// NewTrailIndex calls decode.NewTrailIndex.
+func NewTrailIndex(input io.ReaderAt) (*TrailIndex, error) {
+	return decode.NewTrailIndex(input)
+}
+
+// ParseTokenSequence calls decode.ParseTokenSequence.
+func ParseTokenSequence(data []byte) ([]Token, error) {
+	return decode.ParseTokenSequence(data)
+}
+
+// ReadBsmRecord calls decode.ReadBsmRecord.
+func ReadBsmRecord(input io.Reader) (BsmRecord, error) {
+	return decode.ReadBsmRecord(input)
+}
+
+// RecordGenerator calls decode.RecordGenerator.
+func RecordGenerator(input io.Reader) chan ParsingResult {
+	return decode.RecordGenerator(input)
+}
+
+// ScanHeaders calls decode.ScanHeaders.
+func ScanHeaders(input io.ReaderAt) ([]RecordRef, error) {
+	return decode.ScanHeaders(input)
+}
+
+// Filtering, see package filter.
+type (
+	Anomaly    = filter.Anomaly
+	Deduper    = filter.Deduper
+	Filter     = filter.Filter
+	Rule       = filter.Rule
+	RuleSet    = filter.RuleSet
+	TokenClass = filter.TokenClass
+	Validator  = filter.Validator
+)
+
+const (
+	ClassExecArgs = filter.ClassExecArgs
+	ClassPath     = filter.ClassPath
+	ClassReturn   = filter.ClassReturn
+	ClassSocket   = filter.ClassSocket
+	ClassSubject  = filter.ClassSubject
+)
+
+// CompileRules calls filter.CompileRules.
+func CompileRules(rules []Rule) *RuleSet {
+	return filter.CompileRules(rules)
+}
+
+// FilterRecords calls filter.FilterRecords.
+func FilterRecords(in chan ParsingResult, keep Filter) chan ParsingResult {
+	return filter.FilterRecords(in, keep)
+}
+
+// NewDeduper calls filter.NewDeduper.
+func NewDeduper(window int) *Deduper {
+	return filter.NewDeduper(window)
+}
+
+// NewValidator calls filter.NewValidator.
+func NewValidator() *Validator {
+	return filter.NewValidator()
+}
+
+// Sinks, see package output.
+type (
+	BatchSink = output.BatchSink
+	Batcher   = output.Batcher
+	Sink      = output.Sink
+	SinkFunc  = output.SinkFunc
+	ZoneDemux = output.ZoneDemux
+	ZoneRoute = output.ZoneRoute
+)
+
+// TokenFromByteInput converts bytes read from a given input
+// to a BSM token.
+//
+// Deprecated: use decode.ReadToken.
+func TokenFromByteInput(input io.Reader) (Token, error) {
+	return decode.ReadToken(input)
+}
+
+// TokenName returns the name of the given token.
+//
+// Deprecated: use token.Name.
+func TokenName(tok interface{}) string {
+	return token.Name(tok)
+}
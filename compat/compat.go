@@ -0,0 +1,147 @@
+// Package compat checks serialized records of different versions of
+// this module for backward compatibility, so pipelines can gate
+// upgrades, e.g. by serializing a reference trail with the new version
+// and comparing it to the output of the version in production:
+//
+//	if err := compat.CheckRecordJSON(old, new); err != nil {
+//		log.Fatal("upgrade breaks consumers: ", err)
+//	}
+package compat
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Problem is a change breaking consumers of the old representation.
+type Problem struct {
+	Path   string // location in the record, e.g. "tokens[1].Path"
+	Reason string
+}
+
+func (p Problem) String() string {
+	return p.Path + ": " + p.Reason
+}
+
+// IncompatibleError lists the problems found by CheckRecordJSON.
+type IncompatibleError struct {
+	Problems []Problem
+}
+
+func (e *IncompatibleError) Error() string {
+	problems := make([]string, len(e.Problems))
+	for i, p := range e.Problems {
+		problems[i] = p.String()
+	}
+	return "incompatible record: " + strings.Join(problems, "; ")
+}
+
+// CheckRecordJSON reports whether a consumer of the old serialization
+// of a record can read the new one: the schema version must not
+// decrease, every field of the old record must still be present with
+// the same JSON type (fields and array elements may be added), and the
+// type names of tokens must not change. Fields which are null in the
+// old record may be missing or of any type in the new one, but values
+// must not be replaced by null. The problems found are returned as
+// *IncompatibleError.
+func CheckRecordJSON(old, new []byte) error {
+	var oldRec, newRec map[string]interface{}
+	if err := unmarshal(old, &oldRec); err != nil {
+		return fmt.Errorf("old record: %w", err)
+	}
+	if err := unmarshal(new, &newRec); err != nil {
+		return fmt.Errorf("new record: %w", err)
+	}
+
+	var problems []Problem
+	oldVersion, _ := oldRec["schema_version"].(json.Number)
+	newVersion, _ := newRec["schema_version"].(json.Number)
+	if o, err := oldVersion.Int64(); err == nil {
+		if n, err := newVersion.Int64(); err != nil || n < o {
+			problems = append(problems, Problem{"schema_version", fmt.Sprintf("%s replaced by %v", oldVersion, newRec["schema_version"])})
+		}
+	}
+	compare("", oldRec, newRec, &problems)
+	if len(problems) > 0 {
+		return &IncompatibleError{Problems: problems}
+	}
+	return nil
+}
+
+// unmarshal decodes a JSON object keeping numbers as written.
+func unmarshal(data []byte, v *map[string]interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(v); err != nil {
+		return err
+	}
+	if *v == nil {
+		return fmt.Errorf("not a JSON object")
+	}
+	return nil
+}
+
+// jsonType names the JSON type of a decoded value.
+func jsonType(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	}
+	return "object"
+}
+
+// compare adds the problems of replacing old by new at path.
+func compare(path string, old, new interface{}, problems *[]Problem) {
+	if old == nil {
+		return
+	}
+	if oldType, newType := jsonType(old), jsonType(new); oldType != newType {
+		*problems = append(*problems, Problem{path, fmt.Sprintf("%s replaced by %s", oldType, newType)})
+		return
+	}
+	switch o := old.(type) {
+	case map[string]interface{}:
+		n := new.(map[string]interface{})
+		if path != "" && o["type"] != nil && o["type"] != n["type"] {
+			*problems = append(*problems, Problem{path, fmt.Sprintf("token %v replaced by %v", o["type"], n["type"])})
+			return
+		}
+		keys := make([]string, 0, len(o))
+		for key := range o {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			value := o[key]
+			child := key
+			if path != "" {
+				child = path + "." + key
+			}
+			newValue, ok := n[key]
+			if !ok && value != nil {
+				*problems = append(*problems, Problem{child, "removed"})
+				continue
+			}
+			compare(child, value, newValue, problems)
+		}
+	case []interface{}:
+		n := new.([]interface{})
+		if len(n) < len(o) {
+			*problems = append(*problems, Problem{path, fmt.Sprintf("%d elements removed", len(o)-len(n))})
+		}
+		for i := 0; i < len(o) && i < len(n); i++ {
+			compare(fmt.Sprintf("%s[%d]", path, i), o[i], n[i], problems)
+		}
+	}
+}
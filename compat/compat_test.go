@@ -0,0 +1,73 @@
+package compat
+
+import (
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/tpltnt/go-bsm/decode"
+)
+
+func TestCheckRecordJSON(t *testing.T) {
+	file, err := os.Open("../start_stop.bsm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	rec, err := decode.NewDecoder(file).Decode()
+	if err != nil && err != io.EOF {
+		t.Fatal(err)
+	}
+	current, err := rec.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckRecordJSON(current, current); err != nil {
+		t.Error("record incompatible with itself:", err)
+	}
+	nulls, err := decode.JSONPolicy{Nulls: true}.Marshal(&rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckRecordJSON(current, nulls); err != nil {
+		t.Error("null fields reported:", err)
+	}
+
+	old := `{"schema_version":1,"seconds":1,"tokens":[{"type":"text","TokenID":40,"Text":"a"},{"type":"return32","TokenID":39}]}`
+	for _, test := range []struct {
+		new      string
+		problems []string
+	}{
+		{`{"schema_version":3,"seconds":1,"tokens":[{"type":"text","TokenID":40,"Text":"a","escaped":false},{"type":"return32","TokenID":39}],"annotations":{}}`, nil},
+		{`{"schema_version":1,"seconds":"1","tokens":[{"type":"text","TokenID":40,"Text":"a"},{"type":"return32","TokenID":39}]}`, []string{"seconds: number replaced by string"}},
+		{`{"schema_version":1,"seconds":1,"tokens":[{"type":"text","TokenID":40}]}`, []string{"tokens: 1 elements removed", "tokens[0].Text: removed"}},
+		{`{"schema_version":1,"seconds":null,"tokens":[{"type":"text","TokenID":40,"Text":"a"},{"type":"return32","TokenID":39}]}`, []string{"seconds: number replaced by null"}},
+		{`{"schema_version":0,"seconds":1,"tokens":[{"type":"path","TokenID":35,"Path":"a"},{"type":"return32","TokenID":39}]}`, []string{"schema_version: 1 replaced by 0", "tokens[0]: token text replaced by path"}},
+	} {
+		err := CheckRecordJSON([]byte(old), []byte(test.new))
+		var incompatible *IncompatibleError
+		if test.problems == nil {
+			if err != nil {
+				t.Errorf("%s: unexpected error %v", test.new, err)
+			}
+			continue
+		}
+		if !errors.As(err, &incompatible) {
+			t.Errorf("%s: got %v, expected problems", test.new, err)
+			continue
+		}
+		var got []string
+		for _, p := range incompatible.Problems {
+			got = append(got, p.String())
+		}
+		if strings.Join(got, "|") != strings.Join(test.problems, "|") {
+			t.Errorf("%s: got problems %q", test.new, got)
+		}
+	}
+
+	if err := CheckRecordJSON([]byte(old), []byte("[]")); err == nil {
+		t.Error("expected error for non-object")
+	}
+}
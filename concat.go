@@ -0,0 +1,125 @@
+package bsm
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/tpltnt/go-bsm/decode"
+	"github.com/tpltnt/go-bsm/encode"
+)
+
+// ConcatTrails writes the records of the given trail files to out as a
+// single trail, e.g. to feed auditreduce. The records of every trail
+// are enclosed in file tokens, so the original boundaries remain
+// observable (see FileToken): the opening token of a trail names the
+// previous trail, the closing token the next one. Existing file tokens
+// are replaced but keep their time stamps (and at the ends of the output
+// their names), trails without are stamped with the times of their first
+// and last record. Records are copied unchanged, an incomplete record at
+// the end of a trail (e.g. a .not_terminated one) is dropped.
+func ConcatTrails(out io.Writer, ins ...string) error {
+	for i, name := range ins {
+		file, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		err = concatTrail(out, file, ins, i)
+		file.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// concatTrail writes the records of the i-th trail, which is read from
+// file, enclosed in file tokens.
+func concatTrail(out io.Writer, file *os.File, names []string, i int) error {
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	refs, err := ScanHeaders(file)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) { // incomplete header
+		return err
+	}
+	for len(refs) > 0 && refs[len(refs)-1].Offset+int64(refs[len(refs)-1].Length) > info.Size() {
+		refs = refs[:len(refs)-1]
+	}
+
+	// the opening and closing file tokens, found before the first and
+	// after the last record
+	opening, closing := fileToken(info.ModTime(), ""), fileToken(info.ModTime(), "")
+	if len(refs) == 0 {
+		if files := readFileTokens(file, 0, info.Size()); len(files) > 0 {
+			opening, closing = files[0], files[len(files)-1]
+		}
+	} else {
+		opening, closing = fileToken(refs[0].Time, ""), fileToken(refs[len(refs)-1].Time, "")
+		if files := readFileTokens(file, 0, refs[0].Offset); len(files) > 0 {
+			opening = files[0]
+		}
+		last := refs[len(refs)-1]
+		if files := readFileTokens(file, last.Offset+int64(last.Length), info.Size()); len(files) > 0 {
+			closing = files[len(files)-1]
+		}
+	}
+	if i > 0 {
+		opening.PathName = names[i-1]
+	}
+	if i < len(names)-1 {
+		closing.PathName = names[i+1]
+	}
+
+	if err := writeFileToken(out, opening); err != nil {
+		return err
+	}
+	for _, ref := range refs {
+		if _, err := io.Copy(out, io.NewSectionReader(file, ref.Offset, int64(ref.Length))); err != nil {
+			return err
+		}
+	}
+	return writeFileToken(out, closing)
+}
+
+// readFileTokens returns the file tokens between the given offsets,
+// which are expected to hold nothing else. Reading stops at anything
+// else, e.g. padding.
+func readFileTokens(input io.ReaderAt, start, end int64) []FileToken {
+	var files []FileToken
+	section := io.NewSectionReader(input, start, end-start)
+	for {
+		tok, err := decode.ReadToken(section)
+		if err != nil {
+			return files
+		}
+		file, ok := tok.(FileToken)
+		if !ok {
+			return files
+		}
+		files = append(files, file)
+	}
+}
+
+// fileToken returns a file token with the given time and path.
+func fileToken(t time.Time, path string) FileToken {
+	return FileToken{
+		TokenID:      0x11,
+		Seconds:      uint32(t.Unix()),
+		Microseconds: uint32(t.Nanosecond() / int(time.Millisecond)), // msec like libbsm
+		PathName:     path,
+	}
+}
+
+// writeFileToken writes the binary form of a file token.
+func writeFileToken(out io.Writer, file FileToken) error {
+	data, err := encode.Token(file)
+	if err != nil {
+		return err
+	}
+	_, err = out.Write(data)
+	return err
+}
@@ -0,0 +1,62 @@
+package bsm
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestConcatTrails(t *testing.T) {
+	dir := t.TempDir()
+	a, b, c := filepath.Join(dir, "a"), filepath.Join(dir, "b"), filepath.Join(dir, "c")
+	writeTrail(t, a, true, 1000, 1100)
+	writeTrail(t, b, false, 1200)
+	data, err := os.ReadFile("start_stop.bsm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(c, data[:70], 0o600); err != nil { // second record incomplete
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := ConcatTrails(&buf, a, b, c); err != nil {
+		t.Fatal(err)
+	}
+
+	var files []FileToken
+	decoder := NewDecoder(bytes.NewReader(buf.Bytes()))
+	decoder.FileTokenHandler = func(file FileToken) { files = append(files, file) }
+	var events []uint16
+	for {
+		rec, err := decoder.Decode()
+		if err != nil {
+			break
+		}
+		events = append(events, rec.EventType)
+	}
+	if len(events) != 4 || events[3] != 45000 {
+		t.Error("unexpected records:", events)
+	}
+	if end := decoder.End(); end == nil || end.File == nil {
+		t.Fatal("trail not terminated")
+	}
+	// a keeps its file tokens (and the name at the start), b is stamped
+	// with the time of its record
+	paths := []string{"a", b, a, c, b, ""}
+	if len(files) != len(paths) {
+		t.Fatalf("got file tokens %v", files)
+	}
+	for i, file := range files {
+		if file.PathName != paths[i] {
+			t.Errorf("file token %d: got %q, expected %q", i, file.PathName, paths[i])
+		}
+	}
+	if files[0].Seconds != 1000 || files[1].Seconds != 1110 || files[2].Seconds != 1200 || files[3].Seconds != 1200 {
+		t.Error("unexpected file token times:", files)
+	}
+	if refs, err := ScanHeaders(bytes.NewReader(buf.Bytes())); err != nil || len(refs) != 4 {
+		t.Error("unexpected records:", refs, err)
+	}
+}
@@ -0,0 +1,230 @@
+package bsm
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// AuditEvent is an entry of the audit_event(5) file.
+type AuditEvent struct {
+	Number      uint16
+	Name        string   // e.g. AUE_EXECVE
+	Description string   // e.g. execve(2)
+	Classes     []string // short names of the audit classes, e.g. pc and ex
+}
+
+// ParseAuditEvents reads the event definitions of an audit_event(5) file,
+// e.g. /etc/security/audit_event. The variations found on macOS are
+// accepted as well: descriptions containing colons, entries without
+// classes and the definitions as XML property list.
+func ParseAuditEvents(r io.Reader) (map[uint16]AuditEvent, error) {
+	input := bufio.NewReader(r)
+	if isPlist(input) {
+		return parseAuditEventsPlist(input)
+	}
+	events := map[uint16]AuditEvent{}
+	scanner := bufio.NewScanner(input)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Split(text, ":")
+		if len(fields) < 3 {
+			return nil, fmt.Errorf("line %d: expected at least 3 fields, got %d", line, len(fields))
+		}
+		number, err := strconv.ParseUint(fields[0], 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid event number %q", line, fields[0])
+		}
+		event := AuditEvent{Number: uint16(number), Name: fields[1], Description: fields[2]}
+		if len(fields) > 3 {
+			event.Description = strings.Join(fields[2:len(fields)-1], ":")
+			event.Classes = splitList(fields[len(fields)-1])
+		}
+		events[uint16(number)] = event
+	}
+	return events, scanner.Err()
+}
+
+// AuditControl holds the audit classes selected by an audit_control(5)
+// file. Class names may be prefixed by "+" (success only), "-" (failure
+// only) or "^" (excluded), as in the file.
+type AuditControl struct {
+	Flags   []string // classes audited for all users
+	NAFlags []string // classes audited for events not attributable to a user
+}
+
+// ParseAuditControl reads the flags and naflags of an audit_control(5)
+// file, e.g. /etc/security/audit_control. Other settings are ignored.
+func ParseAuditControl(r io.Reader) (AuditControl, error) {
+	var control AuditControl
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		text := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(text, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(text, ":")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "flags":
+			control.Flags = splitList(value)
+		case "naflags":
+			control.NAFlags = splitList(value)
+		}
+	}
+	return control, scanner.Err()
+}
+
+// splitList splits a comma separated list, dropping empty elements.
+func splitList(s string) []string {
+	var list []string
+	for _, e := range strings.Split(s, ",") {
+		if e = strings.TrimSpace(e); e != "" {
+			list = append(list, e)
+		}
+	}
+	return list
+}
+
+// classSelection is an audit class selected by AuditControl.
+type classSelection struct {
+	success, failure bool
+}
+
+// selection returns the classes selected by the given flags.
+func selection(flags []string) map[string]classSelection {
+	selected := map[string]classSelection{}
+	for _, flag := range flags {
+		exclude := strings.HasPrefix(flag, "^")
+		flag = strings.TrimPrefix(flag, "^")
+		sel := classSelection{success: true, failure: true}
+		switch {
+		case strings.HasPrefix(flag, "+"):
+			sel.failure = false
+		case strings.HasPrefix(flag, "-"):
+			sel.success = false
+		}
+		flag = strings.TrimLeft(flag, "+-")
+		current := selected[flag]
+		if exclude {
+			current.success = current.success && !sel.success
+			current.failure = current.failure && !sel.failure
+		} else {
+			current.success = current.success || sel.success
+			current.failure = current.failure || sel.failure
+		}
+		selected[flag] = current
+	}
+	return selected
+}
+
+// CoverageReport compares the records of a trail with the audit
+// configuration.
+type CoverageReport struct {
+	Records int
+	// Classes is the number of records by configured class. Classes
+	// without records are listed in Silent.
+	Classes map[string]int
+	Silent  []string
+	// Missing lists the events of the configured classes which didn't
+	// occur, ordered by number. Many of them are rare, so this is a hint
+	// rather than a finding.
+	Missing []AuditEvent
+	// Unconfigured is the number of records by event type whose classes
+	// aren't configured, e.g. events selected per user (audit_user(5)).
+	Unconfigured map[uint16]int
+	// Unknown is the number of records by event type missing in the
+	// event definitions.
+	Unknown map[uint16]int
+}
+
+// Coverage reports which of the audit classes configured in control
+// actually produced records in the trail and which of the events they
+// are expected to capture (according to events, see ParseAuditEvents)
+// are absent. Records of non-attributable events are matched against
+// NAFlags, all others against Flags.
+func Coverage(trail io.Reader, control AuditControl, events map[uint16]AuditEvent) (CoverageReport, error) {
+	report := CoverageReport{Classes: map[string]int{}, Unconfigured: map[uint16]int{}, Unknown: map[uint16]int{}}
+	flags := selection(control.Flags)
+	naflags := selection(control.NAFlags)
+	seen := map[uint16]bool{}
+	err := ForEachRecord(trail, func(rec *BsmRecord) error {
+		report.Records += 1
+		seen[rec.EventType] = true
+		event, ok := events[rec.EventType]
+		if !ok {
+			report.Unknown[rec.EventType] += 1
+			return nil
+		}
+		selected := flags
+		if subject, ok := rec.Subject(); !ok || subject.AuditID == DefaultAuditID {
+			selected = naflags
+		}
+		failed := rec.Failed()
+		matched := false
+		for _, class := range matchingClasses(event, selected) {
+			sel := selected[class]
+			if (failed && sel.failure) || (!failed && sel.success) {
+				report.Classes[class] += 1
+				matched = true
+			}
+		}
+		if !matched {
+			report.Unconfigured[rec.EventType] += 1
+		}
+		return nil
+	})
+	if err != nil {
+		return report, err
+	}
+
+	for _, selected := range []map[string]classSelection{flags, naflags} {
+		for class, sel := range selected {
+			if _, ok := report.Classes[class]; !ok && (sel.success || sel.failure) {
+				report.Classes[class] = 0
+			}
+		}
+	}
+	for class, n := range report.Classes {
+		if n == 0 {
+			report.Silent = append(report.Silent, class)
+		}
+	}
+	sort.Strings(report.Silent)
+
+	for number, event := range events {
+		if !seen[number] && (len(matchingClasses(event, flags)) > 0 || len(matchingClasses(event, naflags)) > 0) {
+			report.Missing = append(report.Missing, event)
+		}
+	}
+	sort.Slice(report.Missing, func(i, j int) bool { return report.Missing[i].Number < report.Missing[j].Number })
+	return report, nil
+}
+
+// matchingClasses returns the selected classes of the event. The class
+// "all" matches all events except the ones of class "no".
+func matchingClasses(event AuditEvent, selected map[string]classSelection) []string {
+	var classes []string
+	if sel, ok := selected["all"]; ok && (sel.success || sel.failure) {
+		for _, class := range event.Classes {
+			if class != "no" {
+				classes = append(classes, "all")
+				break
+			}
+		}
+	}
+	for _, class := range event.Classes {
+		if sel, ok := selected[class]; ok && class != "all" && (sel.success || sel.failure) {
+			classes = append(classes, class)
+		}
+	}
+	return classes
+}
@@ -0,0 +1,80 @@
+package bsm
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/tpltnt/go-bsm/encode"
+)
+
+const testAuditEvents = `# events
+23:AUE_EXECVE:execve(2):pc,ex
+5:AUE_CREAT:creat(2):fc
+72:AUE_OPEN_R:open(2) - read:fr
+45000:AUE_audit_startup:audit startup:ad
+6152:AUE_login:login - local:lo
+`
+
+const testAuditControl = `#
+dir:/var/audit
+flags:lo,+ex,fc
+naflags:lo,ad
+`
+
+func TestCoverage(t *testing.T) {
+	events, err := ParseAuditEvents(strings.NewReader(testAuditEvents))
+	if err != nil {
+		t.Fatal(err)
+	}
+	control, err := ParseAuditControl(strings.NewReader(testAuditControl))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var trail bytes.Buffer
+	encoder := encode.NewEncoder(&trail)
+	for _, r := range []struct {
+		event  uint16
+		status byte
+	}{{23, 0}, {23, 1}, {72, 0}, {45000, 0}, {999, 0}} {
+		rec := BsmRecord{
+			Version:   11,
+			EventType: r.event,
+			Tokens: []Token{
+				SubjectToken32bit{AuditID: 1001},
+				ReturnToken32bit{ErrorNumber: r.status},
+			},
+		}
+		if r.event == 45000 {
+			rec.Tokens[0] = SubjectToken32bit{AuditID: DefaultAuditID}
+		}
+		if err := encoder.Encode(&rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	report, err := Coverage(&trail, control, events)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Records != 5 {
+		t.Errorf("got %d records, expected 5", report.Records)
+	}
+	if report.Classes["ex"] != 1 || report.Classes["ad"] != 1 {
+		t.Errorf("unexpected classes %v", report.Classes)
+	}
+	if strings.Join(report.Silent, ",") != "fc,lo" {
+		t.Errorf("got silent classes %v, expected fc and lo", report.Silent)
+	}
+	if len(report.Missing) != 2 || report.Missing[0].Number != 5 || report.Missing[1].Number != 6152 {
+		t.Errorf("unexpected missing events %v", report.Missing)
+	}
+	// the failed execve and open aren't selected
+	if report.Unconfigured[23] != 1 || report.Unconfigured[72] != 1 {
+		t.Errorf("unexpected unconfigured events %v", report.Unconfigured)
+	}
+	if report.Unknown[999] != 1 {
+		t.Errorf("unexpected unknown events %v", report.Unknown)
+	}
+}
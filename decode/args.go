@@ -0,0 +1,70 @@
+package decode
+
+import (
+	"strconv"
+
+	"github.com/tpltnt/go-bsm/token"
+)
+
+// syscallArgNames maps event types to the names of the system call
+// arguments (by argument ID) as recorded by the FreeBSD kernel.
+var syscallArgNames = map[uint16]map[uint8]string{
+	3:   {2: "flags", 3: "mode"},                 // AUE_OPEN
+	10:  {2: "mode"},                             // AUE_CHMOD
+	11:  {2: "uid", 3: "gid"},                    // AUE_CHOWN
+	15:  {1: "pid", 2: "signal"},                 // AUE_KILL
+	30:  {1: "fd", 2: "cmd"},                     // AUE_FCNTL
+	32:  {1: "fd"},                               // AUE_CONNECT
+	33:  {1: "fd"},                               // AUE_ACCEPT
+	34:  {1: "fd"},                               // AUE_BIND
+	38:  {1: "fd", 2: "uid", 3: "gid"},           // AUE_FCHOWN
+	39:  {1: "fd", 2: "mode"},                    // AUE_FCHMOD
+	40:  {1: "ruid", 2: "euid"},                  // AUE_SETREUID
+	41:  {1: "rgid", 2: "egid"},                  // AUE_SETREGID
+	47:  {2: "mode"},                             // AUE_MKDIR
+	183: {1: "domain", 2: "type", 3: "protocol"}, // AUE_SOCKET
+	192: {1: "fd"},                               // AUE_READ
+	195: {1: "fd"},                               // AUE_WRITE
+	200: {1: "uid"},                              // AUE_SETUID
+	205: {1: "gid"},                              // AUE_SETGID
+	214: {1: "egid"},                             // AUE_SETEGID
+	215: {1: "euid"},                             // AUE_SETEUID
+}
+
+func init() {
+	// AUE_OPEN_R ... AUE_OPEN_RWTC share the arguments of AUE_OPEN
+	for event := uint16(72); event <= 83; event++ {
+		syscallArgNames[event] = syscallArgNames[3]
+	}
+}
+
+// NamedArgs returns the values of all 'arg' tokens of the record by
+// argument name. Names are taken from the system call prototype of
+// the event type if known, otherwise the description of the token is
+// used. Arguments without any name are called "arg<ID>".
+func (rec *BsmRecord) NamedArgs() map[string]uint64 {
+	args := map[string]uint64{}
+	names := syscallArgNames[rec.EventType]
+	for _, tok := range rec.Tokens {
+		var id uint8
+		var value uint64
+		var text string
+		switch v := tok.(type) {
+		case token.ArgToken32bit:
+			id, value, text = v.ArgumentID, uint64(v.ArgumentValue), v.Text
+		case token.ArgToken64bit:
+			id, value, text = v.ArgumentID, v.ArgumentValue, v.Text
+		default:
+			continue
+		}
+		name, ok := names[id]
+		if !ok {
+			name = text
+		}
+		if name == "" {
+			name = "arg" + strconv.Itoa(int(id))
+		}
+		args[name] = value
+	}
+	return args
+}
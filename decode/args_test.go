@@ -0,0 +1,52 @@
+package decode
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/tpltnt/go-bsm/token"
+)
+
+func Test_parsing_ArgToken64bit(t *testing.T) {
+	data := []byte{0x71, // token ID
+		0x02,                                           // argument ID
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0x01, // argument value
+		0x00, 0x06, // length incl. NUL
+		0x66, 0x6c, 0x61, 0x67, 0x73, 0x00, // "flags"
+		0x13, // next token
+	}
+	tok, err := ReadToken(bytes.NewBuffer(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	arg, ok := tok.(token.ArgToken64bit)
+	if !ok {
+		t.Fatal("expected ArgToken64bit, got", tok)
+	}
+	if arg.ArgumentID != 2 || arg.ArgumentValue != 513 || arg.Text != "flags" {
+		t.Error("unexpected arg token:", arg)
+	}
+}
+
+func TestNamedArgs(t *testing.T) {
+	rec := BsmRecord{
+		EventType: 72, // AUE_OPEN_R
+		Tokens: []token.Token{
+			token.ArgToken32bit{TokenID: 0x2d, ArgumentID: 2, ArgumentValue: 0x0601, Text: "flags"},
+			token.ArgToken64bit{TokenID: 0x71, ArgumentID: 3, ArgumentValue: 0644},
+			token.ArgToken32bit{TokenID: 0x2d, ArgumentID: 4, ArgumentValue: 7, Text: "extra"},
+			token.ArgToken32bit{TokenID: 0x2d, ArgumentID: 5, ArgumentValue: 9},
+			token.PathToken{TokenID: 0x23, Path: "/etc/passwd"},
+		},
+	}
+	args := rec.NamedArgs()
+	expected := map[string]uint64{"flags": 0x0601, "mode": 0644, "extra": 7, "arg5": 9}
+	if len(args) != len(expected) {
+		t.Error("unexpected arguments:", args)
+	}
+	for name, value := range expected {
+		if args[name] != value {
+			t.Errorf("argument %s: expected %d, got %d", name, value, args[name])
+		}
+	}
+}
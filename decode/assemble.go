@@ -0,0 +1,128 @@
+package decode
+
+import (
+	"fmt"
+
+	"github.com/tpltnt/go-bsm/token"
+)
+
+// DefaultMaxPending is the default of Assembler.MaxPending.
+const DefaultMaxPending = 10000
+
+// TaggedToken is a token received from a sharded or multiplexed
+// transport. The tokens of every source are numbered consecutively,
+// starting at 0.
+type TaggedToken struct {
+	Source   string // e.g. the host or shard the token was read from
+	Sequence uint64 // position of the token within its source
+	Token    token.Token
+}
+
+// Assembler reconstitutes records from tokens arriving out of order and
+// interleaved from several sources. It is not safe for concurrent use.
+type Assembler struct {
+	// MaxPending limits the number of tokens buffered per source while
+	// waiting for a missing one. The default is DefaultMaxPending.
+	MaxPending int
+
+	sources map[string]*assembly
+}
+
+// assembly is the state of a single source.
+type assembly struct {
+	next    uint64                 // sequence number of the next token
+	pending map[uint64]token.Token // tokens received ahead of next
+	record  *BsmRecord             // record in progress (if any)
+}
+
+// NewAssembler returns an empty assembler.
+func NewAssembler() *Assembler {
+	return &Assembler{sources: map[string]*assembly{}}
+}
+
+// Add passes a token to the assembler and returns the records it
+// completes, in order of their source. Malformed streams (e.g. a record
+// without trailer) are reported as error, the other records are
+// returned along with it.
+func (a *Assembler) Add(t TaggedToken) ([]BsmRecord, error) {
+	src, ok := a.sources[t.Source]
+	if !ok {
+		src = &assembly{pending: map[uint64]token.Token{}}
+		a.sources[t.Source] = src
+	}
+	if t.Sequence < src.next {
+		return nil, fmt.Errorf("source %s: duplicate token %d", t.Source, t.Sequence)
+	}
+	if _, dup := src.pending[t.Sequence]; dup {
+		return nil, fmt.Errorf("source %s: duplicate token %d", t.Source, t.Sequence)
+	}
+	if len(src.pending) >= a.maxPending() {
+		return nil, fmt.Errorf("source %s: too many tokens pending, token %d missing", t.Source, src.next)
+	}
+	src.pending[t.Sequence] = t.Token
+
+	var records []BsmRecord
+	var err error
+	for {
+		tok, ok := src.pending[src.next]
+		if !ok {
+			break
+		}
+		delete(src.pending, src.next)
+		src.next += 1
+
+		var header BsmRecord
+		switch {
+		case header.setHeader(tok):
+			if src.record != nil && err == nil {
+				err = fmt.Errorf("source %s: record without trailer before token %d", t.Source, src.next-1)
+			}
+			src.record = &header
+		case src.record == nil:
+			if _, ok := tok.(token.FileToken); !ok && err == nil {
+				err = fmt.Errorf("source %s: token %d outside of a record", t.Source, src.next-1)
+			}
+		default:
+			if _, ok := tok.(token.TrailerToken); ok {
+				records = append(records, *src.record)
+				src.record = nil
+			} else {
+				src.record.Tokens = append(src.record.Tokens, tok)
+			}
+		}
+	}
+	return records, err
+}
+
+// Pending returns the number of tokens of the source waiting for a
+// missing one.
+func (a *Assembler) Pending(source string) int {
+	if src, ok := a.sources[source]; ok {
+		return len(src.pending)
+	}
+	return 0
+}
+
+// Close forgets the given source. It returns an error if tokens are
+// missing or the last record is incomplete.
+func (a *Assembler) Close(source string) error {
+	src, ok := a.sources[source]
+	if !ok {
+		return nil
+	}
+	delete(a.sources, source)
+	switch {
+	case len(src.pending) > 0:
+		return fmt.Errorf("source %s: token %d missing", source, src.next)
+	case src.record != nil:
+		return fmt.Errorf("source %s: incomplete record", source)
+	}
+	return nil
+}
+
+func (a *Assembler) maxPending() int {
+	if a.MaxPending > 0 {
+		return a.MaxPending
+	}
+	return DefaultMaxPending
+}
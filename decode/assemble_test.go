@@ -0,0 +1,89 @@
+package decode
+
+import (
+	"bytes"
+	"math/rand"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/tpltnt/go-bsm/token"
+)
+
+func TestAssembler(t *testing.T) {
+	data, err := os.ReadFile("../start_stop.bsm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tagged []TaggedToken
+	for _, source := range []string{"a", "b"} {
+		seq := uint64(0)
+		for tok, err := range Tokens(bytes.NewReader(data)) {
+			if err != nil {
+				t.Fatal(err)
+			}
+			tagged = append(tagged, TaggedToken{Source: source, Sequence: seq, Token: tok})
+			seq += 1
+		}
+	}
+	rand.New(rand.NewSource(1)).Shuffle(len(tagged), func(i, j int) {
+		tagged[i], tagged[j] = tagged[j], tagged[i]
+	})
+
+	var expected []BsmRecord
+	decoder := NewDecoder(bytes.NewReader(data))
+	for {
+		rec, err := decoder.Decode()
+		if err != nil {
+			break
+		}
+		expected = append(expected, rec)
+	}
+
+	assembler := NewAssembler()
+	records := map[string][]BsmRecord{}
+	for _, tt := range tagged {
+		recs, err := assembler.Add(tt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		records[tt.Source] = append(records[tt.Source], recs...)
+	}
+	for _, source := range []string{"a", "b"} {
+		if !reflect.DeepEqual(records[source], expected) {
+			t.Errorf("source %s: records differ from the decoded ones", source)
+		}
+		if err := assembler.Close(source); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestAssemblerErrors(t *testing.T) {
+	assembler := NewAssembler()
+	assembler.MaxPending = 2
+	header := token.HeaderToken32bit{TokenID: 0x14, EventType: 1}
+	if _, err := assembler.Add(TaggedToken{Source: "a", Sequence: 1, Token: token.TextToken{}}); err != nil {
+		t.Error(err)
+	}
+	if _, err := assembler.Add(TaggedToken{Source: "a", Sequence: 1, Token: token.TextToken{}}); err == nil {
+		t.Error("duplicate not detected")
+	}
+	if _, err := assembler.Add(TaggedToken{Source: "a", Sequence: 2, Token: header}); err != nil {
+		t.Error(err)
+	}
+	if _, err := assembler.Add(TaggedToken{Source: "a", Sequence: 3, Token: header}); err == nil {
+		t.Error("pending limit not enforced")
+	}
+	if assembler.Pending("a") != 2 {
+		t.Errorf("got %d pending tokens, expected 2", assembler.Pending("a"))
+	}
+	if err := assembler.Close("a"); err == nil {
+		t.Error("missing token not reported")
+	}
+
+	// a text token outside of a record
+	if _, err := assembler.Add(TaggedToken{Source: "b", Token: token.TextToken{}}); err == nil {
+		t.Error("token outside of a record not reported")
+	}
+}
@@ -0,0 +1,471 @@
+// test parsing of BSM files
+package decode
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/tpltnt/go-bsm/token"
+)
+
+func TestTokenFromByteInput(t *testing.T) {
+	data := []byte{0x00}
+	_, err := ReadToken(bytes.NewBuffer(data))
+	if err == nil {
+		t.Error("one byte record should yield an error")
+	}
+	if !strings.Contains(err.Error(), "can't determine the size of the given token (type)") {
+		t.Error("unexpected error message:", err.Error())
+	}
+	// iport token as minimal test case
+	data = []byte{0x2c, 0x23, 0x42}
+	tok, err := ReadToken(bytes.NewBuffer(data))
+	if err != nil {
+		t.Error(err)
+	}
+	switch v := tok.(type) {
+	case token.IPortToken:
+		if v.PortNumber != 9026 {
+			t.Error("wrong port number in IPortToken")
+		}
+	default:
+		t.Error("expected IPortToken, but got", v)
+	}
+}
+
+func Test_parsing_ExpandedProcessToken32bit(t *testing.T) {
+	data := []byte{
+		0x7b,                   // token ID
+		0x00, 0x01, 0x02, 0x03, // audit ID
+		0x00, 0x01, 0x02, 0x03, // effective user ID
+		0x00, 0x01, 0x02, 0x03, // effective group ID
+		0x00, 0x01, 0x02, 0x03, // real user ID
+		0x00, 0x01, 0x02, 0x03, // real group ID
+		0x00, 0x01, 0x02, 0x03, // process ID
+		0x00, 0x01, 0x02, 0x03, // session ID
+		0x00, 0x01, 0x02, 0x03, // terminal port ID
+		0x00, 0x00, 0x00, 0x04, // address length -> IPv4
+		0x00, 0x01, 0x02, 0x03, // actual IP
+	}
+
+	tok, err := ReadToken(bytes.NewBuffer(data))
+	if err != nil {
+		t.Error(err.Error())
+	}
+	switch v := tok.(type) {
+	case token.ExpandedProcessToken32bit:
+		if v.TerminalAddressLength != 0x04 {
+			t.Error("invalid address length on 32 bit expanded process token")
+		}
+	default:
+		t.Error("unexpected token found")
+	}
+
+	data = []byte{
+		0x7b,                   // token ID
+		0x00, 0x01, 0x02, 0x03, // audit ID
+		0x00, 0x01, 0x02, 0x03, // effective user ID
+		0x00, 0x01, 0x02, 0x03, // effective group ID
+		0x00, 0x01, 0x02, 0x03, // real user ID
+		0x00, 0x01, 0x02, 0x03, // real group ID
+		0x00, 0x01, 0x02, 0x03, // process ID
+		0x00, 0x01, 0x02, 0x03, // session ID
+		0x00, 0x01, 0x02, 0x03, // terminal port ID
+		0x00, 0x00, 0x00, 0x10, // address length -> IPv6
+		0x00, 0x01, 0x02, 0x03, // actual IP
+		0x04, 0x05, 0x06, 0x07,
+		0x08, 0x09, 0x0a, 0x0b,
+		0x0c, 0x0d, 0x0e, 0x0f,
+	}
+	tok, err = ReadToken(bytes.NewBuffer(data))
+	if err != nil {
+		t.Error(err.Error())
+	}
+	switch tok.(type) {
+	case token.ExpandedProcessToken32bit:
+	default:
+		t.Error("unexpected token found")
+	}
+
+	data = []byte{
+		0x7b,                   // token ID
+		0x00, 0x01, 0x02, 0x03, // audit ID
+		0x00, 0x01, 0x02, 0x03, // effective user ID
+		0x00, 0x01, 0x02, 0x03, // effective group ID
+		0x00, 0x01, 0x02, 0x03, // real user ID
+		0x00, 0x01, 0x02, 0x03, // real group ID
+		0x00, 0x01, 0x02, 0x03, // process ID
+		0x00, 0x01, 0x02, 0x03, // session ID
+		0x00, 0x01, 0x02, 0x03, // terminal port ID
+		0x00, 0x00, 0x00, 0x11, // invalid address length
+		0x00, 0x01, 0x02, 0x03, // actual IP
+		0x04, 0x05, 0x06, 0x07,
+		0x08, 0x09, 0x0a, 0x0b,
+		0x0c, 0x0d, 0x0e, 0x0f,
+		0x41,
+	}
+	_, err = ReadToken(bytes.NewBuffer(data))
+	if err == nil {
+		t.Error("expected an error on invalid length")
+	}
+
+}
+
+func Test_small_example_token(t *testing.T) {
+	data := []byte{
+		0x14,                   // --- 32bit header token ID
+		0x00, 0x00, 0x00, 0x38, // 56 bytes in record
+		0x0b,       // version number (2991)
+		0xaf, 0xc8, // event type
+		0x00, 0x00, // event modifier / sub-type
+		0x5a, 0x9a, 0xc2, 0xe6, // timestamp seconds
+		0x00, 0x00, 0x03, 0x01, // timestamp nanoseconds
+		0x28,       // --- text token ID
+		0x00, 0x16, // string length (22 bytes)
+		0x61, 0x75, 0x64, 0x69, // actual string
+		0x74, 0x64, 0x3a, 0x3a,
+		0x41, 0x75, 0x64, 0x69,
+		0x74, 0x20, 0x73, 0x74,
+		0x61, 0x72, 0x74, 0x75,
+		0x70, 0x00,
+		0x27,                   // --- return token ID
+		0x00,                   // error number
+		0x00, 0x00, 0x00, 0x00, // return value
+		0x13,       // --- trailer token ID
+		0xb1, 0x05, // trailer magic
+		0x00, 0x00, 0x00, 0x38, // record byte count (56 bytes)
+	}
+	input := bytes.NewBuffer(data)
+
+	// --- parse tokens single handed ---
+	// parse first token
+	tok, err := ReadToken(input)
+	if err != nil {
+		t.Error(err.Error())
+	}
+	switch tok.(type) {
+	case token.HeaderToken32bit:
+	default:
+		t.Error("unexpected token found")
+	}
+
+	// parse second token
+	tok, err = ReadToken(input)
+	if err != nil {
+		t.Error(err.Error())
+	}
+	switch v := tok.(type) {
+	case token.TextToken:
+		if v.TextLength != 22 {
+			t.Error("wrong text length")
+		}
+		if v.Text != "auditd::Audit startup" {
+			t.Error("unexpected text")
+		}
+	default:
+		t.Error("unexpected token found")
+	}
+
+	// parse third token
+	tok, err = ReadToken(input)
+	if err != nil {
+		t.Error(err.Error())
+	}
+	switch v := tok.(type) {
+	case token.ReturnToken32bit:
+		if v.ErrorNumber != 0 {
+			t.Error("unexpected error number")
+		}
+		if v.ReturnValue != 0 {
+			t.Error("unexpected return value")
+		}
+	default:
+		t.Error("unexpected token found")
+	}
+
+	// parse fourth (last) token
+	tok, err = ReadToken(input)
+	if err != nil {
+		t.Error(err.Error())
+	}
+	switch v := tok.(type) {
+	case token.TrailerToken:
+		if v.RecordByteCount != 0 {
+			t.Error("unexpected record byte count")
+		}
+	default:
+		t.Error("unexpected token found")
+	}
+
+	// --- try to parse complete record ---
+	input = bytes.NewBuffer(data)
+	rec, err := ReadBsmRecord(input)
+	if err != nil {
+		t.Error(err.Error())
+	}
+	if 2 != len(rec.Tokens) {
+		t.Error("unexpected number od tokens in BSM record")
+	}
+
+	// --- try the generator ---
+	input = bytes.NewBuffer(data)
+	rcount := 0
+	for _ = range RecordGenerator(input) {
+		rcount += 1
+		if rcount > 2 { // original + EOF
+			t.Error("more records than expected")
+		}
+	}
+}
+
+func Test_parsing_root_login(t *testing.T) {
+	data := []byte{
+		0x14, // --- 32bit header token
+		0x00, 0x00, 0x00, 0x61,
+		0x0b,
+		0x18, 0x0f,
+		0x00, 0x00,
+		0x5a, 0x9a, 0xc2, 0x1f,
+		0x00, 0x00, 0x03, 0x63,
+		0x24,                   // --- 32bit subject token
+		0xff, 0xff, 0xff, 0xff, // audit ID
+		0x00, 0x00, 0x00, 0x00, // effective user ID
+		0x00, 0x00, 0x00, 0x00, // effective group ID
+		0x00, 0x00, 0x00, 0x00, // real user ID
+		0x00, 0x00, 0x00, 0x00, // real group ID
+		0x00, 0x00, 0x02, 0xf2, // process ID
+		0x00, 0x00, 0x02, 0xf2, // audit session ID
+		0x00, 0x00, 0x00, 0x00, // terminal port ID
+		0x00, 0x00, 0x00, 0x00, // machine IP address
+		0x28,       // --- text token
+		0x00, 0x1a, // test length (26)
+		0x73, 0x75, 0x63, 0x63, // text
+		0x65, 0x73, 0x73, 0x66,
+		0x75, 0x6c, 0x20, 0x61,
+		0x75, 0x74, 0x68, 0x65,
+		0x6e, 0x74, 0x69, 0x63,
+		0x61, 0x74, 0x69, 0x6f,
+		0x6e, 0x00,
+		0x27, // --- return token
+		0x00,
+		0x00, 0x00, 0x00, 0x00,
+		0x13, // --- trailer token
+		0xb1, 0x05,
+		0x00, 0x00, 0x00, 0x61,
+		0x14, // --- 32bit subject token
+		0x00, 0x00, 0x00, 0x61,
+		0x0b,
+		0x80, 0x20,
+		0x00, 0x00,
+		0x5a, 0x9a, 0xc2, 0x27,
+		0x00, 0x00, 0x01, 0xf9,
+		0x7a, // expanded 32bit subject token
+		0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x03, 0x35,
+		0x00, 0x00, 0x03, 0x35,
+		0x00, 0x00, 0x1c, 0x65,
+		0x00, 0x00, 0x00, 0x04,
+		0x5d, 0xb8, 0xd8, 0x26,
+		0x28, // --- text token
+		0x00, 0x16,
+		0x73, 0x75, 0x63, 0x63,
+		0x65, 0x73, 0x73, 0x66,
+		0x75, 0x6c, 0x20, 0x6c,
+		0x6f, 0x67, 0x69, 0x6e,
+		0x20, 0x72, 0x6f, 0x6f,
+		0x74, 0x00,
+		0x27, // --- return token
+		0x00,
+		0x00, 0x00, 0x00, 0x00,
+		0x13, // --- trailer token
+		0xb1, 0x05,
+		0x00, 0x00, 0x00, 0x61,
+		0x14, // 32 bit header
+		0x00, 0x00, 0x00, 0x39,
+		0x0b,
+		0xaf, 0xc9,
+		0x00, 0x00,
+		0x5a, 0x9a, 0xc2, 0x43,
+		0x00, 0x00, 0x03, 0xa1,
+		0x28, // --- text token
+		0x00, 0x17,
+		0x61, 0x75, 0x64, 0x69,
+		0x74, 0x64, 0x3a, 0x3a,
+		0x41, 0x75, 0x64, 0x69,
+		0x74, 0x20, 0x73, 0x68,
+		0x75, 0x74, 0x64, 0x6f,
+		0x77, 0x6e, 0x00,
+		0x27, // --- return token
+		0x00, 0x00, 0x00, 0x00, 0x00,
+		0x13, // --- trailer token
+		0xb1, 0x05, 0x00, 0x00, 0x00, 0x39,
+	}
+	input := bytes.NewBuffer(data)
+	rec, err := ReadBsmRecord(input)
+	if err != nil {
+		t.Error(err.Error())
+	}
+	if 3 != len(rec.Tokens) { // subject + text + return
+		t.Error("unexpected number of tokens in BSM record")
+	}
+
+	// record with expanded 32 bit subject token
+	rec, err = ReadBsmRecord(input)
+	if err != nil {
+		t.Error(err.Error())
+	}
+	if 3 != len(rec.Tokens) { // subject + text + return
+		t.Error("unexpected number of tokens in BSM record")
+	}
+
+	subjectToken, ok := rec.Tokens[0].(token.ExpandedSubjectToken32bit)
+	if !ok {
+		t.Error("asserting ExpandedSubjectToken32bit type failed")
+	}
+	if subjectToken.EffectiveUserID != 0 {
+		t.Error("wrong effective user ID")
+	}
+
+	textToken, ok := rec.Tokens[1].(token.TextToken)
+	if !ok {
+		t.Error("asserting TextToken type failed")
+	}
+	if textToken.Text != "successful login root" {
+		t.Error("unexpected string in text token")
+	}
+
+	// record with plain text token
+	rec, err = ReadBsmRecord(input)
+	if err != nil {
+		t.Error(err.Error())
+	}
+	if 2 != len(rec.Tokens) { // text + return
+		t.Error("unexpected number of tokens in BSM record")
+	}
+
+}
+
+func Test_reading_from_file(t *testing.T) {
+	file, err := os.Open("../start_stop.bsm")
+	if err != nil {
+		t.Error(err)
+	}
+	defer file.Close()
+
+	rcount := 0
+	for _ = range RecordGenerator(file) {
+		rcount += 1
+		if rcount > 3 { // start + stop + EOF
+			t.Error("more records than expected")
+		}
+	}
+}
+
+func TestForEachRecord(t *testing.T) {
+	file, err := os.Open("../start_stop.bsm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	texts := []string{}
+	err = ForEachRecord(file, func(rec *BsmRecord) error {
+		texts = append(texts, rec.Tokens[0].(token.TextToken).Text)
+		return nil
+	})
+	if err != nil {
+		t.Error(err)
+	}
+	if len(texts) != 2 || texts[0] != "auditd::Audit startup" || texts[1] != "auditd::Audit shutdown" {
+		t.Error("unexpected records:", texts)
+	}
+
+	// early abort
+	file.Seek(0, 0)
+	stop := errors.New("stop")
+	count := 0
+	err = ForEachRecord(file, func(rec *BsmRecord) error {
+		count += 1
+		return stop
+	})
+	if err != stop {
+		t.Error("expected callback error, got", err)
+	}
+	if count != 1 {
+		t.Error("callback was called after abort")
+	}
+}
+
+// stingyReader hands out at most one byte per call and every other
+// call returns no data at all (like an interrupted read on a pipe).
+type stingyReader struct {
+	data  []byte
+	calls int
+}
+
+func (r *stingyReader) Read(p []byte) (int, error) {
+	r.calls += 1
+	if len(r.data) == 0 {
+		return 0, io.EOF
+	}
+	if r.calls%2 == 0 || len(p) == 0 {
+		return 0, nil
+	}
+	p[0] = r.data[0]
+	r.data = r.data[1:]
+	return 1, nil
+}
+
+func Test_reading_from_stingy_reader(t *testing.T) {
+	data, err := os.ReadFile("../start_stop.bsm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, input := range []io.Reader{
+		iotest.OneByteReader(bytes.NewReader(data)),
+		&stingyReader{data: data},
+	} {
+		rcount := 0
+		err := ForEachRecord(input, func(rec *BsmRecord) error {
+			rcount += 1
+			return nil
+		})
+		if err != nil {
+			t.Error(err)
+		}
+		if rcount != 2 {
+			t.Error("unexpected number of records:", rcount)
+		}
+	}
+
+	// variable sized token requiring several size determinations
+	data = []byte{0x3c, // exec args token ID
+		0x00, 0x00, 0x00, 0x03, // count
+		0x2f, 0x62, 0x69, 0x6e, 0x2f, 0x6c, 0x73, 0x00, // "/bin/ls"
+		0x2d, 0x6c, 0x00, // "-l"
+		0x2f, 0x74, 0x6d, 0x70, 0x00, // "/tmp"
+		0x13, // start of next token
+	}
+	buf, err := readTokenBytes(&stingyReader{data: data}, token.DialectUnknown, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf, data[:len(data)-1]) {
+		t.Error("unexpected token bytes:", buf)
+	}
+
+	// truncated token
+	_, err = readTokenBytes(iotest.OneByteReader(bytes.NewReader(data[:10])), token.DialectUnknown, nil)
+	if err != io.ErrUnexpectedEOF {
+		t.Error("expected io.ErrUnexpectedEOF, got", err)
+	}
+}
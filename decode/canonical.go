@@ -0,0 +1,96 @@
+package decode
+
+import (
+	"net"
+	"reflect"
+	"sort"
+
+	"github.com/tpltnt/go-bsm/token"
+)
+
+// isArg reports whether the token is an 'arg' token and returns its
+// argument number.
+func isArg(tok token.Token) (uint8, bool) {
+	switch v := tok.(type) {
+	case token.ArgToken32bit:
+		return v.ArgumentID, true
+	case token.ArgToken64bit:
+		return v.ArgumentID, true
+	}
+	return 0, false
+}
+
+// canonicalToken returns a copy of the token with IPv4 addresses in
+// their 4 byte form and sorted group lists.
+func canonicalToken(tok token.Token) token.Token {
+	if v, ok := tok.(token.GroupsToken); ok {
+		v.GroupList = append([]uint32(nil), v.GroupList...)
+		sort.Slice(v.GroupList, func(i, j int) bool { return v.GroupList[i] < v.GroupList[j] })
+		return v
+	}
+
+	value := reflect.ValueOf(tok)
+	if value.Kind() != reflect.Struct {
+		return tok
+	}
+	ipType := reflect.TypeOf(net.IP{})
+	c := reflect.New(value.Type()).Elem()
+	c.Set(value)
+	for i := 0; i < c.NumField(); i++ {
+		field := c.Field(i)
+		if field.Type() != ipType || field.IsNil() {
+			continue
+		}
+		if ip4 := field.Interface().(net.IP).To4(); ip4 != nil {
+			field.Set(reflect.ValueOf(append(net.IP(nil), ip4...)))
+		}
+	}
+	return c.Interface()
+}
+
+// Canonicalize brings the record into a canonical form: consecutive
+// 'arg' tokens are ordered by argument number, group lists are sorted
+// and IPv4 addresses use their 4 byte form. Records decoding the same
+// information are deep-equal in canonical form.
+func Canonicalize(rec *BsmRecord) {
+	if len(rec.Tokens) == 0 {
+		rec.Tokens = nil
+		return
+	}
+	tokens := make([]token.Token, len(rec.Tokens))
+	for i, tok := range rec.Tokens {
+		tokens[i] = canonicalToken(tok)
+	}
+	for start := 0; start < len(tokens); start++ {
+		if _, ok := isArg(tokens[start]); !ok {
+			continue
+		}
+		end := start + 1
+		for end < len(tokens) {
+			if _, ok := isArg(tokens[end]); !ok {
+				break
+			}
+			end += 1
+		}
+		args := tokens[start:end]
+		sort.SliceStable(args, func(i, j int) bool {
+			a, _ := isArg(args[i])
+			b, _ := isArg(args[j])
+			return a < b
+		})
+		start = end
+	}
+	rec.Tokens = tokens
+}
+
+// Equal reports whether both records carry the same information, i.e.
+// are deep-equal in canonical form (see Canonicalize).
+func Equal(a, b *BsmRecord) bool {
+	ca, cb := *a, *b
+	Canonicalize(&ca)
+	Canonicalize(&cb)
+	if len(ca.Annotations) == 0 && len(cb.Annotations) == 0 {
+		ca.Annotations, cb.Annotations = nil, nil
+	}
+	return reflect.DeepEqual(ca, cb)
+}
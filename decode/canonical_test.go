@@ -0,0 +1,63 @@
+package decode
+
+import (
+	"net"
+	"testing"
+
+	"github.com/tpltnt/go-bsm/token"
+)
+
+func TestCanonicalize(t *testing.T) {
+	rec := BsmRecord{Tokens: []token.Token{
+		token.ArgToken32bit{TokenID: 0x2d, ArgumentID: 2, ArgumentValue: 0x601},
+		token.ArgToken32bit{TokenID: 0x2d, ArgumentID: 1, ArgumentValue: 0x42},
+		token.PathToken{TokenID: 0x23, Path: "/etc/passwd"},
+		token.GroupsToken{TokenID: 0x34, NumberOfGroups: 3, GroupList: []uint32{20, 0, 12}},
+		token.SubjectToken32bit{TokenID: 0x24, TerminalMachineAddress: net.IPv4(192, 0, 2, 1)},
+	}}
+	groups := rec.Tokens[3].(token.GroupsToken).GroupList
+	Canonicalize(&rec)
+
+	if rec.Tokens[0].(token.ArgToken32bit).ArgumentID != 1 || rec.Tokens[1].(token.ArgToken32bit).ArgumentID != 2 {
+		t.Error("arg tokens not ordered")
+	}
+	if list := rec.Tokens[3].(token.GroupsToken).GroupList; list[0] != 0 || list[2] != 20 {
+		t.Error("group list not sorted:", list)
+	}
+	if groups[0] != 20 {
+		t.Error("original group list modified")
+	}
+	if addr := rec.Tokens[4].(token.SubjectToken32bit).TerminalMachineAddress; len(addr) != 4 {
+		t.Error("IPv4 address not normalized:", len(addr))
+	}
+	if _, ok := rec.Tokens[2].(token.PathToken); !ok {
+		t.Error("token order changed")
+	}
+}
+
+func TestEqual(t *testing.T) {
+	a := BsmRecord{EventType: 72, Tokens: []token.Token{
+		token.ArgToken32bit{TokenID: 0x2d, ArgumentID: 2},
+		token.ArgToken32bit{TokenID: 0x2d, ArgumentID: 1},
+		token.SubjectToken32bit{TokenID: 0x24, TerminalMachineAddress: net.IPv4(192, 0, 2, 1)},
+	}}
+	b := BsmRecord{EventType: 72, Tokens: []token.Token{
+		token.ArgToken32bit{TokenID: 0x2d, ArgumentID: 1},
+		token.ArgToken32bit{TokenID: 0x2d, ArgumentID: 2},
+		token.SubjectToken32bit{TokenID: 0x24, TerminalMachineAddress: net.IP{192, 0, 2, 1}},
+	}, Annotations: map[string]string{}}
+	if !Equal(&a, &b) {
+		t.Error("records not equal")
+	}
+	if _, ok := a.Tokens[0].(token.ArgToken32bit); !ok || a.Tokens[0].(token.ArgToken32bit).ArgumentID != 2 {
+		t.Error("Equal modified the record")
+	}
+
+	b.EventModifier = 1
+	if Equal(&a, &b) {
+		t.Error("records with different headers are equal")
+	}
+	if !Equal(&BsmRecord{}, &BsmRecord{Tokens: []token.Token{}}) {
+		t.Error("empty records not equal")
+	}
+}
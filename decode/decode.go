@@ -0,0 +1,215 @@
+// Package decode reads BSM records from audit trails.
+package decode
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/tpltnt/go-bsm/token"
+)
+
+// ReadToken converts bytes read from a given input to a BSM token.
+func ReadToken(input io.Reader) (token.Token, error) {
+	tokenBuffer, err := readTokenBytes(input, token.DialectUnknown, nil)
+	if err != nil {
+		return nil, err
+	}
+	return token.Parse(tokenBuffer)
+}
+
+// ReadTokenBytes reads the raw bytes of the next token written in the
+// given dialect, e.g. to copy tokens unchanged. It doesn't consume the
+// NUL which may follow path tokens (see token.Dialect.PathNULFollows),
+// use a TokenReader to read all tokens of a trail.
+func ReadTokenBytes(input io.Reader, dialect token.Dialect) ([]byte, error) {
+	return readTokenBytes(input, dialect, nil)
+}
+
+// TokenReader reads the raw tokens of a trail written in the given
+// dialect, e.g. to copy tokens unchanged. The NUL of a path token which
+// isn't counted by its length is returned as part of the token, like
+// the Decoder reads it.
+type TokenReader struct {
+	input   io.Reader
+	dialect token.Dialect
+	next    []byte // byte read after the last token
+}
+
+// NewTokenReader returns a reader of the tokens of the given input.
+func NewTokenReader(input io.Reader, dialect token.Dialect) *TokenReader {
+	return &TokenReader{input: input, dialect: dialect}
+}
+
+// ReadToken returns the raw bytes of the next token. It returns io.EOF
+// at the end of the input.
+func (r *TokenReader) ReadToken() ([]byte, error) {
+	input := r.input
+	if r.next != nil {
+		input = io.MultiReader(bytes.NewReader(r.next), r.input)
+	}
+	data, err := readTokenBytes(input, r.dialect, nil)
+	if err != nil {
+		return nil, err
+	}
+	data, r.next, err = readPathNUL(r.input, data, r.dialect)
+	return data, err
+}
+
+// readPathNUL reads the byte following the token data if it is a path
+// token whose NUL may follow (see token.Dialect.PathNULFollows). The
+// NUL is appended to data, any other byte is returned as next, as it
+// starts the next token.
+func readPathNUL(input io.Reader, data []byte, dialect token.Dialect) (token, next []byte, err error) {
+	if !dialect.PathNULFollows(data) {
+		return data, nil, nil
+	}
+	b := make([]byte, 1)
+	if _, err := io.ReadFull(input, b); err != nil {
+		if err == io.EOF {
+			return data, nil, nil
+		}
+		return nil, nil, err
+	}
+	if b[0] == 0 {
+		return append(data, 0), nil, nil
+	}
+	return data, b, nil
+}
+
+// readTokenBytes reads the raw bytes of the next token written in the
+// given dialect from the input. Buffer allocations are accounted in
+// stats (if not nil).
+func readTokenBytes(input io.Reader, dialect token.Dialect, stats *DecoderStats) ([]byte, error) {
+	return readTokenBytesSized(input, dialect, nil, stats)
+}
+
+// readTokenBytesSized works like readTokenBytes with the sizes of the
+// given table.
+func readTokenBytesSized(input io.Reader, dialect token.Dialect, sizes token.SizeTable, stats *DecoderStats) ([]byte, error) {
+	tokenBuffer := make([]byte, 1)
+	stats.allocated(cap(tokenBuffer))
+
+	// try to use only token ID
+	if _, err := io.ReadFull(input, tokenBuffer); err != nil {
+		return nil, err // plain io.EOF if the input is exhausted
+	}
+
+	// read more bytes until the size of the token is known and all of
+	// its bytes are present. io.ReadFull takes care of short reads.
+	for {
+		size, moreBytes, err := sizes.Size(tokenBuffer, dialect)
+		if err != nil {
+			return nil, err
+		}
+		wanted := size
+		if moreBytes != 0 {
+			wanted = len(tokenBuffer) + moreBytes
+		}
+		if wanted == len(tokenBuffer) {
+			return tokenBuffer, nil
+		}
+		if wanted < len(tokenBuffer) {
+			return nil, fmt.Errorf("inconsistent size (%d bytes) of token 0x%x", wanted, tokenBuffer[0])
+		}
+
+		// increase token buffer to hold new bytes
+		if cap(tokenBuffer) < wanted {
+			capacity := 2 * cap(tokenBuffer)
+			if capacity < wanted {
+				capacity = wanted
+			}
+			tmp := make([]byte, len(tokenBuffer), capacity)
+			stats.allocated(cap(tmp))
+			copy(tmp, tokenBuffer)
+			tokenBuffer = tmp
+		}
+		bufidx := len(tokenBuffer) // index where to fill the buffer
+		tokenBuffer = tokenBuffer[:wanted]
+		if _, err := io.ReadFull(input, tokenBuffer[bufidx:]); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF // we are in the middle of a token
+			}
+			return nil, err
+		}
+	}
+}
+
+// BsmRecord represents a BSM record.
+type BsmRecord struct {
+	Seconds       uint64               // record time stamp (8 bytes)
+	NanoSeconds   uint64               // record time stamp (8 bytes)
+	Version       byte                 // BSM record version number (from header)
+	EventType     uint16               // event type (from header)
+	EventModifier uint16               // event sub-type (from header)
+	ByteCount     uint32               // number of bytes in record (from header)
+	Tokens        []token.Token        // generic list of all tokens
+	Privilege     *PrivilegeTransition // set by FlagPrivilegeTransitions
+	Annotations   map[string]string    // set by transforms, see Annotate
+	Truncated     bool                 // strings were capped (see Decoder.MaxStringLength)
+}
+
+// ParsingResult encapsulates the result of the parsing
+// process to be used in conjunction with channels.
+type ParsingResult struct {
+	Record BsmRecord
+	Error  error
+	Issues error // problems tolerated in lenient mode (see Decoder.Issues)
+}
+
+// ReadBsmRecord read a complete BSM record from the given byte source.
+func ReadBsmRecord(input io.Reader) (BsmRecord, error) {
+	return NewDecoder(input).Decode()
+}
+
+// RecordGenerator yields a continous stream of BSM records
+// until the source is exhausted.
+func RecordGenerator(input io.Reader) chan ParsingResult {
+	return NewDecoder(input).Records()
+}
+
+// Records yields the records of the decoder like RecordGenerator, along
+// with the problems tolerated in Lenient mode.
+func (d *Decoder) Records() chan ParsingResult {
+	resChan := make(chan ParsingResult)
+
+	// cookie-cutter iterator
+	go func() {
+		for { // extraction loop
+			rec, err := d.Decode()
+			res := ParsingResult{
+				Record: rec,
+				Error:  err,
+				Issues: d.Issues(),
+			}
+			resChan <- res
+			// leave source is exhausted
+			if res.Error == io.EOF {
+				break
+			}
+		}
+		close(resChan)
+	}()
+
+	return resChan
+}
+
+// ForEachRecord calls fn for every BSM record read from the given
+// source until it is exhausted. Iteration stops at the first parsing
+// error or as soon as fn returns an error, which is then returned.
+// Unlike RecordGenerator no goroutine is involved.
+func ForEachRecord(input io.Reader, fn func(*BsmRecord) error) error {
+	decoder := NewDecoder(input)
+	for {
+		rec, err := decoder.Decode()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err = fn(&rec); err != nil {
+			return err
+		}
+	}
+}
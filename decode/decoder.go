@@ -0,0 +1,482 @@
+package decode
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/tpltnt/go-bsm/token"
+)
+
+// errSkipped signals a record rejected by the HeaderFilter.
+var errSkipped = errors.New("record skipped")
+
+// DecoderStats holds counters about the work done by a Decoder. All
+// values are derived from the input only, so decoding the same trail
+// twice yields the same numbers.
+type DecoderStats struct {
+	BytesRead      uint64 // number of bytes consumed from the input
+	RecordsParsed  uint64 // number of complete records
+	TokensParsed   uint64 // number of tokens (incl. header and trailer)
+	PeakTokenSize  int    // size of the largest token in bytes
+	Allocations    uint64 // number of token buffers allocated
+	AllocatedBytes uint64 // total size of all token buffers
+	Errors         uint64 // number of records which failed to decode
+	RecordsSkipped uint64 // number of records rejected by the HeaderFilter
+	Truncated      uint64 // number of tokens with strings capped at MaxStringLength
+}
+
+// allocated accounts a buffer of the given size. It is a no-op on
+// a nil receiver to keep the stats optional for callers.
+func (stats *DecoderStats) allocated(size int) {
+	if stats == nil {
+		return
+	}
+	stats.Allocations += 1
+	stats.AllocatedBytes += uint64(size)
+}
+
+// TrailEnd describes how a trail ended, see Decoder.End.
+type TrailEnd struct {
+	File    *token.FileToken // file token closing the trail (if any)
+	Padding int              // number of zero bytes following the last token
+}
+
+// TruncatedTrailError is returned by the Decoder if the input ends
+// within a record, e.g. to splice in the remainder from a later copy of
+// the trail. It wraps io.ErrUnexpectedEOF.
+type TruncatedTrailError struct {
+	Records uint64 // number of complete records read before
+	Offset  uint64 // offset of the partial record in the input
+	Data    []byte // bytes of the partial record read (nil unless Lenient or the input is an io.Seeker)
+}
+
+func (e *TruncatedTrailError) Error() string {
+	return fmt.Sprintf("trail truncated within record at offset %d (%d bytes) after %d records", e.Offset, len(e.Data), e.Records)
+}
+
+func (e *TruncatedTrailError) Unwrap() error {
+	return io.ErrUnexpectedEOF
+}
+
+// countingReader counts the bytes read from the wrapped reader. If
+// capture is set, they are appended to captured.
+type countingReader struct {
+	input    io.Reader
+	count    uint64
+	capture  bool
+	captured []byte
+}
+
+func (cr *countingReader) Read(p []byte) (int, error) {
+	n, err := cr.input.Read(p)
+	cr.count += uint64(n)
+	if cr.capture {
+		cr.captured = append(cr.captured, p[:n]...)
+	}
+	return n, err
+}
+
+// Decoder reads BSM records from an input stream.
+type Decoder struct {
+	// FileTokenHandler (if not nil) is called for every 'file' token
+	// found between records, e.g. at the boundaries of concatenated
+	// trails. File tokens are skipped in any case.
+	FileTokenHandler func(token.FileToken)
+
+	// Versions lists the record versions considered valid. If nil,
+	// KnownVersions is used.
+	Versions []byte
+
+	// Strict makes the decoder reject records with a version not
+	// listed in Versions with an *UnknownVersionError. By default
+	// such records are decoded like any other record.
+	Strict bool
+
+	// Interner (if not nil) is used to deduplicate the strings of
+	// decoded tokens. This saves memory when keeping many records
+	// around, at the cost of a map lookup per string.
+	Interner *Interner
+
+	// HeaderFilter (if not nil) is called with every record as soon
+	// as its header is read, i.e. before any other token is parsed.
+	// Records it rejects are skipped using the record byte count of
+	// the header without parsing their tokens.
+	HeaderFilter func(rec *BsmRecord) bool
+
+	// Dialect is the operating system which wrote the trail. It
+	// controls how ambiguous fields are decoded, e.g. DialectUnknown
+	// (the default) and DialectLinux accept the AU_IPv4/AU_IPv6 enum
+	// values (1/2) as address type next to the address length (4/16),
+	// and DialectLinux accepts path tokens whose NUL terminator isn't
+	// counted by their length (see token.Dialect.PathLengthCountsNUL).
+	Dialect token.Dialect
+
+	// MaxStringLength (if > 0) caps the strings of decoded tokens
+	// (paths, texts, arguments, ...) at the given number of bytes,
+	// e.g. to bound the memory held by records of processes executed
+	// with huge arguments. Records with capped strings are marked as
+	// Truncated.
+	MaxStringLength int
+
+	// NUL selects whether the NUL bytes terminating strings are
+	// stripped (the default) or kept.
+	NUL token.NULPolicy
+
+	// Lenient makes the decoder tolerate problems within a record
+	// which leave the rest of the stream readable: tokens which can't
+	// be parsed are skipped (up to the end of the record if their size
+	// is unknown), strings missing their NUL byte and byte counts not
+	// matching the record are accepted. The problems of the record
+	// last decoded are returned by Issues.
+	Lenient bool
+
+	// Sizes (if not nil) overrides the sizes of tokens of fixed size,
+	// for producers deviating from the standard layout.
+	Sizes token.SizeTable
+
+	input     *countingReader
+	stats     DecoderStats
+	truncated bool             // a string of the current record was capped
+	first     [1]byte          // first byte of a token read ahead
+	next      []byte           // byte read after the last (path) token, starting the next one
+	lastFile  *token.FileToken // file token read after the last record
+	issues    []error          // problems of the current record (see Lenient)
+	end       *TrailEnd
+}
+
+// NewDecoder returns a decoder reading from the given input.
+func NewDecoder(input io.Reader) *Decoder {
+	return &Decoder{
+		input: &countingReader{input: input},
+	}
+}
+
+// Stats returns the statistics of all records decoded so far.
+func (d *Decoder) Stats() DecoderStats {
+	stats := d.stats
+	stats.BytesRead = d.input.count
+	return stats
+}
+
+// End returns how the trail ended once Decode returned io.EOF: the
+// closing file token and the zero bytes auditd may leave after it. It
+// returns nil if the input ended right after a record.
+func (d *Decoder) End() *TrailEnd {
+	return d.end
+}
+
+// readToken reads and parses the next token of the input.
+func (d *Decoder) readToken() (token.Token, error) {
+	if d.next == nil {
+		return d.readTokenFrom(d.input)
+	}
+	next := d.next
+	d.next = nil
+	return d.readTokenFrom(io.MultiReader(bytes.NewReader(next), d.input))
+}
+
+// readHeaderToken reads the token starting a record. A zero byte at this
+// position starts the padding at the end of a trail, unless no record or
+// file token was read so far.
+func (d *Decoder) readHeaderToken() (token.Token, error) {
+	if _, err := io.ReadFull(d.input, d.first[:]); err != nil {
+		if err == io.EOF && d.lastFile != nil {
+			d.end = &TrailEnd{File: d.lastFile}
+		}
+		return nil, err
+	}
+	if d.first[0] == 0 && (d.lastFile != nil || d.stats.RecordsParsed+d.stats.RecordsSkipped > 0) {
+		return nil, d.skipPadding()
+	}
+	return d.readTokenFrom(io.MultiReader(bytes.NewReader(d.first[:]), d.input))
+}
+
+// skipPadding consumes the zero bytes up to the end of the input, the
+// first of which was read already. It returns io.EOF if there are no
+// other bytes.
+func (d *Decoder) skipPadding() error {
+	start := d.input.count - 1
+	buf := make([]byte, 512)
+	for {
+		n, err := d.input.Read(buf)
+		for i := 0; i < n; i++ {
+			if buf[i] != 0 {
+				return fmt.Errorf("non-zero byte 0x%02x in padding at offset %d", buf[i], d.input.count-uint64(n-i))
+			}
+		}
+		if err == io.EOF {
+			d.end = &TrailEnd{File: d.lastFile, Padding: int(d.input.count - start)}
+			return io.EOF
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// readTokenFrom reads and parses the next token of the given input,
+// which is (or starts with data read from) the input of the decoder.
+func (d *Decoder) readTokenFrom(input io.Reader) (token.Token, error) {
+	tokenBuffer, err := readTokenBytesSized(input, d.Dialect, d.Sizes, &d.stats)
+	if err != nil {
+		return nil, err
+	}
+	if d.stats.PeakTokenSize < len(tokenBuffer) {
+		d.stats.PeakTokenSize = len(tokenBuffer)
+	}
+	tok, err := d.Sizes.ParseNUL(tokenBuffer, d.NUL)
+	if err != nil {
+		return nil, &unparsedTokenError{ID: tokenBuffer[0], Err: err}
+	}
+	if tokenBuffer, d.next, err = readPathNUL(d.input, tokenBuffer, d.Dialect); err != nil {
+		return nil, err
+	}
+	if d.Lenient && !nulTerminated(tokenBuffer) {
+		d.issue(d.input.count-uint64(len(tokenBuffer)+len(d.next)), fmt.Errorf("token 0x%02x: string not NUL terminated", tokenBuffer[0]))
+	}
+	d.stats.TokensParsed += 1
+	if d.MaxStringLength > 0 {
+		var truncated bool
+		if tok, truncated = truncateToken(tok, d.MaxStringLength); truncated {
+			d.stats.Truncated += 1
+			d.truncated = true
+		}
+	}
+	if d.Interner != nil {
+		tok = d.Interner.internToken(tok)
+	}
+	return tok, nil
+}
+
+// Decode reads the next complete BSM record. It returns io.EOF
+// if the input is exhausted.
+// TODO: check record size for consistency
+func (d *Decoder) Decode() (BsmRecord, error) {
+	rec, err := d.readRecord()
+	for err == errSkipped {
+		d.stats.RecordsSkipped += 1
+		rec, err = d.readRecord()
+	}
+	if err != nil {
+		if err != io.EOF {
+			d.stats.Errors += 1
+		}
+		return rec, err
+	}
+	d.stats.RecordsParsed += 1
+	return rec, nil
+}
+
+func (d *Decoder) readRecord() (BsmRecord, error) {
+	rec := BsmRecord{}
+	d.truncated = false
+	d.issues = nil
+	d.next = nil
+	// the bytes of the record are checked in lenient mode only, others
+	// are read again if the record turns out to be truncated
+	d.input.capture = d.Lenient
+	defer func() { d.input.capture = false }()
+
+	// start: header token (after any file tokens)
+	start := d.input.count
+	d.input.captured = d.input.captured[:0]
+	header, err := d.readHeaderToken()
+	if err != nil {
+		return rec, d.truncatedError(start, err)
+	}
+	for {
+		file, ok := header.(token.FileToken)
+		if !ok {
+			break
+		}
+		if d.FileTokenHandler != nil {
+			d.FileTokenHandler(file)
+		}
+		d.lastFile = &file
+		start = d.input.count
+		d.input.captured = d.input.captured[:0]
+		header, err = d.readHeaderToken()
+		if err != nil {
+			return rec, d.truncatedError(start, err) // io.EOF after a trailing file token
+		}
+	}
+	d.lastFile = nil
+
+	if !rec.setHeader(header) {
+		return rec, errors.New("no header token found")
+	}
+
+	if d.HeaderFilter != nil && !d.HeaderFilter(&rec) {
+		return rec, d.truncatedError(start, d.skipRecord(rec.ByteCount, d.input.count-start))
+	}
+
+	for {
+		offset := d.input.count
+		nextToken, err := d.readToken()
+		if err != nil && d.Lenient && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+			var unparsed *unparsedTokenError
+			if errors.As(err, &unparsed) {
+				d.issue(offset, err) // the token is skipped
+				continue
+			}
+			// the size of the token is unknown, so is the next token
+			d.issue(offset, fmt.Errorf("%w, skipped rest of record", err))
+			if err := d.skipRecord(rec.ByteCount, d.input.count-start); err != errSkipped {
+				return rec, d.truncatedError(start, err)
+			}
+			break
+		}
+		if err != nil {
+			return rec, d.truncatedError(start, eofWithin(err))
+		}
+
+		// the trailer token indicates the end of record
+		if _, isEnd := nextToken.(token.TrailerToken); isEnd {
+			if d.Lenient {
+				d.checkSize(&rec, start)
+			}
+			break
+		}
+		rec.Tokens = append(rec.Tokens, nextToken)
+	}
+	rec.Truncated = d.truncated
+
+	// check the version after reading the complete record to stay
+	// in sync with the stream
+	if d.Strict {
+		versions := d.Versions
+		if versions == nil {
+			versions = KnownVersions
+		}
+		if !acceptsVersion(versions, rec.Version) {
+			return rec, &UnknownVersionError{Version: rec.Version}
+		}
+	}
+
+	return rec, nil
+}
+
+// Issues returns the problems tolerated in the record last decoded in
+// Lenient mode, joined with errors.Join, or nil if there were none.
+func (d *Decoder) Issues() error {
+	return errors.Join(d.issues...)
+}
+
+// issue notes a problem of the current record found at the given offset.
+func (d *Decoder) issue(offset uint64, err error) {
+	d.issues = append(d.issues, fmt.Errorf("offset %d: %w", offset, err))
+}
+
+// checkSize compares the byte counts of the header and the trailer of
+// the record starting at the given offset with its actual size.
+func (d *Decoder) checkSize(rec *BsmRecord, start uint64) {
+	data := d.input.captured
+	size := uint32(len(data))
+	if rec.ByteCount != size {
+		d.issue(start, fmt.Errorf("header byte count %d, record has %d bytes", rec.ByteCount, size))
+	}
+	trailer := data[len(data)-7:]
+	if magic := binary.BigEndian.Uint16(trailer[1:3]); magic != 0xb105 {
+		d.issue(start, fmt.Errorf("invalid trailer magic 0x%04x", magic))
+	}
+	if n := binary.BigEndian.Uint32(trailer[3:7]); n != size {
+		d.issue(start, fmt.Errorf("trailer byte count %d, record has %d bytes", n, size))
+	}
+}
+
+// unparsedTokenError is returned for a token of known size which can't
+// be parsed.
+type unparsedTokenError struct {
+	ID  byte
+	Err error
+}
+
+func (e *unparsedTokenError) Error() string { return e.Err.Error() }
+
+func (e *unparsedTokenError) Unwrap() error { return e.Err }
+
+// nulTerminated reports whether the string at the end of a raw token
+// ends with a NUL byte. Tokens without such a string are reported as
+// terminated.
+func nulTerminated(data []byte) bool {
+	switch data[0] {
+	case 0x11, 0x23, 0x28, 0x2d, 0x60, 0x71: // file, path, text, arg, zonename tokens
+	default:
+		return true
+	}
+	var length int
+	switch data[0] {
+	case 0x11:
+		length = int(binary.BigEndian.Uint16(data[9:11]))
+	case 0x2d:
+		length = int(binary.BigEndian.Uint16(data[6:8]))
+	case 0x71:
+		length = int(binary.BigEndian.Uint16(data[10:12]))
+	default:
+		length = int(binary.BigEndian.Uint16(data[1:3]))
+	}
+	return length == 0 || data[len(data)-1] == 0
+}
+
+// eofWithin turns io.EOF into io.ErrUnexpectedEOF for input ending
+// within a record.
+func eofWithin(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// truncatedError returns a *TruncatedTrailError for io.ErrUnexpectedEOF
+// within the record starting at the given offset, any other error as is.
+func (d *Decoder) truncatedError(start uint64, err error) error {
+	if err != io.ErrUnexpectedEOF {
+		return err
+	}
+	return &TruncatedTrailError{
+		Records: d.stats.RecordsParsed + d.stats.RecordsSkipped,
+		Offset:  start,
+		Data:    d.partial(start),
+	}
+}
+
+// partial returns the bytes read since the given offset: the bytes
+// captured in Lenient mode, otherwise the bytes read again if the input
+// is an io.Seeker, nil if it isn't.
+func (d *Decoder) partial(start uint64) []byte {
+	if d.input.capture {
+		return append([]byte(nil), d.input.captured...)
+	}
+	seeker, ok := d.input.input.(io.ReadSeeker)
+	if !ok {
+		return nil
+	}
+	size := int64(d.input.count - start)
+	if _, err := seeker.Seek(-size, io.SeekCurrent); err != nil {
+		return nil
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(seeker, data); err != nil {
+		return nil
+	}
+	return data
+}
+
+// skipRecord discards the rest of a record of the given size of which
+// consumed bytes were read already.
+func (d *Decoder) skipRecord(size uint32, consumed uint64) error {
+	if uint64(size) < consumed {
+		return fmt.Errorf("invalid record size %d", size)
+	}
+	remaining := int64(uint64(size) - consumed)
+	if n, err := io.CopyN(io.Discard, d.input, remaining); n < remaining {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return err
+	}
+	return errSkipped
+}
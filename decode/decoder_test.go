@@ -0,0 +1,336 @@
+package decode
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/tpltnt/go-bsm/token"
+)
+
+func TestDecoderStats(t *testing.T) {
+	data, err := os.ReadFile("../start_stop.bsm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoder := NewDecoder(bytes.NewReader(data))
+	for {
+		_, err := decoder.Decode()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stats := decoder.Stats()
+	if stats.BytesRead != uint64(len(data)) {
+		t.Errorf("read %d bytes, expected %d", stats.BytesRead, len(data))
+	}
+	if stats.RecordsParsed != 2 {
+		t.Error("unexpected number of records:", stats.RecordsParsed)
+	}
+	if stats.TokensParsed != 8 { // 2 * (header + text + return + trailer)
+		t.Error("unexpected number of tokens:", stats.TokensParsed)
+	}
+	if stats.PeakTokenSize != 26 { // text token "auditd::Audit shutdown"
+		t.Error("unexpected peak token size:", stats.PeakTokenSize)
+	}
+	if stats.Allocations == 0 || stats.AllocatedBytes < uint64(len(data)) {
+		t.Error("allocations not accounted")
+	}
+	if stats.Errors != 0 {
+		t.Error("unexpected errors:", stats.Errors)
+	}
+
+	// the same input yields the same numbers
+	again := NewDecoder(bytes.NewReader(data))
+	for {
+		if _, err := again.Decode(); err != nil {
+			break
+		}
+	}
+	if again.Stats() != stats {
+		t.Error("stats are not deterministic")
+	}
+
+	// broken input
+	decoder = NewDecoder(bytes.NewReader([]byte{0x00}))
+	if _, err := decoder.Decode(); err == nil {
+		t.Error("expected an error")
+	}
+	if decoder.Stats().Errors != 1 {
+		t.Error("error not counted")
+	}
+}
+
+func TestDecoderFileTokens(t *testing.T) {
+	record, err := os.ReadFile("../start_stop.bsm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	record = record[:56] // first record only
+	fileToken := func(name string) []byte {
+		return append([]byte{0x11, // token ID
+			0x5a, 0x9a, 0xc2, 0xe6, // seconds
+			0x00, 0x00, 0x00, 0x2a, // milliseconds
+			0x00, byte(len(name) + 1), // file name length incl. NUL
+		}, append([]byte(name), 0x00)...)
+	}
+	data := fileToken("20180303.trail")
+	data = append(data, record...)
+	data = append(data, fileToken("20180303.trail")...)
+	data = append(data, fileToken("20180304.trail")...)
+	data = append(data, record...)
+	data = append(data, fileToken("")...)
+
+	files := []token.FileToken{}
+	decoder := NewDecoder(bytes.NewReader(data))
+	decoder.FileTokenHandler = func(tok token.FileToken) {
+		files = append(files, tok)
+	}
+	rcount := 0
+	for {
+		_, err := decoder.Decode()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		rcount += 1
+	}
+	if rcount != 2 {
+		t.Error("unexpected number of records:", rcount)
+	}
+	if len(files) != 4 {
+		t.Fatal("unexpected number of file tokens:", len(files))
+	}
+	if files[0].PathName != "20180303.trail" || files[2].PathName != "20180304.trail" || files[3].PathName != "" {
+		t.Error("unexpected file names:", files)
+	}
+	if files[0].Seconds != 1520091878 || files[0].Microseconds != 42 {
+		t.Error("unexpected file time stamp")
+	}
+
+	// file tokens are skipped without handler
+	rec, err := ReadBsmRecord(bytes.NewReader(data))
+	if err != nil {
+		t.Error(err)
+	}
+	if len(rec.Tokens) != 2 {
+		t.Error("unexpected number of tokens")
+	}
+}
+
+func TestDecoderTrailEnd(t *testing.T) {
+	record, err := os.ReadFile("../start_stop.bsm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	record = record[:56] // first record only
+	file := []byte{0x11, 0, 0, 0, 1, 0, 0, 0, 0, 0, 2, 'x', 0}
+
+	tests := []struct {
+		name    string
+		data    []byte
+		end     bool // End is expected to return a value
+		file    bool // with a file token
+		padding int
+	}{
+		{"plain", record, false, false, 0},
+		{"file token", append(append([]byte{}, record...), file...), true, true, 0},
+		{"padding", append(append([]byte{}, record...), make([]byte, 1000)...), true, false, 1000},
+		{"file token and padding", append(append(append([]byte{}, record...), file...), make([]byte, 3)...), true, true, 3},
+	}
+	for _, test := range tests {
+		decoder := NewDecoder(bytes.NewReader(test.data))
+		if _, err := decoder.Decode(); err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if _, err := decoder.Decode(); err != io.EOF {
+			t.Fatalf("%s: got %v, expected EOF", test.name, err)
+		}
+		end := decoder.End()
+		if (end != nil) != test.end {
+			t.Fatalf("%s: unexpected end %+v", test.name, end)
+		}
+		if end == nil {
+			continue
+		}
+		if (end.File != nil) != test.file {
+			t.Errorf("%s: unexpected file token %v", test.name, end.File)
+		}
+		if end.Padding != test.padding {
+			t.Errorf("%s: got %d padding bytes, expected %d", test.name, end.Padding, test.padding)
+		}
+	}
+
+	data := append(append([]byte{}, record...), 0, 0, 1)
+	decoder := NewDecoder(bytes.NewReader(data))
+	decoder.Decode()
+	if _, err := decoder.Decode(); err == nil || err == io.EOF {
+		t.Errorf("got %v, expected error for data after padding", err)
+	}
+}
+
+func TestDecoderHeaderFilter(t *testing.T) {
+	data, err := os.ReadFile("../start_stop.bsm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data = append(data, data...)
+	decoder := NewDecoder(bytes.NewReader(data))
+	decoder.HeaderFilter = func(rec *BsmRecord) bool {
+		if len(rec.Tokens) != 0 {
+			t.Error("filter called with tokens")
+		}
+		return rec.EventType == 45001
+	}
+	records := []BsmRecord{}
+	for {
+		rec, err := decoder.Decode()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, rec)
+	}
+	if len(records) != 2 || records[0].EventType != 45001 || records[1].Tokens[0].(token.TextToken).Text != "auditd::Audit shutdown" {
+		t.Error("unexpected records:", records)
+	}
+	stats := decoder.Stats()
+	if stats.RecordsSkipped != 2 || stats.RecordsParsed != 2 || stats.TokensParsed != 10 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+
+	// truncated record
+	decoder = NewDecoder(bytes.NewReader(data[:40]))
+	decoder.HeaderFilter = func(*BsmRecord) bool { return false }
+	if _, err := decoder.Decode(); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Error("unexpected error:", err)
+	}
+}
+
+// benchmarkDecode decodes a trail of 2000 records keeping the records
+// accepted by the filter.
+func benchmarkDecode(b *testing.B, keep func(*BsmRecord) bool, headerOnly bool) {
+	data, err := os.ReadFile("../start_stop.bsm")
+	if err != nil {
+		b.Fatal(err)
+	}
+	trail := bytes.Repeat(data, 1000)
+	b.SetBytes(int64(len(trail)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		decoder := NewDecoder(bytes.NewReader(trail))
+		if headerOnly {
+			decoder.HeaderFilter = keep
+		}
+		for {
+			rec, err := decoder.Decode()
+			if err != nil {
+				break
+			}
+			keep(&rec)
+		}
+	}
+}
+
+func rareEvent(rec *BsmRecord) bool { return rec.EventType == 45001 && rec.Seconds%7 == 0 }
+
+func BenchmarkDecode(b *testing.B)             { benchmarkDecode(b, func(*BsmRecord) bool { return true }, false) }
+func BenchmarkDecodeFilter(b *testing.B)       { benchmarkDecode(b, rareEvent, false) }
+func BenchmarkDecodeHeaderFilter(b *testing.B) { benchmarkDecode(b, rareEvent, true) }
+
+func TestDecoderTruncatedTrail(t *testing.T) {
+	data, err := os.ReadFile("../start_stop.bsm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, size := range []int{56 + 10, 56 + 18, 56 + 30} { // within the header, after it and within a token
+		decoder := NewDecoder(bytes.NewReader(data[:size]))
+		if _, err := decoder.Decode(); err != nil {
+			t.Fatal(err)
+		}
+		_, err := decoder.Decode()
+		var truncated *TruncatedTrailError
+		if !errors.As(err, &truncated) {
+			t.Fatalf("%d bytes: got %v, expected *TruncatedTrailError", size, err)
+		}
+		if !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Errorf("%d bytes: io.ErrUnexpectedEOF not wrapped", size)
+		}
+		if truncated.Records != 1 || truncated.Offset != 56 || !bytes.Equal(truncated.Data, data[56:size]) {
+			t.Errorf("%d bytes: unexpected error %+v", size, truncated)
+		}
+	}
+
+	// the bytes of inputs which can't seek are kept in lenient mode only
+	for _, lenient := range []bool{false, true} {
+		decoder := NewDecoder(io.MultiReader(bytes.NewReader(data[:56+30])))
+		decoder.Lenient = lenient
+		decoder.Decode()
+		_, err := decoder.Decode()
+		var truncated *TruncatedTrailError
+		if !errors.As(err, &truncated) || truncated.Offset != 56 {
+			t.Fatalf("lenient %v: unexpected error %v", lenient, err)
+		}
+		if lenient != (truncated.Data != nil) || lenient && !bytes.Equal(truncated.Data, data[56:56+30]) {
+			t.Errorf("lenient %v: got data %x", lenient, truncated.Data)
+		}
+	}
+}
+
+func TestDecoderLenient(t *testing.T) {
+	path := []byte{0x23, 0x00, 0x04, '/', 'e', 't', 'c'} // missing NUL
+	perm := append([]byte{0x32}, make([]byte, 28)...)    // not parsed
+	broken := rawRecord(11, 23, path, perm)
+	broken[len(broken)-1] += 1 // trailer byte count
+	unknown := rawRecord(11, 23, []byte{0xee, 1, 2, 3})
+	data := append(append(broken, unknown...), rawRecord(11, 23)...)
+
+	if _, err := NewDecoder(bytes.NewReader(data)).Decode(); err == nil {
+		t.Error("expected error without lenient mode")
+	}
+
+	decoder := NewDecoder(bytes.NewReader(data))
+	decoder.Lenient = true
+	var issues []int
+	for i := 0; ; i++ {
+		rec, err := decoder.Decode()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("record %d: %v", i, err)
+		}
+		if i == 0 {
+			if p, _ := rec.Path(); p != "/etc" || len(rec.Tokens) != 1 {
+				t.Errorf("unexpected tokens %+v", rec.Tokens)
+			}
+		}
+		n := 0
+		if err := decoder.Issues(); err != nil {
+			n = len(err.(interface{ Unwrap() []error }).Unwrap())
+		}
+		issues = append(issues, n)
+	}
+	if len(issues) != 3 || issues[0] != 3 || issues[1] != 1 || issues[2] != 0 {
+		t.Errorf("unexpected number of issues per record: %v", issues)
+	}
+
+	decoder = NewDecoder(bytes.NewReader(data))
+	decoder.Lenient = true
+	results := decoder.Records()
+	if res := <-results; res.Error != nil || res.Issues == nil {
+		t.Errorf("unexpected result %+v", res)
+	}
+	for range results {
+	}
+}
@@ -0,0 +1,172 @@
+package decode
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/tpltnt/go-bsm/token"
+)
+
+// DetectSamples is the number of records examined by DetectDialect.
+var DetectSamples = 1000
+
+// DialectReport describes the producer of a trail as far as it can be
+// told from a sample of its records.
+type DialectReport struct {
+	Dialect   token.Dialect  // most likely dialect, DialectUnknown if undecided
+	Producer  string         // e.g. "OpenBSM 1.1", derived from the record versions
+	Records   int            // number of records examined
+	Versions  map[byte]int   // number of records by version
+	Tokens    map[string]int // number of tokens by name (see token.Name)
+	Evidence  []string       // observations the dialect is derived from
+	Anomalies []string       // layout problems found, e.g. wrong byte counts
+}
+
+// producers names the record versions.
+var producers = map[byte]string{
+	VersionOldDarwin:  "Darwin (pre-OpenBSM)",
+	VersionSolaris:    "Solaris",
+	VersionTSolaris25: "Trusted Solaris 2.5",
+	VersionTSolaris:   "Trusted Solaris",
+	VersionOpenBSM10:  "OpenBSM 1.0",
+	VersionOpenBSM11:  "OpenBSM 1.1",
+}
+
+// darwinEvents are event types only written by macOS.
+var darwinEvents = map[token.EventType]bool{
+	token.AUE_SESSION_START: true, token.AUE_SESSION_UPDATE: true,
+	token.AUE_SESSION_END: true, token.AUE_SESSION_CLOSE: true,
+}
+
+// DetectDialect examines the first DetectSamples records read from r
+// and reports which operating system likely wrote them. OpenBSM trails
+// of macOS and FreeBSD only differ in a few event types, so these are
+// often reported as DialectUnknown with an OpenBSM producer, which is
+// fine for decoding. Reading stops at the first token which can't be
+// read, which is reported as an anomaly.
+func DetectDialect(r io.Reader) (DialectReport, error) {
+	report := DialectReport{Versions: map[byte]int{}, Tokens: map[string]int{}}
+	input := &countingReader{input: r}
+	votes := map[token.Dialect]string{}
+	var record []byte // raw record read so far
+	var start uint64  // offset of the record
+	for report.Records < DetectSamples {
+		offset := input.count
+		data, err := readTokenBytes(input, token.DialectUnknown, nil)
+		if err == io.EOF {
+			if record != nil {
+				report.Anomalies = append(report.Anomalies, fmt.Sprintf("offset %d: record without trailer", start))
+			}
+			break
+		}
+		if err != nil {
+			if report.Records == 0 && len(report.Tokens) == 0 {
+				return report, err
+			}
+			report.Anomalies = append(report.Anomalies, fmt.Sprintf("offset %d: %v", offset, err))
+			break
+		}
+		tok, err := token.Parse(data)
+		if err != nil {
+			report.Anomalies = append(report.Anomalies, fmt.Sprintf("offset %d: token 0x%02x: %v", offset, data[0], err))
+		} else {
+			report.Tokens[token.Name(tok)] += 1
+		}
+
+		switch data[0] {
+		case 0x14, 0x15, 0x74, 0x79: // header
+			if record != nil {
+				report.Anomalies = append(report.Anomalies, fmt.Sprintf("offset %d: record without trailer", start))
+			}
+			record, start = data, offset
+			version := data[5]
+			report.Versions[version] += 1
+			switch version {
+			case VersionOldDarwin:
+				votes[token.DialectDarwin] = "record version 1 (pre-OpenBSM Darwin)"
+			case VersionSolaris, VersionTSolaris25, VersionTSolaris:
+				votes[token.DialectSolaris] = fmt.Sprintf("record version %d (Solaris)", version)
+			}
+			if darwinEvents[token.EventType(binary.BigEndian.Uint16(data[6:8]))] {
+				votes[token.DialectDarwin] = "macOS session events"
+			}
+			if data[0] == 0x15 || data[0] == 0x79 {
+				checkAddressType(&report, votes, offset, binary.BigEndian.Uint32(data[10:14]))
+			}
+			continue
+		case 0x13: // trailer
+			if record == nil {
+				report.Anomalies = append(report.Anomalies, fmt.Sprintf("offset %d: trailer without header", offset))
+				continue
+			}
+			record = append(record, data...)
+			size := uint32(len(record))
+			if n := binary.BigEndian.Uint32(record[1:5]); n != size {
+				report.Anomalies = append(report.Anomalies, fmt.Sprintf("offset %d: header byte count %d, record has %d bytes", start, n, size))
+			}
+			if n := binary.BigEndian.Uint32(data[3:7]); n != size {
+				report.Anomalies = append(report.Anomalies, fmt.Sprintf("offset %d: trailer byte count %d, record has %d bytes", start, n, size))
+			}
+			record = nil
+			report.Records += 1
+			continue
+		case 0x60: // zonename
+			votes[token.DialectSolaris] = "zonename tokens"
+		case 0x7a, 0x7b: // expanded subject/process
+			checkAddressType(&report, votes, offset, binary.BigEndian.Uint32(data[33:37]))
+		}
+		if record == nil {
+			if data[0] != 0x11 { // file tokens separate records
+				report.Anomalies = append(report.Anomalies, fmt.Sprintf("offset %d: token 0x%02x outside of a record", offset, data[0]))
+			}
+			continue
+		}
+		record = append(record, data...)
+	}
+
+	report.Producer = producer(report.Versions)
+	for d, reason := range votes {
+		report.Evidence = append(report.Evidence, fmt.Sprintf("%s: %s", d, reason))
+	}
+	sort.Strings(report.Evidence)
+	if len(votes) == 1 {
+		for d := range votes {
+			report.Dialect = d
+		}
+	} else if len(votes) > 1 {
+		report.Anomalies = append(report.Anomalies, "conflicting evidence for several dialects")
+	}
+	return report, nil
+}
+
+// checkAddressType records an address type stored as AU_IPv4/AU_IPv6
+// instead of the address length, as done by non-OpenBSM producers.
+func checkAddressType(report *DialectReport, votes map[token.Dialect]string, offset uint64, addrType uint32) {
+	switch addrType {
+	case 1, 2:
+		votes[token.DialectLinux] = "address types stored as AU_IPv4/AU_IPv6"
+	case 4, 16:
+	default:
+		report.Anomalies = append(report.Anomalies, fmt.Sprintf("offset %d: invalid address type %d", offset, addrType))
+	}
+}
+
+// producer returns the name of the most common record version.
+func producer(versions map[byte]int) string {
+	var best byte
+	count := 0
+	for v, n := range versions {
+		if n > count || (n == count && v > best) {
+			best, count = v, n
+		}
+	}
+	if count == 0 {
+		return ""
+	}
+	if name, ok := producers[best]; ok {
+		return name
+	}
+	return fmt.Sprintf("unknown (version %d)", best)
+}
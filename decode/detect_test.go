@@ -0,0 +1,73 @@
+package decode
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/tpltnt/go-bsm/token"
+)
+
+// rawRecord returns a record of the given version framed by a 32 bit
+// header and a trailer around the given tokens.
+func rawRecord(version byte, event uint16, body ...[]byte) []byte {
+	size := 18 + 7
+	for _, b := range body {
+		size += len(b)
+	}
+	rec := []byte{0x14}
+	rec = binary.BigEndian.AppendUint32(rec, uint32(size))
+	rec = append(rec, version)
+	rec = binary.BigEndian.AppendUint16(rec, event)
+	rec = append(rec, make([]byte, 2+8)...) // modifier, time
+	for _, b := range body {
+		rec = append(rec, b...)
+	}
+	rec = append(rec, 0x13, 0xb1, 0x05)
+	return binary.BigEndian.AppendUint32(rec, uint32(size))
+}
+
+func TestDetectDialect(t *testing.T) {
+	data, err := os.ReadFile("../start_stop.bsm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err := DetectDialect(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Records == 0 || report.Producer == "" || report.Tokens["header32"] != report.Records {
+		t.Errorf("unexpected report %+v", report)
+	}
+
+	zonename := []byte{0x60, 0x00, 0x07, 'g', 'l', 'o', 'b', 'a', 'l', 0x00}
+	report, err = DetectDialect(bytes.NewReader(rawRecord(VersionSolaris, 6152, zonename)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Dialect != token.DialectSolaris || report.Producer != "Solaris" || len(report.Anomalies) != 0 {
+		t.Errorf("unexpected report %+v", report)
+	}
+
+	session := rawRecord(VersionOpenBSM11, 44901)
+	if report, _ := DetectDialect(bytes.NewReader(session)); report.Dialect != token.DialectDarwin {
+		t.Errorf("got dialect %v, expected darwin", report.Dialect)
+	}
+}
+
+func TestDetectDialectAnomalies(t *testing.T) {
+	rec := rawRecord(VersionOpenBSM11, 45000)
+	rec[4] += 1 // header byte count
+	report, err := DetectDialect(bytes.NewReader(append(rec, rawRecord(VersionSolaris, 45000)...)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Records != 2 || report.Dialect != token.DialectSolaris {
+		t.Errorf("unexpected report %+v", report)
+	}
+	if len(report.Anomalies) != 1 || !strings.Contains(report.Anomalies[0], "header byte count 26") {
+		t.Errorf("unexpected anomalies %q", report.Anomalies)
+	}
+}
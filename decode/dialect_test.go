@@ -0,0 +1,122 @@
+package decode
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"testing"
+
+	"github.com/tpltnt/go-bsm/token"
+)
+
+func TestDialectAddressType(t *testing.T) {
+	// expanded 32bit subject token with AU_IPv4 as address type
+	subject := []byte{
+		0x7a,
+		0x00, 0x00, 0x03, 0xe9, // audit ID
+		0x00, 0x00, 0x00, 0x00, // effective user ID
+		0x00, 0x00, 0x00, 0x00, // effective group ID
+		0x00, 0x00, 0x00, 0x00, // real user ID
+		0x00, 0x00, 0x00, 0x00, // real group ID
+		0x00, 0x00, 0x03, 0x35, // process ID
+		0x00, 0x00, 0x03, 0x35, // session ID
+		0x00, 0x00, 0x1c, 0x65, // terminal port ID
+		0x00, 0x00, 0x00, 0x01, // AU_IPv4
+		0xc0, 0x00, 0x02, 0x01, // 192.0.2.1
+	}
+	for _, dialect := range []token.Dialect{token.DialectUnknown, token.DialectLinux} {
+		buf, err := readTokenBytes(bytes.NewReader(subject), dialect, nil)
+		if err != nil {
+			t.Fatal(dialect, err)
+		}
+		tok, err := token.Parse(buf)
+		if err != nil {
+			t.Fatal(dialect, err)
+		}
+		v := tok.(token.ExpandedSubjectToken32bit)
+		if v.AuditID != 1001 || !v.TerminalMachineAddress.Equal(net.IPv4(192, 0, 2, 1)) {
+			t.Error("unexpected token:", v)
+		}
+	}
+	for _, dialect := range []token.Dialect{token.DialectDarwin, token.DialectFreeBSD, token.DialectSolaris} {
+		if _, err := readTokenBytes(bytes.NewReader(subject), dialect, nil); err == nil {
+			t.Error("expected error for", dialect)
+		}
+	}
+
+	// AU_IPv6
+	subject[36] = 0x02
+	data := append(subject, make([]byte, 12)...)
+	decoder := NewDecoder(bytes.NewReader(data))
+	tok, err := decoder.readToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if addr := tok.(token.ExpandedSubjectToken32bit).TerminalMachineAddress; len(addr) != 16 || addr[0] != 0xc0 {
+		t.Error("unexpected address:", addr)
+	}
+}
+
+func TestDecoderAttributeDarwin(t *testing.T) {
+	attr := []byte{0x73}
+	attr = append(attr, make([]byte, 4*4)...)
+	attr = binary.BigEndian.AppendUint64(attr, 42) // node ID
+	attr = binary.BigEndian.AppendUint64(attr, 9)  // device
+
+	decoder := NewDecoder(bytes.NewReader(rawRecord(11, 72, attr)))
+	decoder.Dialect = token.DialectDarwin
+	rec, err := decoder.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, ok := rec.Tokens[0].(token.AttributeToken64bit)
+	if !ok || v.FileSystemNodeID != 42 || v.Device != 9 {
+		t.Errorf("got %+v, expected device 9", rec.Tokens[0])
+	}
+}
+
+func TestDecoderSizes(t *testing.T) {
+	// return token with two bytes appended by the producer
+	data := rawRecord(11, 23, []byte{0x27, 0, 0, 0, 0, 1, 0xca, 0xfe})
+	if _, err := NewDecoder(bytes.NewReader(data)).Decode(); err == nil {
+		t.Error("expected error with standard sizes")
+	}
+	decoder := NewDecoder(bytes.NewReader(data))
+	decoder.Sizes = token.SizeTable{0x27: 8}
+	rec, err := decoder.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := rec.Tokens[0].(token.ReturnToken32bit); !ok || v.ReturnValue != 1 {
+		t.Errorf("unexpected tokens %+v", rec.Tokens)
+	}
+}
+
+func TestDecoderPathNUL(t *testing.T) {
+	ret := []byte{0x27, 0, 0, 0, 0, 0}
+	uncounted := rawRecord(11, 5, append([]byte{0x23, 0, 4, '/', 'e', 't', 'c', 0}, ret...))
+	unterminated := rawRecord(11, 5, append([]byte{0x23, 0, 4, '/', 'e', 't', 'c'}, ret...))
+	counted := rawRecord(11, 5, append([]byte{0x23, 0, 5, '/', 'e', 't', 'c', 0}, ret...))
+
+	decoder := NewDecoder(bytes.NewReader(append(append(uncounted, unterminated...), counted...)))
+	decoder.Dialect = token.DialectLinux
+	decoder.Lenient = true
+	for i := 0; i < 3; i++ {
+		rec, err := decoder.Decode()
+		if err != nil {
+			t.Fatalf("record %d: %v", i, err)
+		}
+		if path, _ := rec.Path(); path != "/etc" || len(rec.Tokens) != 2 {
+			t.Errorf("record %d: unexpected tokens %+v", i, rec.Tokens)
+		}
+		// only the path without NUL is reported
+		if issues := decoder.Issues(); (issues != nil) != (i == 1) {
+			t.Errorf("record %d: unexpected issues %v", i, issues)
+		}
+	}
+
+	// other dialects count the NUL
+	if _, err := NewDecoder(bytes.NewReader(uncounted)).Decode(); err == nil {
+		t.Error("expected error for uncounted NUL")
+	}
+}
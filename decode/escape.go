@@ -0,0 +1,125 @@
+package decode
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/tpltnt/go-bsm/token"
+)
+
+// EscapeString returns s with control bytes and bytes which are not
+// part of valid UTF-8 escaped (like praudit(1) does), i.e. as \n, \r,
+// \t or \xNN. Backslashes are doubled, so the raw bytes can be restored
+// with UnescapeString. Printable text, including non-ASCII characters,
+// is kept.
+func EscapeString(s string) string {
+	if !needsEscaping(s) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case r == utf8.RuneError && size == 1:
+			b.WriteString(`\x`)
+			b.WriteString(strconv.FormatUint(uint64(s[i])|0x100, 16)[1:])
+		case r == '\\':
+			b.WriteString(`\\`)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r < 0x20 || r == 0x7f:
+			b.WriteString(`\x`)
+			b.WriteString(strconv.FormatUint(uint64(r)|0x100, 16)[1:])
+		default:
+			b.WriteString(s[i : i+size])
+		}
+		i += size
+	}
+	return b.String()
+}
+
+// needsEscaping reports whether EscapeString changes s.
+func needsEscaping(s string) bool {
+	if !utf8.ValidString(s) {
+		return true
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < 0x20 || s[i] == 0x7f || s[i] == '\\' {
+			return true
+		}
+	}
+	return false
+}
+
+// UnescapeString reverses EscapeString.
+func UnescapeString(s string) (string, error) {
+	if !strings.Contains(s, `\`) {
+		return s, nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			b.WriteByte(s[i])
+			continue
+		}
+		if i+1 == len(s) {
+			return "", errors.New("trailing backslash")
+		}
+		i += 1
+		switch s[i] {
+		case '\\':
+			b.WriteByte('\\')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		case 'x':
+			if i+2 >= len(s) {
+				return "", errors.New("truncated \\x escape")
+			}
+			v, err := strconv.ParseUint(s[i+1:i+3], 16, 8)
+			if err != nil {
+				return "", err
+			}
+			b.WriteByte(byte(v))
+			i += 2
+		default:
+			return "", errors.New("unknown escape \\" + string(s[i]))
+		}
+	}
+	return b.String(), nil
+}
+
+// ValidUTF8 reports whether all strings of the record are valid UTF-8.
+// Strings are kept as raw bytes when decoding, so invalid ones (e.g.
+// file names in a legacy encoding) are retained as written.
+func (rec *BsmRecord) ValidUTF8() bool {
+	for _, tok := range rec.Tokens {
+		if _, escaped := escapeToken(tok); escaped {
+			return false
+		}
+	}
+	return true
+}
+
+// escapeToken returns the token with its strings escaped (see
+// EscapeString) if any of them isn't valid UTF-8, and whether it did.
+func escapeToken(tok token.Token) (token.Token, bool) {
+	valid := true
+	mapStrings(tok, func(s string) string {
+		valid = valid && utf8.ValidString(s)
+		return s
+	})
+	if valid {
+		return tok, false
+	}
+	return mapStrings(tok, EscapeString), true
+}
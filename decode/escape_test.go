@@ -0,0 +1,70 @@
+package decode
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/tpltnt/go-bsm/token"
+)
+
+func TestEscapeString(t *testing.T) {
+	tests := []struct {
+		raw, escaped string
+	}{
+		{"/etc/passwd", "/etc/passwd"},
+		{"Grüße", "Grüße"},
+		{"a\nb\tc\rd", `a\nb\tc\rd`},
+		{"\x1b[31mred", `\x1b[31mred`},
+		{"caf\xe9", `caf\xe9`},
+		{`C:\dir`, `C:\\dir`},
+		{"\x7f", `\x7f`},
+	}
+	for _, test := range tests {
+		if got := EscapeString(test.raw); got != test.escaped {
+			t.Errorf("EscapeString(%q): got %q, expected %q", test.raw, got, test.escaped)
+		}
+		if got, err := UnescapeString(test.escaped); err != nil || got != test.raw {
+			t.Errorf("UnescapeString(%q): got %q (%v), expected %q", test.escaped, got, err, test.raw)
+		}
+	}
+	for _, s := range []string{`trailing\`, `\x4`, `\q`, `\xzz`} {
+		if _, err := UnescapeString(s); err == nil {
+			t.Errorf("UnescapeString(%q): expected error", s)
+		}
+	}
+}
+
+func TestEscapedJSON(t *testing.T) {
+	rec := BsmRecord{Tokens: []token.Token{
+		token.PathToken{TokenID: 0x23, Path: `\\host\share\caf` + "\xe9"},
+		token.TextToken{TokenID: 0x28, Text: "line\nbreak"},
+	}}
+	if rec.ValidUTF8() {
+		t.Error("invalid UTF-8 not detected")
+	}
+	data, err := json.Marshal(rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out struct {
+		Tokens []map[string]interface{} `json:"tokens"`
+	}
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatal(err)
+	}
+	path := out.Tokens[0]
+	if path["escaped"] != true {
+		t.Errorf("path not marked as escaped: %v", path)
+	}
+	if raw, err := UnescapeString(path["Path"].(string)); err != nil || raw != rec.Tokens[0].(token.PathToken).Path {
+		t.Errorf("raw bytes not retained: %q (%v)", raw, err)
+	}
+	// valid UTF-8 is left to the JSON encoder
+	if _, ok := out.Tokens[1]["escaped"]; ok || out.Tokens[1]["Text"] != "line\nbreak" {
+		t.Errorf("unexpected text token %v", out.Tokens[1])
+	}
+	if strings.Contains(string(data), "\n") {
+		t.Error("raw newline in JSON output")
+	}
+}
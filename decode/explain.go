@@ -0,0 +1,160 @@
+package decode
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"reflect"
+	"strings"
+
+	"github.com/tpltnt/go-bsm/token"
+)
+
+// explainWidth is the number of bytes printed per line of the dump.
+const explainWidth = 8
+
+// fieldRange is the byte range of a single token field.
+type fieldRange struct {
+	name  string
+	value interface{}
+	size  int
+}
+
+// Explain prints the tokens read from r as an annotated hex dump to w.
+// Every line shows the offset and the bytes of a single field, labeled
+// with the token and field name and the decoded value. Tokens whose
+// layout can't be attributed to their fields are dumped as a whole.
+// It stops at the first token which can't be read.
+func Explain(w io.Writer, r io.Reader) error {
+	input := &countingReader{input: r}
+	records := 0
+	for {
+		offset := input.count
+		data, err := readTokenBytes(input, token.DialectUnknown, nil)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("token at offset %d: %w", offset, err)
+		}
+		tok, err := token.Parse(data)
+		if err != nil {
+			fmt.Fprintf(w, "%08x  %s  unparsable token: %v\n", offset, hexBytes(data), err)
+			continue
+		}
+		name := token.Name(tok)
+		switch tok.(type) {
+		case token.HeaderToken32bit, token.HeaderToken64bit,
+			token.ExpandedHeaderToken32bit, token.ExpandedHeaderToken64bit:
+			fmt.Fprintf(w, "# record %d at offset %d\n", records, offset)
+			records += 1
+		}
+
+		fields := tokenFields(tok)
+		total := 0
+		for _, f := range fields {
+			total += f.size
+		}
+		if total != len(data) {
+			explainLine(w, offset, data, fmt.Sprintf("%s (%d bytes, unknown layout)", name, len(data)))
+			continue
+		}
+		for _, f := range fields {
+			label := name + "." + f.name
+			if f.value != nil {
+				label += fmt.Sprintf(" = %v", f.value)
+			}
+			explainLine(w, offset, data[:f.size], label)
+			offset += uint64(f.size)
+			data = data[f.size:]
+		}
+	}
+}
+
+// explainLine prints data starting at offset with the label next to
+// its first line.
+func explainLine(w io.Writer, offset uint64, data []byte, label string) {
+	for len(data) > 0 || label != "" {
+		n := min(len(data), explainWidth)
+		line := fmt.Sprintf("%08x  %-*s  %s", offset, explainWidth*3-1, hexBytes(data[:n]), label)
+		fmt.Fprintln(w, strings.TrimRight(line, " "))
+		offset += uint64(n)
+		data = data[n:]
+		label = ""
+	}
+}
+
+// hexBytes returns data as space separated hex bytes.
+func hexBytes(data []byte) string {
+	parts := make([]string, len(data))
+	for i, b := range data {
+		parts[i] = hex.EncodeToString([]byte{b})
+	}
+	return strings.Join(parts, " ")
+}
+
+// tokenFields returns the fields of the given token along with their
+// size in the binary representation, derived from the field types.
+func tokenFields(tok token.Token) []fieldRange {
+	v := reflect.ValueOf(tok)
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+	var fields []fieldRange
+	var last uint64 // value of the last numeric field, e.g. a length
+	for i := 0; i < v.NumField(); i++ {
+		name := v.Type().Field(i).Name
+		f := v.Field(i)
+		switch value := f.Interface().(type) {
+		case net.IP:
+			size := 16
+			if value.To4() != nil {
+				size = 4
+			}
+			fields = append(fields, fieldRange{name, value, size})
+		case string:
+			size := len(value) + 1 // NUL
+			if value == "" && last == 0 {
+				size = 0
+			}
+			fields = append(fields, fieldRange{name, fmt.Sprintf("%q", value), size})
+		case []string:
+			size := 0
+			for _, s := range value {
+				size += len(s) + 1
+			}
+			fields = append(fields, fieldRange{name, fmt.Sprintf("%q", value), size})
+		case []uint32:
+			fields = append(fields, fieldRange{name, value, 4 * len(value)})
+		case [][]byte:
+			size := 0
+			for _, item := range value {
+				size += len(item)
+			}
+			fields = append(fields, fieldRange{name, nil, size})
+		default:
+			switch f.Kind() {
+			case reflect.Uint8, reflect.Int8:
+				fields = append(fields, fieldRange{name, f.Interface(), 1})
+			case reflect.Uint16, reflect.Int16:
+				fields = append(fields, fieldRange{name, f.Interface(), 2})
+			case reflect.Uint32, reflect.Int32:
+				fields = append(fields, fieldRange{name, f.Interface(), 4})
+			case reflect.Uint64, reflect.Int64:
+				fields = append(fields, fieldRange{name, f.Interface(), 8})
+			default:
+				return nil
+			}
+			if f.CanUint() {
+				last = f.Uint()
+			} else {
+				last = uint64(f.Int())
+			}
+		}
+	}
+	if len(fields) > 0 && fields[0].name == "TokenID" {
+		fields[0].value = fmt.Sprintf("0x%02x", fields[0].value)
+	}
+	return fields
+}
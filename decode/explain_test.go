@@ -0,0 +1,44 @@
+package decode
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestExplain(t *testing.T) {
+	data, err := os.ReadFile("../start_stop.bsm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if err := Explain(&out, bytes.NewReader(data)); err != nil {
+		t.Fatal(err)
+	}
+	dump := out.String()
+	for _, want := range []string{
+		"# record 0 at offset 0\n",
+		"00000000  14                       header32.TokenID = 0x14\n",
+		"header32.RecordByteCount = ",
+		"trailer.TrailerMagic = 45317\n",
+	} {
+		if !strings.Contains(dump, want) {
+			t.Errorf("missing %q in dump:\n%s", want, dump)
+		}
+	}
+	if strings.Contains(dump, "unknown layout") {
+		t.Errorf("unexpected unknown layout:\n%s", dump)
+	}
+}
+
+func TestExplainTruncated(t *testing.T) {
+	data, err := os.ReadFile("../start_stop.bsm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if err := Explain(&out, bytes.NewReader(data[:10])); err == nil {
+		t.Error("expected error for truncated input")
+	}
+}
@@ -0,0 +1,92 @@
+package decode
+
+import (
+	"encoding/base64"
+	"errors"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// TrailIndex holds the references to all records of a trail.
+type TrailIndex struct {
+	Refs []RecordRef // ordered by offset
+}
+
+// NewTrailIndex scans the headers of all records of the trail (see
+// ScanHeaders).
+func NewTrailIndex(input io.ReaderAt) (*TrailIndex, error) {
+	refs, err := ScanHeaders(input)
+	if err != nil {
+		return nil, err
+	}
+	return &TrailIndex{Refs: refs}, nil
+}
+
+// Cursor is an opaque position within an indexed trail, e.g. to be
+// handed out as page token by a REST API. The empty cursor denotes the
+// beginning of the trail.
+type Cursor string
+
+const cursorPrefix = "o:"
+
+// cursorAt returns the cursor of the record at the given offset.
+func cursorAt(offset int64) Cursor {
+	return Cursor(base64.RawURLEncoding.EncodeToString([]byte(cursorPrefix + strconv.FormatInt(offset, 10))))
+}
+
+// offset decodes the trail offset of the cursor.
+func (c Cursor) offset() (int64, error) {
+	if c == "" {
+		return 0, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(string(c))
+	if err != nil || !strings.HasPrefix(string(raw), cursorPrefix) {
+		return 0, errors.New("invalid cursor")
+	}
+	offset, err := strconv.ParseInt(string(raw[len(cursorPrefix):]), 10, 64)
+	if err != nil || offset < 0 {
+		return 0, errors.New("invalid cursor")
+	}
+	return offset, nil
+}
+
+// ExportChunk loads up to limit records starting at the cursor. It
+// returns the cursor of the next chunk, which is empty if the end of
+// the trail was reached. As cursors refer to trail offsets they stay
+// valid when the index is rebuilt after the trail grew.
+func ExportChunk(index *TrailIndex, cursor Cursor, limit int) ([]BsmRecord, Cursor, error) {
+	offset, err := cursor.offset()
+	if err != nil {
+		return nil, "", err
+	}
+	if limit < 1 {
+		return nil, "", errors.New("limit must be positive")
+	}
+	start := sort.Search(len(index.Refs), func(i int) bool {
+		return index.Refs[i].Offset >= offset
+	})
+	if start < len(index.Refs) && index.Refs[start].Offset != offset {
+		return nil, "", errors.New("cursor does not point to a record")
+	}
+
+	end := start + limit
+	if end > len(index.Refs) {
+		end = len(index.Refs)
+	}
+	records := make([]BsmRecord, 0, end-start)
+	for _, ref := range index.Refs[start:end] {
+		rec, err := ref.Load()
+		if err != nil {
+			return nil, "", err
+		}
+		records = append(records, rec)
+	}
+
+	next := Cursor("")
+	if end < len(index.Refs) {
+		next = cursorAt(index.Refs[end].Offset)
+	}
+	return records, next, nil
+}
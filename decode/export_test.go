@@ -0,0 +1,58 @@
+package decode
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+func TestExportChunk(t *testing.T) {
+	data, err := os.ReadFile("../start_stop.bsm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data = append(data, data...) // 4 records
+	index, err := NewTrailIndex(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cursor := Cursor("")
+	events := []uint16{}
+	pages := 0
+	for {
+		records, next, err := ExportChunk(index, cursor, 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pages += 1
+		for _, rec := range records {
+			events = append(events, rec.EventType)
+		}
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+	if pages != 2 || len(events) != 4 {
+		t.Fatal("unexpected pages/records:", pages, len(events))
+	}
+	if events[0] != 45000 || events[3] != 45001 {
+		t.Error("unexpected records:", events)
+	}
+
+	// resume with the cursor of the last page
+	records, next, err := ExportChunk(index, cursor, 10)
+	if err != nil || len(records) != 1 || next != "" {
+		t.Error("resuming failed:", len(records), next, err)
+	}
+
+	for _, cursor := range []Cursor{"garbage!", cursorAt(3), Cursor("bzotMQ")} {
+		if _, _, err := ExportChunk(index, cursor, 1); err == nil {
+			t.Error("expected error for cursor", cursor)
+		}
+	}
+	if _, _, err := ExportChunk(index, "", 0); err == nil {
+		t.Error("expected error for invalid limit")
+	}
+}
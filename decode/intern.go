@@ -0,0 +1,120 @@
+package decode
+
+import "github.com/tpltnt/go-bsm/token"
+
+// Default bounds of an Interner.
+const (
+	DefaultMaxStrings = 1 << 16 // distinct strings held
+	DefaultMaxLength  = 1024    // length of the longest string interned (MAXPATHLEN)
+)
+
+// stringOverhead is the estimated memory used by a string held by an
+// Interner besides its bytes (header and map entry).
+const stringOverhead = 64
+
+// Interner deduplicates strings so identical values (paths, zone
+// names, exec arguments, ...) share the same backing storage. An
+// Interner is not safe for concurrent use, but can be shared by
+// decoders running one after another (e.g. across rotated trails).
+type Interner struct {
+	// MaxStrings bounds the number of distinct strings held. Once it
+	// is reached the Interner is emptied and starts over, so long
+	// running decoders don't accumulate every path ever seen. The
+	// default is DefaultMaxStrings.
+	MaxStrings int
+
+	// MaxLength is the length of the longest string interned, longer
+	// strings are returned as is. The default is DefaultMaxLength.
+	MaxLength int
+
+	Resets uint64 // number of times the Interner was emptied
+
+	strings map[string]string
+}
+
+// NewInterner returns an empty Interner.
+func NewInterner() *Interner {
+	return &Interner{strings: map[string]string{}}
+}
+
+func (in *Interner) maxStrings() int {
+	if in.MaxStrings <= 0 {
+		return DefaultMaxStrings
+	}
+	return in.MaxStrings
+}
+
+func (in *Interner) maxLength() int {
+	if in.MaxLength <= 0 {
+		return DefaultMaxLength
+	}
+	return in.MaxLength
+}
+
+// Intern returns the canonical instance of s.
+func (in *Interner) Intern(s string) string {
+	if canonical, ok := in.strings[s]; ok {
+		return canonical
+	}
+	if len(s) > in.maxLength() {
+		return s
+	}
+	if len(in.strings) >= in.maxStrings() {
+		in.strings = make(map[string]string, len(in.strings))
+		in.Resets += 1
+	}
+	in.strings[s] = s
+	return s
+}
+
+// Len returns the number of distinct strings held by the Interner.
+func (in *Interner) Len() int {
+	return len(in.strings)
+}
+
+// MaxBytes returns an estimate of the memory the Interner holds at
+// most, given its bounds.
+func (in *Interner) MaxBytes() int64 {
+	return int64(in.maxStrings()) * int64(in.maxLength()+stringOverhead)
+}
+
+// internAll interns every string of the slice in place.
+func (in *Interner) internAll(values []string) {
+	for i, s := range values {
+		values[i] = in.Intern(s)
+	}
+}
+
+// internToken returns the token with all its strings interned.
+func (in *Interner) internToken(tok token.Token) token.Token {
+	switch v := tok.(type) {
+	case token.ArgToken32bit:
+		v.Text = in.Intern(v.Text)
+		return v
+	case token.ArgToken64bit:
+		v.Text = in.Intern(v.Text)
+		return v
+	case token.ExecArgsToken:
+		in.internAll(v.Text)
+		return v
+	case token.ExecEnvToken:
+		in.internAll(v.Text)
+		return v
+	case token.FileToken:
+		v.PathName = in.Intern(v.PathName)
+		return v
+	case token.PathToken:
+		v.Path = in.Intern(v.Path)
+		return v
+	case token.PathAttrToken:
+		in.internAll(v.Path)
+		return v
+	case token.TextToken:
+		v.Text = in.Intern(v.Text)
+		return v
+	case token.ZonenameToken:
+		v.Zonename = in.Intern(v.Zonename)
+		return v
+	}
+	return tok
+}
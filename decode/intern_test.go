@@ -0,0 +1,80 @@
+package decode
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"testing"
+	"unsafe"
+
+	"github.com/tpltnt/go-bsm/token"
+)
+
+func TestInterner(t *testing.T) {
+	in := NewInterner()
+	a := in.Intern(string([]byte("/usr/bin/true")))
+	b := in.Intern(string([]byte("/usr/bin/true")))
+	if unsafe.StringData(a) != unsafe.StringData(b) {
+		t.Error("strings do not share storage")
+	}
+	if in.Len() != 1 {
+		t.Error("unexpected number of strings:", in.Len())
+	}
+
+	tok := in.internToken(token.ExecArgsToken{Count: 2, Text: []string{string([]byte("/usr/bin/true")), "-v"}})
+	args := tok.(token.ExecArgsToken).Text
+	if unsafe.StringData(args[0]) != unsafe.StringData(a) {
+		t.Error("exec args not interned")
+	}
+	if in.Len() != 2 {
+		t.Error("unexpected number of strings:", in.Len())
+	}
+}
+
+func TestDecoderInterner(t *testing.T) {
+	data, err := os.ReadFile("../start_stop.bsm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoder := NewDecoder(bytes.NewReader(append(data, data...)))
+	decoder.Interner = NewInterner()
+	texts := []string{}
+	for {
+		rec, err := decoder.Decode()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		texts = append(texts, rec.Tokens[0].(token.TextToken).Text)
+	}
+	if len(texts) != 4 {
+		t.Fatal("unexpected number of records:", len(texts))
+	}
+	if texts[0] != texts[2] || unsafe.StringData(texts[0]) != unsafe.StringData(texts[2]) {
+		t.Error("text not interned")
+	}
+	if decoder.Interner.Len() != 2 {
+		t.Error("unexpected number of strings:", decoder.Interner.Len())
+	}
+}
+
+func TestInternerBounds(t *testing.T) {
+	in := NewInterner()
+	in.MaxStrings = 2
+	in.MaxLength = 4
+	for _, s := range []string{"a", "b", "a", "c"} {
+		in.Intern(s)
+	}
+	if in.Len() != 1 || in.Resets != 1 {
+		t.Errorf("got %d strings after %d resets", in.Len(), in.Resets)
+	}
+	in.Intern("too long")
+	if in.Len() != 1 {
+		t.Error("long string interned")
+	}
+	if in.MaxBytes() != 2*(4+stringOverhead) {
+		t.Error("unexpected memory bound:", in.MaxBytes())
+	}
+}
@@ -0,0 +1,67 @@
+package decode
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/tpltnt/go-bsm/token"
+)
+
+// SchemaVersion is the version of the JSON representation of records
+// written by this package. It is embedded in every serialized record,
+// the matching JSON schema is provided by the schema package.
+const SchemaVersion = 3
+
+// jsonRecord is the JSON representation of a BsmRecord.
+type jsonRecord struct {
+	SchemaVersion int                  `json:"schema_version"`
+	Time          string               `json:"time"`
+	Seconds       uint64               `json:"seconds"`
+	NanoSeconds   uint64               `json:"nanoseconds"`
+	Version       byte                 `json:"version"`
+	EventType     uint16               `json:"event_type"`
+	EventModifier uint16               `json:"event_modifier"`
+	ByteCount     uint32               `json:"byte_count"`
+	Tokens        []json.RawMessage    `json:"tokens"`
+	Privilege     *PrivilegeTransition `json:"privilege,omitempty"`
+	Annotations   map[string]string    `json:"annotations,omitempty"`
+	Truncated     bool                 `json:"truncated,omitempty"`
+}
+
+// marshalToken serializes a token as JSON object with its type name
+// in the "type" field followed by the fields of the token. Tokens with
+// strings which aren't valid UTF-8 (and would be mangled by JSON) have
+// all their strings escaped (see EscapeString) and "escaped" set.
+func (p JSONPolicy) marshalToken(tok interface{}) (json.RawMessage, error) {
+	name := token.Name(tok)
+	if name == "" {
+		return nil, fmt.Errorf("can't serialize unknown token type %T", tok)
+	}
+	tok, escaped := escapeToken(tok)
+	fields, err := p.Marshal(tok)
+	if err != nil {
+		return nil, err
+	}
+	buf := bytes.NewBufferString(`{"type":`)
+	typeName, _ := json.Marshal(name)
+	buf.Write(typeName)
+	if escaped {
+		buf.WriteString(`,"escaped":true`)
+	} else if p.Nulls {
+		buf.WriteString(`,"escaped":false`)
+	}
+	if len(fields) > 2 { // more than "{}"
+		buf.WriteByte(',')
+		buf.Write(fields[1:])
+	} else {
+		buf.WriteByte('}')
+	}
+	return buf.Bytes(), nil
+}
+
+// MarshalJSON serializes the record according to the current
+// SchemaVersion and the DefaultJSONPolicy.
+func (rec BsmRecord) MarshalJSON() ([]byte, error) {
+	return DefaultJSONPolicy.marshalRecord(&rec)
+}
@@ -0,0 +1,84 @@
+package decode
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/tpltnt/go-bsm/schema"
+	"github.com/tpltnt/go-bsm/token"
+)
+
+func TestRecordMarshalJSON(t *testing.T) {
+	if SchemaVersion != schema.Latest {
+		t.Error("schema package is out of sync")
+	}
+
+	file, err := os.Open("../start_stop.bsm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	rec, err := ReadBsmRecord(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := json.Marshal(rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := struct {
+		SchemaVersion int    `json:"schema_version"`
+		Time          string `json:"time"`
+		EventType     uint16 `json:"event_type"`
+		Tokens        []map[string]interface{}
+	}{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.SchemaVersion != SchemaVersion {
+		t.Error("schema version missing")
+	}
+	if out.EventType != 45000 {
+		t.Error("unexpected event type:", out.EventType)
+	}
+	if out.Time != "2018-03-03T15:44:38.000000769Z" {
+		t.Error("unexpected time:", out.Time)
+	}
+	if len(out.Tokens) != 2 || out.Tokens[0]["type"] != "text" || out.Tokens[0]["Text"] != "auditd::Audit startup" {
+		t.Error("unexpected tokens:", out.Tokens)
+	}
+	if out.Tokens[1]["type"] != "return32" {
+		t.Error("unexpected token:", out.Tokens[1])
+	}
+
+	// annotations are preserved
+	rec.Annotate("owner", "alice")
+	rec.Annotate("owner", "bob")
+	if value, ok := rec.Annotation("owner"); !ok || value != "bob" {
+		t.Error("unexpected annotation:", value)
+	}
+	data, err = json.Marshal(rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	annotated := struct {
+		Annotations map[string]string `json:"annotations"`
+	}{}
+	if err := json.Unmarshal(data, &annotated); err != nil {
+		t.Fatal(err)
+	}
+	if annotated.Annotations["owner"] != "bob" {
+		t.Error("annotations not serialized:", string(data))
+	}
+
+	// tokens without fields still carry their type
+	raw, err := DefaultJSONPolicy.marshalToken(token.SeqToken{})
+	if err != nil || string(raw) != `{"type":"seq","TokenID":0,"SequenceNumber":0}` {
+		t.Error("unexpected serialization:", string(raw), err)
+	}
+	if _, err := DefaultJSONPolicy.marshalToken(42); err == nil {
+		t.Error("expected error on unknown token")
+	}
+}
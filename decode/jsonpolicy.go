@@ -0,0 +1,261 @@
+package decode
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// JSONPolicy selects how records (and values derived from them, like
+// the events of the compact package) are serialized as JSON. The keys
+// of objects always come in a fixed order: the order of the fields of
+// the Go types, with the type of a token first, and sorted map keys.
+type JSONPolicy struct {
+	// Nulls writes optional fields which aren't set (e.g. the
+	// privilege transition or annotations of a record) as null or
+	// their zero value instead of omitting them, so all objects of a
+	// kind have the same keys. The schema package allows these nulls.
+	Nulls bool
+
+	// StringInt64 writes 64-bit integers as strings, as many consumers
+	// (JavaScript, Elasticsearch doubles) lose the precision of numbers
+	// beyond 2^53. Such output doesn't validate against the schema
+	// package.
+	StringInt64 bool
+}
+
+// DefaultJSONPolicy is the policy of BsmRecord.MarshalJSON: optional
+// fields are omitted and all integers are numbers.
+var DefaultJSONPolicy = JSONPolicy{}
+
+// rawMessageType is the type of pre-serialized values.
+var rawMessageType = reflect.TypeOf(json.RawMessage(nil))
+
+// Marshal serializes v according to the policy. Records (BsmRecord or
+// *BsmRecord) are serialized like BsmRecord.MarshalJSON, structs field
+// by field honoring their json tags. Values implementing json.Marshaler
+// are serialized by their own method.
+func (p JSONPolicy) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := p.encode(&buf, reflect.ValueOf(v)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// marshalRecord serializes a record according to the policy and the
+// current SchemaVersion.
+func (p JSONPolicy) marshalRecord(rec *BsmRecord) ([]byte, error) {
+	out := jsonRecord{
+		SchemaVersion: SchemaVersion,
+		Time:          rec.Time().UTC().Format(time.RFC3339Nano),
+		Seconds:       rec.Seconds,
+		NanoSeconds:   rec.NanoSeconds,
+		Version:       rec.Version,
+		EventType:     rec.EventType,
+		EventModifier: rec.EventModifier,
+		ByteCount:     rec.ByteCount,
+		Tokens:        make([]json.RawMessage, 0, len(rec.Tokens)),
+		Privilege:     rec.Privilege,
+		Annotations:   rec.Annotations,
+		Truncated:     rec.Truncated,
+	}
+	for _, tok := range rec.Tokens {
+		raw, err := p.marshalToken(tok)
+		if err != nil {
+			return nil, err
+		}
+		out.Tokens = append(out.Tokens, raw)
+	}
+	return p.Marshal(out)
+}
+
+// Interfaces of values which serialize themselves.
+var (
+	marshalerType     = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// encode writes the JSON representation of v to buf. Values read from
+// unexported (embedded) fields can't be passed to encoding/json, so
+// basic kinds are written here.
+func (p JSONPolicy) encode(buf *bytes.Buffer, v reflect.Value) error {
+	if !v.IsValid() {
+		buf.WriteString("null")
+		return nil
+	}
+	if v.CanInterface() {
+		switch rec := v.Interface().(type) {
+		case BsmRecord:
+			return p.write(buf, func() ([]byte, error) { return p.marshalRecord(&rec) })
+		case *BsmRecord:
+			if rec != nil {
+				return p.write(buf, func() ([]byte, error) { return p.marshalRecord(rec) })
+			}
+		}
+		if v.Type() == rawMessageType {
+			buf.Write(v.Bytes())
+			return nil
+		}
+		if v.Type().Implements(marshalerType) || v.Type().Implements(textMarshalerType) {
+			return p.write(buf, func() ([]byte, error) { return json.Marshal(v.Interface()) })
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			buf.WriteString("null")
+			return nil
+		}
+		return p.encode(buf, v.Elem())
+	case reflect.Bool:
+		buf.WriteString(strconv.FormatBool(v.Bool()))
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		p.integer(buf, strconv.FormatInt(v.Int(), 10), v.Kind() == reflect.Int64)
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		p.integer(buf, strconv.FormatUint(v.Uint(), 10), v.Kind() == reflect.Uint64)
+		return nil
+	case reflect.String:
+		return p.write(buf, func() ([]byte, error) { return json.Marshal(v.String()) })
+	case reflect.Struct:
+		buf.WriteByte('{')
+		if _, err := p.encodeFields(buf, v, 0); err != nil {
+			return err
+		}
+		buf.WriteByte('}')
+		return nil
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			buf.WriteString("null")
+			return nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 && v.CanInterface() {
+			break // base64 as encoding/json does
+		}
+		buf.WriteByte('[')
+		for i := 0; i < v.Len(); i++ {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := p.encode(buf, v.Index(i)); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+		return nil
+	case reflect.Map:
+		if v.IsNil() {
+			buf.WriteString("null")
+			return nil
+		}
+		if v.Type().Key().Kind() != reflect.String {
+			break
+		}
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+		buf.WriteByte('{')
+		for i, k := range keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			key, _ := json.Marshal(k.String())
+			buf.Write(key)
+			buf.WriteByte(':')
+			if err := p.encode(buf, v.MapIndex(k)); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+		return nil
+	}
+	if !v.CanInterface() {
+		return fmt.Errorf("can't serialize unexported value of type %s", v.Type())
+	}
+	return p.write(buf, func() ([]byte, error) { return json.Marshal(v.Interface()) })
+}
+
+// integer writes the given decimal integer, as string if it is a 64-bit
+// integer and the policy asks for it.
+func (p JSONPolicy) integer(buf *bytes.Buffer, digits string, is64 bool) {
+	if is64 && p.StringInt64 {
+		buf.WriteByte('"')
+		buf.WriteString(digits)
+		buf.WriteByte('"')
+		return
+	}
+	buf.WriteString(digits)
+}
+
+// encodeFields writes the fields of the struct v (including the ones
+// of embedded structs) as members of an object already holding n
+// members. It returns the number of members afterwards.
+func (p JSONPolicy) encodeFields(buf *bytes.Buffer, v reflect.Value, n int) (int, error) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" && options == "" {
+			continue
+		}
+		value := v.Field(i)
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			var err error
+			if n, err = p.encodeFields(buf, value, n); err != nil {
+				return n, err
+			}
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		omitEmpty := strings.Contains(","+options+",", ",omitempty,")
+		if omitEmpty && !p.Nulls && isEmptyValue(value) {
+			continue
+		}
+		if n > 0 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(name)
+		buf.Write(key)
+		buf.WriteByte(':')
+		if err := p.encode(buf, value); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+// isEmptyValue reports whether v is omitted by the omitempty option of
+// encoding/json.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Struct:
+		return false
+	}
+	return v.IsZero()
+}
+
+// write appends the output of marshal to buf.
+func (p JSONPolicy) write(buf *bytes.Buffer, marshal func() ([]byte, error)) error {
+	data, err := marshal()
+	if err != nil {
+		return err
+	}
+	buf.Write(data)
+	return nil
+}
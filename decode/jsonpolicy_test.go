@@ -0,0 +1,143 @@
+package decode
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/tpltnt/go-bsm/schema"
+	"github.com/tpltnt/go-bsm/token"
+)
+
+func TestJSONPolicyDefault(t *testing.T) {
+	data, err := os.ReadFile("../start_stop.bsm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ForEachRecord(bytes.NewReader(data), func(rec *BsmRecord) error {
+		for _, tok := range rec.Tokens {
+			expected, err := json.Marshal(tok)
+			if err != nil {
+				return err
+			}
+			got, err := DefaultJSONPolicy.Marshal(tok)
+			if err != nil {
+				return err
+			}
+			if !bytes.Equal(got, expected) {
+				t.Errorf("%T: got %s, expected %s", tok, got, expected)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestJSONPolicy(t *testing.T) {
+	rec := BsmRecord{Seconds: 1 << 60, EventType: 23,
+		Tokens: []token.Token{token.ArgToken64bit{ArgumentID: 1, ArgumentValue: 1<<63 + 1, Text: "flags"}}}
+
+	data, err := JSONPolicy{Nulls: true, StringInt64: true}.Marshal(&rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, part := range []string{
+		`"seconds":"1152921504606846976"`,
+		`"event_type":23,`,
+		`"ArgumentValue":"9223372036854775809"`,
+		`"escaped":false`,
+		`"privilege":null,"annotations":null,"truncated":false}`,
+	} {
+		if !strings.Contains(string(data), part) {
+			t.Errorf("%s not in %s", part, data)
+		}
+	}
+
+	data, err = DefaultJSONPolicy.Marshal(rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, _ := json.Marshal(rec)
+	if !bytes.Equal(data, expected) || strings.Contains(string(data), "privilege") {
+		t.Errorf("unexpected default serialization %s", data)
+	}
+}
+
+func TestJSONPolicyNullsSchema(t *testing.T) {
+	data, err := schema.JSONSchema(SchemaVersion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := struct {
+		Required   []string
+		Properties map[string]struct {
+			Type  interface{}
+			Const interface{}
+		}
+	}{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := BsmRecord{Seconds: 1, EventType: 23, Tokens: []token.Token{token.TextToken{TokenID: 0x28, Text: "a"}}}
+	data, err = JSONPolicy{Nulls: true}.Marshal(&rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := map[string]interface{}{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range doc.Required {
+		if _, ok := out[key]; !ok {
+			t.Errorf("required %s missing", key)
+		}
+	}
+	// the top-level values have one of the types of the schema
+	for key, value := range out {
+		property, ok := doc.Properties[key]
+		if !ok {
+			t.Errorf("%s not in schema", key)
+			continue
+		}
+		if property.Const != nil {
+			if value != property.Const {
+				t.Errorf("%s: got %v, expected %v", key, value, property.Const)
+			}
+			continue
+		}
+		types, ok := property.Type.([]interface{})
+		if !ok {
+			types = []interface{}{property.Type}
+		}
+		valid := false
+		for _, typ := range types {
+			valid = valid || jsonSchemaType(value, typ.(string))
+		}
+		if !valid {
+			t.Errorf("%s: %v is not of type %v", key, value, property.Type)
+		}
+	}
+}
+
+// jsonSchemaType reports whether the unmarshaled JSON value v is of the
+// given JSON schema type.
+func jsonSchemaType(v interface{}, typ string) bool {
+	switch v := v.(type) {
+	case nil:
+		return typ == "null"
+	case bool:
+		return typ == "boolean"
+	case float64:
+		return typ == "number" || typ == "integer" && v == float64(int64(v))
+	case string:
+		return typ == "string"
+	case []interface{}:
+		return typ == "array"
+	}
+	return typ == "object"
+}
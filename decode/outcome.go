@@ -0,0 +1,86 @@
+package decode
+
+import (
+	"strconv"
+
+	"github.com/tpltnt/go-bsm/token"
+)
+
+// OutcomeStatus tells whether the audited operation succeeded.
+type OutcomeStatus byte
+
+// Statuses of an Outcome.
+const (
+	OutcomeUnknown OutcomeStatus = iota // the record doesn't tell
+	OutcomeSuccess
+	OutcomeFailure
+)
+
+// Outcome is the normalized result of the operation of a record.
+type Outcome struct {
+	Status OutcomeStatus
+	Errno  uint32 // BSM error number of a failure, 0 if unknown
+}
+
+// Success reports whether the operation succeeded.
+func (o Outcome) Success() bool {
+	return o.Status == OutcomeSuccess
+}
+
+// Failure returns the error number of a failed operation, false if the
+// operation didn't fail or the outcome is unknown.
+func (o Outcome) Failure() (errno uint32, ok bool) {
+	return o.Errno, o.Status == OutcomeFailure
+}
+
+// String returns "success", "failure", "failure (errno N)" or
+// "unknown".
+func (o Outcome) String() string {
+	switch o.Status {
+	case OutcomeSuccess:
+		return "success"
+	case OutcomeFailure:
+		if o.Errno != 0 {
+			return "failure (errno " + strconv.FormatUint(uint64(o.Errno), 10) + ")"
+		}
+		return "failure"
+	}
+	return "unknown"
+}
+
+// Outcome returns whether the audited operation succeeded. It is taken
+// from the error number of the return token, or of the exit token of
+// records without one. The exit status of exit(2) (AUE_EXIT) is no
+// failure, as the call can't fail. Records without either token are
+// failed if the header flags them (see token.EventModifierFailure),
+// otherwise the outcome is unknown, e.g. for text only records.
+func (rec *BsmRecord) Outcome() Outcome {
+	exit, hasExit := token.ExitToken{}, false
+	for _, tok := range rec.Tokens {
+		switch v := tok.(type) {
+		case token.ReturnToken32bit:
+			return rec.outcome(uint32(v.ErrorNumber))
+		case token.ReturnToken64bit:
+			return rec.outcome(uint32(v.ErrorNumber))
+		case token.ExitToken:
+			if !hasExit {
+				exit, hasExit = v, true
+			}
+		}
+	}
+	if hasExit {
+		return rec.outcome(exit.Status)
+	}
+	if rec.EventModifier&token.EventModifierFailure != 0 {
+		return Outcome{Status: OutcomeFailure}
+	}
+	return Outcome{}
+}
+
+// outcome applies the special cases of the event to an error number.
+func (rec *BsmRecord) outcome(errno uint32) Outcome {
+	if errno == 0 || rec.Event() == token.AUE_EXIT {
+		return Outcome{Status: OutcomeSuccess}
+	}
+	return Outcome{Status: OutcomeFailure, Errno: errno}
+}
@@ -0,0 +1,48 @@
+package decode
+
+import (
+	"testing"
+
+	"github.com/tpltnt/go-bsm/token"
+)
+
+func TestOutcome(t *testing.T) {
+	for _, test := range []struct {
+		rec  BsmRecord
+		want Outcome
+	}{
+		{BsmRecord{EventType: 23, Tokens: []token.Token{token.ReturnToken32bit{}}}, Outcome{Status: OutcomeSuccess}},
+		{BsmRecord{EventType: 72, Tokens: []token.Token{token.ReturnToken64bit{ErrorNumber: 2, ReturnValue: ^uint64(0)}}}, Outcome{OutcomeFailure, 2}},
+		// the return token wins over the exit token
+		{BsmRecord{Tokens: []token.Token{token.ExitToken{Status: 1}, token.ReturnToken32bit{}}}, Outcome{Status: OutcomeSuccess}},
+		{BsmRecord{EventType: 6152, Tokens: []token.Token{token.ExitToken{Status: 13}}}, Outcome{OutcomeFailure, 13}},
+		// the exit token of exit(2) holds the exit status
+		{BsmRecord{EventType: uint16(token.AUE_EXIT), Tokens: []token.Token{token.ExitToken{Status: 1}}}, Outcome{Status: OutcomeSuccess}},
+		{BsmRecord{EventModifier: token.EventModifierFailure, Tokens: []token.Token{token.TextToken{}}}, Outcome{Status: OutcomeFailure}},
+		{BsmRecord{Tokens: []token.Token{token.TextToken{}}}, Outcome{}},
+	} {
+		if got := test.rec.Outcome(); got != test.want {
+			t.Errorf("%v: got %v, expected %v", test.rec.Tokens, got, test.want)
+		}
+		if test.rec.Failed() != (test.want.Status == OutcomeFailure) {
+			t.Errorf("%v: Failed disagrees with Outcome", test.rec.Tokens)
+		}
+	}
+
+	if errno, ok := (Outcome{OutcomeFailure, 13}).Failure(); !ok || errno != 13 {
+		t.Error("unexpected failure", errno)
+	}
+	for _, test := range []struct {
+		outcome Outcome
+		want    string
+	}{
+		{Outcome{Status: OutcomeSuccess}, "success"},
+		{Outcome{OutcomeFailure, 13}, "failure (errno 13)"},
+		{Outcome{Status: OutcomeFailure}, "failure"},
+		{Outcome{}, "unknown"},
+	} {
+		if got := test.outcome.String(); got != test.want {
+			t.Errorf("got %q, expected %q", got, test.want)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package decode
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/tpltnt/go-bsm/token"
+)
+
+// TokenProfile holds the statistics of a token type within a trail.
+type TokenProfile struct {
+	ID      byte
+	Name    string // see token.Name, empty if no token of the type could be parsed
+	Count   uint64 // number of tokens
+	Bytes   uint64 // total size of the tokens
+	MinSize int
+	MaxSize int
+}
+
+// TrailProfile holds the token statistics of a trail.
+type TrailProfile struct {
+	Bytes  uint64         // size of the trail
+	Tokens []TokenProfile // ordered by token ID
+}
+
+// Profile reads all tokens of r and returns their number and sizes by
+// token type, e.g. to tune buffer sizes or to spot unusual token mixes.
+func Profile(r io.Reader) (TrailProfile, error) {
+	var profile TrailProfile
+	input := &countingReader{input: r}
+	profiles := map[byte]*TokenProfile{}
+	for {
+		offset := input.count
+		data, err := readTokenBytes(input, token.DialectUnknown, nil)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return profile, fmt.Errorf("token at offset %d: %w", offset, err)
+		}
+		p, ok := profiles[data[0]]
+		if !ok {
+			p = &TokenProfile{ID: data[0], MinSize: len(data)}
+			profiles[data[0]] = p
+		}
+		if p.Name == "" {
+			if tok, err := token.Parse(data); err == nil {
+				p.Name = token.Name(tok)
+			}
+		}
+		p.Count += 1
+		p.Bytes += uint64(len(data))
+		p.MinSize = min(p.MinSize, len(data))
+		p.MaxSize = max(p.MaxSize, len(data))
+	}
+	profile.Bytes = input.count
+	for _, p := range profiles {
+		profile.Tokens = append(profile.Tokens, *p)
+	}
+	sort.Slice(profile.Tokens, func(i, j int) bool { return profile.Tokens[i].ID < profile.Tokens[j].ID })
+	return profile, nil
+}
@@ -0,0 +1,40 @@
+package decode
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestProfile(t *testing.T) {
+	text := func(s string) []byte {
+		return append([]byte{0x28, 0, byte(len(s) + 1)}, append([]byte(s), 0)...)
+	}
+	var trail []byte
+	trail = append(trail, rawRecord(11, 1, text("a"))...)
+	trail = append(trail, rawRecord(11, 1, text("abc"), text("abcdef"))...)
+
+	profile, err := Profile(bytes.NewReader(trail))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profile.Bytes != uint64(len(trail)) {
+		t.Errorf("got %d bytes, expected %d", profile.Bytes, len(trail))
+	}
+	expected := []TokenProfile{
+		{ID: 0x13, Name: "trailer", Count: 2, Bytes: 14, MinSize: 7, MaxSize: 7},
+		{ID: 0x14, Name: "header32", Count: 2, Bytes: 36, MinSize: 18, MaxSize: 18},
+		{ID: 0x28, Name: "text", Count: 3, Bytes: 22, MinSize: 5, MaxSize: 10},
+	}
+	if len(profile.Tokens) != len(expected) {
+		t.Fatalf("got %d token types, expected %d", len(profile.Tokens), len(expected))
+	}
+	for i := range expected {
+		if profile.Tokens[i] != expected[i] {
+			t.Errorf("got %+v, expected %+v", profile.Tokens[i], expected[i])
+		}
+	}
+
+	if _, err := Profile(bytes.NewReader([]byte{0xff})); err == nil {
+		t.Error("expected error for invalid token")
+	}
+}
@@ -0,0 +1,217 @@
+package decode
+
+import (
+	"net"
+	"net/netip"
+	"time"
+
+	"github.com/tpltnt/go-bsm/token"
+)
+
+// DefaultAuditID is the audit user ID of processes which were never
+// associated with a login session (AU_DEFAUDITID).
+const DefaultAuditID = 0xffffffff
+
+// PrivilegeTransition describes a record whose subject acts with an
+// effective user ID other than its audit user ID, e.g. after su(1),
+// sudo(8) or the execution of a setuid binary (see
+// bsm.FlagPrivilegeTransitions).
+type PrivilegeTransition struct {
+	AuditID         uint32 // audit user ID (the user who logged in)
+	EffectiveUserID uint32 // effective user ID
+	OriginalUser    string // name of the audit user (empty if unresolvable)
+}
+
+// Subject is a normalized view on the different 'subject' token
+// variants (32/64 bit, expanded or not) of a record.
+type Subject struct {
+	AuditID                uint32     // audit user ID
+	EffectiveUserID        uint32     // effective user ID
+	EffectiveGroupID       uint32     // effective group ID
+	RealUserID             uint32     // real user ID
+	RealGroupID            uint32     // real group ID
+	ProcessID              uint32     // process ID
+	SessionID              uint32     // audit session ID
+	TerminalPortID         uint64     // terminal port ID
+	TerminalMachineAddress net.IP     // IP address of machine
+	TerminalAddr           netip.Addr // same as above, invalid if unknown
+}
+
+// Subject returns the first subject token of the record. The
+// boolean is false if the record does not contain a subject token.
+func (rec *BsmRecord) Subject() (Subject, bool) {
+	for _, tok := range rec.Tokens {
+		switch v := tok.(type) {
+		case token.SubjectToken32bit:
+			return Subject{
+				AuditID:                v.AuditID,
+				EffectiveUserID:        v.EffectiveUserID,
+				EffectiveGroupID:       v.EffectiveGroupID,
+				RealUserID:             v.RealUserID,
+				RealGroupID:            v.RealGroupID,
+				ProcessID:              v.ProcessID,
+				SessionID:              v.SessionID,
+				TerminalPortID:         uint64(v.TerminalPortID),
+				TerminalMachineAddress: v.TerminalMachineAddress,
+				TerminalAddr:           v.TerminalAddr(),
+			}, true
+		case token.SubjectToken64bit:
+			return Subject{
+				AuditID:                v.AuditID,
+				EffectiveUserID:        v.EffectiveUserID,
+				EffectiveGroupID:       v.EffectiveGroupID,
+				RealUserID:             v.RealUserID,
+				RealGroupID:            v.RealGroupID,
+				ProcessID:              v.ProcessID,
+				SessionID:              v.SessionID,
+				TerminalPortID:         v.TerminalPortID,
+				TerminalMachineAddress: v.TerminalMachineAddress,
+				TerminalAddr:           v.TerminalAddr(),
+			}, true
+		case token.ExpandedSubjectToken32bit:
+			return Subject{
+				AuditID:                v.AuditID,
+				EffectiveUserID:        v.EffectiveUserID,
+				EffectiveGroupID:       v.EffectiveGroupID,
+				RealUserID:             v.RealUserID,
+				RealGroupID:            v.RealGroupID,
+				ProcessID:              v.ProcessID,
+				SessionID:              v.SessionID,
+				TerminalPortID:         uint64(v.TerminalPortID),
+				TerminalMachineAddress: v.TerminalMachineAddress,
+				TerminalAddr:           v.TerminalAddr(),
+			}, true
+		case token.ExpandedSubjectToken64bit:
+			return Subject{
+				AuditID:                v.AuditID,
+				EffectiveUserID:        v.EffectiveUserID,
+				EffectiveGroupID:       v.EffectiveGroupID,
+				RealUserID:             v.RealUserID,
+				RealGroupID:            v.RealGroupID,
+				ProcessID:              v.ProcessID,
+				SessionID:              v.SessionID,
+				TerminalPortID:         v.TerminalPortID,
+				TerminalMachineAddress: v.TerminalMachineAddress,
+				TerminalAddr:           v.TerminalAddr(),
+			}, true
+		}
+	}
+	return Subject{}, false
+}
+
+// Zone returns the name of the zone or jail the record originated
+// from. The boolean is false if the record carries no zonename token.
+func (rec *BsmRecord) Zone() (string, bool) {
+	for _, tok := range rec.Tokens {
+		if v, ok := tok.(token.ZonenameToken); ok {
+			return v.Zonename, true
+		}
+	}
+	return "", false
+}
+
+// Time returns the time stamp of the record.
+func (rec *BsmRecord) Time() time.Time {
+	return time.Unix(int64(rec.Seconds), int64(rec.NanoSeconds))
+}
+
+// Event returns the event type of the record.
+func (rec *BsmRecord) Event() token.EventType {
+	return token.EventType(rec.EventType)
+}
+
+// Path returns the first path of the record. The boolean is false if
+// the record does not contain a path token.
+func (rec *BsmRecord) Path() (string, bool) {
+	for _, tok := range rec.Tokens {
+		if v, ok := tok.(token.PathToken); ok {
+			return v.Path, true
+		}
+	}
+	return "", false
+}
+
+// Failed reports whether the audited operation failed (see Outcome).
+func (rec *BsmRecord) Failed() bool {
+	return rec.Outcome().Status == OutcomeFailure
+}
+
+// Annotate attaches a key/value annotation to the record (e.g. by an
+// enrichment transform). An existing value for the key is replaced.
+func (rec *BsmRecord) Annotate(key, value string) {
+	if rec.Annotations == nil {
+		rec.Annotations = map[string]string{}
+	}
+	rec.Annotations[key] = value
+}
+
+// Annotation returns the value of the annotation with the given key.
+// The boolean is false if the record has no such annotation.
+func (rec *BsmRecord) Annotation(key string) (string, bool) {
+	value, ok := rec.Annotations[key]
+	return value, ok
+}
+
+// setHeader copies the fields of the given header token into the
+// record. It returns false if the token is no header token.
+func (rec *BsmRecord) setHeader(header token.Token) bool {
+	switch v := header.(type) {
+	case token.HeaderToken32bit:
+		rec.Version = v.VersionNumber
+		rec.EventType = v.EventType
+		rec.EventModifier = v.EventModifier
+		rec.ByteCount = v.RecordByteCount
+		rec.Seconds = uint64(v.Seconds)
+		rec.NanoSeconds = uint64(v.NanoSeconds)
+	case token.HeaderToken64bit:
+		rec.Version = v.VersionNumber
+		rec.EventType = v.EventType
+		rec.EventModifier = v.EventModifier
+		rec.ByteCount = v.RecordByteCount
+		rec.Seconds = v.Seconds
+		rec.NanoSeconds = v.NanoSeconds
+	case token.ExpandedHeaderToken32bit:
+		rec.Version = v.VersionNumber
+		rec.EventType = v.EventType
+		rec.EventModifier = v.EventModifier
+		rec.ByteCount = v.RecordByteCount
+		rec.Seconds = uint64(v.Seconds)
+		rec.NanoSeconds = uint64(v.NanoSeconds)
+	case token.ExpandedHeaderToken64bit:
+		rec.Version = v.VersionNumber
+		rec.EventType = v.EventType
+		rec.EventModifier = v.EventModifier
+		rec.ByteCount = v.RecordByteCount
+		rec.Seconds = v.Seconds
+		rec.NanoSeconds = v.NanoSeconds
+	default:
+		return false
+	}
+	return true
+}
+
+// TerminalDevice returns the device of the terminal of the subject.
+// As Subject does not keep the width of the port ID, values which fit
+// into 32 bit are split as 32 bit device numbers.
+func (s Subject) TerminalDevice(d token.Dialect) token.Device {
+	if s.TerminalPortID > 0xffffffff {
+		return d.SplitDevice(s.TerminalPortID, 64)
+	}
+	return d.SplitDevice(s.TerminalPortID, 32)
+}
+
+// FileInfo returns the attributes of the first file of the record,
+// named after the first path token. The boolean is false if the record
+// carries no attribute token.
+func (rec *BsmRecord) FileInfo() (*token.AttributeFileInfo, bool) {
+	name, _ := rec.Path()
+	for _, tok := range rec.Tokens {
+		switch v := tok.(type) {
+		case token.AttributeToken32bit:
+			return v.FileInfo(name), true
+		case token.AttributeToken64bit:
+			return v.FileInfo(name), true
+		}
+	}
+	return nil, false
+}
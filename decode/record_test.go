@@ -0,0 +1,60 @@
+package decode
+
+import (
+	"io/fs"
+	"net"
+	"net/netip"
+	"testing"
+
+	"github.com/tpltnt/go-bsm/token"
+)
+
+func TestSubjectTerminalAddr(t *testing.T) {
+	rec := BsmRecord{Tokens: []token.Token{
+		token.ProcessToken32bit{TokenID: 0x26, TerminalMachineAddress: net.IPv4(10, 0, 0, 1)},
+		token.SubjectToken64bit{TokenID: 0x75, TerminalMachineAddress: net.ParseIP("::ffff:10.0.0.2")},
+	}}
+	subject, ok := rec.Subject()
+	if !ok || subject.TerminalAddr != netip.MustParseAddr("10.0.0.2") {
+		t.Error("unexpected subject address:", subject.TerminalAddr)
+	}
+	if addr := rec.Tokens[0].(token.ProcessToken32bit).TerminalAddr(); !addr.Is4() {
+		t.Error("unexpected process address:", addr)
+	}
+}
+
+func TestSubjectTerminalDevice(t *testing.T) {
+	subject := Subject{TerminalPortID: 24<<32 | 5}
+	if dev := subject.TerminalDevice(token.DialectSolaris); dev != (token.Device{Major: 24, Minor: 5}) {
+		t.Error("unexpected terminal device:", dev)
+	}
+}
+
+func TestRecordFileInfo(t *testing.T) {
+	attr := token.AttributeToken64bit{TokenID: 0x73, FileAccessMode: 040755, OwnerUserID: 501, FileSystemNodeID: 42}
+	rec := BsmRecord{Tokens: []token.Token{
+		token.PathToken{TokenID: 0x23, Path: "/Users/alice"},
+		attr,
+	}}
+	var info fs.FileInfo
+	info, ok := rec.FileInfo()
+	if !ok {
+		t.Fatal("no file info")
+	}
+	if info.Name() != "alice" || !info.IsDir() || info.Mode().Perm() != 0755 || info.Size() != 0 || !info.ModTime().IsZero() {
+		t.Error("unexpected file info:", info)
+	}
+	if info.Sys().(token.AttributeToken64bit) != attr {
+		t.Error("unexpected token")
+	}
+	if fi := info.(*token.AttributeFileInfo); fi.UserID != 501 || fi.Inode != 42 {
+		t.Error("unexpected owner or inode")
+	}
+
+	if _, ok := (&BsmRecord{}).FileInfo(); ok {
+		t.Error("unexpected file info")
+	}
+	if name := (token.AttributeToken32bit{}).FileInfo("").Name(); name != "" {
+		t.Error("unexpected name:", name)
+	}
+}
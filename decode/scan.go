@@ -0,0 +1,76 @@
+package decode
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"github.com/tpltnt/go-bsm/token"
+)
+
+// RecordRef is a lightweight reference to a record of a trail. It only
+// holds the header fields, the complete record is parsed by Load.
+type RecordRef struct {
+	Offset        int64     // position of the header token in the trail
+	Length        uint32    // size of the record in bytes (from header)
+	Time          time.Time // record time stamp
+	EventType     uint16    // event type
+	EventModifier uint16    // event sub-type
+
+	input io.ReaderAt
+}
+
+// Load reads and parses the referenced record.
+func (ref RecordRef) Load() (BsmRecord, error) {
+	if ref.input == nil {
+		return BsmRecord{}, errors.New("record reference without input")
+	}
+	return NewDecoder(io.NewSectionReader(ref.input, ref.Offset, int64(ref.Length))).Decode()
+}
+
+// ScanHeaders reads only the header tokens of all records in the trail
+// and skips the remaining tokens using the byte count of the header.
+// This is much faster than parsing all records, e.g. to show a timeline
+// of a huge trail and Load the records on demand. File tokens between
+// records are skipped. On error, the references found so far are
+// returned with the error.
+func ScanHeaders(input io.ReaderAt) ([]RecordRef, error) {
+	refs := []RecordRef{}
+	offset := int64(0)
+	for {
+		buf, err := readTokenBytes(io.NewSectionReader(input, offset, math.MaxInt64-offset), token.DialectUnknown, nil)
+		if err == io.EOF {
+			return refs, nil
+		}
+		if err != nil {
+			return refs, err
+		}
+		tok, err := token.Parse(buf)
+		if err != nil {
+			return refs, err
+		}
+		if _, ok := tok.(token.FileToken); ok {
+			offset += int64(len(buf))
+			continue
+		}
+
+		rec := BsmRecord{}
+		if !rec.setHeader(tok) {
+			return refs, fmt.Errorf("no header token found at offset %d", offset)
+		}
+		if rec.ByteCount < uint32(len(buf)) {
+			return refs, fmt.Errorf("invalid record size %d at offset %d", rec.ByteCount, offset)
+		}
+		refs = append(refs, RecordRef{
+			Offset:        offset,
+			Length:        rec.ByteCount,
+			Time:          rec.Time(),
+			EventType:     rec.EventType,
+			EventModifier: rec.EventModifier,
+			input:         input,
+		})
+		offset += int64(rec.ByteCount)
+	}
+}
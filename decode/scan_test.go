@@ -0,0 +1,49 @@
+package decode
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/tpltnt/go-bsm/token"
+)
+
+func TestScanHeaders(t *testing.T) {
+	data, err := os.ReadFile("../start_stop.bsm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	refs, err := ScanHeaders(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(refs) != 2 {
+		t.Fatal("unexpected number of records:", len(refs))
+	}
+	if refs[0].Offset != 0 || refs[0].Length != 56 || refs[1].Offset != 56 {
+		t.Error("unexpected offsets:", refs)
+	}
+	if refs[0].EventType != 45000 || refs[1].EventType != 45001 {
+		t.Error("unexpected event types")
+	}
+	if refs[0].Time.Unix() != 1520091878 {
+		t.Error("unexpected time:", refs[0].Time)
+	}
+
+	rec, err := refs[1].Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.EventType != 45001 || rec.Tokens[0].(token.TextToken).Text != "auditd::Audit shutdown" {
+		t.Error("unexpected record:", rec)
+	}
+
+	// truncated trail
+	refs, err = ScanHeaders(bytes.NewReader(data[:60]))
+	if err == nil || len(refs) != 1 {
+		t.Error("expected error and one record, got", len(refs))
+	}
+	if _, err := (RecordRef{}).Load(); err == nil {
+		t.Error("expected error without input")
+	}
+}
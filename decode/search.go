@@ -0,0 +1,198 @@
+package decode
+
+import (
+	"regexp"
+	"regexp/syntax"
+	"strings"
+
+	"github.com/tpltnt/go-bsm/token"
+)
+
+// Texts returns the contents of the text-bearing tokens of the record:
+// text, path, exec args, exec env and zonename tokens.
+func (rec *BsmRecord) Texts() []string {
+	var texts []string
+	for _, tok := range rec.Tokens {
+		switch v := tok.(type) {
+		case token.TextToken:
+			texts = append(texts, v.Text)
+		case token.PathToken:
+			texts = append(texts, v.Path)
+		case token.ExecArgsToken:
+			texts = append(texts, v.Text...)
+		case token.ExecEnvToken:
+			texts = append(texts, v.Text...)
+		case token.ZonenameToken:
+			texts = append(texts, v.Zonename)
+		}
+	}
+	return texts
+}
+
+// matcher matches a regular expression, skipping texts which lack the
+// literal every match must contain.
+type matcher struct {
+	re      *regexp.Regexp
+	literal string
+}
+
+func compileMatcher(pattern string) (*matcher, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	m := &matcher{re: re}
+	if parsed, err := syntax.Parse(pattern, syntax.Perl); err == nil {
+		m.literal = requiredLiteral(parsed.Simplify())
+	}
+	return m, nil
+}
+
+// requiredLiteral returns the longest case sensitive literal which any
+// match of re contains (empty if unknown).
+func requiredLiteral(re *syntax.Regexp) string {
+	switch re.Op {
+	case syntax.OpLiteral:
+		if re.Flags&syntax.FoldCase == 0 {
+			return string(re.Rune)
+		}
+	case syntax.OpCapture, syntax.OpPlus:
+		return requiredLiteral(re.Sub[0])
+	case syntax.OpConcat:
+		longest := ""
+		for _, sub := range re.Sub {
+			if literal := requiredLiteral(sub); len(literal) > len(longest) {
+				longest = literal
+			}
+		}
+		return longest
+	}
+	return ""
+}
+
+func (m *matcher) matches(rec *BsmRecord) bool {
+	for _, text := range rec.Texts() {
+		if !strings.Contains(text, m.literal) {
+			continue
+		}
+		if m.re.MatchString(text) {
+			return true
+		}
+	}
+	return false
+}
+
+// Search returns the indices of the records whose text-bearing tokens
+// (see BsmRecord.Texts) match the regular expression.
+func Search(records []BsmRecord, pattern string) ([]int, error) {
+	m, err := compileMatcher(pattern)
+	if err != nil {
+		return nil, err
+	}
+	var result []int
+	for i := range records {
+		if m.matches(&records[i]) {
+			result = append(result, i)
+		}
+	}
+	return result, nil
+}
+
+// Contains returns the indices of the records whose text-bearing tokens
+// contain substr.
+func Contains(records []BsmRecord, substr string) []int {
+	result, _ := Search(records, regexp.QuoteMeta(substr))
+	return result
+}
+
+// TextIndex is a trigram index of the text-bearing tokens of an indexed
+// trail. It narrows a search down to the records which contain all
+// trigrams of the literal part of the pattern.
+type TextIndex struct {
+	index *TrailIndex
+	grams map[string][]int // trigram -> ascending indices into index.Refs
+}
+
+// NewTextIndex loads all records of the trail once to build the index.
+func NewTextIndex(index *TrailIndex) (*TextIndex, error) {
+	ti := &TextIndex{index: index, grams: map[string][]int{}}
+	for i, ref := range index.Refs {
+		rec, err := ref.Load()
+		if err != nil {
+			return nil, err
+		}
+		seen := map[string]bool{}
+		for _, text := range rec.Texts() {
+			for j := 0; j+3 <= len(text); j++ {
+				gram := text[j : j+3]
+				if !seen[gram] {
+					seen[gram] = true
+					ti.grams[gram] = append(ti.grams[gram], i)
+				}
+			}
+		}
+	}
+	return ti, nil
+}
+
+// candidates returns the indices of the records which may contain
+// literal, or nil if all records have to be considered.
+func (ti *TextIndex) candidates(literal string) []int {
+	if len(literal) < 3 {
+		return nil
+	}
+	result := ti.grams[literal[:3]]
+	for j := 1; j+3 <= len(literal) && len(result) > 0; j++ {
+		result = intersect(result, ti.grams[literal[j:j+3]])
+	}
+	if result == nil {
+		result = []int{}
+	}
+	return result
+}
+
+// intersect returns the common elements of two ascending lists.
+func intersect(a, b []int) []int {
+	var result []int
+	for len(a) > 0 && len(b) > 0 {
+		switch {
+		case a[0] < b[0]:
+			a = a[1:]
+		case a[0] > b[0]:
+			b = b[1:]
+		default:
+			result = append(result, a[0])
+			a, b = a[1:], b[1:]
+		}
+	}
+	return result
+}
+
+// Search returns the references of the records whose text-bearing
+// tokens match the regular expression. Only records containing the
+// literal part of the pattern are loaded and matched.
+func (ti *TextIndex) Search(pattern string) ([]RecordRef, error) {
+	m, err := compileMatcher(pattern)
+	if err != nil {
+		return nil, err
+	}
+	candidates := ti.candidates(m.literal)
+	if candidates == nil {
+		candidates = make([]int, len(ti.index.Refs))
+		for i := range candidates {
+			candidates[i] = i
+		}
+	}
+	var result []RecordRef
+	for _, i := range candidates {
+		ref := ti.index.Refs[i]
+		rec, err := ref.Load()
+		if err != nil {
+			return result, err
+		}
+		if m.matches(&rec) {
+			result = append(result, ref)
+		}
+	}
+	return result, nil
+}
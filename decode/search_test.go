@@ -0,0 +1,70 @@
+package decode
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/tpltnt/go-bsm/token"
+)
+
+func TestSearch(t *testing.T) {
+	records := []BsmRecord{
+		{Tokens: []token.Token{token.PathToken{Path: "/etc/passwd"}}},
+		{Tokens: []token.Token{token.ExecArgsToken{Text: []string{"curl", "http://evil.example"}}}},
+		{Tokens: []token.Token{token.ZonenameToken{Zonename: "global"}}},
+	}
+	result, err := Search(records, `evil\.example$`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result) != 1 || result[0] != 1 {
+		t.Error("unexpected result:", result)
+	}
+	if result := Contains(records, "/etc/"); len(result) != 1 || result[0] != 0 {
+		t.Error("unexpected result:", result)
+	}
+	if result, _ := Search(records, "(?i)GLOBAL"); len(result) != 1 || result[0] != 2 {
+		t.Error("unexpected case insensitive result:", result)
+	}
+	if _, err := Search(records, "("); err == nil {
+		t.Error("expected error for invalid pattern")
+	}
+}
+
+func TestRequiredLiteral(t *testing.T) {
+	m, err := compileMatcher(`^/usr/(s?)bin/ssh-agent`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.literal != "bin/ssh-agent" {
+		t.Error("unexpected literal:", m.literal)
+	}
+}
+
+func TestTextIndex(t *testing.T) {
+	data, err := os.ReadFile("../start_stop.bsm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	index, err := NewTrailIndex(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ti, err := NewTextIndex(index)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if refs, err := ti.Search("Audit shut"); err != nil || len(refs) != 1 || refs[0].EventType != 45001 {
+		t.Error("unexpected result:", refs, err)
+	}
+	if refs, err := ti.Search("auditd::"); err != nil || len(refs) != 2 {
+		t.Error("unexpected result:", refs, err)
+	}
+	if refs, err := ti.Search("nothing"); err != nil || len(refs) != 0 {
+		t.Error("unexpected result:", refs, err)
+	}
+	if candidates := ti.candidates("startup"); len(candidates) != 1 {
+		t.Error("unexpected candidates:", candidates)
+	}
+}
@@ -0,0 +1,33 @@
+package decode
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/tpltnt/go-bsm/token"
+)
+
+// ParseTokenSequence parses a sequence of tokens without header and
+// trailer framing, e.g. BSM tokens extracted from an Endpoint Security
+// event or another envelope. Header, trailer and file tokens are
+// returned like any other token.
+func ParseTokenSequence(data []byte) ([]token.Token, error) {
+	input := bytes.NewReader(data)
+	tokens := []token.Token{}
+	for {
+		offset := len(data) - input.Len()
+		buf, err := readTokenBytes(input, token.DialectUnknown, nil)
+		if err == io.EOF {
+			return tokens, nil
+		}
+		if err != nil {
+			return tokens, fmt.Errorf("token at offset %d: %w", offset, err)
+		}
+		tok, err := token.Parse(buf)
+		if err != nil {
+			return tokens, fmt.Errorf("token at offset %d: %w", offset, err)
+		}
+		tokens = append(tokens, tok)
+	}
+}
@@ -0,0 +1,46 @@
+package decode
+
+import (
+	"errors"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/tpltnt/go-bsm/token"
+)
+
+func TestParseTokenSequence(t *testing.T) {
+	data, err := os.ReadFile("../start_stop.bsm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// text and return token of the first record
+	body := data[18:49]
+	tokens, err := ParseTokenSequence(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tokens) != 2 {
+		t.Fatal("unexpected number of tokens:", len(tokens))
+	}
+	if text, ok := tokens[0].(token.TextToken); !ok || text.Text != "auditd::Audit startup" {
+		t.Error("unexpected token:", tokens[0])
+	}
+	if _, ok := tokens[1].(token.ReturnToken32bit); !ok {
+		t.Error("unexpected token:", tokens[1])
+	}
+
+	// framing is kept
+	tokens, err = ParseTokenSequence(data)
+	if err != nil || len(tokens) != 8 {
+		t.Error("unexpected result for complete trail:", len(tokens), err)
+	}
+
+	if tokens, err := ParseTokenSequence(nil); err != nil || len(tokens) != 0 {
+		t.Error("unexpected result for empty input")
+	}
+	tokens, err = ParseTokenSequence(body[:30])
+	if !errors.Is(err, io.ErrUnexpectedEOF) || len(tokens) != 1 {
+		t.Error("unexpected result for truncated input:", len(tokens), err)
+	}
+}
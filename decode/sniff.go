@@ -0,0 +1,97 @@
+package decode
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	"github.com/tpltnt/go-bsm/token"
+)
+
+// SniffRecords is the number of records checked by SniffBSM.
+var SniffRecords = 10
+
+// DialectGuess is the producer of a trail as guessed by SniffBSM from
+// its first records.
+type DialectGuess struct {
+	Dialect  token.Dialect // DialectUnknown if undecided
+	Producer string        // see DialectReport
+	Records  int           // number of records checked
+}
+
+// sniffReader remembers the error of the underlying reader, to tell it
+// apart from data which isn't a trail.
+type sniffReader struct {
+	input io.Reader
+	err   error
+}
+
+func (sr *sniffReader) Read(p []byte) (int, error) {
+	n, err := sr.input.Read(p)
+	if err != nil && err != io.EOF {
+		sr.err = err
+	}
+	return n, err
+}
+
+// SniffBSM reports whether r looks like a BSM trail, i.e. if its first
+// SniffRecords records (or all of them, if there are fewer) consist of
+// a header of a known version, tokens and a trailer with matching byte
+// counts. Leading and separating file tokens are allowed. Data which
+// isn't a trail isn't an error, only failures to read are. For trails
+// the dialect is guessed as done by DetectDialect.
+func SniffBSM(r io.Reader) (bool, DialectGuess, error) {
+	var guess DialectGuess
+	input := &sniffReader{input: r}
+	var sample bytes.Buffer
+	var record []byte // raw record read so far
+	for guess.Records < SniffRecords {
+		data, err := readTokenBytes(input, token.DialectUnknown, nil)
+		if input.err != nil {
+			return false, guess, input.err
+		}
+		if err == io.EOF && record == nil && guess.Records > 0 {
+			break
+		}
+		if err != nil {
+			return false, guess, nil
+		}
+		sample.Write(data)
+
+		switch data[0] {
+		case 0x14, 0x15, 0x74, 0x79: // header
+			if record != nil || !acceptsVersion(KnownVersions, data[5]) {
+				return false, guess, nil
+			}
+			record = data
+		case 0x13: // trailer
+			if record == nil || binary.BigEndian.Uint16(data[1:3]) != 0xb105 {
+				return false, guess, nil
+			}
+			record = append(record, data...)
+			size := uint32(len(record))
+			if binary.BigEndian.Uint32(record[1:5]) != size || binary.BigEndian.Uint32(data[3:7]) != size {
+				return false, guess, nil
+			}
+			record = nil
+			guess.Records += 1
+		case 0x11: // file
+			if record != nil {
+				return false, guess, nil
+			}
+		default:
+			if record == nil {
+				return false, guess, nil
+			}
+			record = append(record, data...)
+		}
+	}
+
+	report, err := DetectDialect(&sample)
+	if err != nil {
+		return false, guess, nil
+	}
+	guess.Dialect = report.Dialect
+	guess.Producer = report.Producer
+	return true, guess, nil
+}
@@ -0,0 +1,57 @@
+package decode
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/tpltnt/go-bsm/token"
+)
+
+func TestSniffBSM(t *testing.T) {
+	data, err := os.ReadFile("../start_stop.bsm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ok, guess, err := SniffBSM(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok || guess.Records == 0 || guess.Producer == "" {
+		t.Errorf("trail not recognized: %v %+v", ok, guess)
+	}
+
+	ok, guess, err = SniffBSM(bytes.NewReader(rawRecord(1, 44901)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok || guess.Dialect != token.DialectDarwin {
+		t.Errorf("got %v %+v, expected darwin trail", ok, guess)
+	}
+
+	broken := rawRecord(11, 1)
+	broken[4] += 1 // byte count
+	for name, data := range map[string][]byte{
+		"empty":      nil,
+		"text":       []byte(strings.Repeat("not a trail\n", 10)),
+		"version":    rawRecord(42, 1),
+		"byte count": broken,
+		"truncated":  rawRecord(11, 1)[:20],
+	} {
+		ok, _, err := SniffBSM(bytes.NewReader(data))
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+		if ok {
+			t.Errorf("%s: recognized as trail", name)
+		}
+	}
+
+	failure := errors.New("failure")
+	if _, _, err := SniffBSM(iotest.ErrReader(failure)); err != failure {
+		t.Errorf("got %v, expected read error", err)
+	}
+}
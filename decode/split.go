@@ -0,0 +1,69 @@
+package decode
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// minRecordSize is the size of the smallest record: a 32 bit header
+// and a trailer token.
+const minRecordSize = 18 + 7
+
+// RecordSplitFunc is a bufio.SplitFunc splitting a trail into the raw
+// bytes of its records using the byte counts of their headers, without
+// decoding the tokens, e.g. to hash or forward the records:
+//
+//	scanner := bufio.NewScanner(trail)
+//	scanner.Buffer(nil, 1<<20) // the largest record expected
+//	scanner.Split(decode.RecordSplitFunc)
+//	for scanner.Scan() {
+//		forward(scanner.Bytes())
+//	}
+//
+// File tokens between records and the zero padding at the end of a
+// trail are skipped. A record not ending with a trailer token is an
+// error, as is an incomplete record at the end of the input (which
+// wraps io.ErrUnexpectedEOF).
+func RecordSplitFunc(data []byte, atEOF bool) (advance int, record []byte, err error) {
+	if len(data) == 0 {
+		return 0, nil, nil
+	}
+
+	size := 0
+	switch data[0] {
+	case 0x00: // padding
+		for size < len(data) && data[size] == 0 {
+			size += 1
+		}
+		return size, nil, nil
+	case 0x11: // file
+		if len(data) >= 11 {
+			size = 11 + int(binary.BigEndian.Uint16(data[9:11]))
+		}
+	case 0x14, 0x15, 0x74, 0x79: // header
+		if len(data) >= 5 {
+			size = int(binary.BigEndian.Uint32(data[1:5]))
+			if size < minRecordSize {
+				return 0, nil, fmt.Errorf("invalid record size %d", size)
+			}
+		}
+	default:
+		return 0, nil, fmt.Errorf("unexpected token 0x%02x at start of record", data[0])
+	}
+
+	if size == 0 || len(data) < size {
+		if atEOF {
+			return 0, nil, fmt.Errorf("incomplete record (%d bytes): %w", len(data), io.ErrUnexpectedEOF)
+		}
+		return 0, nil, nil // request more data
+	}
+	if data[0] == 0x11 {
+		return size, nil, nil
+	}
+	trailer := data[size-7 : size]
+	if trailer[0] != 0x13 || binary.BigEndian.Uint16(trailer[1:3]) != 0xb105 {
+		return 0, nil, fmt.Errorf("record of %d bytes without trailer token", size)
+	}
+	return size, data[:size], nil
+}
@@ -0,0 +1,66 @@
+package decode
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"testing"
+)
+
+func TestRecordSplitFunc(t *testing.T) {
+	data, err := os.ReadFile("../start_stop.bsm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := []byte{0x11, 0, 0, 0, 1, 0, 0, 0, 0, 0, 2, 'a', 0}
+	var trail []byte
+	trail = append(trail, file...)
+	trail = append(trail, data...)
+	trail = append(trail, file...)
+	trail = append(trail, 0, 0, 0, 0)
+
+	scanner := bufio.NewScanner(bytes.NewReader(trail))
+	scanner.Buffer(make([]byte, 8), 1024) // grown while scanning
+	scanner.Split(RecordSplitFunc)
+	var records [][]byte
+	for scanner.Scan() {
+		records = append(records, append([]byte(nil), scanner.Bytes()...))
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || !bytes.Equal(records[0], data[:56]) || !bytes.Equal(records[1], data[56:]) {
+		t.Errorf("unexpected records %x", records)
+	}
+	rec, err := NewDecoder(bytes.NewReader(records[1])).Decode()
+	if err != nil || rec.EventType != 45001 {
+		t.Error("unexpected record:", rec, err)
+	}
+}
+
+func TestRecordSplitFuncErrors(t *testing.T) {
+	data, err := os.ReadFile("../start_stop.bsm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data[:70]))
+	scanner.Split(RecordSplitFunc)
+	records := 0
+	for scanner.Scan() {
+		records += 1
+	}
+	if records != 1 || !errors.Is(scanner.Err(), io.ErrUnexpectedEOF) {
+		t.Error("expected io.ErrUnexpectedEOF after one record, got", records, scanner.Err())
+	}
+
+	broken := append([]byte(nil), data[:56]...)
+	broken[49] = 0x28 // no trailer
+	if _, _, err := RecordSplitFunc(broken, true); err == nil {
+		t.Error("expected error for record without trailer")
+	}
+	if _, _, err := RecordSplitFunc([]byte{0x28, 0, 1, 'a'}, false); err == nil {
+		t.Error("expected error for data not starting with a header")
+	}
+}
@@ -0,0 +1,30 @@
+package decode
+
+import (
+	"io"
+	"iter"
+
+	"github.com/tpltnt/go-bsm/token"
+)
+
+// Tokens returns an iterator over the tokens read from r, including
+// header, trailer and file tokens, without assembling records. The
+// iteration ends when r is exhausted or after yielding the first error.
+func Tokens(r io.Reader) iter.Seq2[token.Token, error] {
+	return func(yield func(token.Token, error) bool) {
+		for {
+			data, err := readTokenBytes(r, token.DialectUnknown, nil)
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			tok, err := token.Parse(data)
+			if !yield(tok, err) || err != nil {
+				return
+			}
+		}
+	}
+}
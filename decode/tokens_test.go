@@ -0,0 +1,58 @@
+package decode
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/tpltnt/go-bsm/token"
+)
+
+func TestTokens(t *testing.T) {
+	data, err := os.ReadFile("../start_stop.bsm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	counts := map[string]int{}
+	for tok, err := range Tokens(bytes.NewReader(data)) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		counts[token.Name(tok)] += 1
+	}
+	decoder := NewDecoder(bytes.NewReader(data))
+	for {
+		if _, err := decoder.Decode(); err != nil {
+			break
+		}
+	}
+	total := 0
+	for _, n := range counts {
+		total += n
+	}
+	if uint64(total) != decoder.Stats().TokensParsed {
+		t.Errorf("got %d tokens, decoder parsed %d", total, decoder.Stats().TokensParsed)
+	}
+	if counts["header32"] != counts["trailer"] || counts["header32"] == 0 {
+		t.Errorf("unexpected token counts %v", counts)
+	}
+
+	// early exit and errors
+	n := 0
+	for range Tokens(bytes.NewReader(data)) {
+		n += 1
+		break
+	}
+	if n != 1 {
+		t.Errorf("iteration didn't stop")
+	}
+	var errs int
+	for _, err := range Tokens(bytes.NewReader([]byte{0x14, 0x00})) {
+		if err != nil {
+			errs += 1
+		}
+	}
+	if errs != 1 {
+		t.Errorf("got %d errors for truncated token, expected 1", errs)
+	}
+}
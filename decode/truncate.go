@@ -0,0 +1,86 @@
+package decode
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/tpltnt/go-bsm/token"
+)
+
+// mapStrings returns the token with fn applied to all its strings
+// (paths, texts, arguments, ...). Slices are copied, so the original
+// token is left unchanged.
+func mapStrings(tok token.Token, fn func(string) string) token.Token {
+	mapAll := func(values []string) []string {
+		mapped := make([]string, len(values))
+		for i, s := range values {
+			mapped[i] = fn(s)
+		}
+		return mapped
+	}
+	switch v := tok.(type) {
+	case token.ArgToken32bit:
+		v.Text = fn(v.Text)
+		return v
+	case token.ArgToken64bit:
+		v.Text = fn(v.Text)
+		return v
+	case token.ExecArgsToken:
+		v.Text = mapAll(v.Text)
+		return v
+	case token.ExecEnvToken:
+		v.Text = mapAll(v.Text)
+		return v
+	case token.FileToken:
+		v.PathName = fn(v.PathName)
+		return v
+	case token.PathToken:
+		v.Path = fn(v.Path)
+		return v
+	case token.PathAttrToken:
+		v.Path = mapAll(v.Path)
+		return v
+	case token.TextToken:
+		v.Text = fn(v.Text)
+		return v
+	case token.ZonenameToken:
+		v.Zonename = fn(v.Zonename)
+		return v
+	}
+	return tok
+}
+
+// truncateToken returns the token with all its strings capped at max
+// bytes (less if that would split a UTF-8 encoded character) and
+// whether any of them was longer. Capped strings are copied, so the
+// (possibly huge) originals can be freed.
+func truncateToken(tok token.Token, max int) (token.Token, bool) {
+	truncated := false
+	tok = mapStrings(tok, func(s string) string {
+		if len(s) <= max {
+			return s
+		}
+		truncated = true
+		return strings.Clone(s[:runeBoundary(s, max)])
+	})
+	return tok, truncated
+}
+
+// runeBoundary returns the offset up to max at which s can be cut
+// without splitting a UTF-8 encoded character. Bytes which aren't valid
+// UTF-8 are cut at max.
+func runeBoundary(s string, max int) int {
+	for n := max; n >= 0 && n > max-utf8.UTFMax; n-- {
+		if !utf8.RuneStart(s[n]) {
+			continue
+		}
+		if n == max {
+			return max
+		}
+		if r, size := utf8.DecodeRuneInString(s[n:]); r != utf8.RuneError && n+size > max {
+			return n
+		}
+		return max
+	}
+	return max
+}
@@ -0,0 +1,65 @@
+package decode
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+
+	"github.com/tpltnt/go-bsm/token"
+)
+
+func TestMaxStringLength(t *testing.T) {
+	long := strings.Repeat("A", 1000)
+	text := binary.BigEndian.AppendUint16([]byte{0x28}, uint16(len(long)+1))
+	text = append(append(text, long...), 0x00)
+	args := append([]byte{0x3c, 0x00, 0x00, 0x00, 0x02}, "/bin/echo\x00"+long+"\x00"...)
+	trail := append(rawRecord(VersionOpenBSM11, 23, args, text), rawRecord(VersionOpenBSM11, 23)...)
+
+	decoder := NewDecoder(bytes.NewReader(trail))
+	decoder.MaxStringLength = 16
+	rec, err := decoder.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rec.Truncated {
+		t.Error("record not marked as truncated")
+	}
+	exec := rec.Tokens[0].(token.ExecArgsToken)
+	if exec.Text[0] != "/bin/echo" || len(exec.Text[1]) != 16 {
+		t.Errorf("unexpected arguments %q", exec.Text)
+	}
+	if s := rec.Tokens[1].(token.TextToken).Text; s != long[:16] {
+		t.Errorf("unexpected text %q", s)
+	}
+	if n := decoder.Stats().Truncated; n != 2 {
+		t.Errorf("got %d truncated tokens, expected 2", n)
+	}
+
+	rec, err = decoder.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.Truncated {
+		t.Error("truncation carried over to the next record")
+	}
+}
+
+func TestTruncateTokenUTF8(t *testing.T) {
+	for _, c := range []struct {
+		s    string
+		max  int
+		want string
+	}{
+		{"/tmp/héllo", 7, "/tmp/h"},
+		{"/tmp/héllo", 8, "/tmp/hé"},
+		{"日本語", 4, "日"},
+		{"日本語", 6, "日本"},
+		{"\xff\xfe\xfd\xfc\xfb", 3, "\xff\xfe\xfd"}, // not UTF-8
+	} {
+		tok, truncated := truncateToken(token.PathToken{Path: c.s}, c.max)
+		if got := tok.(token.PathToken).Path; !truncated || got != c.want {
+			t.Errorf("%q capped at %d: got %q, expected %q", c.s, c.max, got, c.want)
+		}
+	}
+}
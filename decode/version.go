@@ -0,0 +1,46 @@
+package decode
+
+import (
+	"fmt"
+)
+
+// BSM record version numbers found in header tokens
+// (see AUDIT_HEADER_VERSION_* in OpenBSM).
+const (
+	VersionOldDarwin  = 1  // old Darwin (Mac OS X 10.4 and earlier)
+	VersionSolaris    = 2  // Solaris
+	VersionTSolaris25 = 3  // Trusted Solaris 2.5
+	VersionTSolaris   = 4  // Trusted Solaris
+	VersionOpenBSM10  = 10 // OpenBSM 1.0
+	VersionOpenBSM11  = 11 // OpenBSM 1.1 (current)
+)
+
+// KnownVersions lists all record versions known to this package.
+var KnownVersions = []byte{
+	VersionOldDarwin,
+	VersionSolaris,
+	VersionTSolaris25,
+	VersionTSolaris,
+	VersionOpenBSM10,
+	VersionOpenBSM11,
+}
+
+// UnknownVersionError is returned by a strict Decoder for records
+// with a version number it does not accept.
+type UnknownVersionError struct {
+	Version byte // version number found in the header token
+}
+
+func (e *UnknownVersionError) Error() string {
+	return fmt.Sprintf("unknown BSM record version: %d", e.Version)
+}
+
+// acceptsVersion reports whether the given version number is listed.
+func acceptsVersion(versions []byte, version byte) bool {
+	for _, v := range versions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
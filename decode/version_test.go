@@ -0,0 +1,110 @@
+package decode
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/tpltnt/go-bsm/token"
+)
+
+func TestDecoderVersions(t *testing.T) {
+	data, err := os.ReadFile("../start_stop.bsm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	odd := make([]byte, len(data))
+	copy(odd, data)
+	odd[5] = 0x2a // version of first record
+
+	// default: odd versions are accepted and exposed
+	rec, err := NewDecoder(bytes.NewReader(odd)).Decode()
+	if err != nil {
+		t.Error(err)
+	}
+	if rec.Version != 0x2a {
+		t.Error("unexpected record version:", rec.Version)
+	}
+
+	// strict mode with known versions
+	decoder := NewDecoder(bytes.NewReader(odd))
+	decoder.Strict = true
+	_, err = decoder.Decode()
+	verr, ok := err.(*UnknownVersionError)
+	if !ok {
+		t.Fatal("expected *UnknownVersionError, got", err)
+	}
+	if verr.Version != 0x2a {
+		t.Error("unexpected version in error")
+	}
+	rec, err = decoder.Decode() // stream stays in sync
+	if err != nil {
+		t.Error(err)
+	}
+	if rec.Version != VersionOpenBSM11 {
+		t.Error("unexpected record version:", rec.Version)
+	}
+	if _, err = decoder.Decode(); err != io.EOF {
+		t.Error("expected io.EOF, got", err)
+	}
+
+	// strict mode with configured versions
+	decoder = NewDecoder(bytes.NewReader(odd))
+	decoder.Strict = true
+	decoder.Versions = []byte{0x2a}
+	if _, err = decoder.Decode(); err != nil {
+		t.Error(err)
+	}
+	if _, err = decoder.Decode(); err == nil {
+		t.Error("expected version 11 to be rejected")
+	}
+}
+
+func TestParseHeaderToken64bit(t *testing.T) {
+	data := []byte{0x74, // token ID
+		0x00, 0x00, 0x00, 0x40, // record byte count
+		0x0b,       // version number
+		0x00, 0x17, // event type
+		0x00, 0x00, // event modifier
+		0x00, 0x00, 0x00, 0x00, 0x5a, 0x9a, 0xc2, 0xe6, // seconds
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x03, 0x01, // nanoseconds
+	}
+	tok, err := token.ParseHeaderToken64bit(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tok.RecordByteCount != 64 || tok.VersionNumber != 11 || tok.EventType != 23 {
+		t.Error("unexpected header fields:", tok)
+	}
+	if tok.Seconds != 1520091878 || tok.NanoSeconds != 769 {
+		t.Error("unexpected time stamp")
+	}
+}
+
+func TestParseExpandedHeaderToken32bit(t *testing.T) {
+	data := []byte{0x15, // token ID
+		0x00, 0x00, 0x00, 0x40, // record byte count
+		0x0b,       // version number
+		0x00, 0x17, // event type
+		0x00, 0x00, // event modifier
+		0x00, 0x00, 0x00, 0x04, // address type
+		0xc0, 0xa8, 0x00, 0x01, // IPv4
+		0x5a, 0x9a, 0xc2, 0xe6, // seconds
+		0x00, 0x00, 0x03, 0x01, // nanoseconds
+	}
+	tok, err := ReadToken(bytes.NewBuffer(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	header, ok := tok.(token.ExpandedHeaderToken32bit)
+	if !ok {
+		t.Fatal("expected ExpandedHeaderToken32bit")
+	}
+	if !header.MachineAddress.Equal([]byte{192, 168, 0, 1}) {
+		t.Error("unexpected machine address:", header.MachineAddress)
+	}
+	if header.Seconds != 1520091878 || header.NanoSeconds != 769 {
+		t.Error("unexpected time stamp")
+	}
+}
@@ -0,0 +1,320 @@
+// Package encode writes BSM tokens and records in their binary form,
+// i.e. it is the counterpart of package decode.
+package encode
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+
+	"github.com/tpltnt/go-bsm/clock"
+	"github.com/tpltnt/go-bsm/decode"
+	"github.com/tpltnt/go-bsm/token"
+)
+
+// trailerMagic is the magic number of every trailer token.
+const trailerMagic = 0xb105
+
+// headerSize and trailerSize are the sizes of the 32 bit header token
+// and the trailer token written by Record.
+const (
+	headerSize  = 18
+	trailerSize = 7
+)
+
+var be = binary.BigEndian
+
+// appendString appends s followed by a NUL byte. The length prefix (if
+// any) is up to the caller.
+func appendString(buf []byte, s string) []byte {
+	return append(append(buf, s...), 0x00)
+}
+
+// appendText appends s as a string with 16 bit length prefix (incl. NUL).
+func appendText(buf []byte, s string) []byte {
+	buf = be.AppendUint16(buf, uint16(len(s)+1))
+	return appendString(buf, s)
+}
+
+// appendIPv4 appends the 4 byte form of ip (0.0.0.0 if ip is not IPv4).
+func appendIPv4(buf []byte, ip net.IP) []byte {
+	if v4 := ip.To4(); v4 != nil {
+		return append(buf, v4...)
+	}
+	return append(buf, 0, 0, 0, 0)
+}
+
+// appendIPv6 appends the 16 byte form of ip (:: if ip is nil).
+func appendIPv6(buf []byte, ip net.IP) []byte {
+	if v6 := ip.To16(); v6 != nil {
+		return append(buf, v6...)
+	}
+	return append(buf, make([]byte, net.IPv6len)...)
+}
+
+// appendAddress appends the address length (4 bytes) followed by the
+// 4 or 16 byte form of ip.
+func appendAddress(buf []byte, ip net.IP) []byte {
+	if v4 := ip.To4(); v4 != nil {
+		return append(be.AppendUint32(buf, 4), v4...)
+	}
+	if v6 := ip.To16(); v6 != nil {
+		return append(be.AppendUint32(buf, 16), v6...)
+	}
+	return append(be.AppendUint32(buf, 4), 0, 0, 0, 0)
+}
+
+// Token returns the binary form of the given token. The length fields
+// of the token are ignored and computed from its content.
+func Token(tok token.Token) ([]byte, error) {
+	return AppendToken(nil, tok)
+}
+
+// AppendToken appends the binary form of the given token to buf.
+func AppendToken(buf []byte, tok token.Token) ([]byte, error) {
+	switch v := tok.(type) {
+	case token.FileToken:
+		buf = append(buf, 0x11)
+		buf = be.AppendUint32(buf, v.Seconds)
+		buf = be.AppendUint32(buf, v.Microseconds)
+		buf = appendText(buf, v.PathName)
+	case token.TrailerToken:
+		buf = append(buf, 0x13)
+		buf = be.AppendUint16(buf, trailerMagic)
+		buf = be.AppendUint32(buf, v.RecordByteCount)
+	case token.HeaderToken32bit:
+		buf = append(buf, 0x14)
+		buf = be.AppendUint32(buf, v.RecordByteCount)
+		buf = append(buf, v.VersionNumber)
+		buf = be.AppendUint16(buf, v.EventType)
+		buf = be.AppendUint16(buf, v.EventModifier)
+		buf = be.AppendUint32(buf, v.Seconds)
+		buf = be.AppendUint32(buf, v.NanoSeconds)
+	case token.HeaderToken64bit:
+		buf = append(buf, 0x74)
+		buf = be.AppendUint32(buf, v.RecordByteCount)
+		buf = append(buf, v.VersionNumber)
+		buf = be.AppendUint16(buf, v.EventType)
+		buf = be.AppendUint16(buf, v.EventModifier)
+		buf = be.AppendUint64(buf, v.Seconds)
+		buf = be.AppendUint64(buf, v.NanoSeconds)
+	case token.ExpandedHeaderToken64bit:
+		buf = append(buf, 0x79)
+		buf = be.AppendUint32(buf, v.RecordByteCount)
+		buf = append(buf, v.VersionNumber)
+		buf = be.AppendUint16(buf, v.EventType)
+		buf = be.AppendUint16(buf, v.EventModifier)
+		buf = appendAddress(buf, v.MachineAddress)
+		buf = be.AppendUint64(buf, v.Seconds)
+		buf = be.AppendUint64(buf, v.NanoSeconds)
+	case token.PathToken:
+		buf = appendText(append(buf, 0x23), v.Path)
+	case token.TextToken:
+		buf = appendText(append(buf, 0x28), v.Text)
+	case token.ZonenameToken:
+		buf = appendText(append(buf, 0x60), v.Zonename)
+	case token.SubjectToken32bit:
+		buf = append(buf, 0x24)
+		buf = appendIDs(buf, v.AuditID, v.EffectiveUserID, v.EffectiveGroupID,
+			v.RealUserID, v.RealGroupID, v.ProcessID, v.SessionID, v.TerminalPortID)
+		buf = appendIPv4(buf, v.TerminalMachineAddress)
+	case token.ProcessToken32bit:
+		buf = append(buf, 0x26)
+		buf = appendIDs(buf, v.AuditID, v.EffectiveUserID, v.EffectiveGroupID,
+			v.RealUserID, v.RealGroupID, v.ProcessID, v.SessionID, v.TerminalPortID)
+		buf = appendIPv4(buf, v.TerminalMachineAddress)
+	case token.ExpandedSubjectToken32bit:
+		buf = append(buf, 0x7a)
+		buf = appendIDs(buf, v.AuditID, v.EffectiveUserID, v.EffectiveGroupID,
+			v.RealUserID, v.RealGroupID, v.ProcessID, v.SessionID, v.TerminalPortID)
+		buf = appendAddress(buf, v.TerminalMachineAddress)
+	case token.ExpandedProcessToken32bit:
+		buf = append(buf, 0x7b)
+		buf = appendIDs(buf, v.AuditID, v.EffectiveUserID, v.EffectiveGroupID,
+			v.RealUserID, v.RealGroupID, v.ProcessID, v.SessionID, v.TerminalPortID)
+		buf = appendAddress(buf, v.TerminalMachineAddress)
+	case token.ExpandedSubjectToken64bit:
+		buf = append(buf, 0x7c)
+		buf = appendIDs(buf, v.AuditID, v.EffectiveUserID, v.EffectiveGroupID,
+			v.RealUserID, v.RealGroupID, v.ProcessID, v.SessionID)
+		buf = be.AppendUint64(buf, v.TerminalPortID)
+		buf = appendAddress(buf, v.TerminalMachineAddress)
+	case token.ExpandedProcessToken64bit:
+		buf = append(buf, 0x7d)
+		buf = appendIDs(buf, v.AuditID, v.EffectiveUserID, v.EffectiveGroupID,
+			v.RealUserID, v.RealGroupID, v.ProcessID, v.SessionID)
+		buf = be.AppendUint64(buf, v.TerminalPortID)
+		buf = appendAddress(buf, v.TerminalMachineAddress)
+	case token.SubjectToken64bit:
+		buf = append(buf, 0x75)
+		buf = appendIDs(buf, v.AuditID, v.EffectiveUserID, v.EffectiveGroupID,
+			v.RealUserID, v.RealGroupID, v.ProcessID, v.SessionID)
+		buf = be.AppendUint64(buf, v.TerminalPortID)
+		buf = appendIPv4(buf, v.TerminalMachineAddress)
+	case token.ProcessToken64bit:
+		buf = append(buf, 0x77)
+		buf = appendIDs(buf, v.AuditID, v.EffectiveUserID, v.EffectiveGroupID,
+			v.RealUserID, v.RealGroupID, v.ProcessID, v.SessionID)
+		buf = be.AppendUint64(buf, v.TerminalPortID)
+		buf = appendIPv4(buf, v.TerminalMachineAddress)
+	case token.ReturnToken32bit:
+		buf = append(buf, 0x27, v.ErrorNumber)
+		buf = be.AppendUint32(buf, v.ReturnValue)
+	case token.ReturnToken64bit:
+		buf = append(buf, 0x72, v.ErrorNumber)
+		buf = be.AppendUint64(buf, v.ReturnValue)
+	case token.ArgToken32bit:
+		buf = append(buf, 0x2d, v.ArgumentID)
+		buf = be.AppendUint32(buf, v.ArgumentValue)
+		buf = appendText(buf, v.Text)
+	case token.ArgToken64bit:
+		buf = append(buf, 0x71, v.ArgumentID)
+		buf = be.AppendUint64(buf, v.ArgumentValue)
+		buf = appendText(buf, v.Text)
+	case token.InAddrToken:
+		buf = appendIPv4(append(buf, 0x2a), v.IpAddress)
+	case token.ExpandedInAddrToken:
+		// the address type is followed by 16 bytes in any case
+		if v4 := v.IpAddress.To4(); v4 != nil {
+			buf = append(append(buf, 0x7e, 4), v4...)
+			buf = append(buf, make([]byte, 12)...)
+		} else {
+			buf = appendIPv6(append(buf, 0x7e, 16), v.IpAddress)
+		}
+	case token.IpToken:
+		buf = append(buf, 0x2b, v.VersionAndIHL, v.TypeOfService)
+		buf = be.AppendUint16(buf, v.Length)
+		buf = be.AppendUint16(buf, v.ID)
+		buf = be.AppendUint16(buf, v.Offset)
+		buf = append(buf, v.TTL, v.Protocol)
+		buf = be.AppendUint16(buf, v.Checksum)
+		buf = appendIPv4(buf, v.SourceAddress)
+		buf = appendIPv4(buf, v.DestinationAddress)
+	case token.SocketToken:
+		id := v.TokenID
+		if id != 0x80 && id != 0x81 && id != 0x82 {
+			id = 0x2e
+		}
+		buf = be.AppendUint16(append(buf, id), v.SocketFamily)
+		buf = be.AppendUint16(buf, v.LocalPort)
+		if id == 0x81 {
+			buf = appendIPv6(buf, v.SocketAddress)
+		} else {
+			buf = appendIPv4(buf, v.SocketAddress)
+		}
+	case token.ExpandedSocketToken:
+		buf = be.AppendUint16(append(buf, 0x7f), v.SocketDomain)
+		buf = be.AppendUint16(buf, v.SocketType)
+		if v.LocalIpAddress.To4() != nil && (v.RemoteIpAddress == nil || v.RemoteIpAddress.To4() != nil) {
+			buf = be.AppendUint16(buf, 4)
+			buf = appendIPv4(be.AppendUint16(buf, v.LocalPort), v.LocalIpAddress)
+			buf = appendIPv4(be.AppendUint16(buf, v.RemotePort), v.RemoteIpAddress)
+		} else {
+			buf = be.AppendUint16(buf, 16)
+			buf = appendIPv6(be.AppendUint16(buf, v.LocalPort), v.LocalIpAddress)
+			buf = appendIPv6(be.AppendUint16(buf, v.RemotePort), v.RemoteIpAddress)
+		}
+	case token.IPortToken:
+		buf = be.AppendUint16(append(buf, 0x2c), v.PortNumber)
+	case token.SeqToken:
+		buf = be.AppendUint32(append(buf, 0x2f), v.SequenceNumber)
+	case token.ExecArgsToken:
+		buf = appendStrings(append(buf, 0x3c), v.Text)
+	case token.ExecEnvToken:
+		buf = appendStrings(append(buf, 0x3d), v.Text)
+	case token.AttributeToken32bit:
+		buf = append(buf, 0x3e)
+		buf = appendIDs(buf, v.FileAccessMode, v.OwnerUserID, v.OwnerGroupID, v.FileSystemID)
+		buf = be.AppendUint64(buf, v.FileSystemNodeID)
+		buf = be.AppendUint32(buf, v.Device)
+	case token.AttributeToken64bit:
+		buf = append(buf, 0x73)
+		buf = appendIDs(buf, v.FileAccessMode, v.OwnerUserID, v.OwnerGroupID, v.FileSystemID)
+		buf = be.AppendUint64(buf, v.FileSystemNodeID)
+		buf = be.AppendUint64(buf, v.Device)
+	case token.ExitToken:
+		buf = be.AppendUint32(append(buf, 0x52), v.Status)
+		buf = be.AppendUint32(buf, uint32(v.ReturnValue))
+	case token.GroupsToken:
+		buf = be.AppendUint16(append(buf, 0x34), uint16(len(v.GroupList)))
+		buf = appendIDs(buf, v.GroupList...)
+	default:
+		return buf, fmt.Errorf("can't encode token of type %T", tok)
+	}
+	return buf, nil
+}
+
+// appendIDs appends the given 32 bit values.
+func appendIDs(buf []byte, ids ...uint32) []byte {
+	for _, id := range ids {
+		buf = be.AppendUint32(buf, id)
+	}
+	return buf
+}
+
+// appendStrings appends a 32 bit count followed by the NUL-terminated
+// strings.
+func appendStrings(buf []byte, s []string) []byte {
+	buf = be.AppendUint32(buf, uint32(len(s)))
+	for _, str := range s {
+		buf = appendString(buf, str)
+	}
+	return buf
+}
+
+// Record returns the binary form of the given record, framed by a 32 bit
+// header and a trailer token. The byte counts are computed, the
+// ByteCount of the record is ignored.
+func Record(rec *decode.BsmRecord) ([]byte, error) {
+	var body []byte
+	for i, tok := range rec.Tokens {
+		var err error
+		body, err = AppendToken(body, tok)
+		if err != nil {
+			return nil, fmt.Errorf("token %d: %w", i, err)
+		}
+	}
+	size := uint32(headerSize + len(body) + trailerSize)
+
+	buf, _ := AppendToken(make([]byte, 0, size), token.HeaderToken32bit{
+		RecordByteCount: size,
+		VersionNumber:   rec.Version,
+		EventType:       rec.EventType,
+		EventModifier:   rec.EventModifier,
+		Seconds:         uint32(rec.Seconds),
+		NanoSeconds:     uint32(rec.NanoSeconds),
+	})
+	buf = append(buf, body...)
+	return AppendToken(buf, token.TrailerToken{RecordByteCount: size})
+}
+
+// Encoder writes BSM records to an output stream.
+type Encoder struct {
+	// Clock, if set, stamps records without time stamp (zero Seconds
+	// and NanoSeconds) with its current time.
+	Clock clock.Clock
+
+	output io.Writer
+}
+
+// NewEncoder returns an encoder writing to the given output.
+func NewEncoder(output io.Writer) *Encoder {
+	return &Encoder{output: output}
+}
+
+// Encode writes the given record.
+func (e *Encoder) Encode(rec *decode.BsmRecord) error {
+	if e.Clock != nil && rec.Seconds == 0 && rec.NanoSeconds == 0 {
+		stamped := *rec
+		now := e.Clock.Now()
+		stamped.Seconds, stamped.NanoSeconds = uint64(now.Unix()), uint64(now.Nanosecond())
+		rec = &stamped
+	}
+	data, err := Record(rec)
+	if err != nil {
+		return err
+	}
+	_, err = e.output.Write(data)
+	return err
+}
@@ -0,0 +1,121 @@
+package encode
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/tpltnt/go-bsm/clock"
+	"github.com/tpltnt/go-bsm/decode"
+	"github.com/tpltnt/go-bsm/token"
+)
+
+func TestRoundTrip(t *testing.T) {
+	data, err := os.ReadFile("../start_stop.bsm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoder := decode.NewDecoder(bytes.NewReader(data))
+	var records []decode.BsmRecord
+	for {
+		rec, err := decoder.Decode()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, rec)
+	}
+
+	var buf bytes.Buffer
+	encoder := NewEncoder(&buf)
+	for i := range records {
+		if err := encoder.Encode(&records[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	decoder = decode.NewDecoder(&buf)
+	for i := range records {
+		rec, err := decoder.Decode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !decode.Equal(&rec, &records[i]) {
+			t.Errorf("record %d differs after round trip: %v != %v", i, rec, records[i])
+		}
+	}
+	if _, err := decoder.Decode(); err != io.EOF {
+		t.Error("expected EOF, got", err)
+	}
+}
+
+func TestEncoderClock(t *testing.T) {
+	var buf bytes.Buffer
+	encoder := NewEncoder(&buf)
+	encoder.Clock = clock.NewFake(time.Unix(1500000000, 5000000))
+	encoder.Encode(&decode.BsmRecord{EventType: 1})
+	encoder.Encode(&decode.BsmRecord{EventType: 1, Seconds: 42})
+
+	decoder := decode.NewDecoder(&buf)
+	if rec, err := decoder.Decode(); err != nil || rec.Seconds != 1500000000 || rec.NanoSeconds != 5000000 {
+		t.Error("record not stamped:", rec, err)
+	}
+	if rec, err := decoder.Decode(); err != nil || rec.Seconds != 42 || rec.NanoSeconds != 0 {
+		t.Error("time stamp overwritten:", rec, err)
+	}
+}
+
+func TestToken(t *testing.T) {
+	data, err := Token(token.TextToken{Text: "hello"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, []byte{0x28, 0x00, 0x06, 'h', 'e', 'l', 'l', 'o', 0x00}) {
+		t.Errorf("unexpected text token: % x", data)
+	}
+
+	subject := token.SubjectToken64bit{TokenID: 0x75, AuditID: 1000, ProcessID: 42,
+		TerminalPortID: 1 << 40, TerminalMachineAddress: net.IPv4(192, 0, 2, 1).To4()}
+	data, err = Token(subject)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tok, err := token.Parse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed, ok := tok.(token.SubjectToken64bit); !ok || parsed.TerminalPortID != subject.TerminalPortID ||
+		parsed.ProcessID != subject.ProcessID || !parsed.TerminalMachineAddress.Equal(subject.TerminalMachineAddress) {
+		t.Errorf("64 bit subject: got %+v, expected %+v", tok, subject)
+	}
+
+	header := token.ExpandedHeaderToken64bit{TokenID: 0x79, RecordByteCount: 34, VersionNumber: 11,
+		EventType: 23, EventModifier: 1, AddressType: 4, MachineAddress: net.IPv4(192, 0, 2, 1).To4(),
+		Seconds: 1 << 33, NanoSeconds: 500}
+	data, err = Token(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != 34 {
+		t.Errorf("expanded 64 bit header: got %d bytes, expected 34", len(data))
+	}
+	tok, err = token.Parse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed, ok := tok.(token.ExpandedHeaderToken64bit); !ok || parsed.VersionNumber != header.VersionNumber ||
+		parsed.EventType != header.EventType || parsed.EventModifier != header.EventModifier ||
+		parsed.AddressType != header.AddressType || !parsed.MachineAddress.Equal(header.MachineAddress) ||
+		parsed.Seconds != header.Seconds || parsed.NanoSeconds != header.NanoSeconds {
+		t.Errorf("expanded 64 bit header: got %+v, expected %+v", tok, header)
+	}
+
+	if _, err := Token(token.SystemVIpcToken{}); err == nil {
+		t.Error("expected error for unsupported token")
+	}
+}
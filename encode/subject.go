@@ -0,0 +1,40 @@
+package encode
+
+import (
+	"net"
+	"os"
+
+	"github.com/tpltnt/go-bsm/auditsys"
+	"github.com/tpltnt/go-bsm/decode"
+	"github.com/tpltnt/go-bsm/token"
+)
+
+// CurrentSubjectToken returns a subject token describing the calling
+// process, e.g. to emit records on behalf of a daemon. The audit user ID,
+// session and terminal are read with auditsys.GetSession. If they are
+// not available (other systems, kernels without audit support or
+// insufficient privileges) the token holds DefaultAuditID, session 0 and
+// an unspecified terminal.
+func CurrentSubjectToken() token.ExpandedSubjectToken32bit {
+	session, err := auditsys.GetSession()
+	if err != nil {
+		session = auditsys.Session{AuditID: decode.DefaultAuditID}
+	}
+	address := session.Terminal.Address
+	if address == nil {
+		address = net.IPv4zero.To4()
+	}
+	return token.ExpandedSubjectToken32bit{
+		TokenID:                0x7a,
+		AuditID:                session.AuditID,
+		EffectiveUserID:        uint32(os.Geteuid()),
+		EffectiveGroupID:       uint32(os.Getegid()),
+		RealUserID:             uint32(os.Getuid()),
+		RealGroupID:            uint32(os.Getgid()),
+		ProcessID:              uint32(os.Getpid()),
+		SessionID:              uint32(session.SessionID),
+		TerminalPortID:         uint32(session.Terminal.Port), // truncated like au_to_subject32_ex(3)
+		TerminalAddressLength:  uint32(len(address)),
+		TerminalMachineAddress: address,
+	}
+}
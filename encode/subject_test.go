@@ -0,0 +1,37 @@
+package encode
+
+import (
+	"os"
+	"testing"
+
+	"github.com/tpltnt/go-bsm/token"
+)
+
+func TestCurrentSubjectToken(t *testing.T) {
+	subject := CurrentSubjectToken()
+	if subject.ProcessID != uint32(os.Getpid()) {
+		t.Errorf("process ID: got %d, expected %d", subject.ProcessID, os.Getpid())
+	}
+	if subject.RealUserID != uint32(os.Getuid()) || subject.EffectiveUserID != uint32(os.Geteuid()) {
+		t.Error("wrong user IDs")
+	}
+	if int(subject.TerminalAddressLength) != len(subject.TerminalMachineAddress) {
+		t.Errorf("address length %d doesn't match address %v", subject.TerminalAddressLength, subject.TerminalMachineAddress)
+	}
+
+	data, err := AppendToken(nil, subject)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tok, err := token.Parse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, ok := tok.(token.ExpandedSubjectToken32bit)
+	if !ok {
+		t.Fatalf("unexpected token %T", tok)
+	}
+	if parsed.AuditID != subject.AuditID || parsed.ProcessID != subject.ProcessID || !parsed.TerminalMachineAddress.Equal(subject.TerminalMachineAddress) {
+		t.Errorf("round trip: got %+v, expected %+v", parsed, subject)
+	}
+}
@@ -0,0 +1,17 @@
+package encode
+
+import (
+	"github.com/tpltnt/go-bsm/auditsys"
+	"github.com/tpltnt/go-bsm/decode"
+)
+
+// Submit commits the given record to the system audit trail (see
+// auditsys.Audit). Records emitted on behalf of the calling process
+// usually hold a CurrentSubjectToken and a return token.
+func Submit(rec *decode.BsmRecord) error {
+	data, err := Record(rec)
+	if err != nil {
+		return err
+	}
+	return auditsys.Audit(data)
+}
@@ -0,0 +1,113 @@
+package bsm
+
+import "github.com/tpltnt/go-bsm/token"
+
+// EventType is the event type of a record, see token.EventType.
+type EventType = token.EventType
+
+// Event types, see package token.
+const (
+	AUE_EXIT           = token.AUE_EXIT
+	AUE_FORK           = token.AUE_FORK
+	AUE_OPEN           = token.AUE_OPEN
+	AUE_CREAT          = token.AUE_CREAT
+	AUE_LINK           = token.AUE_LINK
+	AUE_UNLINK         = token.AUE_UNLINK
+	AUE_EXEC           = token.AUE_EXEC
+	AUE_CHDIR          = token.AUE_CHDIR
+	AUE_MKNOD          = token.AUE_MKNOD
+	AUE_CHMOD          = token.AUE_CHMOD
+	AUE_CHOWN          = token.AUE_CHOWN
+	AUE_UMOUNT         = token.AUE_UMOUNT
+	AUE_ACCESS         = token.AUE_ACCESS
+	AUE_KILL           = token.AUE_KILL
+	AUE_STAT           = token.AUE_STAT
+	AUE_LSTAT          = token.AUE_LSTAT
+	AUE_ACCT           = token.AUE_ACCT
+	AUE_REBOOT         = token.AUE_REBOOT
+	AUE_SYMLINK        = token.AUE_SYMLINK
+	AUE_READLINK       = token.AUE_READLINK
+	AUE_EXECVE         = token.AUE_EXECVE
+	AUE_CHROOT         = token.AUE_CHROOT
+	AUE_VFORK          = token.AUE_VFORK
+	AUE_SETGROUPS      = token.AUE_SETGROUPS
+	AUE_SETPGRP        = token.AUE_SETPGRP
+	AUE_SWAPON         = token.AUE_SWAPON
+	AUE_SETHOSTNAME    = token.AUE_SETHOSTNAME
+	AUE_FCNTL          = token.AUE_FCNTL
+	AUE_SETPRIORITY    = token.AUE_SETPRIORITY
+	AUE_CONNECT        = token.AUE_CONNECT
+	AUE_ACCEPT         = token.AUE_ACCEPT
+	AUE_BIND           = token.AUE_BIND
+	AUE_SETSOCKOPT     = token.AUE_SETSOCKOPT
+	AUE_SETTIMEOFDAY   = token.AUE_SETTIMEOFDAY
+	AUE_FCHOWN         = token.AUE_FCHOWN
+	AUE_FCHMOD         = token.AUE_FCHMOD
+	AUE_SETREUID       = token.AUE_SETREUID
+	AUE_SETREGID       = token.AUE_SETREGID
+	AUE_RENAME         = token.AUE_RENAME
+	AUE_TRUNCATE       = token.AUE_TRUNCATE
+	AUE_FTRUNCATE      = token.AUE_FTRUNCATE
+	AUE_FLOCK          = token.AUE_FLOCK
+	AUE_SHUTDOWN       = token.AUE_SHUTDOWN
+	AUE_MKDIR          = token.AUE_MKDIR
+	AUE_RMDIR          = token.AUE_RMDIR
+	AUE_UTIMES         = token.AUE_UTIMES
+	AUE_ADJTIME        = token.AUE_ADJTIME
+	AUE_SETRLIMIT      = token.AUE_SETRLIMIT
+	AUE_KILLPG         = token.AUE_KILLPG
+	AUE_STATFS         = token.AUE_STATFS
+	AUE_FSTATFS        = token.AUE_FSTATFS
+	AUE_UNMOUNT        = token.AUE_UNMOUNT
+	AUE_QUOTACTL       = token.AUE_QUOTACTL
+	AUE_MOUNT          = token.AUE_MOUNT
+	AUE_FCHDIR         = token.AUE_FCHDIR
+	AUE_FCHROOT        = token.AUE_FCHROOT
+	AUE_PATHCONF       = token.AUE_PATHCONF
+	AUE_OPEN_R         = token.AUE_OPEN_R
+	AUE_OPEN_RC        = token.AUE_OPEN_RC
+	AUE_OPEN_RT        = token.AUE_OPEN_RT
+	AUE_OPEN_RTC       = token.AUE_OPEN_RTC
+	AUE_OPEN_W         = token.AUE_OPEN_W
+	AUE_OPEN_WC        = token.AUE_OPEN_WC
+	AUE_OPEN_WT        = token.AUE_OPEN_WT
+	AUE_OPEN_WTC       = token.AUE_OPEN_WTC
+	AUE_OPEN_RW        = token.AUE_OPEN_RW
+	AUE_OPEN_RWC       = token.AUE_OPEN_RWC
+	AUE_OPEN_RWT       = token.AUE_OPEN_RWT
+	AUE_OPEN_RWTC      = token.AUE_OPEN_RWTC
+	AUE_SETUID         = token.AUE_SETUID
+	AUE_SETGID         = token.AUE_SETGID
+	AUE_SETEGID        = token.AUE_SETEGID
+	AUE_SETEUID        = token.AUE_SETEUID
+	AUE_login          = token.AUE_login
+	AUE_logout         = token.AUE_logout
+	AUE_ssh            = token.AUE_ssh
+	AUE_openssh        = token.AUE_openssh
+	AUE_audit_startup  = token.AUE_audit_startup
+	AUE_audit_shutdown = token.AUE_audit_shutdown
+	AUE_LINKAT         = token.AUE_LINKAT
+	AUE_RENAMEAT       = token.AUE_RENAMEAT
+	AUE_SESSION_START  = token.AUE_SESSION_START
+	AUE_SESSION_UPDATE = token.AUE_SESSION_UPDATE
+	AUE_SESSION_END    = token.AUE_SESSION_END
+	AUE_SESSION_CLOSE  = token.AUE_SESSION_CLOSE
+
+	AUE_modify_password   = token.AUE_modify_password
+	AUE_create_group      = token.AUE_create_group
+	AUE_delete_group      = token.AUE_delete_group
+	AUE_modify_group      = token.AUE_modify_group
+	AUE_add_to_group      = token.AUE_add_to_group
+	AUE_remove_from_group = token.AUE_remove_from_group
+	AUE_revoke_obj        = token.AUE_revoke_obj
+	AUE_lw_login          = token.AUE_lw_login
+	AUE_lw_logout         = token.AUE_lw_logout
+	AUE_auth_user         = token.AUE_auth_user
+	AUE_ssconn            = token.AUE_ssconn
+	AUE_ssauthorize       = token.AUE_ssauthorize
+	AUE_ssauthint         = token.AUE_ssauthint
+	AUE_calife            = token.AUE_calife
+	AUE_sudo              = token.AUE_sudo
+	AUE_audit_recovery    = token.AUE_audit_recovery
+	AUE_ssauthmech        = token.AUE_ssauthmech
+)
@@ -0,0 +1,114 @@
+package bsm
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Annotations of the records split by SplitExecArgRecord and flattened
+// by FlattenExecArgs.
+const (
+	ExecArgIndexAnnotation   = "exec.arg.index"   // position of the argument, 0 is the program
+	ExecArgCountAnnotation   = "exec.arg.count"   // number of arguments of the exec
+	ExecArgsJoinedAnnotation = "exec.args_joined" // arguments separated by spaces
+	ExecArgsOffsetAnnotation = "exec.args_offset" // comma separated byte offsets of the arguments in exec.args_joined
+)
+
+// execArgs returns the index of the exec args token of rec, -1 if there
+// is none.
+func execArgs(rec *BsmRecord) int {
+	for i, tok := range rec.Tokens {
+		if _, ok := tok.(ExecArgsToken); ok {
+			return i
+		}
+	}
+	return -1
+}
+
+// SplitExecArgRecord returns a record per argument of the exec args
+// token of rec, holding all other tokens of rec and an exec args token
+// with only that argument, annotated with its position (see
+// ExecArgIndexAnnotation). This allows detections on specific
+// positions, e.g. "-e" as second argument of bash. Records without
+// exec args are returned unchanged, records with an empty exec args
+// token are returned unchanged but annotated with a count of 0.
+func SplitExecArgRecord(rec *BsmRecord) []BsmRecord {
+	index := execArgs(rec)
+	if index < 0 {
+		return []BsmRecord{*rec}
+	}
+	args := rec.Tokens[index].(ExecArgsToken)
+	count := strconv.Itoa(len(args.Text))
+	if len(args.Text) == 0 {
+		out := *rec
+		out.Annotations = copyAnnotations(rec.Annotations)
+		out.Annotate(ExecArgCountAnnotation, count)
+		return []BsmRecord{out}
+	}
+
+	split := make([]BsmRecord, len(args.Text))
+	for n, arg := range args.Text {
+		out := *rec
+		out.Tokens = make([]Token, len(rec.Tokens))
+		copy(out.Tokens, rec.Tokens)
+		out.Tokens[index] = ExecArgsToken{TokenID: args.TokenID, Count: 1, Text: []string{arg}}
+		out.Annotations = copyAnnotations(rec.Annotations)
+		out.Annotate(ExecArgIndexAnnotation, strconv.Itoa(n))
+		out.Annotate(ExecArgCountAnnotation, count)
+		split[n] = out
+	}
+	return split
+}
+
+// copyAnnotations returns a copy of the given annotations with room for
+// those added by SplitExecArgRecord.
+func copyAnnotations(annotations map[string]string) map[string]string {
+	c := make(map[string]string, len(annotations)+2)
+	for k, v := range annotations {
+		c[k] = v
+	}
+	return c
+}
+
+// SplitExecArgs yields the records of the given stream split with
+// SplitExecArgRecord. Parsing errors are passed on unchanged.
+func SplitExecArgs(in chan ParsingResult) chan ParsingResult {
+	out := make(chan ParsingResult)
+
+	go func() {
+		for res := range in {
+			if res.Error != nil {
+				out <- res
+				continue
+			}
+			for _, rec := range SplitExecArgRecord(&res.Record) {
+				out <- ParsingResult{Record: rec, Issues: res.Issues}
+			}
+		}
+		close(out)
+	}()
+
+	return out
+}
+
+// FlattenExecArgs is a transformation (see Transform) which annotates
+// records with exec args with the arguments joined by spaces and the
+// offsets of the arguments within, e.g. "bash -e x" and "0,5,8", so
+// column stores can match arguments by position without arrays.
+func FlattenExecArgs(rec *BsmRecord) error {
+	index := execArgs(rec)
+	if index < 0 {
+		return nil
+	}
+	args := rec.Tokens[index].(ExecArgsToken).Text
+	offsets := make([]string, len(args))
+	offset := 0
+	for i, arg := range args {
+		offsets[i] = strconv.Itoa(offset)
+		offset += len(arg) + 1
+	}
+	rec.Annotate(ExecArgsJoinedAnnotation, strings.Join(args, " "))
+	rec.Annotate(ExecArgsOffsetAnnotation, strings.Join(offsets, ","))
+	rec.Annotate(ExecArgCountAnnotation, strconv.Itoa(len(args)))
+	return nil
+}
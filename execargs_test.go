@@ -0,0 +1,94 @@
+package bsm
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestSplitExecArgs(t *testing.T) {
+	exec := BsmRecord{
+		EventType:   uint16(AUE_EXECVE),
+		Annotations: map[string]string{"host": "a"},
+		Tokens: []Token{
+			ExecArgsToken{TokenID: 0x3c, Count: 3, Text: []string{"bash", "-e", "id"}},
+			PathToken{Path: "/bin/bash"},
+			ReturnToken32bit{},
+		},
+	}
+	in := make(chan ParsingResult, 2)
+	in <- ParsingResult{Record: exec}
+	in <- ParsingResult{Record: BsmRecord{EventType: uint16(AUE_EXIT), Tokens: []Token{ReturnToken32bit{}}}}
+	close(in)
+
+	var got []BsmRecord
+	for res := range SplitExecArgs(in) {
+		got = append(got, res.Record)
+	}
+	if len(got) != 4 {
+		t.Fatalf("got %d records, expected 4", len(got))
+	}
+	for i, want := range []string{"bash", "-e", "id"} {
+		rec := got[i]
+		args, ok := rec.Tokens[0].(ExecArgsToken)
+		if !ok || len(args.Text) != 1 || args.Text[0] != want || len(rec.Tokens) != 3 {
+			t.Errorf("record %d: unexpected tokens %v", i, rec.Tokens)
+		}
+		if index, _ := rec.Annotation(ExecArgIndexAnnotation); index != strconv.Itoa(i) {
+			t.Errorf("record %d: got index %q", i, index)
+		}
+		if count, _ := rec.Annotation(ExecArgCountAnnotation); count != "3" {
+			t.Errorf("record %d: got count %q", i, count)
+		}
+		if host, _ := rec.Annotation("host"); host != "a" {
+			t.Errorf("record %d: annotations not copied", i)
+		}
+	}
+	if len(exec.Tokens[0].(ExecArgsToken).Text) != 3 {
+		t.Error("original record changed")
+	}
+	if _, ok := got[3].Annotation(ExecArgIndexAnnotation); ok {
+		t.Error("record without exec args changed:", got[3])
+	}
+}
+
+func TestSplitExecArgsEmpty(t *testing.T) {
+	exec := BsmRecord{
+		EventType:   uint16(AUE_EXECVE),
+		Annotations: map[string]string{"host": "a"},
+		Tokens:      []Token{ExecArgsToken{TokenID: 0x3c}, ReturnToken32bit{}},
+	}
+	got := SplitExecArgRecord(&exec)
+	if len(got) != 1 || len(got[0].Tokens) != 2 {
+		t.Fatal("unexpected records:", got)
+	}
+	if count, _ := got[0].Annotation(ExecArgCountAnnotation); count != "0" {
+		t.Errorf("got count %q", count)
+	}
+	if _, ok := got[0].Annotation(ExecArgIndexAnnotation); ok {
+		t.Error("index of missing argument annotated")
+	}
+	if host, _ := got[0].Annotation("host"); host != "a" {
+		t.Error("annotations not copied")
+	}
+	if _, ok := exec.Annotation(ExecArgCountAnnotation); ok {
+		t.Error("original record changed")
+	}
+}
+
+func TestFlattenExecArgs(t *testing.T) {
+	rec := BsmRecord{Tokens: []Token{ExecArgsToken{Text: []string{"bash", "-e", "echo hi"}}}}
+	if err := FlattenExecArgs(&rec); err != nil {
+		t.Fatal(err)
+	}
+	if joined, _ := rec.Annotation(ExecArgsJoinedAnnotation); joined != "bash -e echo hi" {
+		t.Errorf("got joined args %q", joined)
+	}
+	if offsets, _ := rec.Annotation(ExecArgsOffsetAnnotation); offsets != "0,5,8" {
+		t.Errorf("got offsets %q", offsets)
+	}
+
+	empty := BsmRecord{Tokens: []Token{ReturnToken32bit{}}}
+	if err := FlattenExecArgs(&empty); err != nil || empty.Annotations != nil {
+		t.Error("record without exec args annotated:", empty.Annotations)
+	}
+}
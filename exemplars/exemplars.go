@@ -0,0 +1,73 @@
+// Package exemplars provides small, synthetic BSM byte sequences for
+// the tokens this module can encode and parse and for records as
+// written by every dialect, e.g. as fixtures for tests of programs
+// processing trails.
+// They were built with package encode and hold no data of real systems,
+// so they can be shared freely.
+//
+// The embedded files are regenerated with
+//
+//	go test ./exemplars -update
+package exemplars
+
+import (
+	"embed"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/tpltnt/go-bsm/token"
+)
+
+//go:embed data
+var data embed.FS
+
+// Exemplar is a named byte sequence.
+type Exemplar struct {
+	Name    string        // token name (see token.Name) or dialect name
+	Dialect token.Dialect // dialect the data is written in
+	Data    []byte
+}
+
+// dialects are the dialects records are provided for.
+var dialects = []token.Dialect{token.DialectDarwin, token.DialectFreeBSD, token.DialectSolaris, token.DialectLinux}
+
+// Tokens returns a single token of every type (and address family)
+// supported, ordered by name. Names of variants carry a suffix, e.g.
+// "subject32_ex.ipv6".
+func Tokens() []Exemplar {
+	entries, _ := fs.ReadDir(data, "data/tokens")
+	var tokens []Exemplar
+	for _, e := range entries {
+		name := strings.TrimSuffix(e.Name(), ".bsm")
+		buf, _ := Token(name)
+		tokens = append(tokens, Exemplar{Name: name, Data: buf})
+	}
+	sort.Slice(tokens, func(i, j int) bool { return tokens[i].Name < tokens[j].Name })
+	return tokens
+}
+
+// Token returns the token of the given name.
+func Token(name string) ([]byte, bool) {
+	buf, err := data.ReadFile(path.Join("data/tokens", name+".bsm"))
+	return buf, err == nil
+}
+
+// Records returns a typical record (header, subject, path, attribute,
+// return and trailer, plus a zonename on Solaris) of every dialect.
+func Records() []Exemplar {
+	var records []Exemplar
+	for _, d := range dialects {
+		if buf, ok := Record(d); ok {
+			records = append(records, Exemplar{Name: d.String(), Dialect: d, Data: buf})
+		}
+	}
+	return records
+}
+
+// Record returns the record written in the given dialect.
+func Record(d token.Dialect) ([]byte, bool) {
+	buf, err := data.ReadFile(path.Join("data/records", d.String()+".bsm"))
+	return buf, err == nil
+}
@@ -0,0 +1,156 @@
+package exemplars
+
+import (
+	"bytes"
+	"encoding/binary"
+	"flag"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tpltnt/go-bsm/decode"
+	"github.com/tpltnt/go-bsm/encode"
+	"github.com/tpltnt/go-bsm/token"
+)
+
+var update = flag.Bool("update", false, "regenerate the embedded exemplars")
+
+var (
+	ipv4 = net.IPv4(192, 0, 2, 1).To4()
+	ipv6 = net.ParseIP("2001:db8::1")
+)
+
+// tokenExemplars are the tokens written to data/tokens, i.e. those
+// which can be encoded and parsed.
+var tokenExemplars = map[string]token.Token{
+	"file":              token.FileToken{Seconds: 1600000000, PathName: "/var/audit/20200913120000.not_terminated"},
+	"trailer":           token.TrailerToken{RecordByteCount: 25},
+	"header32":          token.HeaderToken32bit{RecordByteCount: 25, VersionNumber: 11, EventType: 45029, Seconds: 1600000000},
+	"header64":          token.HeaderToken64bit{RecordByteCount: 33, VersionNumber: 11, EventType: 45029, Seconds: 1600000000},
+	"path":              token.PathToken{Path: "/etc/passwd"},
+	"text":              token.TextToken{Text: "auditd::Audit startup"},
+	"zonename":          token.ZonenameToken{Zonename: "global"},
+	"subject32":         token.SubjectToken32bit{AuditID: 1001, EffectiveUserID: 0, RealUserID: 1001, RealGroupID: 20, ProcessID: 4242, SessionID: 100, TerminalMachineAddress: ipv4},
+	"subject64":         token.SubjectToken64bit{AuditID: 1001, EffectiveUserID: 0, RealUserID: 1001, RealGroupID: 20, ProcessID: 4242, SessionID: 100, TerminalMachineAddress: ipv4},
+	"subject32_ex":      token.ExpandedSubjectToken32bit{AuditID: 1001, RealUserID: 1001, ProcessID: 4242, TerminalMachineAddress: ipv4},
+	"subject32_ex.ipv6": token.ExpandedSubjectToken32bit{AuditID: 1001, RealUserID: 1001, ProcessID: 4242, TerminalMachineAddress: ipv6},
+	"process32_ex":      token.ExpandedProcessToken32bit{AuditID: 1001, RealUserID: 1001, ProcessID: 4343, TerminalMachineAddress: ipv6},
+	"return32":          token.ReturnToken32bit{ErrorNumber: 2, ReturnValue: 0xffffffff},
+	"return64":          token.ReturnToken64bit{ReturnValue: 3},
+	"arg32":             token.ArgToken32bit{ArgumentID: 2, ArgumentValue: 0o644, Text: "new file mode"},
+	"arg64":             token.ArgToken64bit{ArgumentID: 1, ArgumentValue: 0x7fff0000, Text: "addr"},
+	"iport":             token.IPortToken{PortNumber: 22},
+	"seq":               token.SeqToken{SequenceNumber: 7},
+	"exec_args":         token.ExecArgsToken{Text: []string{"/bin/ls", "-l"}},
+	"exec_env":          token.ExecEnvToken{Text: []string{"HOME=/root", "LANG=C"}},
+	"attribute32":       token.AttributeToken32bit{FileAccessMode: 0o100644, FileSystemID: 89, FileSystemNodeID: 4711, Device: 0x1234},
+	"attribute64":       token.AttributeToken64bit{FileAccessMode: 0o100644, FileSystemID: 89, FileSystemNodeID: 4711, Device: 0x1234},
+	"exit":              token.ExitToken{Status: 1, ReturnValue: 0},
+}
+
+// build returns the exemplar files by their path below data.
+func build() (map[string][]byte, error) {
+	files := map[string][]byte{}
+	for name, tok := range tokenExemplars {
+		buf, err := encode.Token(tok)
+		if err != nil {
+			return nil, err
+		}
+		files["tokens/"+name+".bsm"] = buf
+	}
+
+	for _, d := range dialects {
+		rec := decode.BsmRecord{
+			Version:   11,
+			EventType: 72, // AUE_OPEN_R
+			Seconds:   1600000000,
+			Tokens: []token.Token{
+				token.ExpandedSubjectToken32bit{AuditID: 1001, EffectiveUserID: 1001, RealUserID: 1001, ProcessID: 4242, TerminalMachineAddress: ipv6},
+				token.PathToken{Path: "/etc/passwd"},
+				token.AttributeToken32bit{FileAccessMode: 0o100644, FileSystemID: 89, FileSystemNodeID: 4711, Device: 0x1234},
+				token.ReturnToken32bit{},
+			},
+		}
+		if d == token.DialectSolaris {
+			rec.Tokens = append(rec.Tokens, token.ZonenameToken{Zonename: "global"})
+		}
+		buf, err := encode.Record(&rec)
+		if err != nil {
+			return nil, err
+		}
+		if d == token.DialectLinux {
+			// terminal address type as AU_IPv6 instead of its length
+			binary.BigEndian.PutUint32(buf[18+33:], 2)
+		}
+		files["records/"+d.String()+".bsm"] = buf
+	}
+	return files, nil
+}
+
+func TestEmbedded(t *testing.T) {
+	files, err := build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, buf := range files {
+		if *update {
+			path := filepath.Join("data", name)
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, buf, 0644); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		embedded, err := data.ReadFile("data/" + name)
+		if err != nil || !bytes.Equal(embedded, buf) {
+			t.Errorf("%s is outdated, run go test -update", name)
+		}
+	}
+}
+
+func TestTokens(t *testing.T) {
+	tokens := Tokens()
+	if len(tokens) != len(tokenExemplars) {
+		t.Errorf("got %d tokens, expected %d", len(tokens), len(tokenExemplars))
+	}
+	for _, e := range tokens {
+		tok, err := token.Parse(e.Data)
+		if err != nil {
+			t.Errorf("%s: %v", e.Name, err)
+			continue
+		}
+		if name := strings.Split(e.Name, ".")[0]; token.Name(tok) != name {
+			t.Errorf("%s: parsed as %s", e.Name, token.Name(tok))
+		}
+	}
+	if _, ok := Token("subject32"); !ok {
+		t.Error("subject32 not found")
+	}
+}
+
+func TestRecords(t *testing.T) {
+	records := Records()
+	if len(records) != len(dialects) {
+		t.Errorf("got %d records, expected %d", len(records), len(dialects))
+	}
+	for _, e := range records {
+		decoder := decode.NewDecoder(bytes.NewReader(e.Data))
+		decoder.Dialect = e.Dialect
+		rec, err := decoder.Decode()
+		if err != nil {
+			t.Errorf("%s: %v", e.Name, err)
+			continue
+		}
+		if p, _ := rec.Path(); p != "/etc/passwd" {
+			t.Errorf("%s: got path %q", e.Name, p)
+		}
+		if _, err := decoder.Decode(); err != io.EOF {
+			t.Errorf("%s: expected a single record, got %v", e.Name, err)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package filter
+
+import (
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/tpltnt/go-bsm/decode"
+	"github.com/tpltnt/go-bsm/token"
+)
+
+// dedupeEntry holds the keys of a record in the dedupe window.
+type dedupeEntry struct {
+	hasSeq bool
+	seq    uint32
+	hash   [sha256.Size]byte
+}
+
+// Deduper drops records which were already seen within a window of the
+// most recent records. Two records are considered equal if they carry
+// the same 'seq' token number or if their content is the same. This is
+// needed for trails shipped over at-least-once transports or merged
+// from overlapping archive files.
+// NOTE: sequence numbers are only unique per host, so don't feed
+// records of several hosts into the same Deduper.
+type Deduper struct {
+	window  []dedupeEntry
+	next    int // index of the next entry to replace
+	full    bool
+	seqs    map[uint32]int
+	hashes  map[[sha256.Size]byte]int
+	Dropped uint64 // number of duplicates dropped so far
+}
+
+// NewDeduper returns a deduper remembering the given number of records.
+func NewDeduper(window int) *Deduper {
+	if window < 1 {
+		window = 1
+	}
+	return &Deduper{
+		window: make([]dedupeEntry, window),
+		seqs:   map[uint32]int{},
+		hashes: map[[sha256.Size]byte]int{},
+	}
+}
+
+// dedupeEntryBytes is the estimated memory used per record of the
+// window: the entry and its keys in both maps.
+const dedupeEntryBytes = 160
+
+// Len returns the number of records remembered.
+func (d *Deduper) Len() int {
+	if d.full {
+		return len(d.window)
+	}
+	return d.next
+}
+
+// Cap returns the size of the window.
+func (d *Deduper) Cap() int {
+	return len(d.window)
+}
+
+// MaxBytes returns an estimate of the memory the Deduper holds once the
+// window is full.
+func (d *Deduper) MaxBytes() int64 {
+	return int64(len(d.window)) * dedupeEntryBytes
+}
+
+// contentHash hashes all decoded information of the record (in
+// canonical form).
+func contentHash(rec *decode.BsmRecord) [sha256.Size]byte {
+	h := sha256.New()
+	fmt.Fprintf(h, "%d.%d/%d/%d/%d", rec.Seconds, rec.NanoSeconds, rec.Version, rec.EventType, rec.EventModifier)
+	canonical := *rec
+	decode.Canonicalize(&canonical)
+	for _, tok := range canonical.Tokens {
+		fmt.Fprintf(h, "|%#v", tok)
+	}
+	var sum [sha256.Size]byte
+	copy(sum[:], h.Sum(nil))
+	return sum
+}
+
+// Keep reports whether the record is new (i.e. no duplicate) and
+// remembers it. It can be used as a Filter.
+func (d *Deduper) Keep(rec *decode.BsmRecord) bool {
+	entry := dedupeEntry{hash: contentHash(rec)}
+	for _, tok := range rec.Tokens {
+		if seq, ok := tok.(token.SeqToken); ok {
+			entry.hasSeq = true
+			entry.seq = seq.SequenceNumber
+			break
+		}
+	}
+
+	duplicate := d.hashes[entry.hash] > 0
+	if entry.hasSeq && d.seqs[entry.seq] > 0 {
+		duplicate = true
+	}
+	if duplicate {
+		d.Dropped += 1
+		return false
+	}
+
+	// forget the oldest record
+	if d.full {
+		old := d.window[d.next]
+		if old.hasSeq {
+			d.seqs[old.seq] -= 1
+			if d.seqs[old.seq] == 0 {
+				delete(d.seqs, old.seq)
+			}
+		}
+		d.hashes[old.hash] -= 1
+		if d.hashes[old.hash] == 0 {
+			delete(d.hashes, old.hash)
+		}
+	}
+
+	d.window[d.next] = entry
+	d.next = (d.next + 1) % len(d.window)
+	if d.next == 0 {
+		d.full = true
+	}
+	if entry.hasSeq {
+		d.seqs[entry.seq] += 1
+	}
+	d.hashes[entry.hash] += 1
+	return true
+}
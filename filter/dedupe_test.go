@@ -0,0 +1,51 @@
+package filter
+
+import (
+	"testing"
+
+	"github.com/tpltnt/go-bsm/decode"
+	"github.com/tpltnt/go-bsm/token"
+)
+
+func TestDeduper(t *testing.T) {
+	record := func(seq uint32, text string) decode.BsmRecord {
+		return decode.BsmRecord{
+			Seconds: 1520091878,
+			Tokens: []token.Token{
+				token.TextToken{TokenID: 0x28, Text: text},
+				token.SeqToken{TokenID: 0x2f, SequenceNumber: seq},
+			},
+		}
+	}
+	noSeq := decode.BsmRecord{Seconds: 1520091878, Tokens: []token.Token{token.TextToken{TokenID: 0x28, Text: "no seq"}}}
+
+	deduper := NewDeduper(3)
+	testData := []struct {
+		rec  decode.BsmRecord
+		keep bool
+	}{
+		{record(1, "a"), true},
+		{record(1, "a"), false}, // exact duplicate
+		{record(1, "b"), false}, // same sequence number
+		{record(2, "b"), true},
+		{noSeq, true},
+		{noSeq, false}, // same content
+		{record(3, "c"), true},
+		{record(4, "d"), true},
+		{record(1, "a"), true}, // fell out of the window
+	}
+	for i, entry := range testData {
+		if keep := deduper.Keep(&entry.rec); keep != entry.keep {
+			t.Errorf("record %d: expected %v, got %v", i, entry.keep, keep)
+		}
+	}
+	if deduper.Dropped != 3 {
+		t.Error("unexpected number of dropped records:", deduper.Dropped)
+	}
+	if deduper.Len() != 3 || deduper.Cap() != 3 || len(deduper.hashes) != 3 {
+		t.Errorf("window holds %d records (%d hashes)", deduper.Len(), len(deduper.hashes))
+	}
+	if NewDeduper(2).Len() != 0 {
+		t.Error("empty deduper not empty")
+	}
+}
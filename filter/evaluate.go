@@ -0,0 +1,70 @@
+package filter
+
+import (
+	"io"
+
+	"github.com/tpltnt/go-bsm/decode"
+)
+
+// EvaluationSamples is the number of sample records EvaluateFilters
+// keeps per rule.
+var EvaluationSamples = 5
+
+// RuleEvaluation holds the result of a single rule of EvaluateFilters.
+type RuleEvaluation struct {
+	Name    string             // name of the rule
+	Matches int                // number of matched records
+	Samples []decode.BsmRecord // the first matched records
+}
+
+// FilterReport is the result of EvaluateFilters.
+type FilterReport struct {
+	Records   int              // number of records evaluated
+	Unmatched int              // number of records no rule matched
+	Rules     []RuleEvaluation // results in the order of the rules
+}
+
+// EvaluateFilters runs the given rules against all records of a trail
+// without shipping anything, e.g. to validate new selection rules
+// against historical data. On a decoding error, the report up to the
+// failed record is returned along with the error.
+func EvaluateFilters(trail io.Reader, rules []Rule) (*FilterReport, error) {
+	rs := CompileRules(rules)
+	report := &FilterReport{Rules: make([]RuleEvaluation, len(rules))}
+	index := map[string][]int{}
+	for i, rule := range rules {
+		report.Rules[i].Name = rule.Name
+		index[rule.Name] = append(index[rule.Name], i)
+	}
+
+	decoder := decode.NewDecoder(trail)
+	for {
+		rec, err := decoder.Decode()
+		if err == io.EOF {
+			return report, nil
+		}
+		if err != nil {
+			return report, err
+		}
+		report.Records += 1
+
+		names := rs.Match(&rec)
+		if len(names) == 0 {
+			report.Unmatched += 1
+		}
+		seen := map[int]bool{}
+		for _, name := range names {
+			for _, i := range index[name] {
+				if seen[i] {
+					continue
+				}
+				seen[i] = true
+				eval := &report.Rules[i]
+				eval.Matches += 1
+				if len(eval.Samples) < EvaluationSamples {
+					eval.Samples = append(eval.Samples, rec)
+				}
+			}
+		}
+	}
+}
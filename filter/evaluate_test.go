@@ -0,0 +1,41 @@
+package filter
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/tpltnt/go-bsm/decode"
+)
+
+func TestEvaluateFilters(t *testing.T) {
+	data, err := os.ReadFile("../start_stop.bsm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err := EvaluateFilters(bytes.NewReader(data), []Rule{
+		{Name: "startup", Events: []uint16{45000}},
+		{Name: "all"},
+		{Name: "none", Match: func(*decode.BsmRecord) bool { return false }},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Records != 2 || report.Unmatched != 0 {
+		t.Error("unexpected report:", report)
+	}
+	for i, want := range []int{1, 2, 0} {
+		if eval := report.Rules[i]; eval.Matches != want || len(eval.Samples) != want {
+			t.Errorf("unexpected evaluation of %s: %d matches, %d samples", eval.Name, eval.Matches, len(eval.Samples))
+		}
+	}
+	if report.Rules[0].Samples[0].EventType != 45000 {
+		t.Error("unexpected sample:", report.Rules[0].Samples[0])
+	}
+
+	// truncated trail
+	report, err = EvaluateFilters(bytes.NewReader(data[:70]), []Rule{{Name: "all"}})
+	if err == nil || report.Records != 1 {
+		t.Error("expected partial report and error:", report, err)
+	}
+}
@@ -0,0 +1,39 @@
+// Package filter selects, deduplicates and validates BSM records.
+package filter
+
+import (
+	"github.com/tpltnt/go-bsm/decode"
+	"github.com/tpltnt/go-bsm/token"
+)
+
+// Filter reports whether a record should be kept.
+type Filter func(rec *decode.BsmRecord) bool
+
+// FilterRecords yields all records of the given stream which are
+// accepted by the filter. Parsing errors are always passed on.
+func FilterRecords(in chan decode.ParsingResult, keep Filter) chan decode.ParsingResult {
+	out := make(chan decode.ParsingResult)
+
+	go func() {
+		for res := range in {
+			if res.Error == nil && !keep(&res.Record) {
+				continue
+			}
+			out <- res
+		}
+		close(out)
+	}()
+
+	return out
+}
+
+// Events returns a filter keeping the records of the given event types.
+func Events(types ...token.EventType) Filter {
+	wanted := make(map[token.EventType]bool, len(types))
+	for _, t := range types {
+		wanted[t] = true
+	}
+	return func(rec *decode.BsmRecord) bool {
+		return wanted[rec.Event()]
+	}
+}
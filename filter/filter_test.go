@@ -0,0 +1,18 @@
+package filter
+
+import (
+	"testing"
+
+	"github.com/tpltnt/go-bsm/decode"
+	"github.com/tpltnt/go-bsm/token"
+)
+
+func TestEvents(t *testing.T) {
+	keep := Events(token.AUE_EXECVE, token.AUE_CONNECT)
+	for event, want := range map[uint16]bool{23: true, 32: true, 72: false} {
+		rec := decode.BsmRecord{EventType: event}
+		if got := keep(&rec); got != want {
+			t.Errorf("event %d: got %v, expected %v", event, got, want)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package filter
+
+import (
+	"path"
+	"strings"
+
+	"github.com/tpltnt/go-bsm/decode"
+	"github.com/tpltnt/go-bsm/token"
+)
+
+// pathNode is a node of the path trie, i.e. a path component.
+type pathNode struct {
+	children map[string]*pathNode
+	exact    bool     // the path of the node is watched
+	prefix   bool     // the path of the node and everything below is watched
+	globs    []string // glob patterns whose literal components end here
+}
+
+func (n *pathNode) child(name string) *pathNode {
+	if n.children == nil {
+		n.children = map[string]*pathNode{}
+	}
+	c, ok := n.children[name]
+	if !ok {
+		c = &pathNode{}
+		n.children[name] = c
+	}
+	return c
+}
+
+// PathMatcher matches paths against a set of exact paths, directory
+// prefixes and glob patterns (see path.Match). The patterns are kept in
+// a trie of path components, so matching a path costs O(components)
+// instead of O(patterns). Only globs below the literal part of the path
+// are evaluated.
+type PathMatcher struct {
+	root pathNode
+}
+
+// NewPathMatcher returns an empty matcher.
+func NewPathMatcher() *PathMatcher {
+	return &PathMatcher{}
+}
+
+// components splits a cleaned absolute or relative path.
+func components(p string) []string {
+	p = strings.Trim(path.Clean(p), "/")
+	if p == "" || p == "." {
+		return nil
+	}
+	return strings.Split(p, "/")
+}
+
+func (m *PathMatcher) node(p string) *pathNode {
+	n := &m.root
+	for _, c := range components(p) {
+		n = n.child(c)
+	}
+	return n
+}
+
+// AddExact watches the given path.
+func (m *PathMatcher) AddExact(p string) {
+	m.node(p).exact = true
+}
+
+// AddPrefix watches the given directory and everything below it, e.g.
+// "/etc" matches "/etc" and "/etc/passwd", but not "/etcetera".
+func (m *PathMatcher) AddPrefix(dir string) {
+	m.node(dir).prefix = true
+}
+
+// AddGlob watches all paths matching the given glob pattern (see
+// path.Match), e.g. "/home/*/.ssh/authorized_keys".
+func (m *PathMatcher) AddGlob(pattern string) error {
+	pattern = path.Clean(pattern)
+	if _, err := path.Match(pattern, ""); err != nil {
+		return err
+	}
+	n := &m.root
+	for _, c := range components(pattern) {
+		if strings.ContainsAny(c, `*?[\`) {
+			break
+		}
+		n = n.child(c)
+	}
+	n.globs = append(n.globs, pattern)
+	return nil
+}
+
+// Match reports whether the path is watched.
+func (m *PathMatcher) Match(p string) bool {
+	p = path.Clean(p)
+	n := &m.root
+	for _, c := range components(p) {
+		if n.prefix || n.matchGlobs(p) {
+			return true
+		}
+		if n = n.children[c]; n == nil {
+			return false
+		}
+	}
+	return n.exact || n.prefix || n.matchGlobs(p)
+}
+
+func (n *pathNode) matchGlobs(p string) bool {
+	for _, glob := range n.globs {
+		if ok, _ := path.Match(glob, p); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Filter returns a filter keeping records with a path token whose path
+// is watched, e.g. as predicate of a Rule.
+func (m *PathMatcher) Filter() Filter {
+	return func(rec *decode.BsmRecord) bool {
+		for _, tok := range rec.Tokens {
+			if v, ok := tok.(token.PathToken); ok && m.Match(v.Path) {
+				return true
+			}
+		}
+		return false
+	}
+}
@@ -0,0 +1,62 @@
+package filter
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/tpltnt/go-bsm/decode"
+	"github.com/tpltnt/go-bsm/token"
+)
+
+func TestPathMatcher(t *testing.T) {
+	m := NewPathMatcher()
+	m.AddExact("/etc/passwd")
+	m.AddPrefix("/etc/ssh")
+	if err := m.AddGlob("/home/*/.ssh/authorized_keys"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.AddGlob("/home/[a"); err == nil {
+		t.Error("expected error for invalid glob")
+	}
+
+	for p, want := range map[string]bool{
+		"/etc/passwd":                      true,
+		"/etc//passwd":                     true,
+		"/etc/passwd.bak":                  false,
+		"/etc":                             false,
+		"/etc/ssh":                         true,
+		"/etc/ssh/sshd_config":             true,
+		"/etc/sshd":                        false,
+		"/home/alice/.ssh/authorized_keys": true,
+		"/home/alice/.ssh/known_hosts":     false,
+		"/var/log/auth.log":                false,
+	} {
+		if got := m.Match(p); got != want {
+			t.Errorf("Match(%q) = %v, want %v", p, got, want)
+		}
+	}
+}
+
+func TestPathMatcherFilter(t *testing.T) {
+	m := NewPathMatcher()
+	m.AddPrefix("/etc")
+	rules := CompileRules([]Rule{{Name: "fim", Match: m.Filter()}})
+	rec := decode.BsmRecord{Tokens: []token.Token{token.PathToken{Path: "/etc/hosts"}}}
+	if names := rules.Match(&rec); len(names) != 1 {
+		t.Error("unexpected match:", names)
+	}
+	rec.Tokens[0] = token.PathToken{Path: "/tmp/hosts"}
+	if names := rules.Match(&rec); len(names) != 0 {
+		t.Error("unexpected match:", names)
+	}
+}
+
+func BenchmarkPathMatcher(b *testing.B) {
+	m := NewPathMatcher()
+	for i := 0; i < 10000; i++ {
+		m.AddExact(fmt.Sprintf("/srv/app%d/config.yaml", i))
+	}
+	for i := 0; i < b.N; i++ {
+		m.Match("/srv/app9999/config.yaml")
+	}
+}
@@ -0,0 +1,97 @@
+package filter
+
+import (
+	"math/bits"
+
+	"github.com/tpltnt/go-bsm/decode"
+)
+
+// Rule is a named filter for a set of event types, e.g. the selection
+// rule of a single tenant.
+type Rule struct {
+	Name   string   // name reported on match
+	Events []uint16 // event types the rule applies to (all if empty)
+	Match  Filter   // additional predicate on the complete record (may be nil)
+}
+
+// RuleSet is a compiled set of rules. For every event type the rules
+// which may apply are precomputed as a bitmap, so a record is only ever
+// evaluated against those rules. Rules without predicate are decided
+// from the event type alone.
+type RuleSet struct {
+	rules      []Rule
+	candidates map[uint16][]uint64 // bitmap of applicable rules by event type
+	generic    []uint64            // bitmap of rules applicable to all event types
+}
+
+// CompileRules compiles the given rules into a RuleSet.
+func CompileRules(rules []Rule) *RuleSet {
+	words := (len(rules) + 63) / 64
+	rs := &RuleSet{
+		rules:      rules,
+		candidates: map[uint16][]uint64{},
+		generic:    make([]uint64, words),
+	}
+	for i, rule := range rules {
+		if len(rule.Events) == 0 {
+			rs.generic[i/64] |= 1 << uint(i%64)
+		}
+	}
+	for i, rule := range rules {
+		for _, event := range rule.Events {
+			bitmap, ok := rs.candidates[event]
+			if !ok {
+				bitmap = make([]uint64, words)
+				copy(bitmap, rs.generic)
+				rs.candidates[event] = bitmap
+			}
+			bitmap[i/64] |= 1 << uint(i%64)
+		}
+	}
+	return rs
+}
+
+// bitmap returns the rules applicable to the given event type.
+func (rs *RuleSet) bitmap(eventType uint16) []uint64 {
+	if bitmap, ok := rs.candidates[eventType]; ok {
+		return bitmap
+	}
+	return rs.generic
+}
+
+// Interested reports whether any rule applies to the event type. As only
+// the header is needed, it can be used to skip records early.
+func (rs *RuleSet) Interested(eventType uint16) bool {
+	for _, word := range rs.bitmap(eventType) {
+		if word != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Match returns the names of all rules matching the record.
+func (rs *RuleSet) Match(rec *decode.BsmRecord) []string {
+	var names []string
+	for w, word := range rs.bitmap(rec.EventType) {
+		for word != 0 {
+			i := w*64 + bits.TrailingZeros64(word)
+			word &= word - 1 // clear lowest bit
+			rule := &rs.rules[i]
+			if rule.Match == nil || rule.Match(rec) {
+				names = append(names, rule.Name)
+			}
+		}
+	}
+	return names
+}
+
+// Filter returns a filter keeping records matched by any rule.
+func (rs *RuleSet) Filter() Filter {
+	return func(rec *decode.BsmRecord) bool {
+		if !rs.Interested(rec.EventType) {
+			return false
+		}
+		return len(rs.Match(rec)) > 0
+	}
+}
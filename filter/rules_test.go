@@ -0,0 +1,86 @@
+package filter
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/tpltnt/go-bsm/decode"
+	"github.com/tpltnt/go-bsm/token"
+)
+
+func TestRuleSet(t *testing.T) {
+	rules := []Rule{
+		{Name: "exec", Events: []uint16{7, 23}},
+		{Name: "root-exec", Events: []uint16{23}, Match: func(rec *decode.BsmRecord) bool {
+			subject, ok := rec.Subject()
+			return ok && subject.EffectiveUserID == 0
+		}},
+		{Name: "everything"},
+		{Name: "never", Events: []uint16{23}, Match: func(rec *decode.BsmRecord) bool { return false }},
+	}
+	rs := CompileRules(rules)
+
+	rec := decode.BsmRecord{EventType: 23, Tokens: []token.Token{token.SubjectToken32bit{TokenID: 0x24}}}
+	names := rs.Match(&rec)
+	if len(names) != 3 || names[0] != "exec" || names[1] != "root-exec" || names[2] != "everything" {
+		t.Error("unexpected matches:", names)
+	}
+	rec.EventType = 45000
+	names = rs.Match(&rec)
+	if len(names) != 1 || names[0] != "everything" {
+		t.Error("unexpected matches:", names)
+	}
+
+	// header-only short-circuit
+	rs = CompileRules(rules[:2])
+	if !rs.Interested(7) || rs.Interested(45000) {
+		t.Error("wrong interest in event types")
+	}
+	keep := rs.Filter()
+	if keep(&rec) {
+		t.Error("record should have been dropped")
+	}
+}
+
+// benchmarkRules returns n rules on distinct event types, each with a
+// cheap predicate on the record.
+func benchmarkRules(n int) []Rule {
+	rules := make([]Rule, n)
+	for i := range rules {
+		rules[i] = Rule{
+			Name:   "rule" + strconv.Itoa(i),
+			Events: []uint16{uint16(i)},
+			Match:  func(rec *decode.BsmRecord) bool { return len(rec.Tokens) > 0 },
+		}
+	}
+	return rules
+}
+
+func BenchmarkRuleSetMatch(b *testing.B) {
+	rs := CompileRules(benchmarkRules(500))
+	rec := decode.BsmRecord{EventType: 23, Tokens: []token.Token{token.TextToken{TokenID: 0x28}}}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		rs.Match(&rec)
+	}
+}
+
+func BenchmarkNaiveRuleMatch(b *testing.B) {
+	rules := benchmarkRules(500)
+	rec := decode.BsmRecord{EventType: 23, Tokens: []token.Token{token.TextToken{TokenID: 0x28}}}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		names := []string{}
+		for _, rule := range rules {
+			applies := false
+			for _, event := range rule.Events {
+				if event == rec.EventType {
+					applies = true
+				}
+			}
+			if applies && rule.Match(&rec) {
+				names = append(names, rule.Name)
+			}
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package filter
+
+import (
+	"fmt"
+
+	"github.com/tpltnt/go-bsm/decode"
+	"github.com/tpltnt/go-bsm/token"
+)
+
+// TokenClass groups the token types carrying the same kind of
+// information (e.g. all variants of the 'subject' token).
+type TokenClass string
+
+// Token classes known to the Validator.
+const (
+	ClassSubject  TokenClass = "subject"
+	ClassReturn   TokenClass = "return"
+	ClassPath     TokenClass = "path"
+	ClassExecArgs TokenClass = "exec_args"
+	ClassSocket   TokenClass = "socket"
+)
+
+// matches reports whether the token belongs to the class.
+func (class TokenClass) matches(tok token.Token) bool {
+	switch tok.(type) {
+	case token.SubjectToken32bit, token.SubjectToken64bit, token.ExpandedSubjectToken32bit, token.ExpandedSubjectToken64bit:
+		return class == ClassSubject
+	case token.ReturnToken32bit, token.ReturnToken64bit:
+		return class == ClassReturn
+	case token.PathToken:
+		return class == ClassPath
+	case token.ExecArgsToken:
+		return class == ClassExecArgs
+	case token.SocketToken, token.ExpandedSocketToken:
+		return class == ClassSocket
+	}
+	return false
+}
+
+// Anomaly describes a deviation of a record from the expectations
+// for its event type.
+type Anomaly struct {
+	EventType uint16     // event type of the record
+	Missing   TokenClass // class of the missing token
+}
+
+func (a Anomaly) String() string {
+	return fmt.Sprintf("record of event type %d lacks a %s token", a.EventType, a.Missing)
+}
+
+// Validator checks records against the tokens expected for their
+// event type, e.g. to spot gaps in the audit configuration or
+// evasion attempts.
+type Validator struct {
+	Expectations map[uint16][]TokenClass // expected token classes by event type
+}
+
+// NewValidator returns a validator with expectations for common
+// kernel and login events.
+func NewValidator() *Validator {
+	exec := []TokenClass{ClassSubject, ClassReturn, ClassExecArgs, ClassPath}
+	network := []TokenClass{ClassSubject, ClassReturn, ClassSocket}
+	file := []TokenClass{ClassSubject, ClassReturn, ClassPath}
+	credentials := []TokenClass{ClassSubject, ClassReturn}
+
+	expectations := map[uint16][]TokenClass{
+		3:     file,        // AUE_OPEN
+		7:     exec,        // AUE_EXEC
+		23:    exec,        // AUE_EXECVE
+		32:    network,     // AUE_CONNECT
+		33:    network,     // AUE_ACCEPT
+		34:    network,     // AUE_BIND
+		200:   credentials, // AUE_SETUID
+		205:   credentials, // AUE_SETGID
+		214:   credentials, // AUE_SETEGID
+		215:   credentials, // AUE_SETEUID
+		6152:  credentials, // AUE_login
+		32800: credentials, // AUE_openssh
+	}
+	for event := uint16(72); event <= 83; event++ { // AUE_OPEN_R ... AUE_OPEN_RWTC
+		expectations[event] = file
+	}
+	return &Validator{Expectations: expectations}
+}
+
+// Validate returns all anomalies found in the record. Records of event
+// types without expectations are always valid.
+func (v *Validator) Validate(rec *decode.BsmRecord) []Anomaly {
+	anomalies := []Anomaly{}
+	for _, class := range v.Expectations[rec.EventType] {
+		found := false
+		for _, tok := range rec.Tokens {
+			if class.matches(tok) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			anomalies = append(anomalies, Anomaly{
+				EventType: rec.EventType,
+				Missing:   class,
+			})
+		}
+	}
+	return anomalies
+}
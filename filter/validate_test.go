@@ -0,0 +1,100 @@
+package filter
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/tpltnt/go-bsm/decode"
+	"github.com/tpltnt/go-bsm/token"
+)
+
+func TestValidator(t *testing.T) {
+	validator := NewValidator()
+
+	exec := decode.BsmRecord{
+		EventType: 23, // AUE_EXECVE
+		Tokens: []token.Token{
+			token.ExecArgsToken{TokenID: 0x3c, Count: 1, Text: []string{"ls"}},
+			token.PathToken{TokenID: 0x23, Path: "/bin/ls"},
+			token.SubjectToken64bit{TokenID: 0x75},
+			token.ReturnToken32bit{TokenID: 0x27},
+		},
+	}
+	if anomalies := validator.Validate(&exec); len(anomalies) != 0 {
+		t.Error("unexpected anomalies:", anomalies)
+	}
+
+	exec.Tokens = exec.Tokens[1:] // drop exec args
+	anomalies := validator.Validate(&exec)
+	if len(anomalies) != 1 || anomalies[0].Missing != ClassExecArgs {
+		t.Error("expected missing exec args, got", anomalies)
+	}
+
+	connect := decode.BsmRecord{
+		EventType: 32, // AUE_CONNECT
+		Tokens: []token.Token{
+			token.SubjectToken32bit{TokenID: 0x24},
+		},
+	}
+	anomalies = validator.Validate(&connect)
+	if len(anomalies) != 2 || anomalies[0].Missing != ClassReturn || anomalies[1].Missing != ClassSocket {
+		t.Error("unexpected anomalies:", anomalies)
+	}
+
+	// no expectations
+	other := decode.BsmRecord{EventType: 45000}
+	if anomalies := validator.Validate(&other); len(anomalies) != 0 {
+		t.Error("unexpected anomalies:", anomalies)
+	}
+}
+
+func Test_parsing_validated_tokens(t *testing.T) {
+	data := []byte{
+		0x3c,                   // exec args token
+		0x00, 0x00, 0x00, 0x02, // count
+		0x6c, 0x73, 0x00, // "ls"
+		0x2d, 0x6c, 0x00, // "-l"
+		0x72,                                           // 64 bit return token
+		0x02,                                           // errno
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, // return value
+		0x7f,       // expanded socket token
+		0x00, 0x02, // socket domain
+		0x00, 0x01, // socket type
+		0x00, 0x04, // address type
+		0x00, 0x50, // local port
+		0x7f, 0x00, 0x00, 0x01, // local address
+		0x1f, 0x90, // remote port
+		0x0a, 0x00, 0x00, 0x02, // remote address
+	}
+	input := bytes.NewBuffer(data)
+
+	tok, err := decode.ReadToken(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	args, ok := tok.(token.ExecArgsToken)
+	if !ok || args.Count != 2 || len(args.Text) != 2 || args.Text[0] != "ls" || args.Text[1] != "-l" {
+		t.Error("unexpected exec args token:", tok)
+	}
+
+	tok, err = decode.ReadToken(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ret, ok := tok.(token.ReturnToken64bit)
+	if !ok || ret.ErrorNumber != 2 || ret.ReturnValue != 0xffffffffffffffff {
+		t.Error("unexpected return token:", tok)
+	}
+
+	tok, err = decode.ReadToken(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	socket, ok := tok.(token.ExpandedSocketToken)
+	if !ok {
+		t.Fatal("expected ExpandedSocketToken, got", tok)
+	}
+	if socket.LocalPort != 80 || socket.RemotePort != 8080 || socket.RemoteIpAddress.String() != "10.0.0.2" {
+		t.Error("unexpected socket token:", socket)
+	}
+}
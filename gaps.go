@@ -0,0 +1,117 @@
+package bsm
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// GapTolerance is the longest time without coverage not reported by
+// GapReport, e.g. the moment between closing a trail and opening the
+// next one on rotation.
+var GapTolerance = time.Second
+
+// Gap is a time window not covered by any trail.
+type Gap struct {
+	Start  time.Time
+	End    time.Time
+	After  string // trail file ending at Start
+	Before string // trail file starting at End
+	Reason string // e.g. "trail not terminated"
+}
+
+// Duration returns the length of the gap.
+func (g Gap) Duration() time.Duration {
+	return g.End.Sub(g.Start)
+}
+
+// trailCoverage is the time span covered by a trail file.
+type trailCoverage struct {
+	name       string
+	start, end time.Time
+	terminated bool // closed by a file token
+}
+
+// GapReport reads all trail files of a directory (e.g. /var/audit) and
+// lists the time windows covered by none of them, e.g. while auditd was
+// down or the disk was full. A trail covers the time from its opening to
+// its closing file token, or from its first to its last record if these
+// are missing. Gaps shorter than GapTolerance are ignored.
+func GapReport(dir string) ([]Gap, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var trails []trailCoverage
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue // e.g. the "current" symlink
+		}
+		trail, err := readCoverage(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", entry.Name(), err)
+		}
+		if !trail.start.IsZero() {
+			trails = append(trails, trail)
+		}
+	}
+	sort.Slice(trails, func(i, j int) bool { return trails[i].start.Before(trails[j].start) })
+
+	var gaps []Gap
+	for i := 1; i < len(trails); i++ {
+		prev := trails[i-1]
+		if trails[i].start.Sub(prev.end) > GapTolerance {
+			gap := Gap{Start: prev.end, End: trails[i].start, After: prev.name, Before: trails[i].name, Reason: "no trail"}
+			if !prev.terminated {
+				gap.Reason = "trail not terminated"
+			}
+			gaps = append(gaps, gap)
+		}
+		if prev.end.After(trails[i].end) {
+			trails[i].end = prev.end // overlapping trails
+		}
+	}
+	return gaps, nil
+}
+
+// readCoverage determines the time span covered by a trail file. An
+// incomplete record at its end is ignored.
+func readCoverage(path string) (trailCoverage, error) {
+	trail := trailCoverage{name: filepath.Base(path)}
+	file, err := os.Open(path)
+	if err != nil {
+		return trail, err
+	}
+	defer file.Close()
+
+	extend := func(t time.Time) {
+		if trail.start.IsZero() || t.Before(trail.start) {
+			trail.start = t
+		}
+		if t.After(trail.end) {
+			trail.end = t
+		}
+	}
+	records := 0
+	decoder := NewDecoder(file)
+	decoder.FileTokenHandler = func(file FileToken) {
+		extend(time.Unix(int64(file.Seconds), int64(file.Microseconds)*1000))
+		trail.terminated = records > 0
+	}
+	for {
+		rec, err := decoder.Decode()
+		if err == io.EOF || errors.Is(err, io.ErrUnexpectedEOF) {
+			return trail, nil
+		}
+		if err != nil {
+			return trail, err
+		}
+		records += 1
+		trail.terminated = false
+		extend(rec.Time())
+	}
+}
@@ -0,0 +1,70 @@
+package bsm
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tpltnt/go-bsm/encode"
+)
+
+// writeTrail writes a trail holding records at the given times (in
+// seconds), enclosed in file tokens if terminated.
+func writeTrail(t *testing.T, path string, terminated bool, times ...uint64) {
+	var buf bytes.Buffer
+	file := func(seconds uint64) {
+		data, err := encode.Token(FileToken{Seconds: uint32(seconds), PathName: filepath.Base(path)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		buf.Write(data)
+	}
+	file(times[0])
+	encoder := encode.NewEncoder(&buf)
+	for _, seconds := range times {
+		rec := BsmRecord{Version: 11, EventType: 1, Seconds: seconds, Tokens: []Token{ReturnToken32bit{}}}
+		if err := encoder.Encode(&rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if terminated {
+		file(times[len(times)-1] + 10)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGapReport(t *testing.T) {
+	dir := t.TempDir()
+	writeTrail(t, filepath.Join(dir, "a"), true, 1000, 1100)
+	writeTrail(t, filepath.Join(dir, "b"), false, 1110, 1200) // crashed
+	writeTrail(t, filepath.Join(dir, "c"), true, 2000, 2100)
+	writeTrail(t, filepath.Join(dir, "d"), true, 2110, 2200)
+	writeTrail(t, filepath.Join(dir, "e"), true, 3000)
+	if err := os.Symlink("d", filepath.Join(dir, "current")); err != nil {
+		t.Fatal(err)
+	}
+
+	gaps, err := GapReport(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Gap{
+		{Start: time.Unix(1200, 0), End: time.Unix(2000, 0), After: "b", Before: "c", Reason: "trail not terminated"},
+		{Start: time.Unix(2210, 0), End: time.Unix(3000, 0), After: "d", Before: "e", Reason: "no trail"},
+	}
+	if len(gaps) != len(expected) {
+		t.Fatalf("got %d gaps, expected %d: %v", len(gaps), len(expected), gaps)
+	}
+	for i := range expected {
+		if gaps[i] != expected[i] {
+			t.Errorf("gap %d: got %+v, expected %+v", i, gaps[i], expected[i])
+		}
+	}
+	if gaps[1].Duration() != 790*time.Second {
+		t.Errorf("unexpected duration %v", gaps[1].Duration())
+	}
+}
@@ -0,0 +1,84 @@
+package bsm
+
+import (
+	"net"
+	"strconv"
+)
+
+// GeoInfo is the geographical and network information about an IP
+// address.
+type GeoInfo struct {
+	Country      string // ISO 3166-1 alpha-2 country code
+	ASN          uint32 // autonomous system number (0 if unknown)
+	Organization string // name of the autonomous system
+}
+
+// GeoIPLookup resolves IP addresses, e.g. backed by a MaxMind database.
+type GeoIPLookup interface {
+	Lookup(ip net.IP) (GeoInfo, error)
+}
+
+// GeoIPLookupFunc is an adapter to use a function as GeoIPLookup.
+type GeoIPLookupFunc func(ip net.IP) (GeoInfo, error)
+
+// Lookup calls f(ip).
+func (f GeoIPLookupFunc) Lookup(ip net.IP) (GeoInfo, error) {
+	return f(ip)
+}
+
+// recordAddresses returns the first address of the record per role:
+// "terminal" (subject), "socket" (socket token), "remote" (remote
+// address of an expanded socket token) and "in_addr".
+func recordAddresses(rec *BsmRecord) map[string]net.IP {
+	addresses := map[string]net.IP{}
+	add := func(role string, ip net.IP) {
+		if _, ok := addresses[role]; !ok && ip != nil {
+			addresses[role] = ip
+		}
+	}
+	if subject, ok := rec.Subject(); ok {
+		add("terminal", subject.TerminalMachineAddress)
+	}
+	for _, token := range rec.Tokens {
+		switch v := token.(type) {
+		case SocketToken:
+			add("socket", v.SocketAddress)
+		case ExpandedSocketToken:
+			add("remote", v.RemoteIpAddress)
+		case InAddrToken:
+			add("in_addr", v.IpAddress)
+		case ExpandedInAddrToken:
+			add("in_addr", v.IpAddress)
+		}
+	}
+	return addresses
+}
+
+// EnrichGeoIP returns a transformation (see Transform) which annotates
+// records with the country and ASN of their public addresses, e.g.
+// "geo.terminal.country" and "geo.terminal.asn" for the subject.
+// Private, loopback and unspecified addresses are skipped. Failing
+// lookups are not an error, they just leave the address unannotated.
+func EnrichGeoIP(lookup GeoIPLookup) func(*BsmRecord) error {
+	return func(rec *BsmRecord) error {
+		for role, ip := range recordAddresses(rec) {
+			if ip.IsPrivate() || ip.IsLoopback() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() {
+				continue
+			}
+			info, err := lookup.Lookup(ip)
+			if err != nil {
+				continue
+			}
+			if info.Country != "" {
+				rec.Annotate("geo."+role+".country", info.Country)
+			}
+			if info.ASN != 0 {
+				rec.Annotate("geo."+role+".asn", strconv.FormatUint(uint64(info.ASN), 10))
+			}
+			if info.Organization != "" {
+				rec.Annotate("geo."+role+".organization", info.Organization)
+			}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,43 @@
+package bsm
+
+import (
+	"errors"
+	"net"
+	"testing"
+)
+
+func TestEnrichGeoIP(t *testing.T) {
+	lookups := 0
+	lookup := GeoIPLookupFunc(func(ip net.IP) (GeoInfo, error) {
+		lookups += 1
+		if ip.Equal(net.IPv4(198, 51, 100, 7)) {
+			return GeoInfo{Country: "DE", ASN: 64500, Organization: "Example"}, nil
+		}
+		return GeoInfo{}, errors.New("not found")
+	})
+	enrich := EnrichGeoIP(lookup)
+
+	rec := BsmRecord{Tokens: []Token{
+		SubjectToken32bit{TokenID: 0x24, TerminalMachineAddress: net.IPv4(198, 51, 100, 7)},
+		ExpandedSocketToken{TokenID: 0x7f, LocalIpAddress: net.IPv4(10, 0, 0, 1), RemoteIpAddress: net.IPv4(203, 0, 113, 1)},
+		InAddrToken{TokenID: 0x2a, IpAddress: net.IPv4(127, 0, 0, 1)},
+	}}
+	if err := enrich(&rec); err != nil {
+		t.Error(err)
+	}
+	if rec.Annotations["geo.terminal.country"] != "DE" || rec.Annotations["geo.terminal.asn"] != "64500" || rec.Annotations["geo.terminal.organization"] != "Example" {
+		t.Error("unexpected annotations:", rec.Annotations)
+	}
+	if len(rec.Annotations) != 3 {
+		t.Error("unexpected number of annotations:", rec.Annotations)
+	}
+	if lookups != 2 { // loopback is skipped
+		t.Error("unexpected number of lookups:", lookups)
+	}
+
+	rec = BsmRecord{}
+	enrich(&rec)
+	if rec.Annotations != nil {
+		t.Error("unexpected annotations without addresses")
+	}
+}
@@ -0,0 +1,186 @@
+// Package inmem provides a columnar in-memory store for BSM records
+// to run ad-hoc analysis over a trail without an external database.
+package inmem
+
+import (
+	"time"
+
+	bsm "github.com/tpltnt/go-bsm"
+)
+
+// Store keeps selected fields of records in columns. Row i of every
+// column belongs to the i-th record added. Paths are dictionary
+// encoded, so grouping by path only compares integers.
+type Store struct {
+	times      []int64  // record time stamps (nanoseconds since epoch)
+	eventTypes []uint16 // event types
+	auditIDs   []uint32 // audit user IDs (bsm.DefaultAuditID if no subject)
+	paths      []uint32 // index into dict (0 is "no path")
+	dict       []string
+	dictIndex  map[string]uint32
+}
+
+// NewStore returns an empty Store.
+func NewStore() *Store {
+	return &Store{
+		dict:      []string{""},
+		dictIndex: map[string]uint32{"": 0},
+	}
+}
+
+// Add appends the record to the store.
+func (s *Store) Add(rec *bsm.BsmRecord) {
+	s.times = append(s.times, rec.Time().UnixNano())
+	s.eventTypes = append(s.eventTypes, rec.EventType)
+	auditID := uint32(bsm.DefaultAuditID)
+	if subject, ok := rec.Subject(); ok {
+		auditID = subject.AuditID
+	}
+	s.auditIDs = append(s.auditIDs, auditID)
+	path, _ := rec.Path()
+	s.paths = append(s.paths, s.pathID(path))
+}
+
+// WriteRecord adds the record, so a Store can be used as bsm.Sink.
+func (s *Store) WriteRecord(rec *bsm.BsmRecord) error {
+	s.Add(rec)
+	return nil
+}
+
+// pathID returns the dictionary index of the path.
+func (s *Store) pathID(path string) uint32 {
+	id, ok := s.dictIndex[path]
+	if !ok {
+		id = uint32(len(s.dict))
+		s.dict = append(s.dict, path)
+		s.dictIndex[path] = id
+	}
+	return id
+}
+
+// Len returns the number of records in the store.
+func (s *Store) Len() int {
+	return len(s.times)
+}
+
+// Row returns the columns of a single record.
+func (s *Store) Row(i int) Row {
+	return Row{
+		Time:      time.Unix(0, s.times[i]),
+		EventType: s.eventTypes[i],
+		AuditID:   s.auditIDs[i],
+		Path:      s.dict[s.paths[i]],
+	}
+}
+
+// Row holds the stored fields of a single record.
+type Row struct {
+	Time      time.Time
+	EventType uint16
+	AuditID   uint32
+	Path      string // empty if the record has no path
+}
+
+// Selection is a list of row indices in ascending order.
+type Selection []int
+
+// All selects every row of the store.
+func (s *Store) All() Selection {
+	sel := make(Selection, s.Len())
+	for i := range sel {
+		sel[i] = i
+	}
+	return sel
+}
+
+// Between keeps the rows of sel with a time stamp in [from, to).
+func (s *Store) Between(sel Selection, from, to time.Time) Selection {
+	lo, hi := from.UnixNano(), to.UnixNano()
+	out := Selection{}
+	for _, i := range sel {
+		if s.times[i] >= lo && s.times[i] < hi {
+			out = append(out, i)
+		}
+	}
+	return out
+}
+
+// EventTypes keeps the rows of sel with one of the given event types.
+func (s *Store) EventTypes(sel Selection, types ...uint16) Selection {
+	wanted := map[uint16]bool{}
+	for _, t := range types {
+		wanted[t] = true
+	}
+	out := Selection{}
+	for _, i := range sel {
+		if wanted[s.eventTypes[i]] {
+			out = append(out, i)
+		}
+	}
+	return out
+}
+
+// AuditIDs keeps the rows of sel with one of the given audit user IDs.
+func (s *Store) AuditIDs(sel Selection, ids ...uint32) Selection {
+	wanted := map[uint32]bool{}
+	for _, id := range ids {
+		wanted[id] = true
+	}
+	out := Selection{}
+	for _, i := range sel {
+		if wanted[s.auditIDs[i]] {
+			out = append(out, i)
+		}
+	}
+	return out
+}
+
+// Paths keeps the rows of sel with a path accepted by match. The
+// predicate is evaluated once per distinct path.
+func (s *Store) Paths(sel Selection, match func(path string) bool) Selection {
+	accepted := make([]bool, len(s.dict))
+	for id, path := range s.dict {
+		accepted[id] = id != 0 && match(path)
+	}
+	out := Selection{}
+	for _, i := range sel {
+		if accepted[s.paths[i]] {
+			out = append(out, i)
+		}
+	}
+	return out
+}
+
+// CountByEventType returns the number of rows of sel per event type.
+func (s *Store) CountByEventType(sel Selection) map[uint16]int {
+	counts := map[uint16]int{}
+	for _, i := range sel {
+		counts[s.eventTypes[i]] += 1
+	}
+	return counts
+}
+
+// CountByAuditID returns the number of rows of sel per audit user ID.
+func (s *Store) CountByAuditID(sel Selection) map[uint32]int {
+	counts := map[uint32]int{}
+	for _, i := range sel {
+		counts[s.auditIDs[i]] += 1
+	}
+	return counts
+}
+
+// CountByPath returns the number of rows of sel per path. Rows
+// without a path are not counted.
+func (s *Store) CountByPath(sel Selection) map[string]int {
+	perID := make([]int, len(s.dict))
+	for _, i := range sel {
+		perID[s.paths[i]] += 1
+	}
+	counts := map[string]int{}
+	for id, n := range perID {
+		if id != 0 && n > 0 {
+			counts[s.dict[id]] = n
+		}
+	}
+	return counts
+}
@@ -0,0 +1,78 @@
+package inmem
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	bsm "github.com/tpltnt/go-bsm"
+)
+
+func record(seconds uint64, eventType uint16, auditID uint32, path string) *bsm.BsmRecord {
+	rec := &bsm.BsmRecord{Seconds: seconds, EventType: eventType}
+	rec.Tokens = append(rec.Tokens, bsm.SubjectToken32bit{TokenID: 0x24, AuditID: auditID})
+	if path != "" {
+		rec.Tokens = append(rec.Tokens, bsm.PathToken{TokenID: 0x23, Path: path})
+	}
+	return rec
+}
+
+func TestStore(t *testing.T) {
+	store := NewStore()
+	store.Add(record(100, 72, 1001, "/etc/passwd"))
+	store.Add(record(200, 72, 1002, "/etc/passwd"))
+	store.Add(record(300, 23, 1001, "/usr/bin/true"))
+	store.Add(record(400, 23, 1001, ""))
+	store.WriteRecord(&bsm.BsmRecord{Seconds: 500, EventType: 45000})
+
+	if store.Len() != 5 {
+		t.Fatal("unexpected number of records:", store.Len())
+	}
+	row := store.Row(4)
+	if row.AuditID != bsm.DefaultAuditID || row.Path != "" || row.EventType != 45000 || row.Time.Unix() != 500 {
+		t.Error("unexpected row:", row)
+	}
+
+	all := store.All()
+	sel := store.Between(all, time.Unix(150, 0), time.Unix(400, 0))
+	if len(sel) != 2 || sel[0] != 1 || sel[1] != 2 {
+		t.Error("unexpected time selection:", sel)
+	}
+	sel = store.AuditIDs(store.EventTypes(all, 23), 1001)
+	if len(sel) != 2 {
+		t.Error("unexpected selection:", sel)
+	}
+	sel = store.Paths(all, func(path string) bool { return path[:5] == "/etc/" })
+	if len(sel) != 2 {
+		t.Error("unexpected path selection:", sel)
+	}
+
+	if counts := store.CountByEventType(all); counts[72] != 2 || counts[23] != 2 || counts[45000] != 1 {
+		t.Error("unexpected event type counts:", counts)
+	}
+	if counts := store.CountByAuditID(all); counts[1001] != 3 || counts[1002] != 1 {
+		t.Error("unexpected audit ID counts:", counts)
+	}
+	if counts := store.CountByPath(all); len(counts) != 2 || counts["/etc/passwd"] != 2 {
+		t.Error("unexpected path counts:", counts)
+	}
+}
+
+func TestStoreFromTrail(t *testing.T) {
+	file, err := os.Open("../start_stop.bsm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	store := NewStore()
+	err = bsm.ForEachRecord(file, func(rec *bsm.BsmRecord) error {
+		return store.WriteRecord(rec)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if counts := store.CountByEventType(store.All()); counts[45000] != 1 || counts[45001] != 1 {
+		t.Error("unexpected event types:", counts)
+	}
+}
@@ -0,0 +1,97 @@
+package bsm
+
+import (
+	"sync"
+	"time"
+)
+
+// LatencyBuckets are the upper bounds of the histogram of LatencyStats.
+var LatencyBuckets = []time.Duration{
+	10 * time.Millisecond,
+	100 * time.Millisecond,
+	time.Second,
+	10 * time.Second,
+	time.Minute,
+	10 * time.Minute,
+}
+
+// LatencyStats accumulates the processing latencies of records, i.e.
+// the time between the time stamp of the record and the time it is
+// processed. It is safe for concurrent use.
+type LatencyStats struct {
+	mutex   sync.Mutex
+	count   uint64
+	total   time.Duration
+	max     time.Duration
+	buckets []uint64 // by LatencyBuckets, the last one for larger values
+}
+
+// LatencyBucket is a bucket of the latency histogram.
+type LatencyBucket struct {
+	Le    time.Duration // upper bound
+	Count uint64        // number of latencies up to Le (cumulative)
+}
+
+// LatencySnapshot is the state of a LatencyStats.
+type LatencySnapshot struct {
+	Count   uint64
+	Total   time.Duration
+	Max     time.Duration
+	Buckets []LatencyBucket // without the implicit +Inf bucket holding Count
+}
+
+// Mean returns the average latency.
+func (s LatencySnapshot) Mean() time.Duration {
+	if s.Count == 0 {
+		return 0
+	}
+	return s.Total / time.Duration(s.Count)
+}
+
+// Observe accounts a latency.
+func (s *LatencyStats) Observe(latency time.Duration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.buckets == nil {
+		s.buckets = make([]uint64, len(LatencyBuckets)+1)
+	}
+	s.count += 1
+	s.total += latency
+	s.max = max(s.max, latency)
+	i := 0
+	for i < len(LatencyBuckets) && latency > LatencyBuckets[i] {
+		i++
+	}
+	s.buckets[i] += 1
+}
+
+// Snapshot returns the current state, e.g. to export it as metrics.
+func (s *LatencyStats) Snapshot() LatencySnapshot {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	snapshot := LatencySnapshot{Count: s.count, Total: s.total, Max: s.max}
+	var cumulative uint64
+	for i, le := range LatencyBuckets {
+		if s.buckets != nil {
+			cumulative += s.buckets[i]
+		}
+		snapshot.Buckets = append(snapshot.Buckets, LatencyBucket{Le: le, Count: cumulative})
+	}
+	return snapshot
+}
+
+// MeasureLatency returns a transformation (see Transform) which
+// annotates records with their processing latency as
+// "processing_latency" (e.g. "1.5s") and accounts it in stats (if not
+// nil). Growing latencies indicate a backlog in the audit queue or in
+// shipping. Latencies below 0 (clock skew) are taken as 0.
+func MeasureLatency(stats *LatencyStats) func(*BsmRecord) error {
+	return func(rec *BsmRecord) error {
+		latency := max(time.Since(rec.Time()), 0)
+		rec.Annotate("processing_latency", latency.String())
+		if stats != nil {
+			stats.Observe(latency)
+		}
+		return nil
+	}
+}
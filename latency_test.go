@@ -0,0 +1,38 @@
+package bsm
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMeasureLatency(t *testing.T) {
+	var stats LatencyStats
+	measure := MeasureLatency(&stats)
+	now := time.Now()
+	for _, age := range []time.Duration{50 * time.Millisecond, 2 * time.Second, time.Hour, -time.Minute} {
+		stamp := now.Add(-age)
+		rec := BsmRecord{Seconds: uint64(stamp.Unix()), NanoSeconds: uint64(stamp.Nanosecond())}
+		if err := measure(&rec); err != nil {
+			t.Fatal(err)
+		}
+		latency, err := time.ParseDuration(rec.Annotations["processing_latency"])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if latency < max(age, 0) || latency > max(age, 0)+time.Second {
+			t.Errorf("got latency %v for a record of age %v", latency, age)
+		}
+	}
+
+	snapshot := stats.Snapshot()
+	if snapshot.Count != 4 || snapshot.Max < time.Hour || snapshot.Mean() < 15*time.Minute {
+		t.Errorf("unexpected snapshot %+v", snapshot)
+	}
+	// 0, 50ms | 2s | 1h
+	expected := []uint64{1, 2, 2, 3, 3, 3}
+	for i, bucket := range snapshot.Buckets {
+		if bucket.Le != LatencyBuckets[i] || bucket.Count != expected[i] {
+			t.Errorf("bucket %d: got %+v, expected %d", i, bucket, expected[i])
+		}
+	}
+}
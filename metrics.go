@@ -0,0 +1,66 @@
+package bsm
+
+// MetricLabelNames are the names of the labels returned by MetricLabels,
+// e.g. to declare a Prometheus counter vector.
+var MetricLabelNames = []string{"class", "outcome", "uid"}
+
+// MetricEventClasses maps common event types of OpenBSM to the event
+// classes used by MetricLabels. Other event types are of class "other".
+var MetricEventClasses = eventClasses(map[string][]EventType{
+	"process":     {AUE_EXIT, AUE_FORK, AUE_KILL, AUE_VFORK, AUE_KILLPG},
+	"exec":        {AUE_EXEC, AUE_EXECVE},
+	"credentials": {AUE_SETGROUPS, AUE_SETREUID, AUE_SETREGID, AUE_SETUID, AUE_SETGID, AUE_SETEGID, AUE_SETEUID},
+	"file_write": {
+		AUE_CREAT, AUE_LINK, AUE_UNLINK, AUE_MKNOD, AUE_SYMLINK, AUE_RENAME, AUE_TRUNCATE, AUE_FTRUNCATE, AUE_MKDIR, AUE_RMDIR,
+		AUE_OPEN_RC, AUE_OPEN_RT, AUE_OPEN_RTC, AUE_OPEN_W, AUE_OPEN_WC, AUE_OPEN_WT,
+		AUE_OPEN_WTC, AUE_OPEN_RW, AUE_OPEN_RWC, AUE_OPEN_RWT, AUE_OPEN_RWTC,
+	},
+	"file_read": {AUE_READLINK, AUE_OPEN_R},
+	"file_attr": {AUE_CHMOD, AUE_CHOWN, AUE_FCHOWN, AUE_FCHMOD, AUE_UTIMES},
+	"network":   {AUE_CONNECT, AUE_ACCEPT, AUE_BIND, AUE_SETSOCKOPT, AUE_SHUTDOWN},
+	"login":     {AUE_login, AUE_logout, AUE_ssh, AUE_openssh},
+	"admin":     {AUE_UMOUNT, AUE_REBOOT, AUE_SETHOSTNAME, AUE_SETTIMEOFDAY, AUE_MOUNT, AUE_audit_startup, AUE_audit_shutdown},
+})
+
+// eventClasses maps the event types listed by class to their class.
+func eventClasses(classes map[string][]EventType) map[uint16]string {
+	m := map[uint16]string{}
+	for class, events := range classes {
+		for _, e := range events {
+			m[uint16(e)] = class
+		}
+	}
+	return m
+}
+
+// MetricLabels returns labels of the record suitable for metrics, e.g.
+// Prometheus counters. Unlike event types, paths or user IDs, each label
+// only takes a handful of values, which keeps the number of time series
+// bounded:
+//
+//   - class: event class (see MetricEventClasses)
+//   - outcome: "success" or "failure"
+//   - uid: effective user ID bucket, "root" (0), "system" (below 1000),
+//     "user" or "none" without subject
+func MetricLabels(rec *BsmRecord) map[string]string {
+	class, ok := MetricEventClasses[rec.EventType]
+	if !ok {
+		class = "other"
+	}
+	outcome := "success"
+	if rec.Failed() {
+		outcome = "failure"
+	}
+	uid := "none"
+	if subject, ok := rec.Subject(); ok {
+		switch {
+		case subject.EffectiveUserID == 0:
+			uid = "root"
+		case subject.EffectiveUserID < 1000:
+			uid = "system"
+		default:
+			uid = "user"
+		}
+	}
+	return map[string]string{"class": class, "outcome": outcome, "uid": uid}
+}
@@ -0,0 +1,44 @@
+package bsm
+
+import "testing"
+
+func TestMetricLabels(t *testing.T) {
+	tests := []struct {
+		rec      BsmRecord
+		expected map[string]string
+	}{
+		{
+			rec: BsmRecord{EventType: 23, Tokens: []Token{
+				SubjectToken32bit{AuditID: 1001, EffectiveUserID: 0},
+				ReturnToken32bit{},
+			}},
+			expected: map[string]string{"class": "exec", "outcome": "success", "uid": "root"},
+		},
+		{
+			rec: BsmRecord{EventType: 72, Tokens: []Token{
+				SubjectToken32bit{EffectiveUserID: 1001},
+				ReturnToken32bit{ErrorNumber: 13},
+			}},
+			expected: map[string]string{"class": "file_read", "outcome": "failure", "uid": "user"},
+		},
+		{
+			rec:      BsmRecord{EventType: uint16(AUE_SETEUID), Tokens: []Token{SubjectToken32bit{EffectiveUserID: 0}}},
+			expected: map[string]string{"class": "credentials", "outcome": "success", "uid": "root"},
+		},
+		{
+			rec:      BsmRecord{EventType: 4242},
+			expected: map[string]string{"class": "other", "outcome": "success", "uid": "none"},
+		},
+	}
+	for i, test := range tests {
+		labels := MetricLabels(&test.rec)
+		if len(labels) != len(MetricLabelNames) {
+			t.Errorf("%d: got %d labels, expected %d", i, len(labels), len(MetricLabelNames))
+		}
+		for name, value := range test.expected {
+			if labels[name] != value {
+				t.Errorf("%d: label %s: got %q, expected %q", i, name, labels[name], value)
+			}
+		}
+	}
+}
@@ -0,0 +1,296 @@
+// Package auditbeat formats records with the field names of the auditd
+// module of auditbeat (and go-audit), e.g.
+//
+//	{"@timestamp":"...","event":{"module":"auditd","action":"executed",...},
+//	 "user":{"id":"0","audit":{"id":"1001"},...},"process":{"pid":4242,...},
+//	 "auditd":{"result":"success","summary":{...},"data":{"syscall":"execve"}}}
+//
+// so dashboards built for Linux audit data can be reused for BSM hosts.
+// Only the fields which can be derived from BSM tokens are set.
+package auditbeat
+
+import (
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tpltnt/go-bsm/decode"
+	"github.com/tpltnt/go-bsm/token"
+)
+
+// actions maps event types to the actions auditbeat reports, all other
+// events are reported by their lower case name (e.g. "chmod") or as
+// "unknown".
+var actions = map[uint16]string{
+	7:     "executed", // AUE_EXEC
+	23:    "executed", // AUE_EXECVE
+	3:     "opened-file",
+	32:    "connected-to",
+	33:    "accepted-connection-from",
+	34:    "bound-socket",
+	40:    "changed-identity-of", // AUE_SETREUID
+	200:   "changed-identity-of", // AUE_SETUID
+	215:   "changed-identity-of", // AUE_SETEUID
+	6152:  "logged-in",           // AUE_login
+	6153:  "logged-out",          // AUE_logout
+	6172:  "logged-in",           // AUE_ssh
+	32800: "logged-in",           // AUE_openssh
+}
+
+func init() {
+	for eventType := uint16(72); eventType <= 83; eventType++ {
+		actions[eventType] = "opened-file" // AUE_OPEN_R ... AUE_OPEN_RWTC
+	}
+}
+
+// Event is a record in the format of auditbeat.
+type Event struct {
+	Timestamp   string            `json:"@timestamp"`
+	Event       EventFields       `json:"event"`
+	User        *UserFields       `json:"user,omitempty"`
+	Process     *ProcessFields    `json:"process,omitempty"`
+	File        *FileFields       `json:"file,omitempty"`
+	Source      *Endpoint         `json:"source,omitempty"`
+	Destination *Endpoint         `json:"destination,omitempty"`
+	Auditd      AuditdFields      `json:"auditd"`
+	Labels      map[string]string `json:"labels,omitempty"` // annotations of the record
+}
+
+// EventFields categorize the event.
+type EventFields struct {
+	Module   string `json:"module"`   // always "auditd"
+	Category string `json:"category"` // "audit-rule" or "user-login"
+	Action   string `json:"action"`   // e.g. "executed"
+	Outcome  string `json:"outcome"`  // "success" or "failure"
+}
+
+// ID is an object holding only an ID.
+type ID struct {
+	ID string `json:"id"`
+}
+
+// UserFields are the IDs of the subject.
+type UserFields struct {
+	ID        string      `json:"id"` // real user ID
+	Group     *ID         `json:"group,omitempty"`
+	Audit     *ID         `json:"audit,omitempty"`
+	Effective *UserFields `json:"effective,omitempty"`
+}
+
+// ProcessFields describe the process of the subject.
+type ProcessFields struct {
+	PID        uint32   `json:"pid"`
+	Name       string   `json:"name,omitempty"`
+	Executable string   `json:"executable,omitempty"`
+	Args       []string `json:"args,omitempty"`
+	Title      string   `json:"title,omitempty"`
+}
+
+// FileFields describe the file the event refers to.
+type FileFields struct {
+	Path  string `json:"path"`
+	Inode string `json:"inode,omitempty"`
+	Mode  string `json:"mode,omitempty"`
+	UID   string `json:"uid,omitempty"`
+	GID   string `json:"gid,omitempty"`
+}
+
+// Endpoint is a network address.
+type Endpoint struct {
+	IP   string  `json:"ip"`
+	Port *uint16 `json:"port,omitempty"`
+}
+
+// AuditdFields are the auditd specific fields.
+type AuditdFields struct {
+	Sequence *uint32           `json:"sequence,omitempty"`
+	Result   string            `json:"result"` // "success" or "fail"
+	Session  string            `json:"session,omitempty"`
+	Summary  Summary           `json:"summary"`
+	Paths    []Path            `json:"paths,omitempty"`
+	Data     map[string]string `json:"data,omitempty"`
+}
+
+// Summary is the normalized "who did what to what".
+type Summary struct {
+	Actor  *Actor  `json:"actor,omitempty"`
+	Action string  `json:"action"`
+	Object *Object `json:"object,omitempty"`
+	How    string  `json:"how,omitempty"`
+}
+
+// Actor is the user behind an event: the audit user ID (primary) and
+// the effective user ID (secondary).
+type Actor struct {
+	Primary   string `json:"primary"`
+	Secondary string `json:"secondary"`
+}
+
+// Object is the target of an event.
+type Object struct {
+	Type      string `json:"type"` // "file" or "socket"
+	Primary   string `json:"primary"`
+	Secondary string `json:"secondary,omitempty"`
+}
+
+// Path is a path of the event with the attributes of the file (if
+// recorded).
+type Path struct {
+	Name  string `json:"name"`
+	Inode string `json:"inode,omitempty"`
+	Mode  string `json:"mode,omitempty"`
+	UID   string `json:"ouid,omitempty"`
+	GID   string `json:"ogid,omitempty"`
+}
+
+// Convert returns the auditbeat representation of the given record.
+func Convert(rec *decode.BsmRecord) Event {
+	syscall := strings.ToLower(strings.TrimPrefix(token.EventType(rec.EventType).Name(token.DialectUnknown), "AUE_"))
+	action, ok := actions[rec.EventType]
+	if !ok {
+		action = syscall
+	}
+	if action == "" {
+		action = "unknown"
+	}
+	ev := Event{
+		Timestamp: rec.Time().UTC().Format(time.RFC3339Nano),
+		Event:     EventFields{Module: "auditd", Category: "audit-rule", Action: action, Outcome: "success"},
+		Auditd:    AuditdFields{Result: "success", Summary: Summary{Action: action}, Data: map[string]string{}},
+		Labels:    rec.Annotations,
+	}
+	if strings.HasPrefix(action, "logged-") {
+		ev.Event.Category = "user-login"
+	}
+	if rec.Failed() {
+		ev.Event.Outcome, ev.Auditd.Result = "failure", "fail"
+	}
+	if syscall != "" {
+		ev.Auditd.Data["syscall"] = syscall
+	}
+
+	if subject, ok := rec.Subject(); ok {
+		ev.User = &UserFields{
+			ID:        id(subject.RealUserID),
+			Group:     &ID{id(subject.RealGroupID)},
+			Audit:     &ID{id(subject.AuditID)},
+			Effective: &UserFields{ID: id(subject.EffectiveUserID), Group: &ID{id(subject.EffectiveGroupID)}},
+		}
+		ev.Process = &ProcessFields{PID: subject.ProcessID}
+		ev.Auditd.Session = id(subject.SessionID)
+		ev.Auditd.Summary.Actor = &Actor{Primary: id(subject.AuditID), Secondary: id(subject.EffectiveUserID)}
+		if subject.TerminalAddr.IsValid() && !subject.TerminalAddr.IsUnspecified() {
+			ev.Source = &Endpoint{IP: subject.TerminalAddr.String()}
+		}
+	}
+
+	for _, tok := range rec.Tokens {
+		switch v := tok.(type) {
+		case token.PathToken:
+			ev.Auditd.Paths = append(ev.Auditd.Paths, Path{Name: v.Path})
+		case token.AttributeToken32bit:
+			setAttributes(ev.Auditd.Paths, v.FileAccessMode, v.OwnerUserID, v.OwnerGroupID, v.FileSystemNodeID)
+		case token.AttributeToken64bit:
+			setAttributes(ev.Auditd.Paths, v.FileAccessMode, v.OwnerUserID, v.OwnerGroupID, v.FileSystemNodeID)
+		case token.ExecArgsToken:
+			if ev.Process == nil {
+				ev.Process = &ProcessFields{}
+			}
+			if ev.Process.Args == nil {
+				ev.Process.Args = v.Text
+				ev.Process.Title = strings.Join(v.Text, " ")
+			}
+		case token.ArgToken32bit:
+			ev.Auditd.Data[v.Text] = "0x" + strconv.FormatUint(uint64(v.ArgumentValue), 16)
+		case token.ArgToken64bit:
+			ev.Auditd.Data[v.Text] = "0x" + strconv.FormatUint(v.ArgumentValue, 16)
+		case token.ReturnToken32bit:
+			ev.Auditd.Data["exit"] = strconv.FormatInt(int64(int32(v.ReturnValue)), 10)
+		case token.ReturnToken64bit:
+			ev.Auditd.Data["exit"] = strconv.FormatInt(int64(v.ReturnValue), 10)
+		case token.SeqToken:
+			seq := v.SequenceNumber
+			ev.Auditd.Sequence = &seq
+		case token.ExpandedSocketToken:
+			if addr := v.RemoteAddrPort(); addr.IsValid() && ev.Destination == nil {
+				port := addr.Port()
+				ev.Destination = &Endpoint{IP: addr.Addr().String(), Port: &port}
+			}
+		case token.SocketToken:
+			if addr := v.LocalAddrPort(); addr.IsValid() && ev.Destination == nil {
+				port := addr.Port()
+				ev.Destination = &Endpoint{IP: addr.Addr().String(), Port: &port}
+			}
+		}
+	}
+
+	if len(ev.Auditd.Paths) > 0 {
+		p := ev.Auditd.Paths[0]
+		ev.File = &FileFields{Path: p.Name, Inode: p.Inode, Mode: p.Mode, UID: p.UID, GID: p.GID}
+		ev.Auditd.Summary.Object = &Object{Type: "file", Primary: p.Name}
+	}
+	if action == "executed" && ev.Process != nil {
+		if ev.File != nil {
+			ev.Process.Executable = ev.File.Path
+		} else if len(ev.Process.Args) > 0 {
+			ev.Process.Executable = ev.Process.Args[0]
+		}
+		ev.Process.Name = path.Base(ev.Process.Executable)
+		ev.Auditd.Summary.How = ev.Process.Executable
+	}
+	if ev.Destination != nil {
+		ev.Auditd.Summary.Object = &Object{Type: "socket", Primary: ev.Destination.IP, Secondary: strconv.Itoa(int(*ev.Destination.Port))}
+	}
+	return ev
+}
+
+// id formats a numeric ID like auditbeat does (as string).
+func id(n uint32) string {
+	return strconv.FormatUint(uint64(n), 10)
+}
+
+// setAttributes attaches the file attributes to the last path which has
+// none yet.
+func setAttributes(paths []Path, mode, uid, gid uint32, inode uint64) {
+	if len(paths) == 0 || paths[len(paths)-1].Inode != "" {
+		return
+	}
+	p := &paths[len(paths)-1]
+	p.Inode = strconv.FormatUint(inode, 10)
+	p.Mode = "0" + strconv.FormatUint(uint64(mode), 8)
+	p.UID, p.GID = id(uid), id(gid)
+}
+
+// Marshal returns the auditbeat JSON representation of the given record.
+func Marshal(rec *decode.BsmRecord) ([]byte, error) {
+	return MarshalPolicy(rec, decode.DefaultJSONPolicy)
+}
+
+// MarshalPolicy works like Marshal with the given serialization policy.
+func MarshalPolicy(rec *decode.BsmRecord, policy decode.JSONPolicy) ([]byte, error) {
+	return policy.Marshal(Convert(rec))
+}
+
+// Writer writes records in the auditbeat format, one object per line.
+// It implements output.Sink.
+type Writer struct {
+	Policy decode.JSONPolicy // serialization policy
+	output io.Writer
+}
+
+// NewWriter returns a writer writing to the given output.
+func NewWriter(output io.Writer) *Writer {
+	return &Writer{output: output}
+}
+
+// WriteRecord writes the given record.
+func (w *Writer) WriteRecord(rec *decode.BsmRecord) error {
+	data, err := MarshalPolicy(rec, w.Policy)
+	if err != nil {
+		return err
+	}
+	_, err = w.output.Write(append(data, '\n'))
+	return err
+}
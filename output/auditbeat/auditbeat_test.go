@@ -0,0 +1,102 @@
+package auditbeat
+
+import (
+	"bytes"
+	"encoding/json"
+	"net"
+	"testing"
+
+	"github.com/tpltnt/go-bsm/decode"
+	"github.com/tpltnt/go-bsm/token"
+)
+
+var subject = token.SubjectToken32bit{
+	TokenID:                0x24,
+	AuditID:                1001,
+	EffectiveUserID:        0,
+	RealUserID:             501,
+	ProcessID:              4242,
+	SessionID:              100004,
+	TerminalMachineAddress: net.IPv4(192, 0, 2, 1),
+}
+
+func TestConvertExec(t *testing.T) {
+	rec := decode.BsmRecord{
+		EventType: 23,
+		Seconds:   1520091878,
+		Tokens: []token.Token{
+			token.ExecArgsToken{TokenID: 0x3c, Count: 2, Text: []string{"ls", "-l"}},
+			token.PathToken{TokenID: 0x23, Path: "/bin/ls"},
+			token.AttributeToken32bit{TokenID: 0x3e, FileAccessMode: 0100755, FileSystemNodeID: 42},
+			subject,
+			token.ReturnToken32bit{TokenID: 0x27},
+		},
+	}
+	ev := Convert(&rec)
+	if ev.Timestamp != "2018-03-03T15:44:38Z" || ev.Event.Action != "executed" || ev.Event.Outcome != "success" {
+		t.Errorf("unexpected event %+v", ev.Event)
+	}
+	if ev.User == nil || ev.User.ID != "501" || ev.User.Audit.ID != "1001" || ev.User.Effective.ID != "0" {
+		t.Errorf("unexpected user %+v", ev.User)
+	}
+	if ev.Process == nil || ev.Process.PID != 4242 || ev.Process.Executable != "/bin/ls" || ev.Process.Name != "ls" || ev.Process.Title != "ls -l" {
+		t.Errorf("unexpected process %+v", ev.Process)
+	}
+	if ev.File == nil || ev.File.Inode != "42" || ev.File.Mode != "0100755" {
+		t.Errorf("unexpected file %+v", ev.File)
+	}
+	if ev.Auditd.Session != "100004" || ev.Auditd.Data["syscall"] != "execve" || ev.Auditd.Summary.Actor.Primary != "1001" {
+		t.Errorf("unexpected auditd fields %+v", ev.Auditd)
+	}
+	if ev.Source == nil || ev.Source.IP != "192.0.2.1" {
+		t.Errorf("unexpected source %+v", ev.Source)
+	}
+}
+
+func TestConvertConnect(t *testing.T) {
+	rec := decode.BsmRecord{
+		EventType: 32,
+		Tokens: []token.Token{
+			token.ExpandedSocketToken{
+				TokenID:         0x7f,
+				AddressType:     4,
+				RemotePort:      443,
+				RemoteIpAddress: net.IPv4(198, 51, 100, 7),
+			},
+			token.ReturnToken32bit{TokenID: 0x27, ErrorNumber: 61, ReturnValue: 0xffffffff},
+		},
+	}
+	ev := Convert(&rec)
+	if ev.Event.Action != "connected-to" || ev.Event.Outcome != "failure" || ev.Auditd.Result != "fail" {
+		t.Errorf("unexpected event %+v / %+v", ev.Event, ev.Auditd)
+	}
+	if ev.Destination == nil || ev.Destination.IP != "198.51.100.7" || *ev.Destination.Port != 443 {
+		t.Errorf("unexpected destination %+v", ev.Destination)
+	}
+	if obj := ev.Auditd.Summary.Object; obj == nil || obj.Type != "socket" || obj.Secondary != "443" {
+		t.Errorf("unexpected object %+v", obj)
+	}
+	if ev.Auditd.Data["exit"] != "-1" {
+		t.Errorf("unexpected data %v", ev.Auditd.Data)
+	}
+}
+
+func TestWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	rec := decode.BsmRecord{EventType: 6152, Tokens: []token.Token{subject}}
+	if err := w.WriteRecord(&rec); err != nil {
+		t.Fatal(err)
+	}
+	var out map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &out); err != nil {
+		t.Fatal(err)
+	}
+	event := out["event"].(map[string]interface{})
+	if event["module"] != "auditd" || event["category"] != "user-login" || event["action"] != "logged-in" {
+		t.Errorf("unexpected event %v", event)
+	}
+	if _, ok := out["@timestamp"]; !ok {
+		t.Error("missing @timestamp")
+	}
+}
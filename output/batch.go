@@ -0,0 +1,107 @@
+package output
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/tpltnt/go-bsm/clock"
+	"github.com/tpltnt/go-bsm/decode"
+)
+
+// BatchSink receives groups of records, e.g. for bulk indexing.
+type BatchSink interface {
+	WriteBatch(recs []decode.BsmRecord) error
+}
+
+// Batcher groups records by count, size and time before handing them to
+// a BatchSink. A zero limit is not applied.
+type Batcher struct {
+	Sink       BatchSink
+	MaxRecords int           // flush once the batch holds this many records
+	MaxBytes   int           // flush once the records of the batch reach this size
+	MaxDelay   time.Duration // flush this long after the first record of a batch
+	Clock      clock.Clock   // times MaxDelay, clock.Real if nil
+
+	batch []decode.BsmRecord
+	size  int
+}
+
+// WriteRecord adds a record to the current batch and flushes the batch
+// if it is full. Time based flushing is done by Run.
+func (b *Batcher) WriteRecord(rec *decode.BsmRecord) error {
+	b.batch = append(b.batch, *rec)
+	b.size += int(rec.ByteCount)
+	if b.MaxRecords > 0 && len(b.batch) >= b.MaxRecords {
+		return b.Flush()
+	}
+	if b.MaxBytes > 0 && b.size >= b.MaxBytes {
+		return b.Flush()
+	}
+	return nil
+}
+
+// Flush hands the current batch (if any) to the sink.
+func (b *Batcher) Flush() error {
+	if len(b.batch) == 0 {
+		return nil
+	}
+	batch := b.batch
+	b.batch = nil
+	b.size = 0
+	return b.Sink.WriteBatch(batch)
+}
+
+// Run batches all records of the given stream until it is exhausted, a
+// parsing error occurs or the context is cancelled. The pending batch
+// is flushed in any case before Run returns.
+func (b *Batcher) Run(ctx context.Context, in chan decode.ParsingResult) error {
+	var timeout <-chan time.Time
+	var timer clock.Timer
+	defer func() {
+		if timer != nil {
+			timer.Stop()
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			if err := b.Flush(); err != nil {
+				return err
+			}
+			return ctx.Err()
+
+		case <-timeout:
+			timeout = nil
+			if err := b.Flush(); err != nil {
+				return err
+			}
+
+		case res, ok := <-in:
+			if !ok || res.Error == io.EOF {
+				return b.Flush()
+			}
+			if res.Error != nil {
+				if err := b.Flush(); err != nil {
+					return err
+				}
+				return res.Error
+			}
+			if err := b.WriteRecord(&res.Record); err != nil {
+				return err
+			}
+			switch {
+			case len(b.batch) == 0: // just flushed
+				timeout = nil
+			case len(b.batch) == 1 && b.MaxDelay > 0: // new batch
+				if timer == nil {
+					timer = clock.Or(b.Clock).NewTimer(b.MaxDelay)
+				} else {
+					timer.Reset(b.MaxDelay)
+				}
+				timeout = timer.C()
+			}
+		}
+	}
+}
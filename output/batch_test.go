@@ -0,0 +1,111 @@
+package output
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/tpltnt/go-bsm/clock"
+	"github.com/tpltnt/go-bsm/decode"
+)
+
+type batchCollector struct {
+	batches [][]decode.BsmRecord
+}
+
+func (c *batchCollector) WriteBatch(recs []decode.BsmRecord) error {
+	c.batches = append(c.batches, recs)
+	return nil
+}
+
+func TestBatcherLimits(t *testing.T) {
+	sink := &batchCollector{}
+	batcher := &Batcher{Sink: sink, MaxRecords: 3, MaxBytes: 100}
+	for _, size := range []uint32{10, 10, 10, 60, 50, 10} {
+		if err := batcher.WriteRecord(&decode.BsmRecord{ByteCount: size}); err != nil {
+			t.Error(err)
+		}
+	}
+	batcher.Flush()
+	if len(sink.batches) != 3 {
+		t.Fatal("unexpected number of batches:", len(sink.batches))
+	}
+	// count limit, size limit, rest
+	for i, expected := range []int{3, 2, 1} {
+		if len(sink.batches[i]) != expected {
+			t.Errorf("batch %d: expected %d records, got %d", i, expected, len(sink.batches[i]))
+		}
+	}
+}
+
+func TestBatcherRun(t *testing.T) {
+	sink := &batchCollector{}
+	batcher := &Batcher{Sink: sink, MaxRecords: 100, MaxDelay: 10 * time.Millisecond}
+	in := make(chan decode.ParsingResult)
+	go func() {
+		in <- decode.ParsingResult{Record: decode.BsmRecord{ByteCount: 1}}
+		in <- decode.ParsingResult{Record: decode.BsmRecord{ByteCount: 1}}
+		time.Sleep(50 * time.Millisecond) // time based flush
+		in <- decode.ParsingResult{Record: decode.BsmRecord{ByteCount: 1}}
+		in <- decode.ParsingResult{Error: io.EOF}
+	}()
+	if err := batcher.Run(context.Background(), in); err != nil {
+		t.Error(err)
+	}
+	if len(sink.batches) != 2 || len(sink.batches[0]) != 2 || len(sink.batches[1]) != 1 {
+		t.Error("unexpected batches:", sink.batches)
+	}
+
+	// flush on shutdown
+	sink = &batchCollector{}
+	batcher = &Batcher{Sink: sink, MaxRecords: 100}
+	in = make(chan decode.ParsingResult)
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		in <- decode.ParsingResult{Record: decode.BsmRecord{ByteCount: 1}}
+		cancel()
+	}()
+	if err := batcher.Run(ctx, in); err != context.Canceled {
+		t.Error("expected context.Canceled, got", err)
+	}
+	if len(sink.batches) != 1 || len(sink.batches[0]) != 1 {
+		t.Error("pending batch was not flushed on shutdown")
+	}
+}
+
+type batchFunc func(recs []decode.BsmRecord) error
+
+func (f batchFunc) WriteBatch(recs []decode.BsmRecord) error { return f(recs) }
+
+func TestBatcherClock(t *testing.T) {
+	batches := make(chan []decode.BsmRecord, 2)
+	fake := clock.NewFake(time.Now())
+	batcher := &Batcher{
+		Sink:     batchFunc(func(recs []decode.BsmRecord) error { batches <- recs; return nil }),
+		MaxDelay: time.Minute,
+		Clock:    fake,
+	}
+	in := make(chan decode.ParsingResult)
+	done := make(chan error)
+	go func() { done <- batcher.Run(context.Background(), in) }()
+
+	in <- decode.ParsingResult{Record: decode.BsmRecord{ByteCount: 1}}
+	for fake.Waiters() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	fake.Advance(time.Minute - time.Second)
+	select {
+	case batch := <-batches:
+		t.Fatal("batch flushed early:", batch)
+	case <-time.After(10 * time.Millisecond):
+	}
+	fake.Advance(time.Second)
+	if batch := <-batches; len(batch) != 1 {
+		t.Error("unexpected batch:", batch)
+	}
+	close(in)
+	if err := <-done; err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,159 @@
+package output
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+)
+
+// Codec compresses the data written by a sink, e.g. newline-delimited
+// JSON which is sent over a WAN link or kept in files.
+type Codec struct {
+	Name      string // e.g. "gzip", like the HTTP Content-Encoding
+	Extension string // suffix of compressed files, e.g. ".gz"
+	NewWriter func(w io.Writer) (io.WriteCloser, error)
+	NewReader func(r io.Reader) (io.ReadCloser, error)
+}
+
+// Names of the built-in codecs.
+const (
+	CodecIdentity = "identity" // no compression
+	CodecGzip     = "gzip"
+	CodecDeflate  = "deflate"
+)
+
+// nopCloser adds a Close method without effect to a writer.
+type nopCloser struct{ io.Writer }
+
+func (nopCloser) Close() error { return nil }
+
+var codecs = struct {
+	sync.RWMutex
+	byName map[string]Codec
+}{byName: map[string]Codec{
+	CodecIdentity: {
+		Name:      CodecIdentity,
+		NewWriter: func(w io.Writer) (io.WriteCloser, error) { return nopCloser{w}, nil },
+		NewReader: func(r io.Reader) (io.ReadCloser, error) { return io.NopCloser(r), nil },
+	},
+	CodecGzip: {
+		Name:      CodecGzip,
+		Extension: ".gz",
+		NewWriter: func(w io.Writer) (io.WriteCloser, error) { return gzip.NewWriter(w), nil },
+		NewReader: func(r io.Reader) (io.ReadCloser, error) { return gzip.NewReader(r) },
+	},
+	CodecDeflate: {
+		Name:      CodecDeflate,
+		Extension: ".deflate",
+		NewWriter: func(w io.Writer) (io.WriteCloser, error) { return flate.NewWriter(w, flate.DefaultCompression) },
+		NewReader: func(r io.Reader) (io.ReadCloser, error) { return flate.NewReader(r), nil },
+	},
+}}
+
+// RegisterCodec makes a codec available by its name, replacing a codec
+// of the same name. Codecs depending on third party packages, e.g. zstd
+// or snappy, are registered by the program, e.g.
+//
+//	output.RegisterCodec(output.Codec{
+//		Name:      "zstd",
+//		Extension: ".zst",
+//		NewWriter: func(w io.Writer) (io.WriteCloser, error) { return zstd.NewWriter(w) },
+//		NewReader: func(r io.Reader) (io.ReadCloser, error) {
+//			d, err := zstd.NewReader(r)
+//			return d.IOReadCloser(), err
+//		},
+//	})
+func RegisterCodec(codec Codec) {
+	codecs.Lock()
+	defer codecs.Unlock()
+	codecs.byName[codec.Name] = codec
+}
+
+// LookupCodec returns the codec of the given name.
+func LookupCodec(name string) (Codec, error) {
+	codecs.RLock()
+	defer codecs.RUnlock()
+	codec, ok := codecs.byName[name]
+	if !ok {
+		return Codec{}, fmt.Errorf("unknown codec %q", name)
+	}
+	return codec, nil
+}
+
+// CodecNames returns the sorted names of the registered codecs.
+func CodecNames() []string {
+	codecs.RLock()
+	defer codecs.RUnlock()
+	names := make([]string, 0, len(codecs.byName))
+	for name := range codecs.byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NegotiateCodec returns the first of the preferred codecs (e.g. of the
+// sink configuration) which is registered and accepted by the receiver
+// (e.g. by the Accept-Encoding of an HTTP server). All registered
+// codecs are accepted if accepted is nil. Without a match the data is
+// sent uncompressed (CodecIdentity).
+func NegotiateCodec(preferred, accepted []string) Codec {
+	for _, name := range preferred {
+		if accepted != nil && !contains(accepted, name) {
+			continue
+		}
+		if codec, err := LookupCodec(name); err == nil {
+			return codec
+		}
+	}
+	codec, _ := LookupCodec(CodecIdentity)
+	return codec
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// flusher is implemented by compressors which can emit the data
+// compressed so far, e.g. gzip.Writer.
+type flusher interface {
+	Flush() error
+}
+
+// streamWriter flushes the compressor after every write.
+type streamWriter struct {
+	io.WriteCloser
+	flusher flusher
+}
+
+func (w streamWriter) Write(p []byte) (int, error) {
+	n, err := w.WriteCloser.Write(p)
+	if err == nil {
+		err = w.flusher.Flush()
+	}
+	return n, err
+}
+
+// CompressStream returns a writer compressing the data written to w
+// with the codec. Unlike the writer of the codec it flushes every write
+// (if the compressor supports it), so each record reaches the receiver
+// right away while the compression still benefits from the previous
+// records. Closing it doesn't close w.
+func CompressStream(w io.Writer, codec Codec) (io.WriteCloser, error) {
+	cw, err := codec.NewWriter(w)
+	if err != nil {
+		return nil, err
+	}
+	if f, ok := cw.(flusher); ok {
+		return streamWriter{WriteCloser: cw, flusher: f}, nil
+	}
+	return cw, nil
+}
@@ -0,0 +1,88 @@
+package output
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCodecRoundTrip(t *testing.T) {
+	for _, name := range []string{CodecIdentity, CodecGzip, CodecDeflate} {
+		codec, err := LookupCodec(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		w, err := codec.NewWriter(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data := strings.Repeat(`{"event":"execve"}`+"\n", 100)
+		io.WriteString(w, data)
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		r, err := codec.NewReader(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := io.ReadAll(r)
+		if err != nil || string(got) != data {
+			t.Errorf("%s: data not restored (%v)", name, err)
+		}
+	}
+	if _, err := LookupCodec("lz4"); err == nil {
+		t.Error("expected error for unknown codec")
+	}
+}
+
+func TestNegotiateCodec(t *testing.T) {
+	RegisterCodec(Codec{Name: "test", Extension: ".test", NewWriter: func(w io.Writer) (io.WriteCloser, error) { return nopCloser{w}, nil }})
+	defer func() {
+		codecs.Lock()
+		delete(codecs.byName, "test")
+		codecs.Unlock()
+	}()
+	if names := CodecNames(); !reflect.DeepEqual(names, []string{"deflate", "gzip", "identity", "test"}) {
+		t.Error("unexpected codecs:", names)
+	}
+
+	for _, test := range []struct {
+		preferred, accepted []string
+		want                string
+	}{
+		{[]string{"zstd", "test", "gzip"}, nil, "test"},            // zstd isn't registered
+		{[]string{"test", "gzip"}, []string{"gzip", "br"}, "gzip"}, // test isn't accepted
+		{[]string{"zstd"}, nil, CodecIdentity},
+		{nil, nil, CodecIdentity},
+	} {
+		if codec := NegotiateCodec(test.preferred, test.accepted); codec.Name != test.want {
+			t.Errorf("%v/%v: got %s, expected %s", test.preferred, test.accepted, codec.Name, test.want)
+		}
+	}
+}
+
+func TestCompressStream(t *testing.T) {
+	codec, _ := LookupCodec(CodecGzip)
+	var buf bytes.Buffer
+	w, err := CompressStream(&buf, codec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.WriteString(w, "first record\n")
+	// the record is readable before the stream is closed
+	r, err := gzip.NewReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := io.ReadAll(r)
+	if string(got) != "first record\n" {
+		t.Errorf("got %q", got)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
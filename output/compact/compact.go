@@ -0,0 +1,219 @@
+// Package compact formats common audit events as purpose-built JSON
+// objects, e.g.
+//
+//	{"event":"execve","exe":"/bin/ls","args":["ls","-l"],"uid":0,...}
+//
+// instead of the generic token list of decode.BsmRecord. Records of
+// other events are formatted like decode.BsmRecord.MarshalJSON does.
+package compact
+
+import (
+	"io"
+	"time"
+
+	"github.com/tpltnt/go-bsm/decode"
+	"github.com/tpltnt/go-bsm/token"
+)
+
+// events maps the supported event types to their compact event name.
+var events = map[token.EventType]string{
+	token.AUE_EXEC:     "execve",
+	token.AUE_EXECVE:   "execve",
+	token.AUE_OPEN:     "open",
+	token.AUE_CONNECT:  "connect",
+	token.AUE_SETREUID: "setuid",
+	token.AUE_SETUID:   "setuid",
+	token.AUE_SETEUID:  "setuid",
+	token.AUE_login:    "login",
+	token.AUE_logout:   "logout",
+	token.AUE_ssh:      "login",
+	token.AUE_openssh:  "login",
+}
+
+func init() {
+	for eventType := token.AUE_OPEN_R; eventType <= token.AUE_OPEN_RWTC; eventType++ {
+		events[eventType] = "open"
+	}
+}
+
+// common holds the fields shared by all compact events.
+type common struct {
+	Event       string            `json:"event"`
+	Time        string            `json:"time"`
+	EventType   uint16            `json:"event_type"`
+	Success     bool              `json:"success"`
+	AuditID     *uint32           `json:"auid,omitempty"`
+	UserID      *uint32           `json:"uid,omitempty"`
+	ProcessID   *uint32           `json:"pid,omitempty"`
+	Source      string            `json:"source,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Truncated   bool              `json:"truncated,omitempty"`
+	Escaped     bool              `json:"escaped,omitempty"` // strings escaped with decode.EscapeString
+}
+
+type execEvent struct {
+	common
+	Exe  string   `json:"exe,omitempty"`
+	Args []string `json:"args,omitempty"`
+}
+
+type openEvent struct {
+	common
+	Path  string  `json:"path,omitempty"`
+	Flags *uint64 `json:"flags,omitempty"`
+}
+
+type connectEvent struct {
+	common
+	Address string  `json:"address,omitempty"`
+	Port    *uint16 `json:"port,omitempty"`
+}
+
+type setuidEvent struct {
+	common
+	NewUserID *uint64 `json:"new_uid,omitempty"`
+}
+
+type loginEvent struct {
+	common
+	Text string `json:"text,omitempty"`
+}
+
+// Supported reports whether records of the given event type have a
+// compact representation.
+func Supported(eventType uint16) bool {
+	_, ok := events[token.EventType(eventType)]
+	return ok
+}
+
+// Marshal returns the compact JSON representation of the given record.
+func Marshal(rec *decode.BsmRecord) ([]byte, error) {
+	return MarshalPolicy(rec, decode.DefaultJSONPolicy)
+}
+
+// MarshalPolicy works like Marshal with the given serialization policy.
+func MarshalPolicy(rec *decode.BsmRecord, policy decode.JSONPolicy) ([]byte, error) {
+	event, ok := events[rec.Event()]
+	if !ok {
+		return policy.Marshal(rec)
+	}
+	c := common{
+		Event:       event,
+		Time:        rec.Time().UTC().Format(time.RFC3339Nano),
+		EventType:   rec.EventType,
+		Success:     !rec.Failed(),
+		Annotations: rec.Annotations,
+		Truncated:   rec.Truncated,
+	}
+	// keep the raw bytes of invalid UTF-8, which JSON would replace
+	str := func(s string) string { return s }
+	if !rec.ValidUTF8() {
+		c.Escaped = true
+		str = decode.EscapeString
+	}
+	if subject, ok := rec.Subject(); ok {
+		c.AuditID = &subject.AuditID
+		c.UserID = &subject.EffectiveUserID
+		c.ProcessID = &subject.ProcessID
+		if subject.TerminalAddr.IsValid() && !subject.TerminalAddr.IsUnspecified() {
+			c.Source = subject.TerminalAddr.String()
+		}
+	}
+
+	switch event {
+	case "execve":
+		out := execEvent{common: c}
+		for _, tok := range rec.Tokens {
+			if v, ok := tok.(token.ExecArgsToken); ok && out.Args == nil {
+				out.Args = make([]string, len(v.Text))
+				for i, arg := range v.Text {
+					out.Args[i] = str(arg)
+				}
+			}
+		}
+		if path, ok := rec.Path(); ok {
+			out.Exe = str(path)
+		} else if len(out.Args) > 0 {
+			out.Exe = out.Args[0]
+		}
+		return policy.Marshal(out)
+	case "open":
+		out := openEvent{common: c, Flags: argument(rec, "flags")}
+		path, _ := rec.Path()
+		out.Path = str(path)
+		return policy.Marshal(out)
+	case "connect":
+		out := connectEvent{common: c}
+		for _, tok := range rec.Tokens {
+			switch v := tok.(type) {
+			case token.ExpandedSocketToken:
+				if addr := v.RemoteAddrPort(); addr.IsValid() {
+					port := addr.Port()
+					out.Address, out.Port = addr.Addr().String(), &port
+				}
+			case token.SocketToken:
+				if addr := v.LocalAddrPort(); addr.IsValid() && out.Address == "" {
+					port := addr.Port()
+					out.Address, out.Port = addr.Addr().String(), &port
+				}
+			}
+		}
+		return policy.Marshal(out)
+	case "setuid":
+		out := setuidEvent{common: c, NewUserID: argument(rec, "uid")}
+		if out.NewUserID == nil {
+			out.NewUserID = argument(rec, "euid")
+		}
+		return policy.Marshal(out)
+	default: // login, logout
+		out := loginEvent{common: c}
+		for _, tok := range rec.Tokens {
+			if v, ok := tok.(token.TextToken); ok {
+				out.Text = str(v.Text)
+				break
+			}
+		}
+		return policy.Marshal(out)
+	}
+}
+
+// argument returns the value of the first argument with the given
+// description, e.g. "flags".
+func argument(rec *decode.BsmRecord, name string) *uint64 {
+	for _, tok := range rec.Tokens {
+		switch v := tok.(type) {
+		case token.ArgToken32bit:
+			if v.Text == name {
+				value := uint64(v.ArgumentValue)
+				return &value
+			}
+		case token.ArgToken64bit:
+			if v.Text == name {
+				return &v.ArgumentValue
+			}
+		}
+	}
+	return nil
+}
+
+// Writer writes records as compact JSON, one object per line. It
+// implements output.Sink.
+type Writer struct {
+	Policy decode.JSONPolicy // serialization policy (the zero value omits unset fields)
+	output io.Writer
+}
+
+// NewWriter returns a writer writing to the given output.
+func NewWriter(output io.Writer) *Writer {
+	return &Writer{output: output}
+}
+
+// WriteRecord writes the given record.
+func (w *Writer) WriteRecord(rec *decode.BsmRecord) error {
+	data, err := MarshalPolicy(rec, w.Policy)
+	if err != nil {
+		return err
+	}
+	_, err = w.output.Write(append(data, '\n'))
+	return err
+}
@@ -0,0 +1,140 @@
+package compact
+
+import (
+	"bytes"
+	"encoding/json"
+	"net"
+	"testing"
+
+	"github.com/tpltnt/go-bsm/decode"
+	"github.com/tpltnt/go-bsm/token"
+)
+
+var subject = token.SubjectToken32bit{
+	TokenID:                0x24,
+	AuditID:                1001,
+	EffectiveUserID:        0,
+	ProcessID:              4242,
+	TerminalMachineAddress: net.IPv4(192, 0, 2, 1),
+}
+
+func TestMarshalExec(t *testing.T) {
+	rec := decode.BsmRecord{
+		EventType: 23,
+		Seconds:   1520091878,
+		Tokens: []token.Token{
+			token.ExecArgsToken{TokenID: 0x3c, Count: 2, Text: []string{"ls", "-l"}},
+			token.PathToken{TokenID: 0x23, Path: "/bin/ls"},
+			subject,
+			token.ReturnToken32bit{TokenID: 0x27},
+		},
+	}
+	data, err := Marshal(&rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"event":"execve","time":"2018-03-03T15:44:38Z","event_type":23,"success":true,` +
+		`"auid":1001,"uid":0,"pid":4242,"source":"192.0.2.1","exe":"/bin/ls","args":["ls","-l"]}`
+	if string(data) != want {
+		t.Errorf("unexpected JSON:\n%s\nwant:\n%s", data, want)
+	}
+}
+
+func TestSupported(t *testing.T) {
+	for _, eventType := range []token.EventType{token.AUE_SETUID, token.AUE_SETEUID, token.AUE_OPEN_RWTC} {
+		if !Supported(uint16(eventType)) {
+			t.Error("unsupported event", eventType)
+		}
+	}
+	if Supported(201) { // AUE_STIME
+		t.Error("stime(2) supported as setuid")
+	}
+}
+
+func TestMarshalConnect(t *testing.T) {
+	rec := decode.BsmRecord{
+		EventType: 32,
+		Tokens: []token.Token{
+			token.ExpandedSocketToken{
+				TokenID:         0x7f,
+				AddressType:     4,
+				RemotePort:      443,
+				RemoteIpAddress: net.IPv4(198, 51, 100, 7),
+			},
+			token.ReturnToken32bit{TokenID: 0x27, ErrorNumber: 61, ReturnValue: 0xffffffff},
+		},
+	}
+	data, err := Marshal(&rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out map[string]interface{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out["event"] != "connect" || out["address"] != "198.51.100.7" || out["port"] != 443.0 || out["success"] != false {
+		t.Error("unexpected connect event:", string(data))
+	}
+}
+
+func TestWriterFallback(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	rec := decode.BsmRecord{EventType: 45000}
+	if Supported(rec.EventType) {
+		t.Error("unexpected compact form for event 45000")
+	}
+	if err := w.WriteRecord(&rec); err != nil {
+		t.Fatal(err)
+	}
+	var out map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &out); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := out["tokens"]; !ok {
+		t.Error("expected generic record:", buf.String())
+	}
+}
+
+func TestMarshalEscaped(t *testing.T) {
+	rec := decode.BsmRecord{
+		EventType: 72,
+		Seconds:   1520091878,
+		Tokens: []token.Token{
+			token.PathToken{TokenID: 0x23, Path: "/tmp/caf\xe9\n"},
+			token.ReturnToken32bit{TokenID: 0x27},
+		},
+	}
+	data, err := Marshal(&rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out struct {
+		Path    string `json:"path"`
+		Escaped bool   `json:"escaped"`
+	}
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatal(err)
+	}
+	if !out.Escaped || out.Path != `/tmp/caf\xe9\n` {
+		t.Errorf("unexpected JSON %s", data)
+	}
+}
+
+func TestMarshalPolicy(t *testing.T) {
+	rec := decode.BsmRecord{
+		EventType: 72,
+		Tokens: []token.Token{
+			token.ArgToken64bit{TokenID: 0x71, ArgumentID: 2, ArgumentValue: 1 << 60, Text: "flags"},
+			token.ReturnToken32bit{TokenID: 0x27},
+		},
+	}
+	data, err := MarshalPolicy(&rec, decode.JSONPolicy{Nulls: true, StringInt64: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"event":"open","time":"1970-01-01T00:00:00Z","event_type":72,"success":true,"auid":null,"uid":null,"pid":null,"source":"","annotations":null,"truncated":false,"escaped":false,"path":"","flags":"1152921504606846976"}`
+	if string(data) != expected {
+		t.Errorf("got %s, expected %s", data, expected)
+	}
+}
@@ -0,0 +1,56 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// ExecScheme prefixes the destinations naming a command, e.g.
+// "exec:///usr/local/bin/forward --host siem".
+const ExecScheme = "exec://"
+
+// Command is a subprocess receiving data (e.g. serialized records) on
+// its standard input.
+type Command struct {
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+}
+
+// StartCommand starts the given command line, split at white space
+// (there is no quoting). The standard output and error of the command
+// are the ones of the current process.
+func StartCommand(command string) (*Command, error) {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return nil, fmt.Errorf("empty command")
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	return &Command{cmd: cmd, stdin: stdin}, nil
+}
+
+// Write passes data to the command.
+func (c *Command) Write(p []byte) (int, error) {
+	return c.stdin.Write(p)
+}
+
+// Close closes the standard input of the command and waits for it to
+// exit. An exit status other than 0 is returned as error.
+func (c *Command) Close() error {
+	err := c.stdin.Close()
+	if werr := c.cmd.Wait(); werr != nil {
+		err = fmt.Errorf("%s: %w", c.cmd.Path, werr)
+	}
+	return err
+}
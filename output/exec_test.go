@@ -0,0 +1,53 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestHelperProcess is the command started by the tests: it copies its
+// standard input to the file named by BSM_HELPER_OUTPUT.
+func TestHelperProcess(t *testing.T) {
+	path := os.Getenv("BSM_HELPER_OUTPUT")
+	if path == "" {
+		return
+	}
+	data, err := io.ReadAll(os.Stdin)
+	if err == nil {
+		err = os.WriteFile(path, data, 0o600)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+func TestStartCommand(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "out")
+	t.Setenv("BSM_HELPER_OUTPUT", out)
+	cmd, err := StartCommand(os.Args[0] + " -test.run=^TestHelperProcess$")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.WriteString(cmd, "{}\n{}\n"); err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Close(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "{}\n{}\n" {
+		t.Errorf("unexpected output %q", data)
+	}
+
+	if _, err := StartCommand(" "); err == nil {
+		t.Error("expected error for empty command")
+	}
+}
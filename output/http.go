@@ -0,0 +1,123 @@
+package output
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/tpltnt/go-bsm/decode"
+)
+
+// HTTPSink posts records as newline-delimited JSON to a URL, compressed
+// with the first of the preferred codecs the server accepts. Servers
+// announce the codecs they accept in the Accept-Encoding header of their
+// responses (RFC 7694). Until the first response the preferred codec is
+// used, a 415 (Unsupported Media Type) response makes the sink send the
+// request again with a codec the server accepts. It implements Sink and
+// BatchSink, e.g. to post batches with a Batcher.
+type HTTPSink struct {
+	URL       string            // endpoint the records are posted to
+	Client    *http.Client      // http.DefaultClient if nil
+	Preferred []string          // codecs in order of preference (see NegotiateCodec), uncompressed if empty
+	Policy    decode.JSONPolicy // serialization policy
+
+	mutex    sync.Mutex
+	accepted []string // codecs accepted by the server, nil if not known yet
+}
+
+// NewHTTPSink returns a sink posting to the given URL.
+func NewHTTPSink(url string, preferred []string) *HTTPSink {
+	return &HTTPSink{URL: url, Preferred: preferred}
+}
+
+// WriteRecord posts a single record.
+func (s *HTTPSink) WriteRecord(rec *decode.BsmRecord) error {
+	return s.WriteBatch([]decode.BsmRecord{*rec})
+}
+
+// WriteBatch posts the records in one request.
+func (s *HTTPSink) WriteBatch(recs []decode.BsmRecord) error {
+	var body bytes.Buffer
+	for i := range recs {
+		data, err := s.Policy.Marshal(&recs[i])
+		if err != nil {
+			return err
+		}
+		body.Write(data)
+		body.WriteByte('\n')
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	codec := NegotiateCodec(s.Preferred, s.accepted)
+	status, err := s.post(body.Bytes(), codec)
+	if status == http.StatusUnsupportedMediaType && codec.Name != CodecIdentity {
+		_, err = s.post(body.Bytes(), NegotiateCodec(s.Preferred, s.accepted))
+	}
+	return err
+}
+
+// post sends the data compressed with the codec and notes the codecs
+// accepted by the server. It returns the status code of the response.
+func (s *HTTPSink) post(data []byte, codec Codec) (int, error) {
+	var body bytes.Buffer
+	w, err := codec.NewWriter(&body)
+	if err != nil {
+		return 0, err
+	}
+	w.Write(data)
+	if err := w.Close(); err != nil {
+		return 0, err
+	}
+	req, err := http.NewRequest(http.MethodPost, s.URL, &body)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	if codec.Name != CodecIdentity {
+		req.Header.Set("Content-Encoding", codec.Name)
+	}
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	if header, ok := resp.Header["Accept-Encoding"]; ok {
+		s.accepted = parseAcceptEncoding(header)
+	} else if resp.StatusCode == http.StatusUnsupportedMediaType {
+		s.accepted = []string{} // no coding accepted
+	}
+	if resp.StatusCode/100 != 2 {
+		return resp.StatusCode, fmt.Errorf("post %s: %s", s.URL, resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// parseAcceptEncoding returns the codings listed by Accept-Encoding
+// headers, e.g. "gzip, deflate;q=0.5". Codings with a weight of 0 are
+// left out.
+func parseAcceptEncoding(header []string) []string {
+	accepted := []string{}
+	for _, line := range header {
+		for _, item := range strings.Split(line, ",") {
+			name, params, _ := strings.Cut(item, ";")
+			name = strings.ToLower(strings.TrimSpace(name))
+			weight, isWeight := strings.CutPrefix(strings.TrimSpace(params), "q=")
+			if q, err := strconv.ParseFloat(weight, 64); name == "" || isWeight && err == nil && q == 0 {
+				continue
+			}
+			accepted = append(accepted, name)
+		}
+	}
+	return accepted
+}
@@ -0,0 +1,82 @@
+package output
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tpltnt/go-bsm/decode"
+)
+
+func TestHTTPSinkNegotiation(t *testing.T) {
+	var encodings []string
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encoding := r.Header.Get("Content-Encoding")
+		encodings = append(encodings, encoding)
+		w.Header().Set("Accept-Encoding", "gzip, deflate;q=0")
+		if encoding != CodecGzip {
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+			return
+		}
+		gz, err := gzip.NewReader(r.Body)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		data, _ := io.ReadAll(gz)
+		bodies = append(bodies, string(data))
+	}))
+	defer server.Close()
+
+	sink := NewHTTPSink(server.URL, []string{CodecDeflate, CodecGzip})
+	for i := 0; i < 2; i++ {
+		if err := sink.WriteRecord(&decode.BsmRecord{EventType: 23}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// deflate is rejected once, gzip is used from then on
+	if want := []string{CodecDeflate, CodecGzip, CodecGzip}; !reflect.DeepEqual(encodings, want) {
+		t.Errorf("got encodings %q, expected %q", encodings, want)
+	}
+	if len(bodies) != 2 || !strings.Contains(bodies[0], `"event_type":23`) || !strings.HasSuffix(bodies[0], "\n") {
+		t.Errorf("unexpected bodies %q", bodies)
+	}
+}
+
+func TestHTTPSinkIdentity(t *testing.T) {
+	var encodings []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encodings = append(encodings, r.Header.Get("Content-Encoding"))
+		if r.Header.Get("Content-Encoding") != "" {
+			w.WriteHeader(http.StatusUnsupportedMediaType) // no Accept-Encoding
+		}
+	}))
+	defer server.Close()
+
+	sink := NewHTTPSink(server.URL, []string{CodecGzip})
+	if err := sink.WriteBatch([]decode.BsmRecord{{}, {}}); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{CodecGzip, ""}; !reflect.DeepEqual(encodings, want) {
+		t.Errorf("got encodings %q, expected %q", encodings, want)
+	}
+
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	if err := sink.WriteRecord(&decode.BsmRecord{}); err == nil {
+		t.Error("expected error for 503")
+	}
+}
+
+func TestParseAcceptEncoding(t *testing.T) {
+	got := parseAcceptEncoding([]string{"gzip;q=1.0, Deflate", "zstd; q=0, br;q=0.5"})
+	if want := []string{"gzip", "deflate", "br"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, expected %q", got, want)
+	}
+}
@@ -0,0 +1,133 @@
+package output
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/tpltnt/go-bsm/clock"
+	"github.com/tpltnt/go-bsm/decode"
+)
+
+// NDJSONWriter writes records as newline-delimited JSON to files in a
+// directory, optionally compressed, and starts a new file once the
+// current one is large enough. Files are written with a ".part" suffix,
+// which is removed when they are complete, so shippers can pick up all
+// files without it. It implements Sink.
+type NDJSONWriter struct {
+	Dir      string            // directory of the files
+	Prefix   string            // prefix of the file names, "records" if empty
+	Gzip     bool              // compress the files with gzip (".ndjson.gz")
+	Codec    string            // compress the files with this codec instead (see RegisterCodec)
+	MaxBytes int64             // rotate once this many bytes (before compression) were written, 0 never
+	Policy   decode.JSONPolicy // serialization policy
+	OnRotate func(path string) // called with the path of every completed file, e.g. to ship it
+	Clock    clock.Clock       // time of the file names, clock.Real if nil
+
+	file    *os.File
+	enc     io.WriteCloser // compressor (if any)
+	w       *bufio.Writer
+	path    string // path of the current file (without ".part")
+	written int64  // bytes written to the current file
+	files   int    // number of files started
+}
+
+// NewNDJSONWriter returns a writer creating files in the given directory.
+func NewNDJSONWriter(dir string) *NDJSONWriter {
+	return &NDJSONWriter{Dir: dir}
+}
+
+// WriteRecord appends the record to the current file, creating one if
+// needed, and rotates the file if it reached MaxBytes.
+func (w *NDJSONWriter) WriteRecord(rec *decode.BsmRecord) error {
+	data, err := w.Policy.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	if w.file == nil {
+		if err := w.open(); err != nil {
+			return err
+		}
+	}
+	n, err := w.w.Write(append(data, '\n'))
+	w.written += int64(n)
+	if err != nil {
+		return err
+	}
+	if w.MaxBytes > 0 && w.written >= w.MaxBytes {
+		return w.Rotate()
+	}
+	return nil
+}
+
+// open starts a new file.
+func (w *NDJSONWriter) open() error {
+	prefix := w.Prefix
+	if prefix == "" {
+		prefix = "records"
+	}
+	codec := w.Codec
+	if codec == "" && w.Gzip {
+		codec = CodecGzip
+	}
+	var c Codec
+	if codec != "" {
+		var err error
+		if c, err = LookupCodec(codec); err != nil {
+			return err
+		}
+	}
+	w.files += 1
+	name := fmt.Sprintf("%s-%s-%04d.ndjson", prefix, clock.Or(w.Clock).Now().UTC().Format("20060102T150405Z"), w.files)
+	w.path = filepath.Join(w.Dir, name+c.Extension)
+	file, err := os.OpenFile(w.path+".part", os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	var out io.Writer = file
+	if c.NewWriter != nil {
+		if w.enc, err = c.NewWriter(file); err != nil {
+			file.Close()
+			return err
+		}
+		out = w.enc
+	}
+	w.file, w.written = file, 0
+	w.w = bufio.NewWriter(out)
+	return nil
+}
+
+// Rotate completes the current file (if any). The next record starts a
+// new one.
+func (w *NDJSONWriter) Rotate() error {
+	if w.file == nil {
+		return nil
+	}
+	err := w.w.Flush()
+	if w.enc != nil {
+		if encErr := w.enc.Close(); err == nil {
+			err = encErr
+		}
+	}
+	if closeErr := w.file.Close(); err == nil {
+		err = closeErr
+	}
+	w.file, w.enc, w.w = nil, nil, nil
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(w.path+".part", w.path); err != nil {
+		return err
+	}
+	if w.OnRotate != nil {
+		w.OnRotate(w.path)
+	}
+	return nil
+}
+
+// Close completes the current file.
+func (w *NDJSONWriter) Close() error {
+	return w.Rotate()
+}
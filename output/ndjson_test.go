@@ -0,0 +1,97 @@
+package output
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tpltnt/go-bsm/decode"
+)
+
+func TestNDJSONWriter(t *testing.T) {
+	dir := t.TempDir()
+	w := NewNDJSONWriter(dir)
+	w.Gzip = true
+	w.MaxBytes = 600
+	var rotated []string
+	w.OnRotate = func(path string) { rotated = append(rotated, path) }
+	for i := 0; i < 5; i++ {
+		if err := w.WriteRecord(&decode.BsmRecord{EventType: uint16(i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if parts, _ := filepath.Glob(filepath.Join(dir, "*.part")); len(parts) != 1 {
+		t.Errorf("expected one incomplete file, got %v", parts)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "records-*.ndjson.gz"))
+	if len(files) < 2 || len(files) != len(rotated) {
+		t.Fatalf("unexpected files %v, rotated %v", files, rotated)
+	}
+	var events []uint16
+	for _, name := range files {
+		file, err := os.Open(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		scanner := bufio.NewScanner(gz)
+		for scanner.Scan() {
+			var rec struct {
+				EventType uint16 `json:"event_type"`
+			}
+			if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+				t.Fatal(err)
+			}
+			events = append(events, rec.EventType)
+		}
+		file.Close()
+	}
+	if len(events) != 5 || events[0] != 0 || events[4] != 4 {
+		t.Errorf("unexpected records %v", events)
+	}
+}
+
+func TestNDJSONWriterPlain(t *testing.T) {
+	dir := t.TempDir()
+	w := NewNDJSONWriter(dir)
+	w.Prefix = "audit"
+	w.WriteRecord(&decode.BsmRecord{EventType: 23})
+	w.Close()
+	files, _ := filepath.Glob(filepath.Join(dir, "audit-*.ndjson"))
+	if len(files) != 1 {
+		t.Fatal("unexpected files:", files)
+	}
+	data, _ := os.ReadFile(files[0])
+	if !strings.HasPrefix(string(data), `{"schema_version":`) || !strings.HasSuffix(string(data), "}\n") {
+		t.Errorf("unexpected content %q", data)
+	}
+}
+
+func TestNDJSONWriterCodec(t *testing.T) {
+	dir := t.TempDir()
+	w := NewNDJSONWriter(dir)
+	w.Codec = CodecDeflate
+	w.WriteRecord(&decode.BsmRecord{EventType: 23})
+	w.Close()
+	files, _ := filepath.Glob(filepath.Join(dir, "records-*.ndjson.deflate"))
+	if len(files) != 1 {
+		t.Fatal("unexpected files:", files)
+	}
+
+	w = NewNDJSONWriter(dir)
+	w.Codec = "lz4"
+	if err := w.WriteRecord(&decode.BsmRecord{EventType: 23}); err == nil {
+		t.Error("expected error for unknown codec")
+	}
+}
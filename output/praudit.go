@@ -0,0 +1,200 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"reflect"
+	"strings"
+
+	"github.com/tpltnt/go-bsm/decode"
+	"github.com/tpltnt/go-bsm/token"
+)
+
+// PrauditWriter writes records like `praudit -r` does: one line per
+// token with the numeric token ID followed by the raw (numeric) values
+// of its fields, separated by commas. Strings are escaped (see
+// decode.EscapeString) with commas written as \x2c, so a file name
+// can't forge fields or lines. It implements Sink.
+//
+// The conversion is best effort: the header is written as 32 bit (or
+// 64 bit if the time doesn't fit) header token, as a decoded record
+// doesn't keep the header variant, and tokens without a known praudit
+// layout are written with all their fields in declaration order.
+type PrauditWriter struct {
+	OneLine bool // write a record per line like `praudit -rl`
+
+	output io.Writer
+}
+
+// NewPrauditWriter returns a writer writing to the given output.
+func NewPrauditWriter(output io.Writer) *PrauditWriter {
+	return &PrauditWriter{output: output}
+}
+
+// WriteRecord writes the given record.
+func (w *PrauditWriter) WriteRecord(rec *decode.BsmRecord) error {
+	lines := PrauditRaw(rec)
+	sep := "\n"
+	if w.OneLine {
+		sep = ","
+	}
+	_, err := io.WriteString(w.output, strings.Join(lines, sep)+"\n")
+	return err
+}
+
+// PrauditRaw returns the lines `praudit -r` prints for the record, from
+// header to trailer.
+func PrauditRaw(rec *decode.BsmRecord) []string {
+	header := 0x14
+	if rec.Seconds > math.MaxUint32 {
+		header = 0x74
+	}
+	lines := []string{fmt.Sprintf("%d,%d,%d,%d,%d,%d,%d", header, rec.ByteCount, rec.Version,
+		rec.EventType, rec.EventModifier, rec.Seconds, rec.NanoSeconds)}
+	for _, tok := range rec.Tokens {
+		lines = append(lines, prauditToken(tok))
+	}
+	return append(lines, fmt.Sprintf("%d,%d", 0x13, rec.ByteCount))
+}
+
+// prauditID formats a user or group ID like praudit does (signed).
+func prauditID(id uint32) string {
+	return fmt.Sprint(int32(id))
+}
+
+// prauditLine joins the token ID and the fields of a token. The fields
+// are escaped, so they can't add fields or lines.
+func prauditLine(id byte, fields ...interface{}) string {
+	parts := make([]string, 0, len(fields)+1)
+	parts = append(parts, fmt.Sprint(id))
+	for _, f := range fields {
+		parts = append(parts, prauditEscape(fmt.Sprint(f)))
+	}
+	return strings.Join(parts, ",")
+}
+
+// prauditEscape escapes s like decode.EscapeString does, and the field
+// delimiter as \x2c.
+func prauditEscape(s string) string {
+	return strings.ReplaceAll(decode.EscapeString(s), ",", `\x2c`)
+}
+
+// prauditProcess formats the fields shared by subject and process
+// tokens.
+func prauditProcess(id byte, auid, euid, egid, ruid, rgid, pid, sid uint32, port uint64, addr net.IP) string {
+	return prauditLine(id, prauditID(auid), prauditID(euid), prauditID(egid), prauditID(ruid), prauditID(rgid),
+		pid, sid, port, prauditIP(addr))
+}
+
+// prauditIP formats an address, unset addresses as 0.0.0.0.
+func prauditIP(ip net.IP) string {
+	if ip == nil {
+		return net.IPv4zero.String()
+	}
+	return ip.String()
+}
+
+// prauditToken formats a token (other than header and trailer).
+func prauditToken(tok token.Token) string {
+	switch v := tok.(type) {
+	case token.ArgToken32bit:
+		return prauditLine(v.TokenID, v.ArgumentID, fmt.Sprintf("0x%x", v.ArgumentValue), v.Text)
+	case token.ArgToken64bit:
+		return prauditLine(v.TokenID, v.ArgumentID, fmt.Sprintf("0x%x", v.ArgumentValue), v.Text)
+	case token.AttributeToken32bit:
+		return prauditLine(v.TokenID, fmt.Sprintf("%o", v.FileAccessMode), prauditID(v.OwnerUserID),
+			prauditID(v.OwnerGroupID), v.FileSystemID, int64(v.FileSystemNodeID), v.Device)
+	case token.AttributeToken64bit:
+		return prauditLine(v.TokenID, fmt.Sprintf("%o", v.FileAccessMode), prauditID(v.OwnerUserID),
+			prauditID(v.OwnerGroupID), v.FileSystemID, int64(v.FileSystemNodeID), v.Device)
+	case token.ExecArgsToken:
+		return prauditLine(v.TokenID, stringFields(v.Text)...)
+	case token.ExecEnvToken:
+		return prauditLine(v.TokenID, stringFields(v.Text)...)
+	case token.ExitToken:
+		return prauditLine(v.TokenID, v.Status, v.ReturnValue)
+	case token.FileToken:
+		return prauditLine(v.TokenID, v.Seconds, v.Microseconds, v.PathName)
+	case token.GroupsToken:
+		groups := make([]interface{}, len(v.GroupList))
+		for i, g := range v.GroupList {
+			groups[i] = prauditID(g)
+		}
+		return prauditLine(v.TokenID, groups...)
+	case token.InAddrToken:
+		return prauditLine(v.TokenID, prauditIP(v.IpAddress))
+	case token.ExpandedInAddrToken:
+		return prauditLine(v.TokenID, prauditIP(v.IpAddress))
+	case token.IPortToken:
+		return prauditLine(v.TokenID, fmt.Sprintf("%#x", v.PortNumber))
+	case token.PathToken:
+		return prauditLine(v.TokenID, v.Path)
+	case token.PathAttrToken:
+		return prauditLine(v.TokenID, stringFields(v.Path)...)
+	case token.ProcessToken32bit:
+		return prauditProcess(v.TokenID, v.AuditID, v.EffectiveUserID, v.EffectiveGroupID, v.RealUserID,
+			v.RealGroupID, v.ProcessID, v.SessionID, uint64(v.TerminalPortID), v.TerminalMachineAddress)
+	case token.ProcessToken64bit:
+		return prauditProcess(v.TokenID, v.AuditID, v.EffectiveUserID, v.EffectiveGroupID, v.RealUserID,
+			v.RealGroupID, v.ProcessID, v.SessionID, v.TerminalPortID, v.TerminalMachineAddress)
+	case token.ExpandedProcessToken32bit:
+		return prauditProcess(v.TokenID, v.AuditID, v.EffectiveUserID, v.EffectiveGroupID, v.RealUserID,
+			v.RealGroupID, v.ProcessID, v.SessionID, uint64(v.TerminalPortID), v.TerminalMachineAddress)
+	case token.ExpandedProcessToken64bit:
+		return prauditProcess(v.TokenID, v.AuditID, v.EffectiveUserID, v.EffectiveGroupID, v.RealUserID,
+			v.RealGroupID, v.ProcessID, v.SessionID, v.TerminalPortID, v.TerminalMachineAddress)
+	case token.SubjectToken32bit:
+		return prauditProcess(v.TokenID, v.AuditID, v.EffectiveUserID, v.EffectiveGroupID, v.RealUserID,
+			v.RealGroupID, v.ProcessID, v.SessionID, uint64(v.TerminalPortID), v.TerminalMachineAddress)
+	case token.SubjectToken64bit:
+		return prauditProcess(v.TokenID, v.AuditID, v.EffectiveUserID, v.EffectiveGroupID, v.RealUserID,
+			v.RealGroupID, v.ProcessID, v.SessionID, v.TerminalPortID, v.TerminalMachineAddress)
+	case token.ExpandedSubjectToken32bit:
+		return prauditProcess(v.TokenID, v.AuditID, v.EffectiveUserID, v.EffectiveGroupID, v.RealUserID,
+			v.RealGroupID, v.ProcessID, v.SessionID, uint64(v.TerminalPortID), v.TerminalMachineAddress)
+	case token.ExpandedSubjectToken64bit:
+		return prauditProcess(v.TokenID, v.AuditID, v.EffectiveUserID, v.EffectiveGroupID, v.RealUserID,
+			v.RealGroupID, v.ProcessID, v.SessionID, v.TerminalPortID, v.TerminalMachineAddress)
+	case token.ReturnToken32bit:
+		return prauditLine(v.TokenID, v.ErrorNumber, v.ReturnValue)
+	case token.ReturnToken64bit:
+		return prauditLine(v.TokenID, v.ErrorNumber, int64(v.ReturnValue))
+	case token.SeqToken:
+		return prauditLine(v.TokenID, v.SequenceNumber)
+	case token.TextToken:
+		return prauditLine(v.TokenID, v.Text)
+	case token.ZonenameToken:
+		return prauditLine(v.TokenID, v.Zonename)
+	}
+	return prauditFields(tok)
+}
+
+// stringFields converts strings for prauditLine.
+func stringFields(s []string) []interface{} {
+	fields := make([]interface{}, len(s))
+	for i := range s {
+		fields[i] = s[i]
+	}
+	return fields
+}
+
+// prauditFields formats a token without known praudit layout: the token
+// ID followed by all other fields.
+func prauditFields(tok token.Token) string {
+	v := reflect.ValueOf(tok)
+	if v.Kind() != reflect.Struct {
+		return fmt.Sprint(tok)
+	}
+	var id byte
+	var fields []interface{}
+	for i := 0; i < v.NumField(); i++ {
+		if v.Type().Field(i).Name == "TokenID" {
+			id = byte(v.Field(i).Uint())
+			continue
+		}
+		fields = append(fields, v.Field(i).Interface())
+	}
+	return prauditLine(id, fields...)
+}
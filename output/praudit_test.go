@@ -0,0 +1,85 @@
+package output
+
+import (
+	"bytes"
+	"net"
+	"testing"
+
+	"github.com/tpltnt/go-bsm/decode"
+	"github.com/tpltnt/go-bsm/token"
+)
+
+func prauditRecord() *decode.BsmRecord {
+	return &decode.BsmRecord{
+		ByteCount:   123,
+		Version:     11,
+		EventType:   72,
+		Seconds:     1500000000,
+		NanoSeconds: 250,
+		Tokens: []token.Token{
+			token.PathToken{TokenID: 0x23, Path: "/etc/passwd"},
+			token.AttributeToken32bit{TokenID: 0x3e, FileAccessMode: 0100644, OwnerUserID: 0,
+				OwnerGroupID: 0xffffffff, FileSystemID: 89, FileSystemNodeID: 4242, Device: 0},
+			token.SubjectToken32bit{TokenID: 0x24, AuditID: 0xffffffff, EffectiveUserID: 1001,
+				EffectiveGroupID: 1001, RealUserID: 1001, RealGroupID: 1001, ProcessID: 512, SessionID: 500,
+				TerminalPortID: 0, TerminalMachineAddress: net.IPv4(10, 0, 0, 1)},
+			token.ReturnToken32bit{TokenID: 0x27, ErrorNumber: 0, ReturnValue: 3},
+		},
+	}
+}
+
+func TestPrauditRaw(t *testing.T) {
+	expected := []string{
+		"20,123,11,72,0,1500000000,250",
+		"35,/etc/passwd",
+		"62,100644,0,-1,89,4242,0",
+		"36,-1,1001,1001,1001,1001,512,500,0,10.0.0.1",
+		"39,0,3",
+		"19,123",
+	}
+	lines := PrauditRaw(prauditRecord())
+	if len(lines) != len(expected) {
+		t.Fatalf("got %q, expected %q", lines, expected)
+	}
+	for i := range lines {
+		if lines[i] != expected[i] {
+			t.Errorf("line %d: got %q, expected %q", i, lines[i], expected[i])
+		}
+	}
+}
+
+func TestPrauditRawFallback(t *testing.T) {
+	rec := &decode.BsmRecord{Tokens: []token.Token{token.SystemVIpcToken{TokenID: 0x22, ObjectIdType: 2, ObjectID: 7}}}
+	if line := PrauditRaw(rec)[1]; line != "34,2,7" {
+		t.Errorf("unexpected line %q", line)
+	}
+}
+
+func TestPrauditRawEscaped(t *testing.T) {
+	rec := &decode.BsmRecord{Tokens: []token.Token{
+		token.PathToken{TokenID: 0x23, Path: "/tmp/x\n20,1,2,3"},
+		token.ExecArgsToken{TokenID: 0x3c, Text: []string{"sh", "-c", "a,b\xff"}},
+	}}
+	lines := PrauditRaw(rec)
+	if lines[1] != `35,/tmp/x\n20\x2c1\x2c2\x2c3` {
+		t.Errorf("unexpected path line %q", lines[1])
+	}
+	if lines[2] != `60,sh,-c,a\x2cb\xff` {
+		t.Errorf("unexpected exec args line %q", lines[2])
+	}
+	if len(lines) != 4 {
+		t.Errorf("got %d lines, expected 4: %q", len(lines), lines)
+	}
+}
+
+func TestPrauditWriterOneLine(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewPrauditWriter(&buf)
+	w.OneLine = true
+	if err := w.WriteRecord(prauditRecord()); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Count(buf.Bytes(), []byte("\n")) != 1 || !bytes.HasSuffix(buf.Bytes(), []byte(",39,0,3,19,123\n")) {
+		t.Errorf("unexpected output %q", buf.String())
+	}
+}
@@ -0,0 +1,17 @@
+// Package output holds sinks which consume decoded BSM records.
+package output
+
+import "github.com/tpltnt/go-bsm/decode"
+
+// Sink receives records, e.g. to store or forward them.
+type Sink interface {
+	WriteRecord(rec *decode.BsmRecord) error
+}
+
+// SinkFunc adapts an ordinary function to the Sink interface.
+type SinkFunc func(rec *decode.BsmRecord) error
+
+// WriteRecord calls f(rec).
+func (f SinkFunc) WriteRecord(rec *decode.BsmRecord) error {
+	return f(rec)
+}
@@ -0,0 +1,72 @@
+package output
+
+import (
+	"io"
+
+	"github.com/tpltnt/go-bsm/decode"
+	"github.com/tpltnt/go-bsm/filter"
+)
+
+// ZoneRoute describes the delivery of the records of a single zone (or
+// jail). A record is written to all sinks if it is accepted by all filters.
+type ZoneRoute struct {
+	Filters []filter.Filter
+	Sinks   []Sink
+}
+
+// deliver writes the record to all sinks of the route. The first error
+// returned by a sink is reported, but all sinks are written to.
+func (route *ZoneRoute) deliver(rec *decode.BsmRecord) error {
+	for _, keep := range route.Filters {
+		if !keep(rec) {
+			return nil
+		}
+	}
+	var firstErr error
+	for _, sink := range route.Sinks {
+		if err := sink.WriteRecord(rec); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// ZoneDemux splits a single record stream into per-zone streams keyed on
+// the zonename token of the records, e.g. to deliver the audit records
+// of every tenant of a host separately.
+type ZoneDemux struct {
+	Routes  map[string]*ZoneRoute // routes by zone name
+	Default *ZoneRoute            // route for unknown zones and records without zonename (may be nil)
+}
+
+// WriteRecord dispatches a single record to the route of its zone.
+// Records without a matching route are dropped.
+func (d *ZoneDemux) WriteRecord(rec *decode.BsmRecord) error {
+	route := d.Default
+	if zone, ok := rec.Zone(); ok {
+		if r, found := d.Routes[zone]; found {
+			route = r
+		}
+	}
+	if route == nil {
+		return nil
+	}
+	return route.deliver(rec)
+}
+
+// Run dispatches all records of the given stream until it is exhausted.
+// It stops at the first parsing or delivery error.
+func (d *ZoneDemux) Run(in chan decode.ParsingResult) error {
+	for res := range in {
+		if res.Error == io.EOF {
+			break
+		}
+		if res.Error != nil {
+			return res.Error
+		}
+		if err := d.WriteRecord(&res.Record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
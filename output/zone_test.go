@@ -0,0 +1,63 @@
+package output
+
+import (
+	"io"
+	"testing"
+
+	"github.com/tpltnt/go-bsm/decode"
+	"github.com/tpltnt/go-bsm/filter"
+	"github.com/tpltnt/go-bsm/token"
+)
+
+func TestZoneDemux(t *testing.T) {
+	tenantA := []string{}
+	tenantB := []string{}
+	global := []string{}
+	collect := func(list *[]string) Sink {
+		return SinkFunc(func(rec *decode.BsmRecord) error {
+			*list = append(*list, rec.Tokens[len(rec.Tokens)-1].(token.TextToken).Text)
+			return nil
+		})
+	}
+	demux := ZoneDemux{
+		Routes: map[string]*ZoneRoute{
+			"tenant-a": {Sinks: []Sink{collect(&tenantA)}},
+			"tenant-b": {
+				Filters: []filter.Filter{func(rec *decode.BsmRecord) bool {
+					return rec.Tokens[len(rec.Tokens)-1].(token.TextToken).Text != "noise"
+				}},
+				Sinks: []Sink{collect(&tenantB)},
+			},
+		},
+		Default: &ZoneRoute{Sinks: []Sink{collect(&global)}},
+	}
+
+	records := []decode.BsmRecord{
+		{Tokens: []token.Token{token.ZonenameToken{TokenID: 0x60, Zonename: "tenant-a"}, token.TextToken{TokenID: 0x28, Text: "a1"}}},
+		{Tokens: []token.Token{token.ZonenameToken{TokenID: 0x60, Zonename: "tenant-b"}, token.TextToken{TokenID: 0x28, Text: "noise"}}},
+		{Tokens: []token.Token{token.ZonenameToken{TokenID: 0x60, Zonename: "tenant-b"}, token.TextToken{TokenID: 0x28, Text: "b1"}}},
+		{Tokens: []token.Token{token.TextToken{TokenID: 0x28, Text: "host"}}},
+		{Tokens: []token.Token{token.ZonenameToken{TokenID: 0x60, Zonename: "tenant-c"}, token.TextToken{TokenID: 0x28, Text: "c1"}}},
+	}
+	in := make(chan decode.ParsingResult)
+	go func() {
+		for _, rec := range records {
+			in <- decode.ParsingResult{Record: rec}
+		}
+		in <- decode.ParsingResult{Error: io.EOF}
+		close(in)
+	}()
+	if err := demux.Run(in); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tenantA) != 1 || tenantA[0] != "a1" {
+		t.Error("unexpected records for tenant-a:", tenantA)
+	}
+	if len(tenantB) != 1 || tenantB[0] != "b1" {
+		t.Error("unexpected records for tenant-b:", tenantB)
+	}
+	if len(global) != 2 || global[0] != "host" || global[1] != "c1" {
+		t.Error("unexpected records for default route:", global)
+	}
+}
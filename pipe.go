@@ -0,0 +1,85 @@
+package bsm
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/tpltnt/go-bsm/auditsys"
+)
+
+// DefaultPipeCheckInterval is the interval WatchPipeDrops uses if none
+// is given.
+const DefaultPipeCheckInterval = 10 * time.Second
+
+// DropNotice reports records the kernel discarded from an audit pipe,
+// which the consumer will never see. It is delivered as the Error of a
+// ParsingResult by WatchPipeDrops.
+type DropNotice struct {
+	Time      time.Time          // when the counters were read
+	Drops     uint64             // records dropped since the last check
+	Truncates uint64             // records truncated since the last check
+	Stats     auditsys.PipeStats // counters of the pipe
+}
+
+func (n *DropNotice) Error() string {
+	return fmt.Sprintf("audit pipe discarded records: %d dropped, %d truncated (%d inserted, %d read in total)",
+		n.Drops, n.Truncates, n.Stats.Inserts, n.Stats.Reads)
+}
+
+// WatchPipeDrops yields the results of in, which are read from the
+// given audit pipe (see auditsys.OpenPipe), and reads the counters of
+// the pipe every interval. If records were dropped or truncated since
+// the last check, a ParsingResult with a *DropNotice as Error is put on
+// the stream, as are errors reading the counters. The counters aren't
+// read any more if the platform doesn't support it.
+func WatchPipeDrops(pipe io.ReadCloser, in chan ParsingResult, interval time.Duration) chan ParsingResult {
+	if interval <= 0 {
+		interval = DefaultPipeCheckInterval
+	}
+	out := make(chan ParsingResult)
+
+	go func() {
+		defer close(out)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		last, err := auditsys.GetPipeStats(pipe)
+		if err == auditsys.ErrNotSupported {
+			ticker.Stop()
+		}
+		known := err == nil // last holds the counters of the pipe
+		if err != nil && err != auditsys.ErrNotSupported {
+			out <- ParsingResult{Error: fmt.Errorf("reading audit pipe counters: %w", err)}
+		}
+		for {
+			select {
+			case res, ok := <-in:
+				if !ok {
+					return
+				}
+				out <- res
+			case now := <-ticker.C:
+				stats, err := auditsys.GetPipeStats(pipe)
+				if err == auditsys.ErrNotSupported {
+					ticker.Stop()
+					continue
+				}
+				if err != nil {
+					out <- ParsingResult{Error: fmt.Errorf("reading audit pipe counters: %w", err)}
+					continue
+				}
+				if known && (stats.Drops > last.Drops || stats.Truncates > last.Truncates) {
+					out <- ParsingResult{Error: &DropNotice{
+						Time:      now,
+						Drops:     stats.Drops - last.Drops,
+						Truncates: stats.Truncates - last.Truncates,
+						Stats:     stats,
+					}}
+				}
+				last, known = stats, true
+			}
+		}
+	}()
+
+	return out
+}
@@ -0,0 +1,99 @@
+package bsm
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tpltnt/go-bsm/auditsys"
+)
+
+// pipePlatform returns increasing drop counters.
+type pipePlatform struct {
+	auditsys.Unsupported
+	mu    sync.Mutex
+	stats auditsys.PipeStats
+	fail  bool // fail the next call
+}
+
+var errPipeStats = errors.New("pipe stats failed")
+
+func (p *pipePlatform) GetPipeStats(io.ReadCloser) (auditsys.PipeStats, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.fail {
+		p.fail = false
+		return p.stats, errPipeStats
+	}
+	p.stats.Inserts += 10
+	p.stats.Reads += 7
+	p.stats.Drops += 3
+	return p.stats, nil
+}
+
+func TestWatchPipeDrops(t *testing.T) {
+	saved := auditsys.Native
+	defer func() { auditsys.Native = saved }()
+	auditsys.Native = &pipePlatform{}
+
+	in := make(chan ParsingResult)
+	out := WatchPipeDrops(nil, in, time.Millisecond)
+	go func() { in <- ParsingResult{Record: BsmRecord{EventType: 23}} }()
+	var records, notices int
+	for records == 0 || notices == 0 {
+		res := <-out
+		var notice *DropNotice
+		switch {
+		case res.Error == nil && res.Record.EventType == 23:
+			records++
+		case errors.As(res.Error, &notice) && notice.Drops == 3:
+			notices++
+		default:
+			t.Fatalf("unexpected result %+v", res)
+		}
+	}
+	close(in)
+	for range out {
+	}
+}
+
+func TestWatchPipeDropsError(t *testing.T) {
+	saved := auditsys.Native
+	defer func() { auditsys.Native = saved }()
+	auditsys.Native = &pipePlatform{fail: true}
+
+	in := make(chan ParsingResult)
+	out := WatchPipeDrops(nil, in, time.Millisecond)
+	if res := <-out; !errors.Is(res.Error, errPipeStats) {
+		t.Errorf("expected error reading the counters, got %+v", res)
+	}
+	// the counters read after the error are not reported as drops
+	var notice *DropNotice
+	if res := <-out; !errors.As(res.Error, &notice) || notice.Drops != 3 {
+		t.Errorf("unexpected result %+v", res)
+	}
+	close(in)
+	for range out {
+	}
+}
+
+func TestWatchPipeDropsUnsupported(t *testing.T) {
+	saved := auditsys.Native
+	defer func() { auditsys.Native = saved }()
+	auditsys.Native = auditsys.Unsupported{}
+
+	in := make(chan ParsingResult)
+	out := WatchPipeDrops(nil, in, time.Millisecond)
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		in <- ParsingResult{Record: BsmRecord{EventType: 23}}
+		close(in)
+	}()
+	for res := range out {
+		if res.Error != nil {
+			t.Error("unexpected error:", res.Error)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package bsm
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// AuditClass is an entry of the audit_class(5) file.
+type AuditClass struct {
+	Mask        uint32 // e.g. 0x00000080
+	Name        string // e.g. pc
+	Description string // e.g. process
+}
+
+// DefaultAuditClasses are the classes of the audit_class(5) file of
+// OpenBSM (macOS, FreeBSD).
+var DefaultAuditClasses = map[string]AuditClass{
+	"no":  {0x00000000, "no", "invalid class"},
+	"fr":  {0x00000001, "fr", "file read"},
+	"fw":  {0x00000002, "fw", "file write"},
+	"fa":  {0x00000004, "fa", "file attribute access"},
+	"fm":  {0x00000008, "fm", "file attribute modify"},
+	"fc":  {0x00000010, "fc", "file create"},
+	"fd":  {0x00000020, "fd", "file delete"},
+	"cl":  {0x00000040, "cl", "file close"},
+	"pc":  {0x00000080, "pc", "process"},
+	"nt":  {0x00000100, "nt", "network"},
+	"ip":  {0x00000200, "ip", "ipc"},
+	"na":  {0x00000400, "na", "non attributable"},
+	"ad":  {0x00000800, "ad", "administrative"},
+	"lo":  {0x00001000, "lo", "login_logout"},
+	"aa":  {0x00002000, "aa", "authentication and authorization"},
+	"ap":  {0x00004000, "ap", "application"},
+	"io":  {0x20000000, "io", "ioctl"},
+	"ex":  {0x40000000, "ex", "exec"},
+	"ot":  {0x80000000, "ot", "miscellaneous"},
+	"all": {0xffffffff, "all", "all flags set"},
+}
+
+// ParseAuditClasses reads the classes of an audit_class(5) file, e.g.
+// /etc/security/audit_class, by name.
+func ParseAuditClasses(r io.Reader) (map[string]AuditClass, error) {
+	classes := map[string]AuditClass{}
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.SplitN(text, ":", 3)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("line %d: expected 3 fields, got %d", line, len(fields))
+		}
+		mask, err := strconv.ParseUint(fields[0], 0, 32)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid class mask %q", line, fields[0])
+		}
+		classes[fields[1]] = AuditClass{Mask: uint32(mask), Name: fields[1], Description: fields[2]}
+	}
+	return classes, scanner.Err()
+}
+
+// AuditMask is a preselection mask (au_mask_t): the classes audited if
+// an event succeeds and if it fails.
+type AuditMask struct {
+	Success uint32
+	Failure uint32
+}
+
+// Preselection flags of au_preselect(3): whether the event succeeded,
+// failed or both.
+const (
+	PreselectSuccess = 1 // AU_PRS_SUCCESS
+	PreselectFailure = 2 // AU_PRS_FAILURE
+	PreselectBoth    = PreselectSuccess | PreselectFailure
+)
+
+// ParseAuditFlags converts flags in audit_control(5) syntax, e.g.
+// "lo,+ex,^-fr", to a mask like getauditflagsbin(3): the flags are
+// applied from left to right, "+" selects success, "-" failure only,
+// and "^" removes the classes from the mask instead of adding them.
+// Unknown classes are an error.
+func ParseAuditFlags(flags string, classes map[string]AuditClass) (AuditMask, error) {
+	var mask AuditMask
+	for _, flag := range strings.Split(flags, ",") {
+		flag = strings.TrimSpace(flag)
+		if flag == "" {
+			continue
+		}
+		name := flag
+		unset := strings.HasPrefix(name, "^")
+		name = strings.TrimPrefix(name, "^")
+		sel := PreselectBoth
+		switch {
+		case strings.HasPrefix(name, "+"):
+			sel = PreselectSuccess
+			name = name[1:]
+		case strings.HasPrefix(name, "-"):
+			sel = PreselectFailure
+			name = name[1:]
+		}
+		class, ok := classes[name]
+		if !ok {
+			return AuditMask{}, fmt.Errorf("unknown audit class %q", flag)
+		}
+		for _, m := range []struct {
+			bit  int
+			mask *uint32
+		}{{PreselectSuccess, &mask.Success}, {PreselectFailure, &mask.Failure}} {
+			switch {
+			case sel&m.bit == 0:
+			case unset:
+				*m.mask &^= class.Mask
+			default:
+				*m.mask |= class.Mask
+			}
+		}
+	}
+	return mask, nil
+}
+
+// Preselector decides whether events are audited exactly like the
+// kernel and au_preselect(3) do, given the class definitions and the
+// classes of the events (see ParseAuditClasses and ParseAuditEvents).
+type Preselector struct {
+	Classes map[string]AuditClass // DefaultAuditClasses if nil
+	Events  map[uint16]AuditEvent
+}
+
+func (p *Preselector) classes() map[string]AuditClass {
+	if p.Classes == nil {
+		return DefaultAuditClasses
+	}
+	return p.Classes
+}
+
+// EventClass returns the class mask of an event, false if the event is
+// unknown.
+func (p *Preselector) EventClass(event uint16) (uint32, bool) {
+	e, ok := p.Events[event]
+	if !ok {
+		return 0, false
+	}
+	// libbsm parses the classes of an event like audit flags
+	mask, err := ParseAuditFlags(strings.Join(e.Classes, ","), p.classes())
+	if err != nil {
+		return 0, false
+	}
+	return mask.Success, true
+}
+
+// Preselect reports whether the event is audited with the given mask,
+// sorf selects the outcome (PreselectSuccess, PreselectFailure or
+// both). Unknown events are an error.
+func (p *Preselector) Preselect(event uint16, mask AuditMask, sorf int) (bool, error) {
+	class, ok := p.EventClass(event)
+	if !ok {
+		return false, fmt.Errorf("unknown event %d", event)
+	}
+	var effective uint32
+	if sorf&PreselectSuccess != 0 {
+		effective |= mask.Success & class
+	}
+	if sorf&PreselectFailure != 0 {
+		effective |= mask.Failure & class
+	}
+	return effective != 0, nil
+}
+
+// Filter returns a Filter keeping the records the kernel would audit:
+// records of non-attributable events (without subject or with the
+// DefaultAuditID) are matched against naflags, all others against
+// flags. Records of unknown events are dropped.
+func (p *Preselector) Filter(flags, naflags AuditMask) Filter {
+	return func(rec *BsmRecord) bool {
+		mask := flags
+		if subject, ok := rec.Subject(); !ok || subject.AuditID == DefaultAuditID {
+			mask = naflags
+		}
+		sorf := PreselectSuccess
+		if rec.Failed() {
+			sorf = PreselectFailure
+		}
+		selected, err := p.Preselect(rec.EventType, mask, sorf)
+		return err == nil && selected
+	}
+}
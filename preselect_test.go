@@ -0,0 +1,105 @@
+package bsm
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseAuditFlags(t *testing.T) {
+	// expected masks as computed by getauditflagsbin(3) of libbsm
+	for _, test := range []struct {
+		flags            string
+		success, failure uint32
+	}{
+		{"", 0, 0},
+		{"lo", 0x1000, 0x1000},
+		{"lo,,ex", 0x40001000, 0x40001000},
+		{"+ex", 0x40000000, 0},
+		{"-fc", 0, 0x10},
+		{"lo,+ex,-fc", 0x40001000, 0x1010},
+		{"all,^-fr", 0xffffffff, 0xfffffffe},
+		{"all,^+fr,^-fw", 0xfffffffe, 0xfffffffd},
+		{"^lo,lo", 0x1000, 0x1000},
+		{"lo,^lo", 0, 0},
+		{"fr,fw,^+fr", 0x2, 0x3},
+		{"no", 0, 0},
+	} {
+		mask, err := ParseAuditFlags(test.flags, DefaultAuditClasses)
+		if err != nil {
+			t.Errorf("%q: %v", test.flags, err)
+			continue
+		}
+		if mask.Success != test.success || mask.Failure != test.failure {
+			t.Errorf("%q: got %#x/%#x, expected %#x/%#x", test.flags, mask.Success, mask.Failure, test.success, test.failure)
+		}
+	}
+	if _, err := ParseAuditFlags("lo,xx", DefaultAuditClasses); err == nil {
+		t.Error("expected error for unknown class")
+	}
+}
+
+func TestPreselect(t *testing.T) {
+	events, err := ParseAuditEvents(strings.NewReader(testAuditEvents + "0:AUE_NULL:indir system call:no\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &Preselector{Events: events}
+
+	// expected results of au_preselect(3) of libbsm
+	for _, test := range []struct {
+		event    uint16
+		flags    string
+		sorf     int
+		selected bool
+	}{
+		{23, "ex", PreselectSuccess, true},
+		{23, "pc", PreselectFailure, true}, // pc,ex
+		{23, "+ex", PreselectFailure, false},
+		{23, "+ex", PreselectBoth, true},
+		{23, "-ex", PreselectSuccess, false},
+		{72, "all,^fr", PreselectBoth, false},
+		{72, "all,^+fr", PreselectFailure, true},
+		{6152, "lo", PreselectSuccess, true},
+		{6152, "fr,fw", PreselectBoth, false},
+		{0, "all", PreselectBoth, false},
+	} {
+		mask, err := ParseAuditFlags(test.flags, DefaultAuditClasses)
+		if err != nil {
+			t.Fatal(err)
+		}
+		selected, err := p.Preselect(test.event, mask, test.sorf)
+		if err != nil || selected != test.selected {
+			t.Errorf("event %d, %q, %d: got %v (%v), expected %v", test.event, test.flags, test.sorf, selected, err, test.selected)
+		}
+	}
+	if _, err := p.Preselect(999, AuditMask{}, PreselectBoth); err == nil {
+		t.Error("expected error for unknown event")
+	}
+
+	flags, _ := ParseAuditFlags("lo,+ex", DefaultAuditClasses)
+	naflags, _ := ParseAuditFlags("ad", DefaultAuditClasses)
+	keep := p.Filter(flags, naflags)
+	for _, test := range []struct {
+		rec  BsmRecord
+		keep bool
+	}{
+		{BsmRecord{EventType: 23, Tokens: []Token{SubjectToken32bit{AuditID: 1001}, ReturnToken32bit{}}}, true},
+		{BsmRecord{EventType: 23, Tokens: []Token{SubjectToken32bit{AuditID: 1001}, ReturnToken32bit{ErrorNumber: 1}}}, false},
+		{BsmRecord{EventType: 45000, Tokens: []Token{SubjectToken32bit{AuditID: DefaultAuditID}}}, true},
+		{BsmRecord{EventType: 6152, Tokens: []Token{SubjectToken32bit{AuditID: DefaultAuditID}}}, false},
+	} {
+		if keep(&test.rec) != test.keep {
+			t.Errorf("event %d: expected keep %v", test.rec.EventType, test.keep)
+		}
+	}
+}
+
+func TestParseAuditClasses(t *testing.T) {
+	classes, err := ParseAuditClasses(strings.NewReader("# classes\n0x00001000:lo:login_logout\n0x00010000:xx:custom: local\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if classes["lo"].Mask != 0x1000 || classes["xx"].Mask != 0x10000 || classes["xx"].Description != "custom: local" {
+		t.Errorf("unexpected classes %+v", classes)
+	}
+}
@@ -0,0 +1,48 @@
+package bsm
+
+import (
+	"os/user"
+	"strconv"
+)
+
+// UserResolver maps a user ID to a user name.
+type UserResolver func(uid uint32) (string, error)
+
+// LookupUser resolves user IDs via the user database of the local host.
+func LookupUser(uid uint32) (string, error) {
+	u, err := user.LookupId(strconv.FormatUint(uint64(uid), 10))
+	if err != nil {
+		return "", err
+	}
+	return u.Username, nil
+}
+
+// FlagPrivilegeTransitions returns a transformation (see Transform) which
+// sets the Privilege field of records whose subject audit user ID differs
+// from the effective user ID. The original user is resolved with the
+// given resolver (LookupUser if nil). Failing lookups are not an error,
+// they just leave the user name empty.
+func FlagPrivilegeTransitions(resolve UserResolver) func(*BsmRecord) error {
+	if resolve == nil {
+		resolve = LookupUser
+	}
+	return func(rec *BsmRecord) error {
+		subject, ok := rec.Subject()
+		if !ok || subject.AuditID == DefaultAuditID {
+			return nil
+		}
+		if subject.AuditID == subject.EffectiveUserID {
+			return nil
+		}
+		name, err := resolve(subject.AuditID)
+		if err != nil {
+			name = ""
+		}
+		rec.Privilege = &PrivilegeTransition{
+			AuditID:         subject.AuditID,
+			EffectiveUserID: subject.EffectiveUserID,
+			OriginalUser:    name,
+		}
+		return nil
+	}
+}
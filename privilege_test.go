@@ -0,0 +1,128 @@
+package bsm
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestFlagPrivilegeTransitions(t *testing.T) {
+	resolve := func(uid uint32) (string, error) {
+		if uid == 1001 {
+			return "alice", nil
+		}
+		return "", errors.New("unknown user")
+	}
+	flag := FlagPrivilegeTransitions(resolve)
+
+	// su to root
+	rec := BsmRecord{
+		Tokens: []Token{
+			SubjectToken32bit{TokenID: 0x24, AuditID: 1001, EffectiveUserID: 0},
+			TextToken{TokenID: 0x28, Text: "su"},
+		},
+	}
+	if err := flag(&rec); err != nil {
+		t.Error(err)
+	}
+	if rec.Privilege == nil {
+		t.Fatal("privilege transition not flagged")
+	}
+	if rec.Privilege.OriginalUser != "alice" {
+		t.Error("unexpected original user:", rec.Privilege.OriginalUser)
+	}
+	if rec.Privilege.EffectiveUserID != 0 {
+		t.Error("wrong effective user ID")
+	}
+
+	// unresolvable user
+	rec = BsmRecord{
+		Tokens: []Token{
+			ExpandedSubjectToken32bit{TokenID: 0x7a, AuditID: 1002, EffectiveUserID: 0},
+		},
+	}
+	flag(&rec)
+	if rec.Privilege == nil || rec.Privilege.OriginalUser != "" {
+		t.Error("expected flagged record without user name")
+	}
+
+	// no transition
+	for _, subject := range []Token{
+		SubjectToken32bit{TokenID: 0x24, AuditID: 1001, EffectiveUserID: 1001},
+		SubjectToken32bit{TokenID: 0x24, AuditID: DefaultAuditID, EffectiveUserID: 0},
+	} {
+		rec = BsmRecord{Tokens: []Token{subject}}
+		flag(&rec)
+		if rec.Privilege != nil {
+			t.Error("unexpected privilege transition for", subject)
+		}
+	}
+}
+
+func TestTransform(t *testing.T) {
+	in := make(chan ParsingResult)
+	go func() {
+		in <- ParsingResult{Record: BsmRecord{Tokens: []Token{
+			SubjectToken32bit{TokenID: 0x24, AuditID: 1001, EffectiveUserID: 0},
+		}}}
+		in <- ParsingResult{Error: errors.New("broken record")}
+		close(in)
+	}()
+
+	results := []ParsingResult{}
+	for res := range Transform(in, FlagPrivilegeTransitions(func(uint32) (string, error) { return "bob", nil })) {
+		results = append(results, res)
+	}
+	if len(results) != 2 {
+		t.Fatal("unexpected number of results")
+	}
+	if results[0].Record.Privilege == nil || results[0].Record.Privilege.OriginalUser != "bob" {
+		t.Error("record was not transformed")
+	}
+	if results[1].Error == nil {
+		t.Error("parsing error got lost")
+	}
+}
+
+func TestParallelTransform(t *testing.T) {
+	in := make(chan ParsingResult)
+	go func() {
+		for i := 0; i < 100; i++ {
+			if i == 50 {
+				in <- ParsingResult{Error: errors.New("broken record")}
+				continue
+			}
+			in <- ParsingResult{Record: BsmRecord{Seconds: uint64(i)}}
+		}
+		close(in)
+	}()
+
+	slow := func(rec *BsmRecord) error {
+		// later records finish first
+		time.Sleep(time.Duration(100-rec.Seconds) * 10 * time.Microsecond)
+		rec.NanoSeconds = rec.Seconds * 2
+		if rec.Seconds == 7 {
+			return errors.New("transformation failed")
+		}
+		return nil
+	}
+	i := 0
+	for res := range ParallelTransform(in, 8, slow) {
+		switch {
+		case i == 50:
+			if res.Error == nil || res.Error.Error() != "broken record" {
+				t.Error("parsing error got lost")
+			}
+		case i == 7:
+			if res.Error == nil {
+				t.Error("transformation error got lost")
+			}
+		case res.Record.Seconds != uint64(i) || res.Record.NanoSeconds != uint64(2*i):
+			t.Error("unexpected record at position", i, res.Record.Seconds)
+		}
+		i += 1
+	}
+	if i != 100 {
+		t.Error("unexpected number of results:", i)
+	}
+}
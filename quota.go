@@ -0,0 +1,181 @@
+package bsm
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// DefaultQuotaInterval is the interval of a Quota without one.
+const DefaultQuotaInterval = time.Minute
+
+// QuotaKey returns the key a record is counted by, false if the record
+// isn't subject to the quota.
+type QuotaKey func(rec *BsmRecord) (string, bool)
+
+// QuotaByAuditID counts the records by audit user ID (e.g. "uid 1001").
+func QuotaByAuditID(rec *BsmRecord) (string, bool) {
+	subject, ok := rec.Subject()
+	if !ok {
+		return "", false
+	}
+	return "uid " + strconv.FormatUint(uint64(subject.AuditID), 10), true
+}
+
+// QuotaBySession counts the records by audit session ID (e.g.
+// "session 100004").
+func QuotaBySession(rec *BsmRecord) (string, bool) {
+	subject, ok := rec.Subject()
+	if !ok {
+		return "", false
+	}
+	return "session " + strconv.FormatUint(uint64(subject.SessionID), 10), true
+}
+
+// Quota caps the number of records per key (e.g. user or session) and
+// interval. Intervals are fixed windows of record time, so replayed
+// trails are limited like live ones.
+type Quota struct {
+	Key      QuotaKey      // e.g. QuotaByAuditID
+	Limit    int           // records passed per key and interval, not applied if zero
+	Interval time.Duration // DefaultQuotaInterval if zero
+	// Summarize emits the summaries of exceeded quotas as records (see
+	// QuotaSummary.Records) instead of errors, so they reach the same
+	// sinks as the records passed.
+	Summarize bool
+}
+
+// QuotaSummary reports the records of a key dropped during an interval.
+// It is delivered as the Error of a ParsingResult by LimitRecords.
+type QuotaSummary struct {
+	Key     string
+	Start   time.Time      // start of the interval
+	End     time.Time      // end of the interval
+	Passed  int            // records passed
+	Dropped int            // records dropped
+	Events  map[uint16]int // dropped records by event type
+}
+
+func (s *QuotaSummary) Error() string {
+	return fmt.Sprintf("quota exceeded by %s: %d records dropped between %s and %s (%d passed)",
+		s.Key, s.Dropped, s.Start.Format(time.RFC3339), s.End.Format(time.RFC3339), s.Passed)
+}
+
+// SuppressedEventType is the event type of the records returned by
+// QuotaSummary.Records, which no producer writes, so filters and sinks
+// tell them from the records of the audited event.
+const SuppressedEventType EventType = 0xffff
+
+// Annotations of the records returned by QuotaSummary.Records.
+const (
+	SuppressedEventAnnotation = "suppressed.event" // event type of the records dropped, e.g. "23"
+	SuppressedCountAnnotation = "suppressed.count" // number of records dropped
+	SuppressedKeyAnnotation   = "suppressed.key"   // key of the quota, e.g. "uid 1001"
+	SuppressedStartAnnotation = "suppressed.start" // start of the interval (RFC 3339)
+	SuppressedEndAnnotation   = "suppressed.end"   // end of the interval (RFC 3339)
+)
+
+// Records returns a synthetic record of SuppressedEventType per event
+// type of the dropped records, stamped with the end of the interval and
+// holding a text token like "suppressed 12345 AUE_EXECVE records for
+// uid 1001 between T1 and T2". The details, including the event type of
+// the dropped records, are attached as annotations.
+func (s *QuotaSummary) Records() []BsmRecord {
+	events := make([]uint16, 0, len(s.Events))
+	for event := range s.Events {
+		events = append(events, event)
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i] < events[j] })
+
+	start, end := s.Start.UTC().Format(time.RFC3339), s.End.UTC().Format(time.RFC3339)
+	recs := make([]BsmRecord, len(events))
+	for i, event := range events {
+		text := fmt.Sprintf("suppressed %d %s records for %s between %s and %s",
+			s.Events[event], EventType(event), s.Key, start, end)
+		rec := BsmRecord{
+			Version:   VersionOpenBSM11,
+			EventType: uint16(SuppressedEventType),
+			Seconds:   uint64(s.End.Unix()),
+			Tokens:    []Token{TextToken{TokenID: 0x28, TextLength: uint16(len(text) + 1), Text: text}},
+		}
+		rec.Annotate(SuppressedEventAnnotation, strconv.Itoa(int(event)))
+		rec.Annotate(SuppressedCountAnnotation, strconv.Itoa(s.Events[event]))
+		rec.Annotate(SuppressedKeyAnnotation, s.Key)
+		rec.Annotate(SuppressedStartAnnotation, start)
+		rec.Annotate(SuppressedEndAnnotation, end)
+		recs[i] = rec
+	}
+	return recs
+}
+
+// LimitRecords yields the records of the given stream, but drops those
+// exceeding the quota, e.g. to protect downstream systems from a user
+// or script flooding the trail. Once an interval ends (i.e. a record of
+// a later interval arrives, or the stream is exhausted) a ParsingResult
+// with a *QuotaSummary as Error (or its records, see Quota.Summarize) is
+// put on the stream for every key which exceeded the quota. Records
+// without key and parsing errors are passed on unchanged.
+func LimitRecords(in chan ParsingResult, quota Quota) chan ParsingResult {
+	interval := quota.Interval
+	if interval <= 0 {
+		interval = DefaultQuotaInterval
+	}
+	out := make(chan ParsingResult)
+
+	go func() {
+		defer close(out)
+		var window time.Time
+		counts := map[string]*QuotaSummary{}
+		flush := func() {
+			var exceeded []*QuotaSummary
+			for _, s := range counts {
+				if s.Dropped > 0 {
+					exceeded = append(exceeded, s)
+				}
+			}
+			sort.Slice(exceeded, func(i, j int) bool { return exceeded[i].Key < exceeded[j].Key })
+			for _, s := range exceeded {
+				if !quota.Summarize {
+					out <- ParsingResult{Error: s}
+					continue
+				}
+				for _, rec := range s.Records() {
+					out <- ParsingResult{Record: rec}
+				}
+			}
+			counts = map[string]*QuotaSummary{}
+		}
+
+		for res := range in {
+			if res.Error != nil || quota.Limit <= 0 {
+				out <- res
+				continue
+			}
+			key, ok := quota.Key(&res.Record)
+			if !ok {
+				out <- res
+				continue
+			}
+			if start := res.Record.Time().Truncate(interval); start.After(window) {
+				flush()
+				window = start
+			}
+			s, ok := counts[key]
+			if !ok {
+				s = &QuotaSummary{Key: key, Start: window, End: window.Add(interval), Events: map[uint16]int{}}
+				counts[key] = s
+			}
+			if s.Passed < quota.Limit {
+				s.Passed += 1
+				out <- res
+				continue
+			}
+			s.Dropped += 1
+			s.Events[res.Record.EventType] += 1
+		}
+		flush()
+	}()
+
+	return out
+}
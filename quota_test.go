@@ -0,0 +1,101 @@
+package bsm
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestLimitRecords(t *testing.T) {
+	record := func(seconds uint64, auid uint32, event EventType) ParsingResult {
+		return ParsingResult{Record: BsmRecord{
+			Seconds:   seconds,
+			EventType: uint16(event),
+			Tokens:    []Token{SubjectToken32bit{AuditID: auid, SessionID: auid + 100}, ReturnToken32bit{}},
+		}}
+	}
+	in := make(chan ParsingResult, 10)
+	for i := 0; i < 5; i++ {
+		in <- record(600, 1001, AUE_OPEN_R) // runaway user
+	}
+	in <- record(610, 1002, AUE_EXECVE)
+	in <- ParsingResult{Record: BsmRecord{Seconds: 620}} // no subject
+	in <- record(660, 1001, AUE_EXECVE)                  // next interval
+	in <- record(661, 1001, AUE_EXECVE)
+	in <- record(662, 1001, AUE_EXECVE)
+	close(in)
+
+	var passed []uint32
+	var summaries []*QuotaSummary
+	for res := range LimitRecords(in, Quota{Key: QuotaByAuditID, Limit: 2}) {
+		var summary *QuotaSummary
+		if errors.As(res.Error, &summary) {
+			summaries = append(summaries, summary)
+			continue
+		}
+		subject, _ := res.Record.Subject()
+		passed = append(passed, subject.AuditID)
+	}
+	if len(passed) != 6 {
+		t.Errorf("unexpected records passed: %v", passed)
+	}
+	if len(summaries) != 2 {
+		t.Fatal("unexpected summaries:", summaries)
+	}
+	first := summaries[0]
+	if first.Key != "uid 1001" || first.Passed != 2 || first.Dropped != 3 || first.Events[uint16(AUE_OPEN_R)] != 3 {
+		t.Errorf("unexpected summary %+v", first)
+	}
+	if first.Start.Unix() != 600 || first.End.Unix() != 660 {
+		t.Errorf("unexpected interval %v - %v", first.Start, first.End)
+	}
+	if summaries[1].Start.Unix() != 660 || summaries[1].Dropped != 1 {
+		t.Errorf("unexpected summary %+v", summaries[1])
+	}
+}
+
+func TestQuotaBySession(t *testing.T) {
+	rec := BsmRecord{Tokens: []Token{SubjectToken32bit{AuditID: 1001, SessionID: 42}}}
+	if key, ok := QuotaBySession(&rec); !ok || key != "session 42" {
+		t.Errorf("got key %q", key)
+	}
+	if _, ok := QuotaBySession(&BsmRecord{}); ok {
+		t.Error("key for record without subject")
+	}
+}
+
+func TestLimitRecordsSummarize(t *testing.T) {
+	in := make(chan ParsingResult, 4)
+	for _, event := range []EventType{AUE_EXECVE, AUE_EXECVE, AUE_EXECVE, AUE_OPEN_R} {
+		in <- ParsingResult{Record: BsmRecord{
+			Seconds:   1000,
+			EventType: uint16(event),
+			Tokens:    []Token{SubjectToken32bit{AuditID: 1001}},
+		}}
+	}
+	close(in)
+
+	var recs []BsmRecord
+	for res := range LimitRecords(in, Quota{Key: QuotaByAuditID, Limit: 1, Summarize: true}) {
+		if res.Error != nil {
+			t.Fatal(res.Error)
+		}
+		recs = append(recs, res.Record)
+	}
+	if len(recs) != 3 {
+		t.Fatalf("got %d records, expected 3", len(recs))
+	}
+	summary := recs[1]
+	if count, _ := summary.Annotation(SuppressedCountAnnotation); count != "2" || summary.EventType != uint16(SuppressedEventType) {
+		t.Errorf("unexpected summary %+v", summary)
+	}
+	if event, _ := summary.Annotation(SuppressedEventAnnotation); event != "23" {
+		t.Errorf("unexpected summary %+v", summary)
+	}
+	expected := "suppressed 2 AUE_EXECVE records for uid 1001 between 1970-01-01T00:16:00Z and 1970-01-01T00:17:00Z"
+	if text := summary.Tokens[0].(TextToken).Text; text != expected {
+		t.Errorf("got %q", text)
+	}
+	if event, _ := recs[2].Annotation(SuppressedEventAnnotation); summary.Seconds != 1020 || event != "72" {
+		t.Errorf("unexpected summaries %+v", recs[1:])
+	}
+}
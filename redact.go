@@ -0,0 +1,67 @@
+package bsm
+
+import (
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/tpltnt/go-bsm/encode"
+	"github.com/tpltnt/go-bsm/token"
+)
+
+// RedactFunc returns the token to write in place of the given one, nil
+// to drop it.
+type RedactFunc func(tok Token) (Token, error)
+
+// Redact copies the trail read from in to out, passing every token but
+// headers and trailers to fn, e.g. to scrub secrets from a trail which
+// should still work with native tools like praudit and auditreduce.
+// Modified tokens are encoded anew, all others (including tokens which
+// can't be parsed) are copied unchanged. The byte counts of records
+// whose size changed are corrected.
+func Redact(in io.Reader, out io.Writer, dialect Dialect, fn RedactFunc) error {
+	return rewriteTrail(in, out, dialect, func(data []byte) ([]byte, error) {
+		switch data[0] {
+		case 0x13, 0x14, 0x15, 0x74, 0x79: // trailer and header
+			return data, nil
+		}
+		tok, err := token.Parse(data)
+		if err != nil {
+			return data, nil
+		}
+		redacted, err := fn(tok)
+		if err != nil || redacted == nil {
+			return nil, err
+		}
+		if reflect.DeepEqual(redacted, tok) {
+			return data, nil
+		}
+		return encode.Token(redacted)
+	})
+}
+
+// ScrubEnv returns a RedactFunc clearing the values of the environment
+// variables in exec env tokens ("NAME=value" becomes "NAME="), except
+// for the variables named by keep, e.g. "PATH".
+func ScrubEnv(keep ...string) RedactFunc {
+	kept := make(map[string]bool, len(keep))
+	for _, name := range keep {
+		kept[name] = true
+	}
+	return func(tok Token) (Token, error) {
+		env, ok := tok.(ExecEnvToken)
+		if !ok {
+			return tok, nil
+		}
+		scrubbed := make([]string, len(env.Text))
+		for i, s := range env.Text {
+			name, _, _ := strings.Cut(s, "=")
+			if !kept[name] {
+				s = name + "="
+			}
+			scrubbed[i] = s
+		}
+		env.Text = scrubbed
+		return env, nil
+	}
+}
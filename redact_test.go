@@ -0,0 +1,91 @@
+package bsm
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"testing"
+
+	"github.com/tpltnt/go-bsm/encode"
+)
+
+func TestRedact(t *testing.T) {
+	var trail bytes.Buffer
+	rec := BsmRecord{
+		Version:   11,
+		EventType: 23,
+		Tokens: []Token{
+			ExecArgsToken{Text: []string{"deploy", "--prod"}},
+			ExecEnvToken{Text: []string{"PATH=/bin:/usr/bin", "API_TOKEN=s3cr3t", "EMPTY"}},
+			TextToken{Text: "password=hunter2"},
+			ReturnToken32bit{},
+		},
+	}
+	if err := encode.NewEncoder(&trail).Encode(&rec); err != nil {
+		t.Fatal(err)
+	}
+
+	scrub := ScrubEnv("PATH")
+	var out bytes.Buffer
+	err := Redact(&trail, &out, DialectUnknown, func(tok Token) (Token, error) {
+		if _, ok := tok.(TextToken); ok {
+			return nil, nil
+		}
+		return scrub(tok)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := out.Bytes()
+	if size := binary.BigEndian.Uint32(data[1:5]); int(size) != len(data) {
+		t.Errorf("header byte count %d, record has %d bytes", size, len(data))
+	}
+	if size := binary.BigEndian.Uint32(data[len(data)-4:]); int(size) != len(data) {
+		t.Errorf("trailer byte count %d, record has %d bytes", size, len(data))
+	}
+	redacted, err := NewDecoder(bytes.NewReader(data)).Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(redacted.Tokens) != 3 {
+		t.Fatal("unexpected tokens:", redacted.Tokens)
+	}
+	env := redacted.Tokens[1].(ExecEnvToken).Text
+	if len(env) != 3 || env[0] != "PATH=/bin:/usr/bin" || env[1] != "API_TOKEN=" || env[2] != "EMPTY=" {
+		t.Errorf("unexpected environment %q", env)
+	}
+}
+
+func TestRedactUnchanged(t *testing.T) {
+	data, err := os.ReadFile("start_stop.bsm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if err := Redact(bytes.NewReader(data), &out, DialectUnknown, ScrubEnv()); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(out.Bytes(), data) {
+		t.Error("trail changed without secrets")
+	}
+}
+
+// linuxTrail is a record written by a Linux producer, whose path
+// token doesn't count the NUL following it.
+var linuxTrail = []byte{
+	0x14, 0x00, 0x00, 0x00, 0x21, 0x0b, 0x00, 0x17, 0x00, 0x00, // header
+	0x5a, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x23, 0x00, 0x04, '/', 'e', 't', 'c', 0x00, // path, NUL not counted
+	0x13, 0xb1, 0x05, 0x00, 0x00, 0x00, 0x21, // trailer
+}
+
+func TestRedactLinux(t *testing.T) {
+	var out bytes.Buffer
+	if err := Redact(bytes.NewReader(linuxTrail), &out, DialectLinux, ScrubEnv()); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(out.Bytes(), linuxTrail) {
+		t.Error("trail changed without secrets")
+	}
+}
@@ -0,0 +1,32 @@
+// Package schema provides the JSON schemas of the serialized BSM
+// records for every schema version, so ingestion pipelines can
+// validate records and evolve with the format.
+package schema
+
+import (
+	"embed"
+	"fmt"
+)
+
+// Latest is the current schema version (matching bsm.SchemaVersion).
+const Latest = 3
+
+//go:embed *.json
+var schemas embed.FS
+
+// Versions returns all known schema versions in ascending order.
+func Versions() []int {
+	versions := make([]int, 0, Latest)
+	for v := 1; v <= Latest; v++ {
+		versions = append(versions, v)
+	}
+	return versions
+}
+
+// JSONSchema returns the JSON schema for the given schema version.
+func JSONSchema(version int) ([]byte, error) {
+	if version < 1 || version > Latest {
+		return nil, fmt.Errorf("unknown schema version: %d", version)
+	}
+	return schemas.ReadFile(fmt.Sprintf("v%d.json", version))
+}
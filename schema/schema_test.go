@@ -0,0 +1,27 @@
+package schema
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestJSONSchema(t *testing.T) {
+	for _, version := range Versions() {
+		data, err := JSONSchema(version)
+		if err != nil {
+			t.Fatal(err)
+		}
+		doc := map[string]interface{}{}
+		if err := json.Unmarshal(data, &doc); err != nil {
+			t.Errorf("schema version %d is no valid JSON: %s", version, err)
+		}
+		properties := doc["properties"].(map[string]interface{})
+		schemaVersion := properties["schema_version"].(map[string]interface{})
+		if schemaVersion["const"] != float64(version) {
+			t.Errorf("schema version %d describes the wrong version", version)
+		}
+	}
+	if _, err := JSONSchema(Latest + 1); err == nil {
+		t.Error("expected an error for unknown version")
+	}
+}
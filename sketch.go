@@ -0,0 +1,174 @@
+package bsm
+
+import (
+	"hash/fnv"
+	"sort"
+)
+
+// CountMinSketch estimates the frequency of items in bounded memory.
+// Estimates are never below the true count and exceed it by at most
+// a fraction of the total count depending on the width.
+type CountMinSketch struct {
+	width  uint32
+	counts [][]uint64 // depth rows of width counters
+}
+
+// NewCountMinSketch returns a sketch with depth rows of width counters.
+func NewCountMinSketch(width, depth int) *CountMinSketch {
+	if width < 1 {
+		width = 1
+	}
+	if depth < 1 {
+		depth = 1
+	}
+	counts := make([][]uint64, depth)
+	for i := range counts {
+		counts[i] = make([]uint64, width)
+	}
+	return &CountMinSketch{width: uint32(width), counts: counts}
+}
+
+// index returns the counter for item in the given row.
+func (cms *CountMinSketch) index(item string, row int) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte{byte(row)})
+	h.Write([]byte(item))
+	return h.Sum32() % cms.width
+}
+
+// Add counts an occurrence of item.
+func (cms *CountMinSketch) Add(item string) {
+	for row := range cms.counts {
+		cms.counts[row][cms.index(item, row)] += 1
+	}
+}
+
+// Count returns the estimated number of occurrences of item.
+func (cms *CountMinSketch) Count(item string) uint64 {
+	var min uint64
+	for row := range cms.counts {
+		c := cms.counts[row][cms.index(item, row)]
+		if row == 0 || c < min {
+			min = c
+		}
+	}
+	return min
+}
+
+// HeavyHitter is an item with its (estimated) count.
+type HeavyHitter struct {
+	Item  string
+	Count uint64
+	Error uint64 // maximum overestimation of Count
+}
+
+// TopK tracks the most frequent items using the space-saving
+// algorithm with a fixed number of counters.
+type TopK struct {
+	size     int
+	counters map[string]*HeavyHitter
+}
+
+// NewTopK returns a TopK tracking up to size items.
+func NewTopK(size int) *TopK {
+	if size < 1 {
+		size = 1
+	}
+	return &TopK{size: size, counters: make(map[string]*HeavyHitter, size)}
+}
+
+// Add counts an occurrence of item. If all counters are in use, the
+// counter of the least frequent item is taken over.
+func (tk *TopK) Add(item string) {
+	if c, ok := tk.counters[item]; ok {
+		c.Count += 1
+		return
+	}
+	if len(tk.counters) < tk.size {
+		tk.counters[item] = &HeavyHitter{Item: item, Count: 1}
+		return
+	}
+	var min *HeavyHitter
+	for _, c := range tk.counters {
+		if min == nil || c.Count < min.Count || (c.Count == min.Count && c.Item < min.Item) {
+			min = c
+		}
+	}
+	delete(tk.counters, min.Item)
+	tk.counters[item] = &HeavyHitter{Item: item, Count: min.Count + 1, Error: min.Count}
+}
+
+// Top returns up to n items ordered by descending count.
+func (tk *TopK) Top(n int) []HeavyHitter {
+	result := make([]HeavyHitter, 0, len(tk.counters))
+	for _, c := range tk.counters {
+		result = append(result, *c)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Item < result[j].Item
+	})
+	if n < len(result) {
+		result = result[:n]
+	}
+	return result
+}
+
+// HeavyHitters collects streaming sketches over a set of dimensions.
+// It is a Sink, so it can be attached to endless streams (e.g. from
+// auditpipe) and queried at any time. Empty dimension values are not
+// counted.
+type HeavyHitters struct {
+	dims     []Dimension
+	topK     map[string]*TopK
+	sketches map[string]*CountMinSketch
+}
+
+// NewHeavyHitters returns a collector tracking the top size values
+// of every dimension.
+func NewHeavyHitters(size int, dims ...Dimension) *HeavyHitters {
+	hh := &HeavyHitters{
+		dims:     dims,
+		topK:     map[string]*TopK{},
+		sketches: map[string]*CountMinSketch{},
+	}
+	for _, dim := range dims {
+		hh.topK[dim.Name] = NewTopK(size)
+		hh.sketches[dim.Name] = NewCountMinSketch(1024, 4)
+	}
+	return hh
+}
+
+// WriteRecord counts the dimension values of the record.
+func (hh *HeavyHitters) WriteRecord(rec *BsmRecord) error {
+	for _, dim := range hh.dims {
+		value := dim.Value(rec)
+		if value == "" {
+			continue
+		}
+		hh.topK[dim.Name].Add(value)
+		hh.sketches[dim.Name].Add(value)
+	}
+	return nil
+}
+
+// Top returns the n most frequent values of the named dimension.
+func (hh *HeavyHitters) Top(dim string, n int) []HeavyHitter {
+	tk, ok := hh.topK[dim]
+	if !ok {
+		return nil
+	}
+	return tk.Top(n)
+}
+
+// Count returns the estimated number of records with the given value
+// of the named dimension.
+func (hh *HeavyHitters) Count(dim string, value string) uint64 {
+	cms, ok := hh.sketches[dim]
+	if !ok {
+		return 0
+	}
+	return cms.Count(value)
+}
@@ -0,0 +1,68 @@
+package bsm
+
+import (
+	"fmt"
+	"net"
+	"testing"
+)
+
+func TestCountMinSketch(t *testing.T) {
+	cms := NewCountMinSketch(64, 4)
+	for i := 0; i < 1000; i++ {
+		cms.Add(fmt.Sprint("item", i%100))
+	}
+	for i := 0; i < 100; i++ {
+		if c := cms.Count(fmt.Sprint("item", i)); c < 10 {
+			t.Error("count below true count:", c)
+		}
+	}
+	if c := NewCountMinSketch(0, 0).Count("x"); c != 0 {
+		t.Error("unexpected count:", c)
+	}
+}
+
+func TestTopK(t *testing.T) {
+	tk := NewTopK(10)
+	for i := 0; i < 50; i++ {
+		tk.Add("a")
+		if i%2 == 0 {
+			tk.Add("b")
+		}
+		tk.Add(fmt.Sprint("noise", i))
+	}
+	top := tk.Top(2)
+	if len(top) != 2 || top[0].Item != "a" || top[1].Item != "b" {
+		t.Fatal("unexpected heavy hitters:", top)
+	}
+	if top[0].Count != 50 || top[0].Error != 0 {
+		t.Error("unexpected count:", top[0])
+	}
+	if len(tk.Top(20)) != 10 {
+		t.Error("more items than counters")
+	}
+}
+
+func TestHeavyHitters(t *testing.T) {
+	hh := NewHeavyHitters(10, DimExecPath, DimSourceIP)
+	for i := 0; i < 5; i++ {
+		hh.WriteRecord(&BsmRecord{Tokens: []Token{
+			SubjectToken32bit{TokenID: 0x24, TerminalMachineAddress: net.IPv4(10, 0, 0, byte(i%2))},
+			ExecArgsToken{TokenID: 0x3c, Count: 1, Text: []string{"/bin/sh"}},
+		}})
+	}
+	hh.WriteRecord(&BsmRecord{})
+
+	top := hh.Top("exec_path", 1)
+	if len(top) != 1 || top[0].Item != "/bin/sh" || top[0].Count != 5 {
+		t.Error("unexpected exec paths:", top)
+	}
+	if top := hh.Top("source_ip", 5); len(top) != 2 || top[0].Item != "10.0.0.0" {
+		t.Error("unexpected source IPs:", top)
+	}
+	if c := hh.Count("source_ip", "10.0.0.1"); c < 2 {
+		t.Error("unexpected count:", c)
+	}
+	if hh.Top("unknown", 1) != nil || hh.Count("unknown", "") != 0 {
+		t.Error("unexpected result for unknown dimension")
+	}
+}
@@ -0,0 +1,115 @@
+package bsm
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// Source is a long-running supplier of records, e.g. a trail file
+// being written, an audit pipe or a network connection.
+type Source interface {
+	// Next returns the next record. io.EOF marks the end of the source.
+	Next() (BsmRecord, error)
+	// Health reports the current state of the source.
+	Health() Health
+}
+
+// Health describes the state of a source for supervision purposes.
+type Health struct {
+	Records    uint64    `json:"records"`     // number of records read
+	Errors     uint64    `json:"errors"`      // number of failed reads
+	LastError  string    `json:"last_error"`  // message of the last error (if any)
+	Failing    bool      `json:"failing"`     // the last read failed
+	LastRecord time.Time `json:"last_record"` // time stamp of the last record
+	LastRead   time.Time `json:"last_read"`   // wall clock time the last record was read
+	Lag        int64     `json:"lag"`         // bytes behind the end of the file (-1 if unknown)
+}
+
+// ReaderSource is a Source decoding records from an io.Reader. If the
+// reader is a file, the lag behind its end is reported.
+type ReaderSource struct {
+	input   io.Reader
+	decoder *Decoder
+
+	mutex  sync.Mutex
+	health Health
+}
+
+// NewReaderSource returns a source decoding the given input.
+func NewReaderSource(input io.Reader) *ReaderSource {
+	return &ReaderSource{
+		input:   input,
+		decoder: NewDecoder(input),
+		health:  Health{Lag: -1},
+	}
+}
+
+// Next decodes the next record of the input.
+func (src *ReaderSource) Next() (BsmRecord, error) {
+	rec, err := src.decoder.Decode()
+
+	src.mutex.Lock()
+	defer src.mutex.Unlock()
+	if err != nil && err != io.EOF {
+		src.health.Errors += 1
+		src.health.LastError = err.Error()
+		src.health.Failing = true
+	}
+	if err == nil {
+		src.health.Failing = false
+		src.health.Records += 1
+		src.health.LastRecord = rec.Time()
+		src.health.LastRead = time.Now()
+	}
+	src.health.Lag = src.lag()
+	return rec, err
+}
+
+// lag determines the number of bytes between the current offset and
+// the end of the file read from.
+func (src *ReaderSource) lag() int64 {
+	file, ok := src.input.(*os.File)
+	if !ok {
+		return -1
+	}
+	info, err := file.Stat()
+	if err != nil || !info.Mode().IsRegular() {
+		return -1
+	}
+	offset, err := file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return -1
+	}
+	return info.Size() - offset
+}
+
+// Health reports the current state of the source.
+func (src *ReaderSource) Health() Health {
+	src.mutex.Lock()
+	defer src.mutex.Unlock()
+	return src.health
+}
+
+// HealthHandler returns an HTTP handler (e.g. for /healthz) reporting
+// the health of the source as JSON. It responds with status 503 if the
+// last read failed or if no record was read for longer than maxIdle
+// (unless maxIdle is 0).
+func HealthHandler(src Source, maxIdle time.Duration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		health := src.Health()
+		status := http.StatusOK
+		if health.Failing {
+			status = http.StatusServiceUnavailable
+		}
+		if maxIdle > 0 && !health.LastRead.IsZero() && time.Since(health.LastRead) > maxIdle {
+			status = http.StatusServiceUnavailable
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(health)
+	})
+}
@@ -0,0 +1,125 @@
+package bsm
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+// flakyReader fails its first read.
+type flakyReader struct {
+	input  io.Reader
+	failed bool
+}
+
+func (r *flakyReader) Read(p []byte) (int, error) {
+	if !r.failed {
+		r.failed = true
+		return 0, errors.New("flaky read")
+	}
+	return r.input.Read(p)
+}
+
+func TestReaderSourceHealth(t *testing.T) {
+	file, err := os.Open("start_stop.bsm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	src := NewReaderSource(file)
+	if _, err := src.Next(); err != nil {
+		t.Fatal(err)
+	}
+	health := src.Health()
+	if health.Records != 1 {
+		t.Error("unexpected number of records:", health.Records)
+	}
+	if health.Lag != 57 { // size of the second record
+		t.Error("unexpected lag:", health.Lag)
+	}
+	if health.LastRecord.Unix() != 1520091878 {
+		t.Error("unexpected time of last record:", health.LastRecord)
+	}
+	src.Next()
+	if _, err := src.Next(); err != io.EOF {
+		t.Error("expected io.EOF, got", err)
+	}
+	if health = src.Health(); health.Lag != 0 || health.Errors != 0 {
+		t.Error("unexpected health:", health)
+	}
+
+	// non-file input
+	src = NewReaderSource(bytes.NewReader([]byte{0x00}))
+	if _, err := src.Next(); err == nil {
+		t.Error("expected an error")
+	}
+	if health = src.Health(); health.Lag != -1 || health.Errors != 1 || health.LastError == "" || !health.Failing {
+		t.Error("unexpected health:", health)
+	}
+
+	// a successful read after a failed one
+	data, err := os.ReadFile("start_stop.bsm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	src = NewReaderSource(&flakyReader{input: bytes.NewReader(data)})
+	if _, err := src.Next(); err == nil {
+		t.Error("expected an error")
+	}
+	if _, err := src.Next(); err != nil {
+		t.Fatal(err)
+	}
+	if health = src.Health(); health.Errors != 1 || health.Records != 1 || health.Failing {
+		t.Error("unexpected health after recovery:", health)
+	}
+}
+
+func TestHealthHandler(t *testing.T) {
+	file, err := os.Open("start_stop.bsm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	src := NewReaderSource(file)
+	src.Next()
+
+	recorder := httptest.NewRecorder()
+	HealthHandler(src, time.Minute).ServeHTTP(recorder, httptest.NewRequest("GET", "/healthz", nil))
+	if recorder.Code != http.StatusOK {
+		t.Error("unexpected status:", recorder.Code)
+	}
+	health := Health{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &health); err != nil {
+		t.Fatal(err)
+	}
+	if health.Records != 1 {
+		t.Error("unexpected health:", health)
+	}
+
+	// failed source
+	recorder = httptest.NewRecorder()
+	HealthHandler(&healthSource{Health{LastError: "gone", Failing: true}}, 0).ServeHTTP(recorder, httptest.NewRequest("GET", "/healthz", nil))
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Error("unexpected status of failed source:", recorder.Code)
+	}
+	recorder = httptest.NewRecorder()
+	HealthHandler(&healthSource{Health{LastError: "gone"}}, 0).ServeHTTP(recorder, httptest.NewRequest("GET", "/healthz", nil))
+	if recorder.Code != http.StatusOK {
+		t.Error("unexpected status of recovered source:", recorder.Code)
+	}
+
+	// stalled source
+	time.Sleep(5 * time.Millisecond)
+	recorder = httptest.NewRecorder()
+	HealthHandler(src, time.Millisecond).ServeHTTP(recorder, httptest.NewRequest("GET", "/healthz", nil))
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Error("unexpected status:", recorder.Code)
+	}
+}
@@ -0,0 +1,92 @@
+package bsm
+
+// PathRoleAnnotation is the annotation holding the role of the path of
+// a record split by SplitPaths.
+const PathRoleAnnotation = "path.role"
+
+// Roles of the paths of a record.
+const (
+	PathRoleTarget   = "target"   // the path of a record with a single path
+	PathRoleFrom     = "from"     // source of a rename or link
+	PathRoleTo       = "to"       // destination of a rename or link
+	PathRoleResolved = "resolved" // any further path, e.g. a resolved symbolic link
+)
+
+// sourceDestEvents are the events whose first two paths name a source
+// and a destination.
+var sourceDestEvents = map[EventType]bool{
+	AUE_RENAME:   true,
+	AUE_RENAMEAT: true, // also renameatx_np(2) on macOS
+	AUE_LINK:     true,
+	AUE_LINKAT:   true,
+}
+
+// pathRole returns the role of the i-th path of a record of the given
+// event type.
+func pathRole(event EventType, i int) string {
+	switch {
+	case sourceDestEvents[event] && i == 0:
+		return PathRoleFrom
+	case sourceDestEvents[event] && i == 1:
+		return PathRoleTo
+	case i == 0:
+		return PathRoleTarget
+	}
+	return PathRoleResolved
+}
+
+// SplitPathRecord returns a record per path token of rec, holding all
+// other tokens of rec but only that path, annotated with the role of
+// the path (see PathRoleAnnotation). Records without path tokens are
+// returned unchanged.
+func SplitPathRecord(rec *BsmRecord) []BsmRecord {
+	var paths []int // indexes of the path tokens
+	for i, tok := range rec.Tokens {
+		if _, ok := tok.(PathToken); ok {
+			paths = append(paths, i)
+		}
+	}
+	if len(paths) == 0 {
+		return []BsmRecord{*rec}
+	}
+
+	split := make([]BsmRecord, len(paths))
+	for n, index := range paths {
+		out := *rec
+		out.Tokens = make([]Token, 0, len(rec.Tokens)-len(paths)+1)
+		for i, tok := range rec.Tokens {
+			if _, ok := tok.(PathToken); !ok || i == index {
+				out.Tokens = append(out.Tokens, tok)
+			}
+		}
+		out.Annotations = make(map[string]string, len(rec.Annotations)+1)
+		for k, v := range rec.Annotations {
+			out.Annotations[k] = v
+		}
+		out.Annotate(PathRoleAnnotation, pathRole(rec.Event(), n))
+		split[n] = out
+	}
+	return split
+}
+
+// SplitPaths yields the records of the given stream split with
+// SplitPathRecord, e.g. to feed file integrity monitoring with one
+// event per path. Parsing errors are passed on unchanged.
+func SplitPaths(in chan ParsingResult) chan ParsingResult {
+	out := make(chan ParsingResult)
+
+	go func() {
+		for res := range in {
+			if res.Error != nil {
+				out <- res
+				continue
+			}
+			for _, rec := range SplitPathRecord(&res.Record) {
+				out <- ParsingResult{Record: rec, Issues: res.Issues}
+			}
+		}
+		close(out)
+	}()
+
+	return out
+}
@@ -0,0 +1,66 @@
+package bsm
+
+import "testing"
+
+func TestSplitPaths(t *testing.T) {
+	rename := BsmRecord{
+		EventType:   uint16(AUE_RENAME),
+		Annotations: map[string]string{"host": "a"},
+		Tokens: []Token{
+			PathToken{Path: "/tmp/a"},
+			PathToken{Path: "/tmp/b"},
+			PathToken{Path: "/private/tmp/b"},
+			ReturnToken32bit{},
+		},
+	}
+	in := make(chan ParsingResult, 2)
+	in <- ParsingResult{Record: rename}
+	in <- ParsingResult{Record: BsmRecord{EventType: uint16(AUE_EXIT), Tokens: []Token{ReturnToken32bit{}}}}
+	close(in)
+
+	var got []BsmRecord
+	for res := range SplitPaths(in) {
+		got = append(got, res.Record)
+	}
+	if len(got) != 4 {
+		t.Fatalf("got %d records, expected 4", len(got))
+	}
+	for i, want := range []struct{ path, role string }{
+		{"/tmp/a", PathRoleFrom}, {"/tmp/b", PathRoleTo}, {"/private/tmp/b", PathRoleResolved},
+	} {
+		rec := got[i]
+		if p, _ := rec.Path(); p != want.path || len(rec.Tokens) != 2 {
+			t.Errorf("record %d: unexpected tokens %v", i, rec.Tokens)
+		}
+		if role, _ := rec.Annotation(PathRoleAnnotation); role != want.role {
+			t.Errorf("record %d: got role %q, expected %q", i, role, want.role)
+		}
+		if host, _ := rec.Annotation("host"); host != "a" {
+			t.Errorf("record %d: annotations not copied", i)
+		}
+	}
+	if _, ok := rename.Annotation(PathRoleAnnotation); ok {
+		t.Error("original record annotated")
+	}
+	if _, ok := got[3].Annotation(PathRoleAnnotation); ok || got[3].EventType != uint16(AUE_EXIT) {
+		t.Error("record without paths changed:", got[3])
+	}
+
+	single := BsmRecord{EventType: uint16(AUE_OPEN_R), Tokens: []Token{PathToken{Path: "/etc/hosts"}}}
+	if split := SplitPathRecord(&single); len(split) != 1 || split[0].Annotations[PathRoleAnnotation] != PathRoleTarget {
+		t.Error("unexpected split of single path record:", split)
+	}
+}
+
+func TestSplitPathsAt(t *testing.T) {
+	for _, event := range []EventType{AUE_LINK, AUE_LINKAT, AUE_RENAMEAT} {
+		rec := BsmRecord{
+			EventType: uint16(event),
+			Tokens:    []Token{PathToken{Path: "/tmp/a"}, PathToken{Path: "/tmp/b"}},
+		}
+		split := SplitPathRecord(&rec)
+		if len(split) != 2 || split[0].Annotations[PathRoleAnnotation] != PathRoleFrom || split[1].Annotations[PathRoleAnnotation] != PathRoleTo {
+			t.Errorf("%v: unexpected split %v", event, split)
+		}
+	}
+}
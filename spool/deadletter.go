@@ -0,0 +1,148 @@
+package spool
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/tpltnt/go-bsm/decode"
+	"github.com/tpltnt/go-bsm/encode"
+)
+
+// DeadLetter is a record which could not be processed, along with the
+// context of the failure.
+type DeadLetter struct {
+	Record decode.BsmRecord
+	Stage  string    // part of the pipeline which failed, e.g. "transform"
+	Error  string    // message of the error
+	Time   time.Time // time of the failure
+}
+
+// deadLetterContext is the JSON form of the context of a dead letter.
+type deadLetterContext struct {
+	Stage string    `json:"stage"`
+	Error string    `json:"error"`
+	Time  time.Time `json:"time"`
+}
+
+// DeadLetterQueue persists records which could not be transformed or
+// delivered, so they aren't lost and can be reprocessed later. Its
+// frames hold the context of the failure (as JSON) next to the record.
+type DeadLetterQueue struct {
+	queue *Queue
+}
+
+// OpenDeadLetters opens the dead letter queue in the given directory,
+// creating it if needed.
+func OpenDeadLetters(dir string) (*DeadLetterQueue, error) {
+	q, err := Open(dir)
+	if err != nil {
+		return nil, err
+	}
+	return &DeadLetterQueue{queue: q}, nil
+}
+
+// Add appends a record which failed in the given stage with err.
+func (d *DeadLetterQueue) Add(rec *decode.BsmRecord, stage string, err error) error {
+	return d.add(DeadLetter{Record: *rec, Stage: stage, Error: err.Error(), Time: time.Now()})
+}
+
+func (d *DeadLetterQueue) add(letter DeadLetter) error {
+	context, err := json.Marshal(deadLetterContext{Stage: letter.Stage, Error: letter.Error, Time: letter.Time})
+	if err != nil {
+		return err
+	}
+	payload := binary.BigEndian.AppendUint32(nil, uint32(len(context)))
+	payload = append(payload, context...)
+	rec, err := encode.Record(&letter.Record)
+	if err != nil {
+		return err
+	}
+	return d.queue.write(append(payload, rec...))
+}
+
+// Next returns the next dead letter, see Queue.Next.
+func (d *DeadLetterQueue) Next() (DeadLetter, error) {
+	var letter DeadLetter
+	err := d.queue.next(func(payload []byte) error {
+		if len(payload) < 4 || int(binary.BigEndian.Uint32(payload))+4 > len(payload) {
+			return errors.New("invalid dead letter")
+		}
+		size := int(binary.BigEndian.Uint32(payload)) + 4
+		var context deadLetterContext
+		if err := json.Unmarshal(payload[4:size], &context); err != nil {
+			return err
+		}
+		rec, err := decode.ReadBsmRecord(bytes.NewReader(payload[size:]))
+		if err != nil {
+			return err
+		}
+		letter = DeadLetter{Record: rec, Stage: context.Stage, Error: context.Error, Time: context.Time}
+		return nil
+	})
+	return letter, err
+}
+
+// Commit drops all dead letters returned by Next, see Queue.Commit.
+func (d *DeadLetterQueue) Commit() error {
+	return d.queue.Commit()
+}
+
+// Rewind makes Next return the uncommitted dead letters again.
+func (d *DeadLetterQueue) Rewind() {
+	d.queue.Rewind()
+}
+
+// Pending returns the number of bytes of uncommitted dead letters.
+func (d *DeadLetterQueue) Pending() int64 {
+	return d.queue.Pending()
+}
+
+// Reprocess passes all pending dead letters to fn. Those it fails for
+// are added again with the new error, so they are kept for another
+// attempt. It returns the number of dead letters reprocessed
+// successfully.
+func (d *DeadLetterQueue) Reprocess(fn func(letter *DeadLetter) error) (int, error) {
+	d.queue.Rewind()
+	d.queue.mutex.Lock()
+	end := d.queue.size // letters added again aren't retried
+	d.queue.mutex.Unlock()
+
+	done := 0
+	for {
+		d.queue.mutex.Lock()
+		exhausted := d.queue.read >= end
+		d.queue.mutex.Unlock()
+		if exhausted {
+			break
+		}
+		letter, err := d.Next()
+		if err == io.EOF { // all committed and truncated
+			break
+		}
+		if err != nil {
+			return done, err
+		}
+		if err := fn(&letter); err != nil {
+			letter.Error = err.Error()
+			letter.Time = time.Now()
+			if err := d.add(letter); err != nil {
+				return done, err
+			}
+		} else {
+			done += 1
+		}
+		if err := d.Commit(); err != nil {
+			return done, err
+		}
+	}
+	return done, nil
+}
+
+// Close closes the queue.
+func (d *DeadLetterQueue) Close() error {
+	return d.queue.Close()
+}
@@ -0,0 +1,62 @@
+package spool
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDeadLetterQueue(t *testing.T) {
+	dir := t.TempDir()
+	d, err := OpenDeadLetters(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := uint16(1); i <= 3; i++ {
+		if err := d.Add(testRecord(i), "sink:0", errors.New("connection refused")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	d.Close()
+
+	// the letters survive a restart
+	if d, err = OpenDeadLetters(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	letter, err := d.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if letter.Record.EventType != 1 || letter.Stage != "sink:0" || letter.Error != "connection refused" || letter.Time.IsZero() {
+		t.Errorf("unexpected dead letter %+v", letter)
+	}
+	d.Rewind()
+
+	// event 2 keeps failing
+	var seen []uint16
+	done, err := d.Reprocess(func(letter *DeadLetter) error {
+		seen = append(seen, letter.Record.EventType)
+		if letter.Record.EventType == 2 {
+			return errors.New("still failing")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if done != 2 || len(seen) != 3 {
+		t.Errorf("reprocessed %d of %v", done, seen)
+	}
+	letter, err = d.Next()
+	if err != nil || letter.Record.EventType != 2 || letter.Error != "still failing" {
+		t.Fatalf("unexpected dead letter %+v, %v", letter, err)
+	}
+
+	done, err = d.Reprocess(func(*DeadLetter) error { return nil })
+	if err != nil || done != 1 {
+		t.Fatalf("reprocessed %d: %v", done, err)
+	}
+	if d.Pending() != 0 {
+		t.Errorf("%d bytes pending", d.Pending())
+	}
+}
@@ -0,0 +1,274 @@
+// Package spool implements a persistent queue of BSM records, e.g. to
+// buffer records between the decoder and a network sink while the
+// collector is unreachable.
+//
+// The queue is a directory holding an append-only data file and the
+// offset of the first uncommitted record. Every record is written as a
+// frame of length, CRC32 checksum and the record in its binary form
+// (see package encode). Appends are synced to disk and the commit offset
+// is replaced atomically, so a crash doesn't lose appended records.
+// Delivery is at least once: a crash may redeliver records, e.g. all
+// records of the data file if it happens while Commit resets the queue
+// after everything was delivered. A torn frame at the end of the data
+// file is discarded on Open. Annotations of the records are not kept.
+package spool
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/tpltnt/go-bsm/decode"
+	"github.com/tpltnt/go-bsm/encode"
+)
+
+const (
+	dataFile   = "data"
+	commitFile = "commit"
+	frameSize  = 8 // length (4 bytes) and checksum (4 bytes)
+)
+
+// Queue is an on-disk queue of records. It is safe for concurrent use
+// by one writer and one reader.
+type Queue struct {
+	dir  string
+	data *os.File
+
+	mutex     sync.Mutex
+	size      int64 // size of the valid part of the data file
+	committed int64 // offset of the first uncommitted record
+	read      int64 // offset of the next record returned by Next
+}
+
+// Open opens the queue in the given directory, creating it if needed.
+func Open(dir string) (*Queue, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	data, err := os.OpenFile(filepath.Join(dir, dataFile), os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	q := &Queue{dir: dir, data: data}
+	if err := q.recover(); err != nil {
+		data.Close()
+		return nil, err
+	}
+	return q, nil
+}
+
+// recover reads the commit offset and drops a torn frame at the end of
+// the data file.
+func (q *Queue) recover() error {
+	buf, err := os.ReadFile(filepath.Join(q.dir, commitFile))
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return err
+	case len(buf) != 8:
+		return errors.New("invalid commit file")
+	default:
+		q.committed = int64(binary.BigEndian.Uint64(buf))
+	}
+
+	info, err := q.data.Stat()
+	if err != nil {
+		return err
+	}
+	if q.committed > info.Size() {
+		return fmt.Errorf("commit offset %d beyond end of data (%d bytes)", q.committed, info.Size())
+	}
+	offset := q.committed
+	for {
+		_, next, err := q.frame(offset, info.Size())
+		if err != nil {
+			break
+		}
+		offset = next
+	}
+	if offset < info.Size() {
+		if err := q.data.Truncate(offset); err != nil {
+			return err
+		}
+	}
+	q.size = offset
+	q.read = q.committed
+	return nil
+}
+
+// frame reads the payload of the frame at the given offset and returns
+// it along with the offset of the next frame. Frames must end before
+// limit.
+func (q *Queue) frame(offset, limit int64) ([]byte, int64, error) {
+	var header [frameSize]byte
+	if _, err := q.data.ReadAt(header[:], offset); err != nil {
+		return nil, 0, err
+	}
+	length := binary.BigEndian.Uint32(header[:4])
+	if offset+frameSize+int64(length) > limit {
+		return nil, 0, io.ErrUnexpectedEOF
+	}
+	payload := make([]byte, length)
+	if _, err := q.data.ReadAt(payload, offset+frameSize); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, 0, err
+	}
+	if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(header[4:]) {
+		return nil, 0, errors.New("checksum mismatch")
+	}
+	return payload, offset + frameSize + int64(length), nil
+}
+
+// WriteRecord appends the given record to the queue. It returns once
+// the record is synced to disk.
+func (q *Queue) WriteRecord(rec *decode.BsmRecord) error {
+	payload, err := encode.Record(rec)
+	if err != nil {
+		return err
+	}
+	return q.write(payload)
+}
+
+// write appends a frame holding the payload and syncs it to disk.
+func (q *Queue) write(payload []byte) error {
+	frame := make([]byte, frameSize, frameSize+len(payload))
+	binary.BigEndian.PutUint32(frame[:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(frame[4:], crc32.ChecksumIEEE(payload))
+	frame = append(frame, payload...)
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if _, err := q.data.WriteAt(frame, q.size); err != nil {
+		return err
+	}
+	if err := q.data.Sync(); err != nil {
+		return err
+	}
+	q.size += int64(len(frame))
+	return nil
+}
+
+// Next returns the next record after the ones returned before. It
+// returns io.EOF if there is none (yet). Records are returned again
+// after a restart or Rewind unless they are committed.
+func (q *Queue) Next() (decode.BsmRecord, error) {
+	var rec decode.BsmRecord
+	err := q.next(func(payload []byte) error {
+		var err error
+		rec, err = decode.ReadBsmRecord(bytes.NewReader(payload))
+		return err
+	})
+	return rec, err
+}
+
+// next passes the payload of the next frame to fn and moves on unless
+// it fails. It returns io.EOF if there is no frame.
+func (q *Queue) next(fn func(payload []byte) error) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if q.read >= q.size {
+		return io.EOF
+	}
+	payload, next, err := q.frame(q.read, q.size)
+	if err != nil {
+		return err
+	}
+	if err := fn(payload); err != nil {
+		return err
+	}
+	q.read = next
+	return nil
+}
+
+// Commit marks all records returned by Next as delivered. Once all
+// records are committed, the data file is truncated.
+func (q *Queue) Commit() error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if q.read == q.size && q.size > 0 {
+		// everything delivered: reset the commit offset before
+		// truncating, a crash in between redelivers the records
+		// instead of leaving an offset beyond the end of the data
+		if err := q.writeCommit(0); err != nil {
+			return err
+		}
+		if err := q.data.Truncate(0); err != nil {
+			return err
+		}
+		q.size, q.read = 0, 0
+		q.committed = 0
+		return q.data.Sync()
+	}
+	if err := q.writeCommit(q.read); err != nil {
+		return err
+	}
+	q.committed = q.read
+	return nil
+}
+
+// writeCommit atomically replaces the commit offset.
+func (q *Queue) writeCommit(offset int64) error {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], uint64(offset))
+	tmp := filepath.Join(q.dir, commitFile+".tmp")
+	file, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(buf[:]); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, filepath.Join(q.dir, commitFile)); err != nil {
+		return err
+	}
+	return syncDir(q.dir)
+}
+
+// syncDir syncs the given directory, making a rename within it durable.
+func syncDir(path string) error {
+	dir, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	err = dir.Sync()
+	if cerr := dir.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// Rewind makes Next return the uncommitted records again, e.g. after a
+// failed delivery.
+func (q *Queue) Rewind() {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	q.read = q.committed
+}
+
+// Pending returns the number of bytes of uncommitted records.
+func (q *Queue) Pending() int64 {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return q.size - q.committed
+}
+
+// Close closes the data file of the queue.
+func (q *Queue) Close() error {
+	return q.data.Close()
+}
@@ -0,0 +1,117 @@
+package spool
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tpltnt/go-bsm/decode"
+	"github.com/tpltnt/go-bsm/token"
+)
+
+func testRecord(eventType uint16) *decode.BsmRecord {
+	return &decode.BsmRecord{
+		Version:   11,
+		EventType: eventType,
+		Seconds:   1520091878,
+		Tokens: []token.Token{
+			token.TextToken{TokenID: 0x28, TextLength: 6, Text: "hello"},
+			token.ReturnToken32bit{TokenID: 0x27},
+		},
+	}
+}
+
+func TestQueue(t *testing.T) {
+	dir := t.TempDir()
+	q, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := uint16(1); i <= 3; i++ {
+		if err := q.WriteRecord(testRecord(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rec, err := q.Next()
+	if err != nil || rec.EventType != 1 {
+		t.Fatal("unexpected record:", rec, err)
+	}
+	if err := q.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if rec, err = q.Next(); err != nil || rec.EventType != 2 {
+		t.Fatal("unexpected record:", rec, err)
+	}
+	q.Close()
+
+	// uncommitted records survive a restart
+	q, err = Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer q.Close()
+	for _, want := range []uint16{2, 3} {
+		rec, err := q.Next()
+		if err != nil || rec.EventType != want {
+			t.Fatal("unexpected record:", rec, err)
+		}
+		if text := rec.Tokens[0].(token.TextToken).Text; text != "hello" {
+			t.Error("unexpected text:", text)
+		}
+	}
+	if _, err := q.Next(); err != io.EOF {
+		t.Error("expected io.EOF, got", err)
+	}
+	q.Rewind()
+	if rec, err := q.Next(); err != nil || rec.EventType != 2 {
+		t.Error("unexpected record after rewind:", rec, err)
+	}
+	q.Next()
+
+	// committing everything truncates the data
+	if err := q.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(filepath.Join(dir, dataFile)); err != nil || info.Size() != 0 {
+		t.Error("data not truncated:", info.Size(), err)
+	}
+	if q.Pending() != 0 {
+		t.Error("unexpected pending bytes:", q.Pending())
+	}
+}
+
+func TestQueueTornFrame(t *testing.T) {
+	dir := t.TempDir()
+	q, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.WriteRecord(testRecord(1))
+	q.WriteRecord(testRecord(2))
+	size := q.Pending()
+	q.Close()
+
+	// simulate a crash while writing the second frame
+	name := filepath.Join(dir, dataFile)
+	if err := os.Truncate(name, size-5); err != nil {
+		t.Fatal(err)
+	}
+	q, err = Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer q.Close()
+	if rec, err := q.Next(); err != nil || rec.EventType != 1 {
+		t.Fatal("unexpected record:", rec, err)
+	}
+	if _, err := q.Next(); err != io.EOF {
+		t.Error("expected io.EOF, got", err)
+	}
+	if err := q.WriteRecord(testRecord(3)); err != nil {
+		t.Fatal(err)
+	}
+	if rec, err := q.Next(); err != nil || rec.EventType != 3 {
+		t.Error("unexpected record:", rec, err)
+	}
+}
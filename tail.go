@@ -0,0 +1,193 @@
+package bsm
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/tpltnt/go-bsm/clock"
+	"github.com/tpltnt/go-bsm/decode"
+)
+
+// PartialRecord is returned by a TailSource for a record which was not
+// completed within the timeout, e.g. because auditd stopped writing to
+// a .not_terminated trail. The record returned along with it holds the
+// header and the tokens decoded so far.
+type PartialRecord struct {
+	Offset int64  // offset of the record in the file
+	Data   []byte // bytes of the record read so far
+}
+
+func (p *PartialRecord) Error() string {
+	return fmt.Sprintf("partial record at offset %d (%d bytes)", p.Offset, len(p.Data))
+}
+
+// TailSource is a Source following a trail file which is still being
+// written. Incomplete records at the end of the file are buffered and
+// decoded as soon as the file grows.
+type TailSource struct {
+	// PollInterval is the time to wait for the file to grow. The
+	// default is 100ms.
+	PollInterval time.Duration
+
+	// Timeout is the time an incomplete record is waited for before
+	// it is returned as *PartialRecord. The default is 10s.
+	Timeout time.Duration
+
+	// Dialect is the operating system which writes the trail (see
+	// Decoder).
+	Dialect Dialect
+
+	// Clock is used to wait and to tell the time, clock.Real if nil.
+	Clock clock.Clock
+
+	// Interner (if not nil) deduplicates the strings of the records
+	// (see Decoder).
+	Interner *Interner
+
+	file    *os.File
+	pending []byte    // bytes read but not yet decoded
+	offset  int64     // file offset of pending[0]
+	since   time.Time // time the pending bytes were found incomplete
+	done    chan struct{}
+	closed  sync.Once
+
+	mutex  sync.Mutex
+	health Health
+}
+
+// NewTailSource returns a source following the given file from its
+// current offset.
+func NewTailSource(file *os.File) *TailSource {
+	offset, _ := file.Seek(0, io.SeekCurrent)
+	return &TailSource{
+		file:   file,
+		offset: offset,
+		done:   make(chan struct{}),
+		health: Health{Lag: -1},
+	}
+}
+
+// Next returns the next record of the file, waiting for it to be
+// written if necessary. It returns io.EOF once the source is closed.
+func (src *TailSource) Next() (BsmRecord, error) {
+	rec, err := src.next()
+
+	src.mutex.Lock()
+	defer src.mutex.Unlock()
+	if err != nil && err != io.EOF {
+		src.health.Errors += 1
+		src.health.LastError = err.Error()
+		src.health.Failing = true
+	}
+	if err == nil {
+		src.health.Failing = false
+		src.health.Records += 1
+		src.health.LastRecord = rec.Time()
+		src.health.LastRead = clock.Or(src.Clock).Now()
+	}
+	if info, statErr := src.file.Stat(); statErr == nil {
+		src.health.Lag = info.Size() - src.offset
+	}
+	return rec, err
+}
+
+func (src *TailSource) next() (BsmRecord, error) {
+	buf := make([]byte, 4096)
+	for {
+		if len(src.pending) > 0 {
+			decoder := decode.NewDecoder(bytes.NewReader(src.pending))
+			decoder.Dialect = src.Dialect
+			decoder.Interner = src.Interner
+			rec, err := decoder.Decode()
+			if err == nil {
+				src.consume(int(decoder.Stats().BytesRead))
+				return rec, nil
+			}
+			switch {
+			case err == io.EOF:
+				// only file tokens or padding, no record started yet
+				src.consume(int(decoder.Stats().BytesRead))
+			case !errors.Is(err, io.ErrUnexpectedEOF):
+				src.consume(len(src.pending))
+				return rec, err
+			default:
+				now := clock.Or(src.Clock).Now()
+				if src.since.IsZero() {
+					src.since = now
+				}
+				if now.Sub(src.since) >= src.timeout() {
+					partial := &PartialRecord{
+						Offset: src.offset,
+						Data:   append([]byte(nil), src.pending...),
+					}
+					src.consume(len(src.pending))
+					return rec, partial
+				}
+			}
+		}
+
+		n, err := src.file.Read(buf)
+		if n > 0 {
+			src.pending = append(src.pending, buf[:n]...)
+			continue
+		}
+		if err != nil && err != io.EOF {
+			return BsmRecord{}, err
+		}
+		select {
+		case <-src.done:
+			return BsmRecord{}, io.EOF
+		case <-clock.Or(src.Clock).After(src.pollInterval()):
+		}
+	}
+}
+
+// consume drops n pending bytes.
+func (src *TailSource) consume(n int) {
+	src.mutex.Lock()
+	defer src.mutex.Unlock()
+	src.pending = src.pending[n:]
+	src.offset += int64(n)
+	src.since = time.Time{}
+}
+
+func (src *TailSource) pollInterval() time.Duration {
+	if src.PollInterval > 0 {
+		return src.PollInterval
+	}
+	return 100 * time.Millisecond
+}
+
+func (src *TailSource) timeout() time.Duration {
+	if src.Timeout > 0 {
+		return src.Timeout
+	}
+	return 10 * time.Second
+}
+
+// Offset returns the file offset of the next record, e.g. to resume
+// following the file later on.
+func (src *TailSource) Offset() int64 {
+	src.mutex.Lock()
+	defer src.mutex.Unlock()
+	return src.offset
+}
+
+// Close stops following the file, a pending or later call to Next
+// returns io.EOF. The file itself is not closed.
+func (src *TailSource) Close() error {
+	src.closed.Do(func() { close(src.done) })
+	return nil
+}
+
+// Health reports the current state of the source.
+func (src *TailSource) Health() Health {
+	src.mutex.Lock()
+	defer src.mutex.Unlock()
+	return src.health
+}
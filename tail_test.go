@@ -0,0 +1,149 @@
+package bsm
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tpltnt/go-bsm/clock"
+)
+
+func TestTailSource(t *testing.T) {
+	data, err := os.ReadFile("start_stop.bsm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	name := filepath.Join(t.TempDir(), "trail.not_terminated")
+	if err := os.WriteFile(name, data[:70], 0600); err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	src := NewTailSource(file)
+	src.PollInterval = time.Millisecond
+	src.Timeout = time.Second
+	if rec, err := src.Next(); err != nil || rec.EventType != 45000 {
+		t.Fatal("unexpected first record:", rec, err)
+	}
+
+	// complete the second record while waiting for it
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		out, err := os.OpenFile(name, os.O_APPEND|os.O_WRONLY, 0)
+		if err != nil {
+			return
+		}
+		out.Write(data[70:])
+		out.Close()
+	}()
+	if rec, err := src.Next(); err != nil || rec.EventType != 45001 {
+		t.Fatal("unexpected second record:", rec, err)
+	}
+	if health := src.Health(); health.Records != 2 || health.Lag != 0 {
+		t.Error("unexpected health:", health)
+	}
+}
+
+func TestTailSourcePartialRecord(t *testing.T) {
+	data, err := os.ReadFile("start_stop.bsm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	name := filepath.Join(t.TempDir(), "trail.not_terminated")
+	if err := os.WriteFile(name, data[:30], 0600); err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	src := NewTailSource(file)
+	src.PollInterval = time.Millisecond
+	src.Timeout = 20 * time.Millisecond
+	rec, err := src.Next()
+	var partial *PartialRecord
+	if !errors.As(err, &partial) {
+		t.Fatal("expected a partial record, got", err)
+	}
+	if partial.Offset != 0 || len(partial.Data) != 30 || rec.EventType != 45000 {
+		t.Error("unexpected partial record:", partial, rec)
+	}
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		src.Close()
+	}()
+	if _, err := src.Next(); err != io.EOF {
+		t.Error("expected io.EOF after Close, got", err)
+	}
+}
+
+func TestTailSourceFileToken(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "trail.not_terminated")
+	file := []byte{0x11, 0, 0, 0, 1, 0, 0, 0, 0, 0, 2, 'a', 0} // file token only
+	if err := os.WriteFile(name, file, 0600); err != nil {
+		t.Fatal(err)
+	}
+	in, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer in.Close()
+
+	src := NewTailSource(in)
+	src.PollInterval = time.Millisecond
+	src.Timeout = 5 * time.Millisecond
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		src.Close()
+	}()
+	if rec, err := src.Next(); err != io.EOF {
+		t.Error("expected io.EOF after Close, got", rec, err)
+	}
+	if offset := src.Offset(); offset != int64(len(file)) {
+		t.Error("unexpected offset:", offset)
+	}
+}
+
+func TestTailSourceClock(t *testing.T) {
+	data, err := os.ReadFile("start_stop.bsm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	name := filepath.Join(t.TempDir(), "trail.not_terminated")
+	if err := os.WriteFile(name, data[:30], 0600); err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	fake := clock.NewFake(time.Now())
+	src := NewTailSource(file)
+	src.Clock = fake
+	src.Timeout = time.Hour
+	go func() {
+		for fake.Waiters() == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		fake.Advance(time.Hour)
+	}()
+	var partial *PartialRecord
+	if _, err := src.Next(); !errors.As(err, &partial) {
+		t.Fatal("expected a partial record, got", err)
+	}
+	if len(partial.Data) != 30 {
+		t.Error("unexpected partial record:", partial)
+	}
+}
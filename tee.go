@@ -0,0 +1,105 @@
+package bsm
+
+// SlowConsumerPolicy determines what Tee does with a record for a
+// consumer whose buffer is full.
+type SlowConsumerPolicy int
+
+const (
+	// TeeBlock waits for the consumer, i.e. the slowest consumer sets
+	// the pace of all others.
+	TeeBlock SlowConsumerPolicy = iota
+	// TeeDropNewest discards the record for this consumer.
+	TeeDropNewest
+	// TeeDropOldest discards the oldest buffered record of this consumer
+	// to make room for the new one.
+	TeeDropOldest
+	// TeeDisconnect closes the channel of the consumer, it doesn't
+	// receive any further records.
+	TeeDisconnect
+)
+
+// TeeConsumer configures a single output of TeeConsumers.
+type TeeConsumer struct {
+	Buffer int                 // number of records buffered for the consumer
+	Policy SlowConsumerPolicy  // what to do if the buffer is full
+	OnDrop func(ParsingResult) // called for every dropped record (may be nil)
+}
+
+// Tee fans the given stream out into n streams which all receive every
+// record. The consumers are unbuffered and block each other, see
+// TeeConsumers for other policies.
+func Tee(in chan ParsingResult, n int) []chan ParsingResult {
+	return TeeConsumers(in, make([]TeeConsumer, n)...)
+}
+
+// TeeConsumers fans the given stream out into one stream per consumer.
+// Records are shared between the streams, i.e. consumers must not
+// modify them. All streams are closed once the input is exhausted.
+func TeeConsumers(in chan ParsingResult, consumers ...TeeConsumer) []chan ParsingResult {
+	outs := make([]chan ParsingResult, len(consumers))
+	for i, c := range consumers {
+		outs[i] = make(chan ParsingResult, c.Buffer)
+	}
+
+	go func() {
+		connected := make([]bool, len(outs))
+		for i := range connected {
+			connected[i] = true
+		}
+		for res := range in {
+			for i, out := range outs {
+				if connected[i] {
+					connected[i] = deliver(out, res, consumers[i])
+				}
+			}
+		}
+		for i, out := range outs {
+			if connected[i] {
+				close(out)
+			}
+		}
+	}()
+
+	return outs
+}
+
+// deliver passes res to out according to the policy of the consumer.
+// It returns false if the consumer got disconnected.
+func deliver(out chan ParsingResult, res ParsingResult, c TeeConsumer) bool {
+	if c.Policy == TeeBlock {
+		out <- res
+		return true
+	}
+	select {
+	case out <- res:
+		return true
+	default:
+	}
+
+	switch c.Policy {
+	case TeeDropNewest:
+		c.dropped(res)
+	case TeeDropOldest:
+		select {
+		case old := <-out:
+			c.dropped(old)
+		default:
+		}
+		select {
+		case out <- res:
+		default: // the consumer is unbuffered
+			c.dropped(res)
+		}
+	case TeeDisconnect:
+		c.dropped(res)
+		close(out)
+		return false
+	}
+	return true
+}
+
+func (c TeeConsumer) dropped(res ParsingResult) {
+	if c.OnDrop != nil {
+		c.OnDrop(res)
+	}
+}
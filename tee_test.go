@@ -0,0 +1,89 @@
+package bsm
+
+import (
+	"sync"
+	"testing"
+)
+
+func teeInput(n int) chan ParsingResult {
+	in := make(chan ParsingResult)
+	go func() {
+		for i := 0; i < n; i++ {
+			in <- ParsingResult{Record: BsmRecord{EventType: uint16(i)}}
+		}
+		close(in)
+	}()
+	return in
+}
+
+func TestTee(t *testing.T) {
+	outs := Tee(teeInput(100), 3)
+	counts := make([]int, len(outs))
+	var wg sync.WaitGroup
+	for i, out := range outs {
+		wg.Add(1)
+		go func(i int, out chan ParsingResult) {
+			defer wg.Done()
+			for res := range out {
+				if int(res.Record.EventType) != counts[i] {
+					t.Error("unexpected order in consumer", i)
+				}
+				counts[i]++
+			}
+		}(i, out)
+	}
+	wg.Wait()
+	for i, count := range counts {
+		if count != 100 {
+			t.Errorf("consumer %d got %d records", i, count)
+		}
+	}
+}
+
+func TestTeeSlowConsumers(t *testing.T) {
+	var mutex sync.Mutex
+	dropped := map[string]int{}
+	onDrop := func(name string) func(ParsingResult) {
+		return func(ParsingResult) {
+			mutex.Lock()
+			dropped[name]++
+			mutex.Unlock()
+		}
+	}
+	outs := TeeConsumers(teeInput(10),
+		TeeConsumer{},
+		TeeConsumer{Buffer: 2, Policy: TeeDropNewest, OnDrop: onDrop("newest")},
+		TeeConsumer{Buffer: 2, Policy: TeeDropOldest, OnDrop: onDrop("oldest")},
+		TeeConsumer{Buffer: 2, Policy: TeeDisconnect, OnDrop: onDrop("disconnect")},
+	)
+
+	// only the blocking consumer reads until the input is exhausted
+	count := 0
+	for range outs[0] {
+		count++
+	}
+	if count != 10 {
+		t.Error("unexpected number of records:", count)
+	}
+
+	var got [][]uint16
+	for _, out := range outs[1:] {
+		var types []uint16
+		for res := range out {
+			types = append(types, res.Record.EventType)
+		}
+		got = append(got, types)
+	}
+	if len(got[0]) != 2 || got[0][0] != 0 || got[0][1] != 1 {
+		t.Error("unexpected records for TeeDropNewest:", got[0])
+	}
+	if len(got[1]) != 2 || got[1][0] != 8 || got[1][1] != 9 {
+		t.Error("unexpected records for TeeDropOldest:", got[1])
+	}
+	if len(got[2]) != 2 {
+		t.Error("unexpected records for TeeDisconnect:", got[2])
+	}
+	if dropped["newest"] != 8 || dropped["oldest"] != 8 || dropped["disconnect"] != 1 {
+		t.Error("unexpected drop counts:", dropped)
+	}
+}
@@ -0,0 +1,168 @@
+package token
+
+import (
+	"net"
+	"net/netip"
+)
+
+// terminalAddr converts a terminal machine address. IPv4-mapped IPv6
+// addresses are unmapped. The zero address (no terminal, e.g. for
+// daemons) yields the invalid netip.Addr{}.
+func terminalAddr(ip net.IP) netip.Addr {
+	addr, ok := netip.AddrFromSlice(ip)
+	if !ok {
+		return netip.Addr{}
+	}
+	addr = addr.Unmap()
+	if addr.IsUnspecified() {
+		return netip.Addr{}
+	}
+	return addr
+}
+
+// TerminalAddr returns the terminal machine address. It is invalid
+// (see netip.Addr.IsValid) if the address is unknown.
+func (t ProcessToken32bit) TerminalAddr() netip.Addr {
+	return terminalAddr(t.TerminalMachineAddress)
+}
+
+// TerminalAddr returns the terminal machine address. It is invalid
+// (see netip.Addr.IsValid) if the address is unknown.
+func (t ProcessToken64bit) TerminalAddr() netip.Addr {
+	return terminalAddr(t.TerminalMachineAddress)
+}
+
+// TerminalAddr returns the terminal machine address. It is invalid
+// (see netip.Addr.IsValid) if the address is unknown.
+func (t ExpandedProcessToken32bit) TerminalAddr() netip.Addr {
+	return terminalAddr(t.TerminalMachineAddress)
+}
+
+// TerminalAddr returns the terminal machine address. It is invalid
+// (see netip.Addr.IsValid) if the address is unknown.
+func (t ExpandedProcessToken64bit) TerminalAddr() netip.Addr {
+	return terminalAddr(t.TerminalMachineAddress)
+}
+
+// TerminalAddr returns the terminal machine address. It is invalid
+// (see netip.Addr.IsValid) if the address is unknown.
+func (t SubjectToken32bit) TerminalAddr() netip.Addr {
+	return terminalAddr(t.TerminalMachineAddress)
+}
+
+// TerminalAddr returns the terminal machine address. It is invalid
+// (see netip.Addr.IsValid) if the address is unknown.
+func (t SubjectToken64bit) TerminalAddr() netip.Addr {
+	return terminalAddr(t.TerminalMachineAddress)
+}
+
+// TerminalAddr returns the terminal machine address. It is invalid
+// (see netip.Addr.IsValid) if the address is unknown.
+func (t ExpandedSubjectToken32bit) TerminalAddr() netip.Addr {
+	return terminalAddr(t.TerminalMachineAddress)
+}
+
+// TerminalAddr returns the terminal machine address. It is invalid
+// (see netip.Addr.IsValid) if the address is unknown.
+func (t ExpandedSubjectToken64bit) TerminalAddr() netip.Addr {
+	return terminalAddr(t.TerminalMachineAddress)
+}
+
+// ipAddr converts an address field. IPv4-mapped IPv6 addresses are
+// unmapped, a missing address yields the invalid netip.Addr{}.
+func ipAddr(ip net.IP) netip.Addr {
+	addr, _ := netip.AddrFromSlice(ip)
+	return addr.Unmap()
+}
+
+// MachineAddr returns the address of the machine writing the record.
+func (t ExpandedHeaderToken32bit) MachineAddr() netip.Addr {
+	return ipAddr(t.MachineAddress)
+}
+
+// MachineAddr returns the address of the machine writing the record.
+func (t ExpandedHeaderToken64bit) MachineAddr() netip.Addr {
+	return ipAddr(t.MachineAddress)
+}
+
+// Addr returns the IP address.
+func (t InAddrToken) Addr() netip.Addr {
+	return ipAddr(t.IpAddress)
+}
+
+// Addr returns the IP address.
+func (t ExpandedInAddrToken) Addr() netip.Addr {
+	return ipAddr(t.IpAddress)
+}
+
+// SourceAddr returns the source address of the IP packet.
+func (t IpToken) SourceAddr() netip.Addr {
+	return ipAddr(t.SourceAddress)
+}
+
+// DestinationAddr returns the destination address of the IP packet.
+func (t IpToken) DestinationAddr() netip.Addr {
+	return ipAddr(t.DestinationAddress)
+}
+
+// LocalAddrPort returns the local address and port of the socket.
+func (t SocketToken) LocalAddrPort() netip.AddrPort {
+	return netip.AddrPortFrom(ipAddr(t.SocketAddress), t.LocalPort)
+}
+
+// LocalAddrPort returns the local address and port of the socket.
+func (t ExpandedSocketToken) LocalAddrPort() netip.AddrPort {
+	return netip.AddrPortFrom(ipAddr(t.LocalIpAddress), t.LocalPort)
+}
+
+// RemoteAddrPort returns the remote address and port of the socket.
+func (t ExpandedSocketToken) RemoteAddrPort() netip.AddrPort {
+	return netip.AddrPortFrom(ipAddr(t.RemoteIpAddress), t.RemotePort)
+}
+
+// socketAddr is the net.Addr of a socket token. The tokens don't tell
+// TCP from UDP reliably (the socket types depend on the dialect), so
+// the network is named after the address family like SocketDomainName.
+type socketAddr netip.AddrPort
+
+// Network returns "inet" or "inet6".
+func (a socketAddr) Network() string {
+	if netip.AddrPort(a).Addr().Is4() {
+		return "inet"
+	}
+	return "inet6"
+}
+
+// String returns the address and port, e.g. "192.0.2.1:80".
+func (a socketAddr) String() string {
+	return netip.AddrPort(a).String()
+}
+
+// netAddr converts an address and port, nil if the address is missing.
+func netAddr(ap netip.AddrPort) net.Addr {
+	if !ap.Addr().IsValid() {
+		return nil
+	}
+	return socketAddr(ap)
+}
+
+// LocalAddr returns the local address of the socket, nil if the token
+// holds no IP address (e.g. for Unix domain sockets).
+func (t SocketToken) LocalAddr() net.Addr {
+	if t.SocketFamily == SocketDomainLocal {
+		return nil
+	}
+	return netAddr(t.LocalAddrPort())
+}
+
+// LocalAddr returns the local address of the socket, nil if it is
+// missing.
+func (t ExpandedSocketToken) LocalAddr() net.Addr {
+	return netAddr(t.LocalAddrPort())
+}
+
+// RemoteAddr returns the remote address of the socket, nil if it is
+// missing.
+func (t ExpandedSocketToken) RemoteAddr() net.Addr {
+	return netAddr(t.RemoteAddrPort())
+}
@@ -0,0 +1,88 @@
+package token
+
+import (
+	"net"
+	"net/netip"
+	"testing"
+)
+
+func TestTerminalAddr(t *testing.T) {
+	tests := []struct {
+		ip   net.IP
+		want netip.Addr
+	}{
+		{net.IPv4(192, 0, 2, 1), netip.MustParseAddr("192.0.2.1")},
+		{net.IP{192, 0, 2, 1}, netip.MustParseAddr("192.0.2.1")},
+		{net.ParseIP("::ffff:192.0.2.1"), netip.MustParseAddr("192.0.2.1")},
+		{net.ParseIP("2001:db8::1"), netip.MustParseAddr("2001:db8::1")},
+		{net.IPv4(0, 0, 0, 0), netip.Addr{}},
+		{net.IPv6zero, netip.Addr{}},
+		{nil, netip.Addr{}},
+	}
+	for _, test := range tests {
+		if got := (ExpandedSubjectToken32bit{TerminalMachineAddress: test.ip}).TerminalAddr(); got != test.want {
+			t.Errorf("%v converted to %v, expected %v", test.ip, got, test.want)
+		}
+	}
+}
+
+func TestAddrAccessors(t *testing.T) {
+	socket := ExpandedSocketToken{
+		TokenID:         0x7f,
+		LocalPort:       22,
+		LocalIpAddress:  net.IPv4(0, 0, 0, 0),
+		RemotePort:      50123,
+		RemoteIpAddress: net.ParseIP("2001:db8::7"),
+	}
+	if ap := socket.LocalAddrPort(); ap != netip.MustParseAddrPort("0.0.0.0:22") {
+		t.Error("unexpected local address:", ap)
+	}
+	if ap := socket.RemoteAddrPort(); ap != netip.MustParseAddrPort("[2001:db8::7]:50123") {
+		t.Error("unexpected remote address:", ap)
+	}
+	if ap := (SocketToken{LocalPort: 80, SocketAddress: net.IP{192, 0, 2, 1}}).LocalAddrPort(); ap.String() != "192.0.2.1:80" {
+		t.Error("unexpected socket address:", ap)
+	}
+
+	if addr := socket.RemoteAddr(); addr == nil || addr.Network() != "inet6" || addr.String() != "[2001:db8::7]:50123" {
+		t.Error("unexpected remote address:", addr)
+	}
+	if addr := (ExpandedSocketToken{LocalPort: 22}).LocalAddr(); addr != nil {
+		t.Error("unexpected local address:", addr)
+	}
+	inet := SocketToken{TokenID: 0x80, SocketFamily: SocketDomainInet, LocalPort: 443, SocketAddress: net.IPv4(192, 0, 2, 1)}
+	if addr := inet.LocalAddr(); addr == nil || addr.Network() != "inet" || addr.String() != "192.0.2.1:443" {
+		t.Error("unexpected socket address:", addr)
+	}
+	if addr := (SocketToken{TokenID: 0x82, SocketFamily: SocketDomainLocal}).LocalAddr(); addr != nil {
+		t.Error("unexpected unix socket address:", addr)
+	}
+
+	ip := IpToken{SourceAddress: net.IPv4(10, 0, 0, 1), DestinationAddress: net.IPv4(10, 0, 0, 2)}
+	if ip.SourceAddr().String() != "10.0.0.1" || ip.DestinationAddr().String() != "10.0.0.2" {
+		t.Error("unexpected packet addresses")
+	}
+	if addr := (ExpandedHeaderToken64bit{MachineAddress: net.ParseIP("2001:db8::1")}).MachineAddr(); !addr.Is6() {
+		t.Error("unexpected machine address:", addr)
+	}
+	if addr := (InAddrToken{}).Addr(); addr.IsValid() {
+		t.Error("unexpected address:", addr)
+	}
+	if addr := (ExpandedInAddrToken{IpAddress: net.IPv4(192, 0, 2, 9)}).Addr(); !addr.Is4() {
+		t.Error("unexpected address:", addr)
+	}
+	if addr := (ExpandedHeaderToken32bit{MachineAddress: net.IPv4(192, 0, 2, 9)}).MachineAddr(); !addr.Is4() {
+		t.Error("unexpected machine address:", addr)
+	}
+}
+
+func TestSocketPortByteOrder(t *testing.T) {
+	// ports are written in network byte order: 0x01bb is 443
+	tok, err := Parse([]byte{0x80, 0x00, 0x02, 0x01, 0xbb, 192, 0, 2, 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ap := tok.(SocketToken).LocalAddrPort(); ap != netip.MustParseAddrPort("192.0.2.1:443") {
+		t.Error("unexpected socket address:", ap)
+	}
+}
@@ -0,0 +1,117 @@
+package token
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"time"
+)
+
+// file type and permission bits of mode_t
+const (
+	modeTypeMask = 0170000
+	modeFifo     = 0010000
+	modeChar     = 0020000
+	modeDir      = 0040000
+	modeBlock    = 0060000
+	modeRegular  = 0100000
+	modeSymlink  = 0120000
+	modeSocket   = 0140000
+	modeSetuid   = 04000
+	modeSetgid   = 02000
+	modeSticky   = 01000
+)
+
+// fileMode converts a mode_t value to an os.FileMode.
+func fileMode(mode uint32) os.FileMode {
+	fm := os.FileMode(mode & 0777)
+	switch mode & modeTypeMask {
+	case modeFifo:
+		fm |= os.ModeNamedPipe
+	case modeChar:
+		fm |= os.ModeDevice | os.ModeCharDevice
+	case modeDir:
+		fm |= os.ModeDir
+	case modeBlock:
+		fm |= os.ModeDevice
+	case modeSymlink:
+		fm |= os.ModeSymlink
+	case modeSocket:
+		fm |= os.ModeSocket
+	case modeRegular:
+	default:
+		fm |= os.ModeIrregular
+	}
+	if mode&modeSetuid != 0 {
+		fm |= os.ModeSetuid
+	}
+	if mode&modeSetgid != 0 {
+		fm |= os.ModeSetgid
+	}
+	if mode&modeSticky != 0 {
+		fm |= os.ModeSticky
+	}
+	return fm
+}
+
+// FileMode returns the file type and permissions of the file.
+func (t AttributeToken32bit) FileMode() os.FileMode {
+	return fileMode(t.FileAccessMode)
+}
+
+// FileMode returns the file type and permissions of the file.
+func (t AttributeToken64bit) FileMode() os.FileMode {
+	return fileMode(t.FileAccessMode)
+}
+
+// AttributeFileInfo exposes the attributes of a file as fs.FileInfo.
+// Size and modification time are not part of the audit record and
+// are always zero. Sys returns the attribute token.
+type AttributeFileInfo struct {
+	Path    string // path the file was accessed by (may be Token)
+	UserID  uint32 // owner
+	GroupID uint32 // group
+	Inode   uint64 // file system node ID
+	mode    os.FileMode
+	token   Token
+}
+
+// FileInfo returns the attributes of the file accessed by the given
+// path (e.g. from a path token of the same record).
+func (t AttributeToken32bit) FileInfo(path string) *AttributeFileInfo {
+	return &AttributeFileInfo{
+		Path:    path,
+		UserID:  t.OwnerUserID,
+		GroupID: t.OwnerGroupID,
+		Inode:   t.FileSystemNodeID,
+		mode:    t.FileMode(),
+		token:   t,
+	}
+}
+
+// FileInfo returns the attributes of the file accessed by the given
+// path (e.g. from a path token of the same record).
+func (t AttributeToken64bit) FileInfo(path string) *AttributeFileInfo {
+	return &AttributeFileInfo{
+		Path:    path,
+		UserID:  t.OwnerUserID,
+		GroupID: t.OwnerGroupID,
+		Inode:   t.FileSystemNodeID,
+		mode:    t.FileMode(),
+		token:   t,
+	}
+}
+
+// Name returns the base name of the path.
+func (fi *AttributeFileInfo) Name() string {
+	if fi.Path == "" {
+		return ""
+	}
+	return path.Base(fi.Path)
+}
+
+func (fi *AttributeFileInfo) Size() int64        { return 0 }
+func (fi *AttributeFileInfo) Mode() fs.FileMode  { return fi.mode }
+func (fi *AttributeFileInfo) ModTime() time.Time { return time.Time{} }
+func (fi *AttributeFileInfo) IsDir() bool        { return fi.mode.IsDir() }
+func (fi *AttributeFileInfo) Sys() interface{}   { return fi.token }
@@ -0,0 +1,28 @@
+package token
+
+import (
+	"os"
+	"testing"
+)
+
+func TestFileMode(t *testing.T) {
+	tests := map[uint32]os.FileMode{
+		0100644: 0644,
+		0104755: os.ModeSetuid | 0755,
+		0041777: os.ModeDir | os.ModeSticky | 0777,
+		0020620: os.ModeDevice | os.ModeCharDevice | 0620,
+		0060660: os.ModeDevice | 0660,
+		0120777: os.ModeSymlink | 0777,
+		0140755: os.ModeSocket | 0755,
+		0010600: os.ModeNamedPipe | 0600,
+		0002755: os.ModeIrregular | os.ModeSetgid | 0755,
+	}
+	for mode, want := range tests {
+		if got := (AttributeToken32bit{FileAccessMode: mode}).FileMode(); got != want {
+			t.Errorf("%o converted to %s, expected %s", mode, got, want)
+		}
+		if got := (AttributeToken64bit{FileAccessMode: mode}).FileMode(); got != want {
+			t.Errorf("%o converted to %s, expected %s", mode, got, want)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package token
+
+import "fmt"
+
+// Device is a device number split into major and minor number.
+type Device struct {
+	Major uint32
+	Minor uint32
+}
+
+func (dev Device) String() string {
+	return fmt.Sprintf("%d,%d", dev.Major, dev.Minor)
+}
+
+// SplitDevice splits a device number as written by the dialect into
+// major and minor number. Bits is the width of the field in the token
+// (32 or 64). For DialectUnknown the number is split like on Darwin.
+func (d Dialect) SplitDevice(dev uint64, bits int) Device {
+	switch d {
+	case DialectFreeBSD:
+		if bits == 32 { // FreeBSD < 12
+			return Device{
+				Major: uint32(dev>>8) & 0xff,
+				Minor: uint32(dev) & 0xffff00ff,
+			}
+		}
+		return Device{
+			Major: uint32((dev>>32)&0xffffff00 | (dev>>8)&0xff),
+			Minor: uint32((dev>>24)&0xff00 | dev&0xffff00ff),
+		}
+	case DialectSolaris:
+		if bits == 32 { // dev32_t: 14 bit major, 18 bit minor
+			return Device{
+				Major: uint32(dev>>18) & 0x3fff,
+				Minor: uint32(dev) & 0x3ffff,
+			}
+		}
+		return Device{
+			Major: uint32(dev >> 32),
+			Minor: uint32(dev),
+		}
+	case DialectLinux:
+		return Device{
+			Major: uint32((dev>>8)&0xfff | (dev>>32)&^0xfff),
+			Minor: uint32(dev&0xff | (dev>>12)&^0xff),
+		}
+	}
+	// Darwin: 8 bit major, 24 bit minor (dev_t is 32 bit, also in
+	// 64 bit tokens)
+	return Device{
+		Major: uint32(dev>>24) & 0xff,
+		Minor: uint32(dev) & 0xffffff,
+	}
+}
+
+// DeviceNumbers returns the device of the file system holding the file.
+func (t AttributeToken32bit) DeviceNumbers(d Dialect) Device {
+	return d.SplitDevice(uint64(t.Device), 32)
+}
+
+// DeviceNumbers returns the device of the file system holding the file.
+func (t AttributeToken64bit) DeviceNumbers(d Dialect) Device {
+	return d.SplitDevice(t.Device, 64)
+}
+
+// TTYName returns the name of the terminal device (e.g. "/dev/ttys001")
+// with the given device number on the local host. This only works for
+// trails written by the local host. The boolean is false if no such
+// device was found or the platform is not supported.
+func TTYName(dev uint64) (string, bool) {
+	return ttyName(dev)
+}
@@ -0,0 +1,7 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris)
+
+package token
+
+func ttyName(dev uint64) (string, bool) {
+	return "", false
+}
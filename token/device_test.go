@@ -0,0 +1,33 @@
+package token
+
+import "testing"
+
+func TestSplitDevice(t *testing.T) {
+	tests := []struct {
+		dialect Dialect
+		dev     uint64
+		bits    int
+		want    Device
+	}{
+		{DialectDarwin, 0x10000003, 32, Device{16, 3}},
+		{DialectUnknown, 0x10000003, 64, Device{16, 3}},
+		{DialectFreeBSD, 0x5a07, 32, Device{0x5a, 0x07}},
+		// makedev(0x1234, 0x5678) on FreeBSD 12+
+		{DialectFreeBSD, 0x1200<<32 | 0x34<<8 | 0x5600<<24 | 0x78, 64, Device{0x1234, 0x5678}},
+		{DialectSolaris, 24<<18 | 5, 32, Device{24, 5}},
+		{DialectSolaris, 24<<32 | 5, 64, Device{24, 5}},
+		{DialectLinux, 0x8801, 64, Device{136, 1}},
+		// makedev(0x1234, 0x5678) with glibc
+		{DialectLinux, 0x1000<<32 | 0x234<<8 | 0x5600<<12 | 0x78, 64, Device{0x1234, 0x5678}},
+	}
+	for _, test := range tests {
+		if got := test.dialect.SplitDevice(test.dev, test.bits); got != test.want {
+			t.Errorf("%s: %#x split into %s, expected %s", test.dialect, test.dev, got, test.want)
+		}
+	}
+
+	attr := AttributeToken32bit{TokenID: 0x3e, Device: 0x10000003}
+	if dev := attr.DeviceNumbers(DialectDarwin); dev.String() != "16,3" {
+		t.Error("unexpected device:", dev)
+	}
+}
@@ -0,0 +1,29 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+
+package token
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// ttyPatterns lists the device files searched for terminals.
+var ttyPatterns = []string{"/dev/console", "/dev/tty*", "/dev/pts/*"}
+
+func ttyName(dev uint64) (string, bool) {
+	for _, pattern := range ttyPatterns {
+		names, _ := filepath.Glob(pattern)
+		for _, name := range names {
+			info, err := os.Stat(name)
+			if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+				continue
+			}
+			stat, ok := info.Sys().(*syscall.Stat_t)
+			if ok && uint64(stat.Rdev) == dev {
+				return name, true
+			}
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,27 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+
+package token
+
+import (
+	"os"
+	"syscall"
+	"testing"
+)
+
+func TestTTYName(t *testing.T) {
+	info, err := os.Stat("/dev/tty")
+	if err != nil {
+		t.Skip("no terminal device:", err)
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		t.Skip("device numbers not supported")
+	}
+	name, ok := TTYName(uint64(stat.Rdev))
+	if !ok || name != "/dev/tty" {
+		t.Error("unexpected terminal name:", name)
+	}
+	if _, ok := TTYName(0xffffffff); ok {
+		t.Error("unexpected terminal found")
+	}
+}
@@ -0,0 +1,49 @@
+package token
+
+// Dialect identifies the operating system which wrote an audit trail.
+// Some fields (e.g. device numbers) are encoded differently per OS.
+type Dialect int
+
+// Known dialects.
+const (
+	DialectUnknown Dialect = iota
+	DialectDarwin          // macOS (OpenBSM)
+	DialectFreeBSD         // FreeBSD (OpenBSM)
+	DialectSolaris         // Solaris / illumos
+	DialectLinux           // Linux (e.g. BSM written by auditd plugins)
+)
+
+func (d Dialect) String() string {
+	switch d {
+	case DialectDarwin:
+		return "darwin"
+	case DialectFreeBSD:
+		return "freebsd"
+	case DialectSolaris:
+		return "solaris"
+	case DialectLinux:
+		return "linux"
+	}
+	return "unknown"
+}
+
+// addressLength maps the address type field of expanded tokens to the
+// length of the address. OpenBSM stores the length (4 or 16), other
+// producers use the AU_IPv4/AU_IPv6 enum values (1 or 2). The latter
+// are only accepted if the dialect is not known to be OpenBSM.
+func (d Dialect) addressLength(value uint32) (int, bool) {
+	switch value {
+	case 4, 16:
+		return int(value), true
+	}
+	if d != DialectUnknown && d != DialectLinux {
+		return 0, false
+	}
+	switch value {
+	case 1: // AU_IPv4
+		return 4, true
+	case 2: // AU_IPv6
+		return 16, true
+	}
+	return 0, false
+}
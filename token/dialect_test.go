@@ -0,0 +1,15 @@
+package token
+
+import "testing"
+
+func TestDialectAddressLength(t *testing.T) {
+	if n, ok := DialectUnknown.addressLength(2); !ok || n != 16 {
+		t.Error("unexpected address length for AU_IPv6:", n, ok)
+	}
+	if _, ok := DialectFreeBSD.addressLength(1); ok {
+		t.Error("unexpected address length for AU_IPv4 on FreeBSD")
+	}
+	if _, ok := DialectUnknown.addressLength(3); ok {
+		t.Error("unexpected address length for type 3")
+	}
+}
@@ -0,0 +1,294 @@
+package token
+
+import (
+	"fmt"
+	"sort"
+)
+
+// EventType is the event type of a record (see the header tokens and
+// audit_event(5)).
+type EventType uint16
+
+// Event types of system calls and user-level events. The numbers are
+// shared by OpenBSM (macOS, FreeBSD) and Solaris.
+const (
+	AUE_EXIT           EventType = 1     // exit(2)
+	AUE_FORK           EventType = 2     // fork(2)
+	AUE_OPEN           EventType = 3     // open(2)
+	AUE_CREAT          EventType = 4     // creat(2)
+	AUE_LINK           EventType = 5     // link(2)
+	AUE_UNLINK         EventType = 6     // unlink(2)
+	AUE_EXEC           EventType = 7     // exec(2)
+	AUE_CHDIR          EventType = 8     // chdir(2)
+	AUE_MKNOD          EventType = 9     // mknod(2)
+	AUE_CHMOD          EventType = 10    // chmod(2)
+	AUE_CHOWN          EventType = 11    // chown(2)
+	AUE_UMOUNT         EventType = 12    // umount(2) - old version
+	AUE_ACCESS         EventType = 14    // access(2)
+	AUE_KILL           EventType = 15    // kill(2)
+	AUE_STAT           EventType = 16    // stat(2)
+	AUE_LSTAT          EventType = 17    // lstat(2)
+	AUE_ACCT           EventType = 18    // acct(2)
+	AUE_REBOOT         EventType = 20    // reboot(2)
+	AUE_SYMLINK        EventType = 21    // symlink(2)
+	AUE_READLINK       EventType = 22    // readlink(2)
+	AUE_EXECVE         EventType = 23    // execve(2)
+	AUE_CHROOT         EventType = 24    // chroot(2)
+	AUE_VFORK          EventType = 25    // vfork(2)
+	AUE_SETGROUPS      EventType = 26    // setgroups(2)
+	AUE_SETPGRP        EventType = 27    // setpgrp(2)
+	AUE_SWAPON         EventType = 28    // swapon(2)
+	AUE_SETHOSTNAME    EventType = 29    // sethostname(2)
+	AUE_FCNTL          EventType = 30    // fcntl(2)
+	AUE_SETPRIORITY    EventType = 31    // setpriority(2)
+	AUE_CONNECT        EventType = 32    // connect(2)
+	AUE_ACCEPT         EventType = 33    // accept(2)
+	AUE_BIND           EventType = 34    // bind(2)
+	AUE_SETSOCKOPT     EventType = 35    // setsockopt(2)
+	AUE_SETTIMEOFDAY   EventType = 37    // settimeofday(2)
+	AUE_FCHOWN         EventType = 38    // fchown(2)
+	AUE_FCHMOD         EventType = 39    // fchmod(2)
+	AUE_SETREUID       EventType = 40    // setreuid(2)
+	AUE_SETREGID       EventType = 41    // setregid(2)
+	AUE_RENAME         EventType = 42    // rename(2)
+	AUE_TRUNCATE       EventType = 43    // truncate(2)
+	AUE_FTRUNCATE      EventType = 44    // ftruncate(2)
+	AUE_FLOCK          EventType = 45    // flock(2)
+	AUE_SHUTDOWN       EventType = 46    // shutdown(2)
+	AUE_MKDIR          EventType = 47    // mkdir(2)
+	AUE_RMDIR          EventType = 48    // rmdir(2)
+	AUE_UTIMES         EventType = 49    // utimes(2)
+	AUE_ADJTIME        EventType = 50    // adjtime(2)
+	AUE_SETRLIMIT      EventType = 51    // setrlimit(2)
+	AUE_KILLPG         EventType = 52    // killpg(2)
+	AUE_STATFS         EventType = 54    // statfs(2)
+	AUE_FSTATFS        EventType = 55    // fstatfs(2)
+	AUE_UNMOUNT        EventType = 56    // unmount(2)
+	AUE_QUOTACTL       EventType = 60    // quotactl(2)
+	AUE_MOUNT          EventType = 62    // mount(2)
+	AUE_FCHDIR         EventType = 68    // fchdir(2)
+	AUE_FCHROOT        EventType = 69    // fchroot(2)
+	AUE_PATHCONF       EventType = 71    // pathconf(2)
+	AUE_OPEN_R         EventType = 72    // open(2) - read
+	AUE_OPEN_RC        EventType = 73    // open(2) - read,creat
+	AUE_OPEN_RT        EventType = 74    // open(2) - read,trunc
+	AUE_OPEN_RTC       EventType = 75    // open(2) - read,creat,trunc
+	AUE_OPEN_W         EventType = 76    // open(2) - write
+	AUE_OPEN_WC        EventType = 77    // open(2) - write,creat
+	AUE_OPEN_WT        EventType = 78    // open(2) - write,trunc
+	AUE_OPEN_WTC       EventType = 79    // open(2) - write,creat,trunc
+	AUE_OPEN_RW        EventType = 80    // open(2) - read,write
+	AUE_OPEN_RWC       EventType = 81    // open(2) - read,write,creat
+	AUE_OPEN_RWT       EventType = 82    // open(2) - read,write,trunc
+	AUE_OPEN_RWTC      EventType = 83    // open(2) - read,write,creat,trunc
+	AUE_SETUID         EventType = 200   // setuid(2)
+	AUE_SETGID         EventType = 205   // setgid(2)
+	AUE_SETEGID        EventType = 214   // setegid(2)
+	AUE_SETEUID        EventType = 215   // seteuid(2)
+	AUE_login          EventType = 6152  // login - local
+	AUE_logout         EventType = 6153  // logout
+	AUE_ssh            EventType = 6172  // login - ssh
+	AUE_openssh        EventType = 32800 // OpenSSH login
+	AUE_audit_startup  EventType = 45000 // audit startup
+	AUE_audit_shutdown EventType = 45001 // audit shutdown
+)
+
+// Event types of the *at system calls, only written by OpenBSM (macOS
+// and FreeBSD). macOS audits renameatx_np(2) as AUE_RENAMEAT.
+const (
+	AUE_LINKAT   EventType = 43154 // linkat(2)
+	AUE_RENAMEAT EventType = 43159 // renameat(2), renameatx_np(2)
+)
+
+// Event types only written by macOS.
+const (
+	AUE_SESSION_START  EventType = 44901 // session start
+	AUE_SESSION_UPDATE EventType = 44902 // session update
+	AUE_SESSION_END    EventType = 44903 // session end
+	AUE_SESSION_CLOSE  EventType = 44904 // session close
+
+	AUE_modify_password   EventType = 45014 // modify password
+	AUE_create_group      EventType = 45015 // create group
+	AUE_delete_group      EventType = 45016 // delete group
+	AUE_modify_group      EventType = 45017 // modify group
+	AUE_add_to_group      EventType = 45018 // add to group
+	AUE_remove_from_group EventType = 45019 // remove from group
+	AUE_revoke_obj        EventType = 45020 // revoke object privilege
+	AUE_lw_login          EventType = 45021 // loginwindow login
+	AUE_lw_logout         EventType = 45022 // loginwindow logout
+	AUE_auth_user         EventType = 45023 // user authentication
+	AUE_ssconn            EventType = 45024 // SecurityServer connection setup
+	AUE_ssauthorize       EventType = 45025 // SecurityServer authorization engine
+	AUE_ssauthint         EventType = 45026 // SecurityServer internal mechanism
+	AUE_calife            EventType = 45027 // calife
+	AUE_sudo              EventType = 45028 // sudo(1)
+	AUE_audit_recovery    EventType = 45029 // audit crash recovery
+	AUE_ssauthmech        EventType = 45030 // SecurityServer authorization mechanism
+)
+
+// eventNames names the event types shared by all dialects.
+var eventNames = map[EventType]string{
+	AUE_EXIT:           "AUE_EXIT",
+	AUE_FORK:           "AUE_FORK",
+	AUE_OPEN:           "AUE_OPEN",
+	AUE_CREAT:          "AUE_CREAT",
+	AUE_LINK:           "AUE_LINK",
+	AUE_UNLINK:         "AUE_UNLINK",
+	AUE_EXEC:           "AUE_EXEC",
+	AUE_CHDIR:          "AUE_CHDIR",
+	AUE_MKNOD:          "AUE_MKNOD",
+	AUE_CHMOD:          "AUE_CHMOD",
+	AUE_CHOWN:          "AUE_CHOWN",
+	AUE_UMOUNT:         "AUE_UMOUNT",
+	AUE_ACCESS:         "AUE_ACCESS",
+	AUE_KILL:           "AUE_KILL",
+	AUE_STAT:           "AUE_STAT",
+	AUE_LSTAT:          "AUE_LSTAT",
+	AUE_ACCT:           "AUE_ACCT",
+	AUE_REBOOT:         "AUE_REBOOT",
+	AUE_SYMLINK:        "AUE_SYMLINK",
+	AUE_READLINK:       "AUE_READLINK",
+	AUE_EXECVE:         "AUE_EXECVE",
+	AUE_CHROOT:         "AUE_CHROOT",
+	AUE_VFORK:          "AUE_VFORK",
+	AUE_SETGROUPS:      "AUE_SETGROUPS",
+	AUE_SETPGRP:        "AUE_SETPGRP",
+	AUE_SWAPON:         "AUE_SWAPON",
+	AUE_SETHOSTNAME:    "AUE_SETHOSTNAME",
+	AUE_FCNTL:          "AUE_FCNTL",
+	AUE_SETPRIORITY:    "AUE_SETPRIORITY",
+	AUE_CONNECT:        "AUE_CONNECT",
+	AUE_ACCEPT:         "AUE_ACCEPT",
+	AUE_BIND:           "AUE_BIND",
+	AUE_SETSOCKOPT:     "AUE_SETSOCKOPT",
+	AUE_SETTIMEOFDAY:   "AUE_SETTIMEOFDAY",
+	AUE_FCHOWN:         "AUE_FCHOWN",
+	AUE_FCHMOD:         "AUE_FCHMOD",
+	AUE_SETREUID:       "AUE_SETREUID",
+	AUE_SETREGID:       "AUE_SETREGID",
+	AUE_RENAME:         "AUE_RENAME",
+	AUE_TRUNCATE:       "AUE_TRUNCATE",
+	AUE_FTRUNCATE:      "AUE_FTRUNCATE",
+	AUE_FLOCK:          "AUE_FLOCK",
+	AUE_SHUTDOWN:       "AUE_SHUTDOWN",
+	AUE_MKDIR:          "AUE_MKDIR",
+	AUE_RMDIR:          "AUE_RMDIR",
+	AUE_UTIMES:         "AUE_UTIMES",
+	AUE_ADJTIME:        "AUE_ADJTIME",
+	AUE_SETRLIMIT:      "AUE_SETRLIMIT",
+	AUE_KILLPG:         "AUE_KILLPG",
+	AUE_STATFS:         "AUE_STATFS",
+	AUE_FSTATFS:        "AUE_FSTATFS",
+	AUE_UNMOUNT:        "AUE_UNMOUNT",
+	AUE_QUOTACTL:       "AUE_QUOTACTL",
+	AUE_MOUNT:          "AUE_MOUNT",
+	AUE_FCHDIR:         "AUE_FCHDIR",
+	AUE_FCHROOT:        "AUE_FCHROOT",
+	AUE_PATHCONF:       "AUE_PATHCONF",
+	AUE_OPEN_R:         "AUE_OPEN_R",
+	AUE_OPEN_RC:        "AUE_OPEN_RC",
+	AUE_OPEN_RT:        "AUE_OPEN_RT",
+	AUE_OPEN_RTC:       "AUE_OPEN_RTC",
+	AUE_OPEN_W:         "AUE_OPEN_W",
+	AUE_OPEN_WC:        "AUE_OPEN_WC",
+	AUE_OPEN_WT:        "AUE_OPEN_WT",
+	AUE_OPEN_WTC:       "AUE_OPEN_WTC",
+	AUE_OPEN_RW:        "AUE_OPEN_RW",
+	AUE_OPEN_RWC:       "AUE_OPEN_RWC",
+	AUE_OPEN_RWT:       "AUE_OPEN_RWT",
+	AUE_OPEN_RWTC:      "AUE_OPEN_RWTC",
+	AUE_SETUID:         "AUE_SETUID",
+	AUE_SETGID:         "AUE_SETGID",
+	AUE_SETEGID:        "AUE_SETEGID",
+	AUE_SETEUID:        "AUE_SETEUID",
+	AUE_login:          "AUE_login",
+	AUE_logout:         "AUE_logout",
+	AUE_ssh:            "AUE_ssh",
+	AUE_openssh:        "AUE_openssh",
+	AUE_audit_startup:  "AUE_audit_startup",
+	AUE_audit_shutdown: "AUE_audit_shutdown",
+}
+
+// dialectEventNames names the event types specific to a dialect.
+var dialectEventNames = map[Dialect]map[EventType]string{
+	DialectDarwin: {
+		AUE_LINKAT:   "AUE_LINKAT",
+		AUE_RENAMEAT: "AUE_RENAMEAT",
+
+		AUE_SESSION_START:  "AUE_SESSION_START",
+		AUE_SESSION_UPDATE: "AUE_SESSION_UPDATE",
+		AUE_SESSION_END:    "AUE_SESSION_END",
+		AUE_SESSION_CLOSE:  "AUE_SESSION_CLOSE",
+
+		AUE_modify_password:   "AUE_modify_password",
+		AUE_create_group:      "AUE_create_group",
+		AUE_delete_group:      "AUE_delete_group",
+		AUE_modify_group:      "AUE_modify_group",
+		AUE_add_to_group:      "AUE_add_to_group",
+		AUE_remove_from_group: "AUE_remove_from_group",
+		AUE_revoke_obj:        "AUE_revoke_obj",
+		AUE_lw_login:          "AUE_lw_login",
+		AUE_lw_logout:         "AUE_lw_logout",
+		AUE_auth_user:         "AUE_auth_user",
+		AUE_ssconn:            "AUE_ssconn",
+		AUE_ssauthorize:       "AUE_ssauthorize",
+		AUE_ssauthint:         "AUE_ssauthint",
+		AUE_calife:            "AUE_calife",
+		AUE_sudo:              "AUE_sudo",
+		AUE_audit_recovery:    "AUE_audit_recovery",
+		AUE_ssauthmech:        "AUE_ssauthmech",
+	},
+	DialectFreeBSD: {
+		AUE_LINKAT:   "AUE_LINKAT",
+		AUE_RENAMEAT: "AUE_RENAMEAT",
+	},
+}
+
+// Name returns the name of the event type as written by the given
+// dialect (e.g. "AUE_EXECVE"), or an empty string if it is unknown.
+// DialectUnknown accepts the names of all dialects.
+func (e EventType) Name(d Dialect) string {
+	if name, ok := eventNames[e]; ok {
+		return name
+	}
+	if d != DialectUnknown {
+		return dialectEventNames[d][e]
+	}
+	for _, names := range dialectEventNames {
+		if name, ok := names[e]; ok {
+			return name
+		}
+	}
+	return ""
+}
+
+// EventTypes returns the named event types of the given dialect (of all
+// dialects for DialectUnknown) in ascending order. Event types named by
+// several dialects are listed once.
+func EventTypes(d Dialect) []EventType {
+	var types []EventType
+	for e := range eventNames {
+		types = append(types, e)
+	}
+	seen := map[EventType]bool{}
+	for dialect, names := range dialectEventNames {
+		if d != DialectUnknown && d != dialect {
+			continue
+		}
+		for e := range names {
+			if !seen[e] {
+				seen[e] = true
+				types = append(types, e)
+			}
+		}
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i] < types[j] })
+	return types
+}
+
+func (e EventType) String() string {
+	if name := e.Name(DialectUnknown); name != "" {
+		return name
+	}
+	return fmt.Sprintf("EventType(%d)", uint16(e))
+}
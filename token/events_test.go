@@ -0,0 +1,72 @@
+package token
+
+import "testing"
+
+func TestEventType(t *testing.T) {
+	for e, want := range map[EventType]string{
+		AUE_EXECVE:        "AUE_EXECVE",
+		AUE_OPEN_RWTC:     "AUE_OPEN_RWTC",
+		AUE_openssh:       "AUE_openssh",
+		AUE_SESSION_START: "AUE_SESSION_START",
+		65000:             "EventType(65000)",
+	} {
+		if got := e.String(); got != want {
+			t.Errorf("%d: got %q, expected %q", uint16(e), got, want)
+		}
+	}
+	if AUE_EXECVE != 23 || AUE_CONNECT != 32 || AUE_SETUID != 200 {
+		t.Error("unexpected event numbers")
+	}
+	if name := AUE_SESSION_START.Name(DialectDarwin); name != "AUE_SESSION_START" {
+		t.Errorf("darwin: got %q", name)
+	}
+	if name := AUE_SESSION_START.Name(DialectSolaris); name != "" {
+		t.Errorf("solaris: got %q, expected no name", name)
+	}
+	if name := AUE_EXECVE.Name(DialectSolaris); name != "AUE_EXECVE" {
+		t.Errorf("solaris: got %q", name)
+	}
+}
+
+func TestEventTypes(t *testing.T) {
+	all := EventTypes(DialectUnknown)
+	for i := 1; i < len(all); i++ {
+		if all[i-1] >= all[i] {
+			t.Fatal("event types not sorted:", all)
+		}
+	}
+	solaris := EventTypes(DialectSolaris)
+	if len(solaris) != len(eventNames) || len(all) <= len(solaris) {
+		t.Errorf("got %d event types for solaris, %d in total", len(solaris), len(all))
+	}
+	if AUE_sudo.Name(DialectDarwin) != "AUE_sudo" {
+		t.Error("macOS event not named")
+	}
+}
+
+func TestEventTypesShared(t *testing.T) {
+	freebsd := dialectEventNames[DialectFreeBSD]
+	defer func() { dialectEventNames[DialectFreeBSD] = freebsd }()
+	dialectEventNames[DialectFreeBSD] = map[EventType]string{AUE_sudo: "AUE_sudo"}
+
+	var n int
+	for _, e := range EventTypes(DialectUnknown) {
+		if e == AUE_sudo {
+			n += 1
+		}
+	}
+	if n != 1 {
+		t.Errorf("AUE_sudo listed %d times", n)
+	}
+}
+
+func TestEventTypesAt(t *testing.T) {
+	for _, d := range []Dialect{DialectDarwin, DialectFreeBSD} {
+		if AUE_RENAMEAT.Name(d) != "AUE_RENAMEAT" || AUE_LINKAT.Name(d) != "AUE_LINKAT" {
+			t.Error("*at events not named for", d)
+		}
+	}
+	if AUE_RENAMEAT.Name(DialectSolaris) != "" {
+		t.Error("OpenBSM event named for solaris")
+	}
+}
@@ -0,0 +1,54 @@
+package token
+
+// ExitInfo is the decoded form of a wait(2) style process status.
+type ExitInfo struct {
+	Exited     bool // process terminated normally (WIFEXITED)
+	ExitCode   int  // exit code if Exited (WEXITSTATUS)
+	Signaled   bool // process was terminated by a signal (WIFSIGNALED)
+	Signal     int  // terminating signal if Signaled (WTERMSIG)
+	CoreDumped bool // a core dump was written (WCOREDUMP)
+}
+
+// ExitInfo splits the status of the exit token into exit code,
+// terminating signal and core dump flag.
+func (t ExitToken) ExitInfo() ExitInfo {
+	info := ExitInfo{}
+	signal := int(t.Status & 0x7f)
+	switch signal {
+	case 0:
+		info.Exited = true
+		info.ExitCode = int((t.Status >> 8) & 0xff)
+	case 0x7f: // stopped process, not terminated
+	default:
+		info.Signaled = true
+		info.Signal = signal
+		info.CoreDumped = t.Status&0x80 != 0
+	}
+	return info
+}
+
+// Exited reports whether the process terminated normally.
+func (t ExitToken) Exited() bool {
+	return t.ExitInfo().Exited
+}
+
+// ExitCode returns the exit code of a normally terminated process
+// and -1 otherwise.
+func (t ExitToken) ExitCode() int {
+	info := t.ExitInfo()
+	if !info.Exited {
+		return -1
+	}
+	return info.ExitCode
+}
+
+// Signal returns the signal which terminated the process or 0 if
+// it was not terminated by a signal.
+func (t ExitToken) Signal() int {
+	return t.ExitInfo().Signal
+}
+
+// CoreDumped reports whether the termination produced a core dump.
+func (t ExitToken) CoreDumped() bool {
+	return t.ExitInfo().CoreDumped
+}
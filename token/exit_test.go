@@ -0,0 +1,47 @@
+package token
+
+import "testing"
+
+func Test_parsing_ExitToken(t *testing.T) {
+	data := []byte{0x52, // token ID
+		0x00, 0x00, 0x01, 0x00, // status: exit(1)
+		0xff, 0xff, 0xff, 0xfe, // return value: -2
+	}
+	token, err := Parse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exit, ok := token.(ExitToken)
+	if !ok {
+		t.Fatal("expected ExitToken, got", token)
+	}
+	if exit.Status != 256 {
+		t.Error("unexpected status:", exit.Status)
+	}
+	if exit.ReturnValue != -2 {
+		t.Error("unexpected return value:", exit.ReturnValue)
+	}
+	if !exit.Exited() || exit.ExitCode() != 1 {
+		t.Error("unexpected exit code:", exit.ExitCode())
+	}
+}
+
+func TestExitInfo(t *testing.T) {
+	testData := map[uint32]ExitInfo{
+		0x0000: {Exited: true},
+		0x2a00: {Exited: true, ExitCode: 42},
+		0x0009: {Signaled: true, Signal: 9},                    // SIGKILL
+		0x008b: {Signaled: true, Signal: 11, CoreDumped: true}, // SIGSEGV + core
+		0x137f: {},                                             // stopped by SIGSTOP
+	}
+	for status, expected := range testData {
+		info := ExitToken{TokenID: 0x52, Status: status}.ExitInfo()
+		if info != expected {
+			t.Errorf("status 0x%x: expected %+v, got %+v", status, expected, info)
+		}
+	}
+	token := ExitToken{TokenID: 0x52, Status: 0x008b}
+	if token.ExitCode() != -1 || token.Signal() != 11 || !token.CoreDumped() || token.Exited() {
+		t.Error("unexpected accessor results")
+	}
+}
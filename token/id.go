@@ -0,0 +1,92 @@
+package token
+
+import "fmt"
+
+// UnknownTokenError is returned for a token ID this package does not
+// know, i.e. whose size (and content) can't be determined.
+type UnknownTokenError struct {
+	ID byte // the unknown token ID
+}
+
+func (e *UnknownTokenError) Error() string {
+	return fmt.Sprintf("can't determine the size of the given token (type): 0x%x", e.ID)
+}
+
+// The Type methods return the token ID of a token, e.g. to tell apart
+// the flavours of a SocketToken.
+
+func (t ArbitraryDataToken) Type() byte { return t.TokenID }
+
+func (t ArgToken32bit) Type() byte { return t.TokenID }
+
+func (t ArgToken64bit) Type() byte { return t.TokenID }
+
+func (t AttributeToken32bit) Type() byte { return t.TokenID }
+
+func (t AttributeToken64bit) Type() byte { return t.TokenID }
+
+func (t ExecArgsToken) Type() byte { return t.TokenID }
+
+func (t ExecEnvToken) Type() byte { return t.TokenID }
+
+func (t ExitToken) Type() byte { return t.TokenID }
+
+func (t ExpandedHeaderToken32bit) Type() byte { return t.TokenID }
+
+func (t ExpandedHeaderToken64bit) Type() byte { return t.TokenID }
+
+func (t ExpandedInAddrToken) Type() byte { return t.TokenID }
+
+func (t ExpandedProcessToken32bit) Type() byte { return t.TokenID }
+
+func (t ExpandedProcessToken64bit) Type() byte { return t.TokenID }
+
+func (t ExpandedSocketToken) Type() byte { return t.TokenID }
+
+func (t ExpandedSubjectToken32bit) Type() byte { return t.TokenID }
+
+func (t ExpandedSubjectToken64bit) Type() byte { return t.TokenID }
+
+func (t FileToken) Type() byte { return t.TokenID }
+
+func (t GroupsToken) Type() byte { return t.TokenID }
+
+func (t HeaderToken32bit) Type() byte { return t.TokenID }
+
+func (t HeaderToken64bit) Type() byte { return t.TokenID }
+
+func (t IPortToken) Type() byte { return t.TokenID }
+
+func (t InAddrToken) Type() byte { return t.TokenID }
+
+func (t IpToken) Type() byte { return t.TokenID }
+
+func (t PathAttrToken) Type() byte { return t.TokenID }
+
+func (t PathToken) Type() byte { return t.TokenID }
+
+func (t ProcessToken32bit) Type() byte { return t.TokenID }
+
+func (t ProcessToken64bit) Type() byte { return t.TokenID }
+
+func (t ReturnToken32bit) Type() byte { return t.TokenID }
+
+func (t ReturnToken64bit) Type() byte { return t.TokenID }
+
+func (t SeqToken) Type() byte { return t.TokenID }
+
+func (t SocketToken) Type() byte { return t.TokenID }
+
+func (t SubjectToken32bit) Type() byte { return t.TokenID }
+
+func (t SubjectToken64bit) Type() byte { return t.TokenID }
+
+func (t SystemVIpcPermissionToken) Type() byte { return t.TokenID }
+
+func (t SystemVIpcToken) Type() byte { return t.TokenID }
+
+func (t TextToken) Type() byte { return t.TokenID }
+
+func (t TrailerToken) Type() byte { return t.TokenID }
+
+func (t ZonenameToken) Type() byte { return t.TokenID }
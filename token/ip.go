@@ -0,0 +1,56 @@
+package token
+
+import "strconv"
+
+// IP header flags (see Flags).
+const (
+	IPFlagMoreFragments = 0x1 // MF
+	IPFlagDontFragment  = 0x2 // DF
+)
+
+// ipProtocols maps IP protocol numbers to their names (as in
+// /etc/protocols).
+var ipProtocols = map[uint8]string{
+	1:   "icmp",
+	2:   "igmp",
+	4:   "ipencap",
+	6:   "tcp",
+	17:  "udp",
+	41:  "ipv6",
+	47:  "gre",
+	50:  "esp",
+	51:  "ah",
+	58:  "ipv6-icmp",
+	89:  "ospf",
+	132: "sctp",
+}
+
+// Version returns the IP version.
+func (t IpToken) Version() uint8 {
+	return t.VersionAndIHL >> 4
+}
+
+// HeaderLength returns the length of the IP header in bytes.
+func (t IpToken) HeaderLength() int {
+	return int(t.VersionAndIHL&0x0f) * 4
+}
+
+// FragmentOffset returns the offset of the fragment in bytes.
+func (t IpToken) FragmentOffset() int {
+	return int(t.Offset&0x1fff) * 8
+}
+
+// Flags returns the 3 bit flags field of the IP header (see
+// IPFlagDontFragment and IPFlagMoreFragments).
+func (t IpToken) Flags() uint8 {
+	return uint8(t.Offset >> 13)
+}
+
+// ProtocolName returns the name of the transported protocol, or its
+// number if the protocol is not known.
+func (t IpToken) ProtocolName() string {
+	if name, ok := ipProtocols[t.Protocol]; ok {
+		return name
+	}
+	return strconv.Itoa(int(t.Protocol))
+}
@@ -0,0 +1,35 @@
+package token
+
+import "testing"
+
+func TestIpToken(t *testing.T) {
+	token := IpToken{
+		TokenID:       0x2b,
+		VersionAndIHL: 0x46,
+		Offset:        0x2000 | 185, // MF, 1480 bytes
+		Protocol:      17,
+	}
+	if token.Version() != 4 {
+		t.Error("unexpected version:", token.Version())
+	}
+	if token.HeaderLength() != 24 {
+		t.Error("unexpected header length:", token.HeaderLength())
+	}
+	if token.FragmentOffset() != 1480 {
+		t.Error("unexpected fragment offset:", token.FragmentOffset())
+	}
+	if token.Flags() != IPFlagMoreFragments {
+		t.Error("unexpected flags:", token.Flags())
+	}
+	if token.ProtocolName() != "udp" {
+		t.Error("unexpected protocol:", token.ProtocolName())
+	}
+
+	token = IpToken{TokenID: 0x2b, VersionAndIHL: 0x45, Offset: 0x4000, Protocol: 253}
+	if token.Flags()&IPFlagDontFragment == 0 || token.FragmentOffset() != 0 {
+		t.Error("DF flag not decoded")
+	}
+	if token.ProtocolName() != "253" {
+		t.Error("unexpected protocol:", token.ProtocolName())
+	}
+}
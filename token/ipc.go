@@ -0,0 +1,39 @@
+package token
+
+import (
+	"fmt"
+	"os"
+)
+
+// IpcObjectType is the type of a System V IPC object.
+type IpcObjectType uint8
+
+// System V IPC object types (AT_IPC_*).
+const (
+	IpcMessageQueue IpcObjectType = 1 // AT_IPC_MSG
+	IpcSemaphore    IpcObjectType = 2 // AT_IPC_SEM
+	IpcSharedMemory IpcObjectType = 3 // AT_IPC_SHM
+)
+
+func (t IpcObjectType) String() string {
+	switch t {
+	case IpcMessageQueue:
+		return "message queue"
+	case IpcSemaphore:
+		return "semaphore"
+	case IpcSharedMemory:
+		return "shared memory"
+	}
+	return fmt.Sprintf("unknown IPC object type %d", uint8(t))
+}
+
+// ObjectType returns the type of the IPC object.
+func (t SystemVIpcToken) ObjectType() IpcObjectType {
+	return IpcObjectType(t.ObjectIdType)
+}
+
+// Permissions returns the access mode as rwx triplets for owner,
+// group and others (e.g. "rw-r-----").
+func (t SystemVIpcPermissionToken) Permissions() string {
+	return os.FileMode(t.AccessMode & 0777).String()[1:]
+}
@@ -0,0 +1,33 @@
+package token
+
+import "testing"
+
+func TestIpcObjectType(t *testing.T) {
+	tests := map[uint8]string{
+		1: "message queue",
+		2: "semaphore",
+		3: "shared memory",
+		9: "unknown IPC object type 9",
+	}
+	for id, want := range tests {
+		token := SystemVIpcToken{TokenID: 0x22, ObjectIdType: id}
+		if got := token.ObjectType().String(); got != want {
+			t.Errorf("object type %d rendered as %q, expected %q", id, got, want)
+		}
+	}
+}
+
+func TestIpcPermissions(t *testing.T) {
+	tests := map[uint32]string{
+		0600:    "rw-------",
+		0640:    "rw-r-----",
+		0777:    "rwxrwxrwx",
+		0101644: "rw-r--r--", // IPC_CREAT and friends are ignored
+	}
+	for mode, want := range tests {
+		token := SystemVIpcPermissionToken{TokenID: 0x32, AccessMode: mode}
+		if got := token.Permissions(); got != want {
+			t.Errorf("mode %o rendered as %q, expected %q", mode, got, want)
+		}
+	}
+}
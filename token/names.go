@@ -0,0 +1,86 @@
+package token
+
+// Name returns the name of the token type as used in serialized
+// records (e.g. "subject32_ex"). It returns an empty string for values
+// which are not a token of this package.
+func Name(token interface{}) string {
+	switch token.(type) {
+	case ArgToken32bit:
+		return "arg32"
+	case ArgToken64bit:
+		return "arg64"
+	case ArbitraryDataToken:
+		return "arbitrary_data"
+	case AttributeToken32bit:
+		return "attribute32"
+	case AttributeToken64bit:
+		return "attribute64"
+	case ExecArgsToken:
+		return "exec_args"
+	case ExecEnvToken:
+		return "exec_env"
+	case ExitToken:
+		return "exit"
+	case FileToken:
+		return "file"
+	case GroupsToken:
+		return "groups"
+	case HeaderToken32bit:
+		return "header32"
+	case HeaderToken64bit:
+		return "header64"
+	case ExpandedHeaderToken32bit:
+		return "header32_ex"
+	case ExpandedHeaderToken64bit:
+		return "header64_ex"
+	case InAddrToken:
+		return "in_addr"
+	case ExpandedInAddrToken:
+		return "in_addr_ex"
+	case IpToken:
+		return "ip"
+	case IPortToken:
+		return "iport"
+	case PathToken:
+		return "path"
+	case PathAttrToken:
+		return "path_attr"
+	case ProcessToken32bit:
+		return "process32"
+	case ProcessToken64bit:
+		return "process64"
+	case ExpandedProcessToken32bit:
+		return "process32_ex"
+	case ExpandedProcessToken64bit:
+		return "process64_ex"
+	case ReturnToken32bit:
+		return "return32"
+	case ReturnToken64bit:
+		return "return64"
+	case SeqToken:
+		return "seq"
+	case SocketToken:
+		return "socket"
+	case ExpandedSocketToken:
+		return "socket_ex"
+	case SubjectToken32bit:
+		return "subject32"
+	case SubjectToken64bit:
+		return "subject64"
+	case ExpandedSubjectToken32bit:
+		return "subject32_ex"
+	case ExpandedSubjectToken64bit:
+		return "subject64_ex"
+	case SystemVIpcToken:
+		return "ipc"
+	case SystemVIpcPermissionToken:
+		return "ipc_perm"
+	case TextToken:
+		return "text"
+	case TrailerToken:
+		return "trailer"
+	case ZonenameToken:
+		return "zonename"
+	}
+	return ""
+}
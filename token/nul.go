@@ -0,0 +1,23 @@
+package token
+
+import "bytes"
+
+// NULPolicy selects how the NUL bytes terminating the strings of path,
+// text, zonename, arg, file and exec tokens are decoded.
+type NULPolicy int
+
+const (
+	// NULStrip removes all trailing NUL bytes (the default), so
+	// strings compare equal regardless of the padding of the producer.
+	NULStrip NULPolicy = iota
+	// NULKeep keeps the strings as stored, including NUL bytes.
+	NULKeep
+)
+
+// cstring returns the string stored in data (incl. its NUL bytes).
+func (nul NULPolicy) cstring(data []byte) string {
+	if nul == NULStrip {
+		data = bytes.TrimRight(data, "\x00")
+	}
+	return string(data)
+}
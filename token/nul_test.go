@@ -0,0 +1,44 @@
+package token
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNULPolicy(t *testing.T) {
+	tests := []struct {
+		data          []byte
+		stripped, raw Token
+	}{
+		{
+			[]byte{0x28, 0x00, 0x05, 'a', 'b', 'c', 0x00, 0x00}, // padded
+			TextToken{TokenID: 0x28, TextLength: 5, Text: "abc"},
+			TextToken{TokenID: 0x28, TextLength: 5, Text: "abc\x00\x00"},
+		},
+		{
+			[]byte{0x23, 0x00, 0x00}, // empty
+			PathToken{TokenID: 0x23},
+			PathToken{TokenID: 0x23},
+		},
+		{
+			[]byte{0x60, 0x00, 0x02, 'z', 'z'}, // not terminated
+			ZonenameToken{TokenID: 0x60, ZonenameLength: 2, Zonename: "zz"},
+			ZonenameToken{TokenID: 0x60, ZonenameLength: 2, Zonename: "zz"},
+		},
+		{
+			[]byte{0x3c, 0x00, 0x00, 0x00, 0x02, 'l', 's', 0x00, '-', 'l', 0x00},
+			ExecArgsToken{TokenID: 0x3c, Count: 2, Text: []string{"ls", "-l"}},
+			ExecArgsToken{TokenID: 0x3c, Count: 2, Text: []string{"ls\x00", "-l\x00"}},
+		},
+	}
+	for _, test := range tests {
+		tok, err := Parse(test.data)
+		if err != nil || !reflect.DeepEqual(tok, test.stripped) {
+			t.Errorf("% x: got %#v (%v), expected %#v", test.data, tok, err, test.stripped)
+		}
+		tok, err = ParseNUL(test.data, NULKeep)
+		if err != nil || !reflect.DeepEqual(tok, test.raw) {
+			t.Errorf("% x: got %#v (%v) keeping NULs, expected %#v", test.data, tok, err, test.raw)
+		}
+	}
+}
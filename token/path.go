@@ -0,0 +1,71 @@
+package token
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// PathLengthCountsNUL reports whether the length field of the path
+// tokens written by the dialect includes the NUL terminator, as it does
+// for OpenBSM and Solaris. Linux producers (e.g. auditd plugins) count
+// only the characters and may write the NUL after them.
+func (d Dialect) PathLengthCountsNUL() bool {
+	return d != DialectLinux
+}
+
+// PathNULFollows reports whether the complete token data written by
+// the dialect is a path token which may be followed by its NUL
+// terminator, uncounted by the length field (see PathLengthCountsNUL).
+// Readers of raw tokens consume such a NUL as part of the token.
+func (d Dialect) PathNULFollows(data []byte) bool {
+	return len(data) >= 3 && data[0] == 0x23 && !d.PathLengthCountsNUL() &&
+		(len(data) == 3 || data[len(data)-1] != 0)
+}
+
+// ParsePathToken parses the bytes of a path token written by the given
+// dialect. If the length field of the dialect doesn't count the NUL
+// terminator (see Dialect.PathLengthCountsNUL), a NUL following the
+// counted bytes is accepted as part of the token. The path is decoded
+// with NULStrip, embedded NUL bytes and bytes which aren't valid UTF-8
+// are kept (see PathToken.Bytes and PathToken.Sanitized).
+func ParsePathToken(data []byte, dialect Dialect) (PathToken, error) {
+	if len(data) < 3 || data[0] != 0x23 {
+		return PathToken{}, fmt.Errorf("no path token")
+	}
+	length, _ := bytesToUint16(data[1:3])
+	size := 3 + int(length)
+	if len(data) < size {
+		return PathToken{}, fmt.Errorf("path token of %d bytes holds only %d bytes", size, len(data))
+	}
+	if len(data) == size+1 && data[size] == 0 && dialect.PathNULFollows(data[:size]) {
+		size += 1
+	}
+	if len(data) != size {
+		return PathToken{}, fmt.Errorf("%d bytes after path token", len(data)-size)
+	}
+	return PathToken{
+		TokenID:    data[0],
+		PathLength: length,
+		Path:       NULStrip.cstring(data[3:size]),
+	}, nil
+}
+
+// Bytes returns the path as stored in the token (without the NUL
+// terminator, unless decoded with NULKeep), including any embedded NUL
+// bytes and bytes which aren't valid UTF-8.
+func (t PathToken) Bytes() []byte {
+	return []byte(t.Path)
+}
+
+// Sanitized returns the path as the kernel used it, i.e. up to the
+// first NUL byte, with bytes which aren't valid UTF-8 replaced by
+// U+FFFD, e.g. for display or string columns. Use Bytes to match the
+// exact path.
+func (t PathToken) Sanitized() string {
+	path := []byte(t.Path)
+	if i := bytes.IndexByte(path, 0); i >= 0 {
+		path = path[:i]
+	}
+	return strings.ToValidUTF8(string(path), "\uFFFD")
+}
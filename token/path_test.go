@@ -0,0 +1,55 @@
+package token
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestParsePathToken(t *testing.T) {
+	for _, test := range []struct {
+		data    []byte
+		dialect Dialect
+		path    string
+	}{
+		{[]byte{0x23, 0x00, 0x05, '/', 'e', 't', 'c', 0}, DialectFreeBSD, "/etc"},
+		{[]byte{0x23, 0x00, 0x04, '/', 'e', 't', 'c'}, DialectLinux, "/etc"},
+		{[]byte{0x23, 0x00, 0x04, '/', 'e', 't', 'c', 0}, DialectLinux, "/etc"}, // NUL not counted
+		{[]byte{0x23, 0x00, 0x05, '/', 'e', 't', 'c', 0}, DialectLinux, "/etc"},
+		{[]byte{0x23, 0x00, 0x00}, DialectUnknown, ""},
+	} {
+		tok, err := ParsePathToken(test.data, test.dialect)
+		if err != nil {
+			t.Errorf("%v: %v", test.data, err)
+			continue
+		}
+		if tok.Path != test.path {
+			t.Errorf("%v: got %q, expected %q", test.data, tok.Path, test.path)
+		}
+	}
+
+	for _, data := range [][]byte{
+		{0x28, 0x00, 0x01, 0},
+		{0x23, 0x00, 0x05, '/'},
+		{0x23, 0x00, 0x04, '/', 'e', 't', 'c', 0}, // uncounted NUL of other dialects
+	} {
+		if _, err := ParsePathToken(data, DialectFreeBSD); err == nil {
+			t.Errorf("%v: expected error", data)
+		}
+	}
+}
+
+func TestPathTokenForms(t *testing.T) {
+	tok, err := ParsePathToken([]byte{0x23, 0x00, 0x08, '/', 't', 0xff, 'p', 0, 'x', 'y', 0}, DialectUnknown)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if raw := tok.Bytes(); !bytes.Equal(raw, []byte{'/', 't', 0xff, 'p', 0, 'x', 'y'}) {
+		t.Errorf("unexpected raw path %q", raw)
+	}
+	if sanitized := tok.Sanitized(); sanitized != "/t\uFFFDp" {
+		t.Errorf("unexpected sanitized path %q", sanitized)
+	}
+	if (PathToken{Path: "/etc/hosts"}).Sanitized() != "/etc/hosts" {
+		t.Error("valid path changed")
+	}
+}
@@ -0,0 +1,68 @@
+package token
+
+import "fmt"
+
+// SizeTable overrides the sizes of tokens of fixed size by token ID,
+// e.g. for forks of BSM producers (found in some appliance firmware)
+// which write a few tokens with additional or fewer bytes. Tokens not in
+// the table are sized as usual. The zero value (nil) uses the standard
+// sizes only.
+type SizeTable map[byte]int
+
+// FixedSizes returns the standard sizes of all tokens of fixed size, to
+// start a SizeTable from.
+func FixedSizes() SizeTable {
+	sizes := SizeTable{}
+	for id := 0; id < 256; id++ {
+		size, moreBytes, err := Size([]byte{byte(id)}, DialectUnknown)
+		if err == nil && moreBytes == 0 && size > 0 {
+			sizes[byte(id)] = size
+		}
+	}
+	return sizes
+}
+
+// entry returns the size of the token with the given ID from the table.
+// Entries for tokens of variable size and sizes below one byte are
+// rejected.
+func (t SizeTable) entry(id byte) (size int, ok bool, err error) {
+	size, ok = t[id]
+	if !ok {
+		return 0, false, nil
+	}
+	_, moreBytes, serr := Size([]byte{id}, DialectUnknown)
+	if size < 1 || (serr == nil && moreBytes != 0) {
+		return 0, true, fmt.Errorf("invalid size table entry for token 0x%x: %d bytes", id, size)
+	}
+	return size, true, nil
+}
+
+// Size works like the package function Size, but takes the size of
+// tokens listed in the table from the table.
+func (t SizeTable) Size(input []byte, dialect Dialect) (size, moreBytes int, err error) {
+	if len(input) > 0 {
+		if size, ok, err := t.entry(input[0]); ok {
+			return size, 0, err
+		}
+	}
+	return Size(input, dialect)
+}
+
+// ParseNUL works like the package function ParseNUL for tokens sized by
+// the table: bytes beyond the standard size of a token are ignored and
+// missing ones are taken to be zero.
+func (t SizeTable) ParseNUL(data []byte, nul NULPolicy) (Token, error) {
+	if len(data) > 0 {
+		if _, ok, err := t.entry(data[0]); err != nil {
+			return nil, err
+		} else if ok {
+			standard, moreBytes, err := Size(data[:1], DialectUnknown)
+			if err == nil && moreBytes == 0 && standard != len(data) {
+				fixed := make([]byte, standard)
+				copy(fixed, data)
+				data = fixed
+			}
+		}
+	}
+	return ParseNUL(data, nul)
+}
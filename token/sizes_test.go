@@ -0,0 +1,49 @@
+package token
+
+import "testing"
+
+func TestSizeTable(t *testing.T) {
+	fixed := FixedSizes()
+	if fixed[0x27] != 6 || fixed[0x13] != 7 {
+		t.Errorf("unexpected fixed sizes: return %d, trailer %d", fixed[0x27], fixed[0x13])
+	}
+	if _, ok := fixed[0x23]; ok {
+		t.Error("path token has no fixed size")
+	}
+
+	sizes := SizeTable{0x27: 8, 0x52: 5}
+	if size, _, _ := sizes.Size([]byte{0x27}, DialectUnknown); size != 8 {
+		t.Errorf("overridden size: got %d, expected 8", size)
+	}
+	if size, _, _ := sizes.Size([]byte{0x13}, DialectUnknown); size != 7 {
+		t.Errorf("standard size: got %d, expected 7", size)
+	}
+
+	// return token with two extra bytes
+	tok, err := sizes.ParseNUL([]byte{0x27, 1, 0, 0, 0, 5, 0xff, 0xff}, NULStrip)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := tok.(ReturnToken32bit); !ok || v.ErrorNumber != 1 || v.ReturnValue != 5 {
+		t.Errorf("unexpected return token %+v", tok)
+	}
+	// exit token missing its return value
+	tok, err = sizes.ParseNUL([]byte{0x52, 0, 0, 0, 3}, NULStrip)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := tok.(ExitToken); !ok || v.Status != 3 || v.ReturnValue != 0 {
+		t.Errorf("unexpected exit token %+v", tok)
+	}
+
+	// entries for tokens of variable size or without bytes are rejected
+	if _, err := (SizeTable{0x23: 5}).ParseNUL([]byte{0x23, 0, 10, 'a', 'b'}, NULStrip); err == nil {
+		t.Error("expected error for path token entry")
+	}
+	if _, _, err := (SizeTable{0x23: 5}).Size([]byte{0x23}, DialectUnknown); err == nil {
+		t.Error("expected error sizing path token entry")
+	}
+	if _, _, err := (SizeTable{0x27: 0}).Size([]byte{0x27}, DialectUnknown); err == nil {
+		t.Error("expected error for empty token entry")
+	}
+}
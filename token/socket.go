@@ -0,0 +1,114 @@
+package token
+
+import "strconv"
+
+// Socket domains (address families) as written into socket tokens by
+// OpenBSM and Solaris (BSM_PF_*). They differ from the native values
+// of most systems, e.g. AF_INET6 is 30 on Darwin and 10 on Linux.
+const (
+	SocketDomainUnspec    = 0
+	SocketDomainLocal     = 1 // AF_UNIX
+	SocketDomainInet      = 2
+	SocketDomainAppleTalk = 16
+	SocketDomainRoute     = 24
+	SocketDomainLink      = 25
+	SocketDomainInet6     = 26
+	SocketDomainKey       = 27
+)
+
+// Socket types as written into socket tokens by OpenBSM and Solaris
+// (BSM_SOCK_*). Note that SOCK_DGRAM and SOCK_STREAM are swapped
+// compared to BSD and Linux.
+const (
+	SocketTypeDgram     = 1
+	SocketTypeStream    = 2
+	SocketTypeRaw       = 4
+	SocketTypeRdm       = 5
+	SocketTypeSeqPacket = 6
+)
+
+// bsmSocketDomains and bsmSocketTypes name the BSM values.
+var (
+	bsmSocketDomains = map[uint16]string{
+		SocketDomainUnspec:    "unspec",
+		SocketDomainLocal:     "unix",
+		SocketDomainInet:      "inet",
+		SocketDomainAppleTalk: "appletalk",
+		SocketDomainRoute:     "route",
+		SocketDomainLink:      "link",
+		SocketDomainInet6:     "inet6",
+		SocketDomainKey:       "key",
+	}
+	bsmSocketTypes = map[uint16]string{
+		SocketTypeDgram:     "dgram",
+		SocketTypeStream:    "stream",
+		SocketTypeRaw:       "raw",
+		SocketTypeRdm:       "rdm",
+		SocketTypeSeqPacket: "seqpacket",
+	}
+)
+
+// linuxSocketDomains and linuxSocketTypes name the native Linux values
+// used by producers which don't translate them.
+var (
+	linuxSocketDomains = map[uint16]string{
+		0:  "unspec",
+		1:  "unix",
+		2:  "inet",
+		5:  "appletalk",
+		10: "inet6",
+		15: "key",
+		16: "netlink",
+		17: "packet",
+	}
+	linuxSocketTypes = map[uint16]string{
+		1: "stream",
+		2: "dgram",
+		3: "raw",
+		4: "rdm",
+		5: "seqpacket",
+	}
+)
+
+// socketTables returns the domain and type tables of the dialect.
+func (d Dialect) socketTables() (domains, types map[uint16]string) {
+	if d == DialectLinux {
+		return linuxSocketDomains, linuxSocketTypes
+	}
+	return bsmSocketDomains, bsmSocketTypes
+}
+
+// SocketDomainName returns the name (e.g. "inet6") of a socket domain
+// or family as written by the dialect, or the number if it is unknown.
+func (d Dialect) SocketDomainName(domain uint16) string {
+	domains, _ := d.socketTables()
+	if name, ok := domains[domain]; ok {
+		return name
+	}
+	return strconv.Itoa(int(domain))
+}
+
+// SocketTypeName returns the name (e.g. "stream") of a socket type as
+// written by the dialect, or the number if it is unknown.
+func (d Dialect) SocketTypeName(socketType uint16) string {
+	_, types := d.socketTables()
+	if name, ok := types[socketType]; ok {
+		return name
+	}
+	return strconv.Itoa(int(socketType))
+}
+
+// FamilyName returns the name of the socket family.
+func (t SocketToken) FamilyName(d Dialect) string {
+	return d.SocketDomainName(t.SocketFamily)
+}
+
+// DomainName returns the name of the socket domain.
+func (t ExpandedSocketToken) DomainName(d Dialect) string {
+	return d.SocketDomainName(t.SocketDomain)
+}
+
+// TypeName returns the name of the socket type.
+func (t ExpandedSocketToken) TypeName(d Dialect) string {
+	return d.SocketTypeName(t.SocketType)
+}
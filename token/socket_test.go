@@ -0,0 +1,30 @@
+package token
+
+import "testing"
+
+func TestSocketNames(t *testing.T) {
+	token := ExpandedSocketToken{TokenID: 0x7f, SocketDomain: SocketDomainInet6, SocketType: SocketTypeStream}
+	if name := token.DomainName(DialectDarwin); name != "inet6" {
+		t.Error("unexpected domain:", name)
+	}
+	if name := token.TypeName(DialectFreeBSD); name != "stream" {
+		t.Error("unexpected type:", name)
+	}
+	// same numbers, native Linux values
+	if name := token.DomainName(DialectLinux); name != "26" {
+		t.Error("unexpected domain:", name)
+	}
+	if name := token.TypeName(DialectLinux); name != "dgram" {
+		t.Error("unexpected type:", name)
+	}
+
+	if name := (SocketToken{TokenID: 0x80, SocketFamily: 2}).FamilyName(DialectUnknown); name != "inet" {
+		t.Error("unexpected family:", name)
+	}
+	if name := DialectLinux.SocketDomainName(10); name != "inet6" {
+		t.Error("unexpected domain:", name)
+	}
+	if name := DialectSolaris.SocketTypeName(3); name != "3" {
+		t.Error("unexpected type:", name)
+	}
+}
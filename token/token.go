@@ -1,18 +1,17 @@
-// Parse BSM files
-package bsm
+// Package token holds the types of all BSM tokens and parses them from
+// their binary representation.
+package token
 
 import (
 	"bytes"
-	"encoding/binary"
 	"errors"
 	"fmt"
-	"io"
 	"math"
 	"net"
-	"strconv"
 )
 
-type empty interface{} // generic type for generator
+// Token is any of the token types of this package (e.g. PathToken).
+type Token interface{}
 
 // ArgToken32bit (or 'arg' token) contains information
 // about arguments of the system call.
@@ -22,7 +21,7 @@ type ArgToken32bit struct {
 	ArgumentID    uint8  // argument ID/number (1 byte)
 	ArgumentValue uint32 // argument value (4 bytes)
 	Length        uint16 // length of the text (2 bytes)
-	Text          string // the string including nul (Length + 1 NUL bytes)
+	Text          string // the string (Length bytes incl. NUL, see NULPolicy)
 }
 
 // ArgToken64bit (or 'arg' token) contains information
@@ -33,7 +32,7 @@ type ArgToken64bit struct {
 	ArgumentID    uint8  // argument ID/number (1 byte)
 	ArgumentValue uint64 // argument value (8 bytes)
 	Length        uint16 // length of the text (2 bytes)
-	Text          string // the string including nul (Length + 1 NUL bytes)
+	Text          string // the string (Length bytes incl. NUL, see NULPolicy)
 }
 
 // ArbitraryDataToken (or 'arbitrary data' token) contains a byte stream
@@ -108,13 +107,13 @@ type ExitToken struct {
 // log file to indicate when the audit log begins and ends. It includes a
 // pathname so that, if concatenated together, original file boundaries are
 // still observable, and gaps in the audit log can be identified.
-// BUG: unable to determine token ID (0x11 vs. 0x78 vs . ?)
+// NOTE: libbsm stores milliseconds in the Microseconds field.
 type FileToken struct {
-	TokenID        byte   // Token ID (1 byte):
+	TokenID        byte   // Token ID (1 byte): 0x11
 	Seconds        uint32 // file timestamp (4 bytes)
 	Microseconds   uint32 // file timestamp (4 bytes)
-	FileNameLength uint16 // file name of audit trail (2 bytes)
-	PathName       string // file name of audit trail (FileNameLength + 1 (NULL))
+	FileNameLength uint16 // length of file name including NUL (2 bytes)
+	PathName       string // file name of audit trail (FileNameLength bytes incl. NUL, see NULPolicy)
 }
 
 // GroupsToken (or 'groups' token) contains a list of group IDs associated
@@ -161,7 +160,7 @@ type HeaderToken64bit struct {
 type ExpandedHeaderToken32bit struct {
 	TokenID         byte   // Token ID (1 byte): 0x15
 	RecordByteCount uint32 // number of bytes in record (4 bytes)
-	VersionNumber   byte   // BSM record version number (2 bytes)
+	VersionNumber   byte   // BSM record version number (1 byte)
 	EventType       uint16 // event type (2 bytes)
 	EventModifier   uint16 // event sub-type (2 bytes)
 	AddressType     uint32 // host address type and length (1 byte in manpage / 4 bytes in Solaris 10)
@@ -176,7 +175,7 @@ type ExpandedHeaderToken32bit struct {
 type ExpandedHeaderToken64bit struct {
 	TokenID         byte   // Token ID (1 byte): 0x79
 	RecordByteCount uint32 // number of bytes in record (4 bytes)
-	VersionNumber   byte   // BSM record version number (2 bytes)
+	VersionNumber   byte   // BSM record version number (1 byte)
 	EventType       uint16 // event type (2 bytes)
 	EventModifier   uint16 // event sub-type (2 bytes)
 	AddressType     uint32 // host address type and length (1 byte in manpage / 4 bytes in Solaris 10)
@@ -185,6 +184,12 @@ type ExpandedHeaderToken64bit struct {
 	NanoSeconds     uint64 // record time stamp (8 bytes)
 }
 
+// EventModifierFailure is the flag of the header event modifier set by
+// Solaris for failed events (PAD_FAILURE). The event modifier has no
+// flag for the width of the encodings, 64-bit producers write 64-bit
+// header and subject tokens instead.
+const EventModifierFailure = 0x8000
+
 // InAddrToken (or 'in_addr' token) holds a (network byte order) IPv4 address.
 // BUGS: token layout documented in audit.log(5) appears to be in conflict with the libbsm(3) implementation of au_to_in_addr_ex(3).
 type InAddrToken struct {
@@ -218,17 +223,19 @@ type IpToken struct {
 	DestinationAddress net.IP // IPv4 destination addess (4 bytes)
 }
 
-// IPortToken (or 'iport' token) stores an IP port number in network byte order.
+// IPortToken (or 'iport' token) stores an IP port number. Like all
+// ports of the socket tokens it is written in network byte order, the
+// field holds the decoded port (e.g. 22).
 type IPortToken struct {
 	TokenID    byte   // Token ID (1 byte): 0x2c
-	PortNumber uint16 // Port number in network byte order (2 bytes)
+	PortNumber uint16 // Port number (2 bytes, network byte order)
 }
 
 // PathToken (or 'path' token) contains a pathname.
 type PathToken struct {
 	TokenID    byte   // Token ID (1 byte): 0x23
 	PathLength uint16 // Length of path in bytes (2 bytes)
-	Path       string // Path name (PathLength bytes + 1 NUL)
+	Path       string // Path name (PathLength bytes incl. NUL, see NULPolicy)
 }
 
 // PathAttrToken (or 'path_attr' token) contains a set of NUL-terminated path names.
@@ -357,7 +364,7 @@ type SeqToken struct {
 type SocketToken struct {
 	TokenID       byte   // Token ID (1 byte): 0x2e (BSM spec), 0x80 (inet32 socket), 0x81 (inet128 token), 0x82 (Unix token)
 	SocketFamily  uint16 // socket family (2 bytes)
-	LocalPort     uint16 // local port (2 bytes)
+	LocalPort     uint16 // local port (2 bytes, network byte order)
 	SocketAddress net.IP // socket address (4 bytes or 8 bytes for inet128 socket)
 }
 
@@ -368,9 +375,9 @@ type ExpandedSocketToken struct {
 	SocketDomain    uint16 // socket domain (2 bytes)
 	SocketType      uint16 // socket type (2 bytes)
 	AddressType     uint16 // address type (IPv4/IPv6) (2 bytes)
-	LocalPort       uint16 // local port (2 bytes)
+	LocalPort       uint16 // local port (2 bytes, network byte order)
 	LocalIpAddress  net.IP // local IP address (4/16 bytes)
-	RemotePort      uint16 // remote port (2 bytes)
+	RemotePort      uint16 // remote port (2 bytes, network byte order)
 	RemoteIpAddress net.IP // remote IP address (4/16 bytes)
 }
 
@@ -447,7 +454,7 @@ type ExpandedSubjectToken64bit struct {
 	ProcessID              uint32 // process ID (4 bytes)
 	SessionID              uint32 // audit session ID (4 bytes)
 	TerminalPortID         uint64 // terminal port ID (8 bytes)
-	TerminalAddressLength  uint8  // length of machine address (1 byte)
+	TerminalAddressLength  uint8  // length of machine address (4 bytes, the values fit in a byte)
 	TerminalMachineAddress net.IP // IP address of machine (4/16 bytes)
 }
 
@@ -477,7 +484,7 @@ type SystemVIpcPermissionToken struct {
 type TextToken struct {
 	TokenID    byte   // Token ID (1 byte): 0x28
 	TextLength uint16 // length of text string including NUL (2 bytes)
-	Text       string // Text string incl. NUL (TextLength bytes)
+	Text       string // Text string (TextLength bytes incl. NUL, see NULPolicy)
 }
 
 // TrailerToken (or 'trailer' terminates) a BSM audit record. This token
@@ -494,7 +501,7 @@ type TrailerToken struct {
 type ZonenameToken struct {
 	TokenID        byte   // Token ID (1 byte): 0x60
 	ZonenameLength uint16 // length of zonename string including NUL (2 bytes)
-	Zonename       string // Zonename string including NUL
+	Zonename       string // Zonename string (ZonenameLength bytes incl. NUL, see NULPolicy)
 }
 
 // Go has this unexpected behaviour, where Uvarint() aborts
@@ -547,6 +554,32 @@ func bytesToUint16(input []byte) (uint16, error) {
 	return result, nil
 }
 
+// missingStrings returns a lower bound for the number of bytes missing
+// to make the given data contain count NUL-terminated strings. As every
+// string needs at least its NUL byte, this is the number of missing NUL
+// bytes. It is capped to not trust hostile counts with huge allocations.
+func missingStrings(data []byte, count int) int {
+	missing := count - bytes.Count(data, []byte{0x00})
+	if missing > 4096 {
+		missing = 4096
+	}
+	return missing
+}
+
+// parseStrings splits data into count NUL-terminated strings.
+func parseStrings(data []byte, count int, nul NULPolicy) ([]string, error) {
+	result := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		end := bytes.IndexByte(data, 0x00)
+		if end < 0 {
+			return nil, errors.New("missing NUL terminated string")
+		}
+		result = append(result, nul.cstring(data[:end+1]))
+		data = data[end+1:]
+	}
+	return result, nil
+}
+
 // Determine the size (in bytes) of the current token. This is a
 // utility function to determine the number of bytes (yet) to read
 // from the input buffer. The return values are:
@@ -554,6 +587,13 @@ func bytesToUint16(input []byte) (uint16, error) {
 // * moreBytes - number of more bytes to read to make determination
 // * err - any error that ocurred
 func determineTokenSize(input []byte) (size, moreBytes int, err error) {
+	return Size(input, DialectUnknown)
+}
+
+// Size determines the size of the token starting with the given bytes
+// like determineTokenSize, but handles the differences of the given
+// dialect (e.g. the encoding of address types).
+func Size(input []byte, dialect Dialect) (size, moreBytes int, err error) {
 	size = 0
 	moreBytes = 0
 	err = nil
@@ -579,7 +619,7 @@ func determineTokenSize(input []byte) (size, moreBytes int, err error) {
 			err = local_err
 			return
 		}
-		size = 1 + 4 + 4 + 2 + int(fileNameLength) + 1 // don't forget NUL
+		size = 1 + 4 + 4 + 2 + int(fileNameLength) // length includes NUL (see au_to_file(3))
 		return
 	case 0x13: // trailer token
 		size = 1 + 2 + 4
@@ -596,14 +636,12 @@ func determineTokenSize(input []byte) (size, moreBytes int, err error) {
 			err = cerr
 			return
 		}
-		switch addrlen {
-		case 4: // IPv4 -> 4 bytes address
-			size = 1 + 4 + 1 + 2 + 2 + 4 + 4 + 4 + 4
-		case 16: // IPv6 -> 16 bytes address
-			size = 1 + 4 + 1 + 2 + 2 + 4 + 16 + 4 + 4
-		default:
+		length, ok := dialect.addressLength(addrlen)
+		if !ok {
 			err = fmt.Errorf("invalid value (%d) for 'address type' field in 32bit expanded header token", addrlen)
+			return
 		}
+		size = 1 + 4 + 1 + 2 + 2 + 4 + length + 4 + 4
 	case 0x21: // arbitrary data token
 		if len(input) < 4 {
 			// need more bytes to read BasicUnit and UnitCount fields
@@ -643,8 +681,8 @@ func determineTokenSize(input []byte) (size, moreBytes int, err error) {
 		// make sure we have strCount NUL-terminated strings
 		// NOTE: this is very crude and does not do a full validation
 		//       since it assumes a benevolent byte stream
-		if bytes.Count(input[3:], []byte{0x00}) < int(strCount) {
-			moreBytes = 1
+		if missing := missingStrings(input[3:], int(strCount)); missing > 0 {
+			moreBytes = missing
 			return
 		}
 		size = len(input)
@@ -714,8 +752,8 @@ func determineTokenSize(input []byte) (size, moreBytes int, err error) {
 		// make sure we have strCount NUL-terminated strings
 		// NOTE: this is very crude and does not do a full validation
 		//       since it assumes a benevolent byte stream
-		if bytes.Count(input[5:], []byte{0x00}) < int(strCount) {
-			moreBytes = 1
+		if missing := missingStrings(input[5:], int(strCount)); missing > 0 {
+			moreBytes = missing
 			return
 		}
 		size = len(input)
@@ -733,8 +771,8 @@ func determineTokenSize(input []byte) (size, moreBytes int, err error) {
 		// make sure we have strCount NUL-terminated strings
 		// NOTE: this is very crude and does not do a full validation
 		//       since it assumes a benevolent byte stream
-		if bytes.Count(input[5:], []byte{0x00}) < int(strCount) {
-			moreBytes = 1
+		if missing := missingStrings(input[5:], int(strCount)); missing > 0 {
+			moreBytes = missing
 			return
 		}
 		size = len(input)
@@ -765,7 +803,7 @@ func determineTokenSize(input []byte) (size, moreBytes int, err error) {
 			err = cerr
 			return
 		}
-		size = 1 + 1 + 8 + 2 + int(strlen) + 1
+		size = 1 + 1 + 8 + 2 + int(strlen) // length includes NUL (see au_to_arg64(3))
 	case 0x72: // 64 bit Return Token
 		size = 1 + 1 + 8
 	case 0x73: // 64 bit attribute token
@@ -787,14 +825,12 @@ func determineTokenSize(input []byte) (size, moreBytes int, err error) {
 			err = cerr
 			return
 		}
-		switch addrlen {
-		case 4: // IPv4 -> 4 bytes address
-			size = 1 + 4 + 2 + 2 + 2 + 4 + 4 + 8 + 8
-		case 16: // IPv6 -> 16 bytes address
-			size = 1 + 4 + 2 + 2 + 2 + 4 + 16 + 8 + 8
-		default:
+		length, ok := dialect.addressLength(addrlen)
+		if !ok {
 			err = fmt.Errorf("invalid value (%d) for 'address type' field in 64bit expanded header token", addrlen)
+			return
 		}
+		size = 1 + 4 + 1 + 2 + 2 + 4 + length + 8 + 8
 	case 0x7a: // expanded 32bit subject token
 		if len(input) < 37 {
 			// need more bytes to read TerminalAddressLength field
@@ -806,14 +842,12 @@ func determineTokenSize(input []byte) (size, moreBytes int, err error) {
 			err = cerr
 			return
 		}
-		switch addrlen {
-		case 4: // IPv4 -> 4 bytes address
-			size = 1 + 4 + 4 + 4 + 4 + 4 + 4 + 4 + 4 + 4 + 4
-		case 16: // IPv6 -> 16 bytes address
-			size = 1 + 4 + 4 + 4 + 4 + 4 + 4 + 4 + 4 + 4 + 16
-		default:
+		length, ok := dialect.addressLength(addrlen)
+		if !ok {
 			err = fmt.Errorf("invalid value (%d) for 'terminal address length' field in 32bit expanded subject token", addrlen)
+			return
 		}
+		size = 1 + 4 + 4 + 4 + 4 + 4 + 4 + 4 + 4 + 4 + length
 	case 0x7b: // 32bit expanded process token
 		if len(input) < 37 {
 			moreBytes = 37 - len(input)
@@ -824,29 +858,45 @@ func determineTokenSize(input []byte) (size, moreBytes int, err error) {
 			err = cerr
 			return
 		}
-		switch addrlen {
-		case 4: // IPv4
-			size = 1 + 4 + 4 + 4 + 4 + 4 + 4 + 4 + 4 + 4 + 4
-		case 16: // IPv6
-			size = 1 + 4 + 4 + 4 + 4 + 4 + 4 + 4 + 4 + 4 + 16
-		default:
+		length, ok := dialect.addressLength(addrlen)
+		if !ok {
 			err = fmt.Errorf("invalid value (%d) for 'terminal address length' field in 32bit expanded process token", addrlen)
+			return
 		}
+		size = 1 + 4 + 4 + 4 + 4 + 4 + 4 + 4 + 4 + 4 + length
 	case 0x7c: // expanded 64bit subject token
-		if len(input) < 38 {
+		if len(input) < 41 {
 			// need more bytes to read TerminalAddressLength field
-			moreBytes = 38 - len(input)
+			moreBytes = 41 - len(input)
 			return
 		}
-		addrlen := input[37]
-		switch addrlen {
-		case 4: // IPv4 -> 4 bytes for address
-			size = 1 + 4 + 4 + 4 + 4 + 4 + 4 + 4 + 8 + 1 + 4
-		case 16: // IPv6 -> 16 bytes for address
-			size = 1 + 4 + 4 + 4 + 4 + 4 + 4 + 4 + 8 + 1 + 16
-		default:
+		addrlen, cerr := bytesToUint32(input[37:41])
+		if cerr != nil {
+			err = cerr
+			return
+		}
+		length, ok := dialect.addressLength(addrlen)
+		if !ok {
 			err = fmt.Errorf("invalid value (%d) for 'terminal address length' field in 64bit expanded subject token", addrlen)
+			return
+		}
+		size = 1 + 4 + 4 + 4 + 4 + 4 + 4 + 4 + 8 + 4 + length
+	case 0x7d: // 64bit expanded process token
+		if len(input) < 41 {
+			moreBytes = 41 - len(input)
+			return
+		}
+		addrlen, cerr := bytesToUint32(input[37:41])
+		if cerr != nil {
+			err = cerr
+			return
 		}
+		length, ok := dialect.addressLength(addrlen)
+		if !ok {
+			err = fmt.Errorf("invalid value (%d) for 'terminal address length' field in 64bit expanded process token", addrlen)
+			return
+		}
+		size = 1 + 4 + 4 + 4 + 4 + 4 + 4 + 4 + 8 + 4 + length
 	case 0x7e: // expanded in_addr token
 		size = 1 + 1 + 16 // libbsm always allocates 16 bytes
 	case 0x7f: // expanded socket token
@@ -875,7 +925,7 @@ func determineTokenSize(input []byte) (size, moreBytes int, err error) {
 	case 0x82: // FreeBSD socket token
 		size = 1 + 2 + 2 + 4
 	default:
-		err = fmt.Errorf("can't determine the size of the given token (type): 0x%x", input[0])
+		err = &UnknownTokenError{ID: input[0]}
 	}
 	return
 }
@@ -944,66 +994,183 @@ func ParseHeaderToken32bit(input []byte) (HeaderToken32bit, error) {
 	return token, nil
 }
 
-// RecordsFromByteInput yields a generator for all records contained
-// in the given byte input. This input has to support the Reader interface
-// and may be a file or a device.
+// ParseHeaderToken64bit parses a HeaderToken64bit out of the given bytes.
+func ParseHeaderToken64bit(input []byte) (HeaderToken64bit, error) {
+	ptr := 0
+	token := HeaderToken64bit{}
 
-// TokenFromByteInput converts bytes read from a given input
-// to a BSM token.
-func TokenFromByteInput(input io.Reader) (empty, error) {
-	tokenBuffer := []byte{0x00}
+	// (static) length check
+	if len(input) != 26 {
+		return token, errors.New("invalid length of 64bit header token")
+	}
 
-	// read all the info we need
-	n, err := input.Read(tokenBuffer[0:1]) // try to use only token ID
-	if nil != err {
-		return nil, err
+	// read token ID
+	tokenID := input[ptr]
+	if tokenID != 0x74 {
+		return token, errors.New("token ID mismatch")
 	}
-	if n != 1 {
-		return nil, errors.New("read " + strconv.Itoa(n) + " bytes, but wanted exactly 1")
+	token.TokenID = tokenID
+	ptr += 1
+
+	// read record byte count (4 bytes)
+	data32, err := bytesToUint32(input[ptr : ptr+4])
+	if err != nil {
+		return token, err
 	}
-	bufidx := 1                                                   // index where to fill the buffer
-	buflen, increase, err := determineTokenSize(tokenBuffer[0:1]) // read only token ID
-	if nil != err {
-		return nil, err
+	token.RecordByteCount = data32
+	ptr += 4
+
+	// read BSM version number (1 byte)
+	token.VersionNumber = input[ptr]
+	ptr += 1
+
+	// read event type (2 bytes)
+	data16, err := bytesToUint16(input[ptr : ptr+2])
+	if err != nil {
+		return token, err
 	}
+	token.EventType = data16
+	ptr += 2
 
-	if increase != 0 { // we need more bytes and test again
-		// increase token buffer to hold new bytes
-		tmp := make([]byte, 1+increase) // we have read one byte already
-		copy(tmp, tokenBuffer)
-		tokenBuffer = tmp
-		for increase > 0 {
-			// try to read all bytes
-			n, err := input.Read(tokenBuffer[bufidx : bufidx+increase])
-			if nil != err {
-				return nil, err
-			}
-			bufidx += n        // move the index the number of bytes read
-			if n != increase { // adjust how many more to read
-				increase -= n
-			} else {
-				increase = 0 // no more bytes need to be read
-			}
-		}
-		buflen, increase, err = determineTokenSize(tokenBuffer)
-		if nil != err {
-			return nil, err
-		}
+	// read event sub-type / modifier
+	data16, err = bytesToUint16(input[ptr : ptr+2])
+	if err != nil {
+		return token, err
+	}
+	token.EventModifier = data16
+	ptr += 2
+
+	// read seconds
+	data64, err := bytesToUint64(input[ptr : ptr+8])
+	if err != nil {
+		return token, err
 	}
-	// read all the (remaining) bytes we need
-	tmp := make([]byte, buflen) // increase token buffer to hold new bytes
-	copy(tmp, tokenBuffer)
-	tokenBuffer = tmp
-	n, err = input.Read(tokenBuffer[bufidx:buflen]) // read remaining bytes
-	if nil != err {
-		return nil, err
+	token.Seconds = data64
+	ptr += 8
+
+	// read nanoseconds
+	data64, err = bytesToUint64(input[ptr : ptr+8])
+	if err != nil {
+		return token, err
+	}
+	token.NanoSeconds = data64
+
+	return token, nil
+}
+
+// parseMachineAddress reads an IPv4 or IPv6 address of the given length.
+// The AU_IPv4/AU_IPv6 enum values (1/2) are accepted as well.
+func parseMachineAddress(input []byte, length uint32) (net.IP, error) {
+	if l, ok := DialectUnknown.addressLength(length); ok {
+		length = uint32(l)
 	}
-	if n != buflen-bufidx {
-		return nil, errors.New("read " + strconv.Itoa(n) + " bytes, but wanted exactly " + strconv.Itoa(buflen-bufidx))
+	switch length {
+	case 4:
+		return net.IPv4(input[0], input[1], input[2], input[3]), nil
+	case 16:
+		addr := make(net.IP, 16)
+		copy(addr, input[:16])
+		return addr, nil
 	}
+	return nil, fmt.Errorf("invalid machine address length: %d", length)
+}
+
+// ParseExpandedHeaderToken32bit parses an ExpandedHeaderToken32bit out of
+// the given bytes.
+func ParseExpandedHeaderToken32bit(input []byte) (ExpandedHeaderToken32bit, error) {
+	token := ExpandedHeaderToken32bit{}
 
-	// process the buffer
+	size, _, err := determineTokenSize(input)
+	if err != nil {
+		return token, err
+	}
+	if size == 0 || len(input) != size {
+		return token, errors.New("invalid length of 32bit expanded header token")
+	}
+	if input[0] != 0x15 {
+		return token, errors.New("token ID mismatch")
+	}
+	token.TokenID = input[0]
+	token.RecordByteCount, _ = bytesToUint32(input[1:5])
+	token.VersionNumber = input[5]
+	token.EventType, _ = bytesToUint16(input[6:8])
+	token.EventModifier, _ = bytesToUint16(input[8:10])
+	token.AddressType, _ = bytesToUint32(input[10:14])
+	token.MachineAddress, err = parseMachineAddress(input[14:], token.AddressType)
+	if err != nil {
+		return token, err
+	}
+	// time stamp is located at the end of the token
+	token.Seconds, _ = bytesToUint32(input[size-8 : size-4])
+	token.NanoSeconds, _ = bytesToUint32(input[size-4 : size])
+
+	return token, nil
+}
+
+// ParseExpandedHeaderToken64bit parses an ExpandedHeaderToken64bit out of
+// the given bytes.
+func ParseExpandedHeaderToken64bit(input []byte) (ExpandedHeaderToken64bit, error) {
+	token := ExpandedHeaderToken64bit{}
+
+	size, _, err := determineTokenSize(input)
+	if err != nil {
+		return token, err
+	}
+	if size == 0 || len(input) != size {
+		return token, errors.New("invalid length of 64bit expanded header token")
+	}
+	if input[0] != 0x79 {
+		return token, errors.New("token ID mismatch")
+	}
+	token.TokenID = input[0]
+	token.RecordByteCount, _ = bytesToUint32(input[1:5])
+	token.VersionNumber = input[5]
+	token.EventType, _ = bytesToUint16(input[6:8])
+	token.EventModifier, _ = bytesToUint16(input[8:10])
+	token.AddressType, _ = bytesToUint32(input[10:14])
+	token.MachineAddress, err = parseMachineAddress(input[14:], token.AddressType)
+	if err != nil {
+		return token, err
+	}
+	// time stamp is located at the end of the token
+	token.Seconds, _ = bytesToUint64(input[size-16 : size-8])
+	token.NanoSeconds, _ = bytesToUint64(input[size-8 : size])
+
+	return token, nil
+}
+
+// Parse converts the raw bytes of a single token to a BSM token. The
+// NUL bytes terminating strings are stripped.
+func Parse(tokenBuffer []byte) (Token, error) {
+	return ParseNUL(tokenBuffer, NULStrip)
+}
+
+// ParseNUL works like Parse, with the given handling of the NUL bytes
+// terminating strings.
+func ParseNUL(tokenBuffer []byte, nul NULPolicy) (Token, error) {
 	switch tokenBuffer[0] {
+	case 0x11: // file token
+		token := FileToken{
+			TokenID: tokenBuffer[0],
+		}
+		val, err := bytesToUint32(tokenBuffer[1:5])
+		if err != nil {
+			return nil, err
+		}
+		token.Seconds = val
+		val, err = bytesToUint32(tokenBuffer[5:9])
+		if err != nil {
+			return nil, err
+		}
+		token.Microseconds = val
+		length, err := bytesToUint16(tokenBuffer[9:11])
+		if err != nil {
+			return nil, err
+		}
+		token.FileNameLength = length
+		token.PathName = nul.cstring(tokenBuffer[11 : 11+length])
+		return token, nil
+
 	case 0x13: // trailer token
 		tmagic, err := bytesToUint16(tokenBuffer[1:3])
 		if err != nil {
@@ -1025,6 +1192,14 @@ func TokenFromByteInput(input io.Reader) (empty, error) {
 			return nil, err
 		}
 		return token, nil
+
+	case 0x15: // 32 bit expanded header token
+		token, err := ParseExpandedHeaderToken32bit(tokenBuffer)
+		if err != nil {
+			return nil, err
+		}
+		return token, nil
+
 	case 0x23: // path token
 		token := PathToken{
 			TokenID: tokenBuffer[0],
@@ -1034,10 +1209,10 @@ func TokenFromByteInput(input io.Reader) (empty, error) {
 			return nil, err
 		}
 		token.PathLength = length
-		token.Path = string(tokenBuffer[3 : length+2])
+		token.Path = nul.cstring(tokenBuffer[3 : 3+length])
 		return token, nil
 
-	case 0x24: // 32 bit subject token
+	case 0x24, 0x26: // 32 bit subject and process tokens (same layout)
 		token := SubjectToken32bit{
 			TokenID: tokenBuffer[0],
 		}
@@ -1094,6 +1269,9 @@ func TokenFromByteInput(input io.Reader) (empty, error) {
 			tokenBuffer[34],
 			tokenBuffer[35],
 			tokenBuffer[36])
+		if token.TokenID == 0x26 {
+			return ProcessToken32bit(token), nil
+		}
 		return token, nil
 
 	case 0x27: // 32 bit return token
@@ -1115,9 +1293,47 @@ func TokenFromByteInput(input io.Reader) (empty, error) {
 		return TextToken{
 			TokenID:    tokenBuffer[0],
 			TextLength: length,
-			Text:       string(tokenBuffer[3 : length+2]), // 3 bytes inital offset - 1 NUL byte = 2 bytes
+			Text:       nul.cstring(tokenBuffer[3 : 3+length]),
 		}, nil
 
+	case 0x2a: // in_addr token
+		return InAddrToken{
+			TokenID:   tokenBuffer[0],
+			IpAddress: net.IPv4(tokenBuffer[1], tokenBuffer[2], tokenBuffer[3], tokenBuffer[4]),
+		}, nil
+
+	case 0x2b: // ip token
+		token := IpToken{
+			TokenID:       tokenBuffer[0],
+			VersionAndIHL: tokenBuffer[1],
+			TypeOfService: tokenBuffer[2],
+			TTL:           tokenBuffer[9],
+			Protocol:      tokenBuffer[10],
+		}
+		val, err := bytesToUint16(tokenBuffer[3:5])
+		if err != nil {
+			return nil, err
+		}
+		token.Length = val
+		val, err = bytesToUint16(tokenBuffer[5:7])
+		if err != nil {
+			return nil, err
+		}
+		token.ID = val
+		val, err = bytesToUint16(tokenBuffer[7:9])
+		if err != nil {
+			return nil, err
+		}
+		token.Offset = val
+		val, err = bytesToUint16(tokenBuffer[11:13])
+		if err != nil {
+			return nil, err
+		}
+		token.Checksum = val
+		token.SourceAddress = net.IPv4(tokenBuffer[13], tokenBuffer[14], tokenBuffer[15], tokenBuffer[16])
+		token.DestinationAddress = net.IPv4(tokenBuffer[17], tokenBuffer[18], tokenBuffer[19], tokenBuffer[20])
+		return token, nil
+
 	case 0x2c: // iport token
 		port, err := bytesToUint16(tokenBuffer[1:3])
 		if err != nil {
@@ -1142,7 +1358,7 @@ func TokenFromByteInput(input io.Reader) (empty, error) {
 			return nil, err
 		}
 		token.Length = length
-		token.Text = string(tokenBuffer[8 : length+7])
+		token.Text = nul.cstring(tokenBuffer[8 : 8+length])
 		return token, nil
 
 	case 0x2e: // socket soken
@@ -1166,6 +1382,65 @@ func TokenFromByteInput(input io.Reader) (empty, error) {
 			tokenBuffer[8])
 		return token, nil
 
+	case 0x2f: // seq token
+		val, err := bytesToUint32(tokenBuffer[1:5])
+		if err != nil {
+			return nil, err
+		}
+		return SeqToken{
+			TokenID:        tokenBuffer[0],
+			SequenceNumber: val,
+		}, nil
+
+	case 0x34: // groups token
+		count, err := bytesToUint16(tokenBuffer[1:3])
+		if err != nil {
+			return nil, err
+		}
+		token := GroupsToken{
+			TokenID:        tokenBuffer[0],
+			NumberOfGroups: count,
+			GroupList:      make([]uint32, count),
+		}
+		for i := range token.GroupList {
+			val, err := bytesToUint32(tokenBuffer[3+4*i : 7+4*i])
+			if err != nil {
+				return nil, err
+			}
+			token.GroupList[i] = val
+		}
+		return token, nil
+
+	case 0x3c: // exec args token
+		count, err := bytesToUint32(tokenBuffer[1:5])
+		if err != nil {
+			return nil, err
+		}
+		text, err := parseStrings(tokenBuffer[5:], int(count), nul)
+		if err != nil {
+			return nil, err
+		}
+		return ExecArgsToken{
+			TokenID: tokenBuffer[0],
+			Count:   count,
+			Text:    text,
+		}, nil
+
+	case 0x3d: // exec env token
+		count, err := bytesToUint32(tokenBuffer[1:5])
+		if err != nil {
+			return nil, err
+		}
+		text, err := parseStrings(tokenBuffer[5:], int(count), nul)
+		if err != nil {
+			return nil, err
+		}
+		return ExecEnvToken{
+			TokenID: tokenBuffer[0],
+			Count:   count,
+			Text:    text,
+		}, nil
+
 	case 0x3e: // 32bit attribute token
 		token := AttributeToken32bit{
 			TokenID: tokenBuffer[0],
@@ -1206,13 +1481,16 @@ func TokenFromByteInput(input io.Reader) (empty, error) {
 		token := ExitToken{
 			TokenID: tokenBuffer[0],
 		}
-		stat, err := bytesToUint32(tokenBuffer[1:3])
+		stat, err := bytesToUint32(tokenBuffer[1:5])
 		if err != nil {
 			return nil, err
 		}
 		token.Status = stat
-		rval, _ := binary.Varint(tokenBuffer[3:7])
-		token.ReturnValue = int32(rval)
+		rval, err := bytesToUint32(tokenBuffer[5:9])
+		if err != nil {
+			return nil, err
+		}
+		token.ReturnValue = int32(rval) // two's complement
 		return token, nil
 
 	case 0x60: // zonename token
@@ -1224,9 +1502,38 @@ func TokenFromByteInput(input io.Reader) (empty, error) {
 			return nil, err
 		}
 		token.ZonenameLength = length
-		token.Zonename = string(tokenBuffer[3 : length+2])
+		token.Zonename = nul.cstring(tokenBuffer[3 : 3+length])
+		return token, nil
+
+	case 0x71: // 64bit arg token
+		token := ArgToken64bit{
+			TokenID:    tokenBuffer[0],
+			ArgumentID: tokenBuffer[1],
+		}
+		val, err := bytesToUint64(tokenBuffer[2:10])
+		if err != nil {
+			return nil, err
+		}
+		token.ArgumentValue = val
+		length, err := bytesToUint16(tokenBuffer[10:12])
+		if err != nil {
+			return nil, err
+		}
+		token.Length = length
+		token.Text = nul.cstring(tokenBuffer[12 : 12+length])
 		return token, nil
 
+	case 0x72: // 64 bit return token
+		rval, err := bytesToUint64(tokenBuffer[2:10])
+		if err != nil {
+			return nil, err
+		}
+		return ReturnToken64bit{
+			TokenID:     tokenBuffer[0],
+			ErrorNumber: tokenBuffer[1],
+			ReturnValue: rval,
+		}, nil
+
 	case 0x73: // 64 bit attribute token
 		token := AttributeToken64bit{
 			TokenID: tokenBuffer[0],
@@ -1263,6 +1570,82 @@ func TokenFromByteInput(input io.Reader) (empty, error) {
 		token.Device = bval
 		return token, nil
 
+	case 0x74: // 64 bit header token
+		token, err := ParseHeaderToken64bit(tokenBuffer)
+		if err != nil {
+			return nil, err
+		}
+		return token, nil
+
+	case 0x75, 0x77: // 64 bit subject and process tokens (same layout)
+		token := SubjectToken64bit{
+			TokenID: tokenBuffer[0],
+		}
+		val, err := bytesToUint32(tokenBuffer[1:5])
+		if err != nil {
+			return nil, err
+		}
+		token.AuditID = val
+
+		val, err = bytesToUint32(tokenBuffer[5:9])
+		if err != nil {
+			return nil, err
+		}
+		token.EffectiveUserID = val
+
+		val, err = bytesToUint32(tokenBuffer[9:13])
+		if err != nil {
+			return nil, err
+		}
+		token.EffectiveGroupID = val
+
+		val, err = bytesToUint32(tokenBuffer[13:17])
+		if err != nil {
+			return nil, err
+		}
+		token.RealUserID = val
+
+		val, err = bytesToUint32(tokenBuffer[17:21])
+		if err != nil {
+			return nil, err
+		}
+		token.RealGroupID = val
+
+		val, err = bytesToUint32(tokenBuffer[21:25])
+		if err != nil {
+			return nil, err
+		}
+		token.ProcessID = val
+
+		val, err = bytesToUint32(tokenBuffer[25:29])
+		if err != nil {
+			return nil, err
+		}
+		token.SessionID = val
+
+		port, err := bytesToUint64(tokenBuffer[29:37])
+		if err != nil {
+			return nil, err
+		}
+		token.TerminalPortID = port
+
+		token.TerminalMachineAddress = net.IPv4(
+			tokenBuffer[37],
+			tokenBuffer[38],
+			tokenBuffer[39],
+			tokenBuffer[40])
+		if token.TokenID == 0x77 {
+			return ProcessToken64bit(token), nil
+		}
+		return token, nil
+
+	case 0x79: // 64 bit expanded header token
+		token, err := ParseExpandedHeaderToken64bit(tokenBuffer)
+		if err != nil {
+			return nil, err
+		}
+		return token, nil
+
 	case 0x7a: // expanded 32bit subject token
 		token := ExpandedSubjectToken32bit{
 			TokenID: tokenBuffer[0],
@@ -1321,17 +1704,9 @@ func TokenFromByteInput(input io.Reader) (empty, error) {
 		}
 		token.TerminalAddressLength = val
 
-		switch val {
-		case 4:
-			token.TerminalMachineAddress = net.IPv4(
-				tokenBuffer[37],
-				tokenBuffer[38],
-				tokenBuffer[39],
-				tokenBuffer[40])
-		case 16:
-			token.TerminalMachineAddress = tokenBuffer[37:53]
-		default:
-			return nil, errors.New("can't process length of terminal machine address")
+		token.TerminalMachineAddress, err = parseMachineAddress(tokenBuffer[37:], val)
+		if err != nil {
+			return nil, err
 		}
 		return token, nil
 
@@ -1393,18 +1768,144 @@ func TokenFromByteInput(input io.Reader) (empty, error) {
 		}
 		token.TerminalAddressLength = val
 
-		switch token.TerminalAddressLength {
-		case 4:
-			token.TerminalMachineAddress = net.IPv4(
-				tokenBuffer[37],
-				tokenBuffer[38],
-				tokenBuffer[39],
-				tokenBuffer[40],
-			)
-		case 16:
-			token.TerminalMachineAddress = tokenBuffer[37:53]
-		default:
-			return nil, errors.New("invalid value for address length in 32bit expanded process token")
+		token.TerminalMachineAddress, err = parseMachineAddress(tokenBuffer[37:], val)
+		if err != nil {
+			return nil, err
+		}
+		return token, nil
+
+	case 0x7c, 0x7d: // expanded 64bit subject and process tokens (same layout)
+		token := ExpandedProcessToken64bit{
+			TokenID: tokenBuffer[0],
+		}
+		val, err := bytesToUint32(tokenBuffer[1:5])
+		if err != nil {
+			return nil, err
+		}
+		token.AuditID = val
+
+		val, err = bytesToUint32(tokenBuffer[5:9])
+		if err != nil {
+			return nil, err
+		}
+		token.EffectiveUserID = val
+
+		val, err = bytesToUint32(tokenBuffer[9:13])
+		if err != nil {
+			return nil, err
+		}
+		token.EffectiveGroupID = val
+
+		val, err = bytesToUint32(tokenBuffer[13:17])
+		if err != nil {
+			return nil, err
+		}
+		token.RealUserID = val
+
+		val, err = bytesToUint32(tokenBuffer[17:21])
+		if err != nil {
+			return nil, err
+		}
+		token.RealGroupID = val
+
+		val, err = bytesToUint32(tokenBuffer[21:25])
+		if err != nil {
+			return nil, err
+		}
+		token.ProcessID = val
+
+		val, err = bytesToUint32(tokenBuffer[25:29])
+		if err != nil {
+			return nil, err
+		}
+		token.SessionID = val
+
+		port, err := bytesToUint64(tokenBuffer[29:37])
+		if err != nil {
+			return nil, err
+		}
+		token.TerminalPortID = port
+
+		val, err = bytesToUint32(tokenBuffer[37:41])
+		if err != nil {
+			return nil, err
+		}
+		token.TerminalAddressLength = val
+
+		token.TerminalMachineAddress, err = parseMachineAddress(tokenBuffer[41:], val)
+		if err != nil {
+			return nil, err
+		}
+		if token.TokenID == 0x7c {
+			return ExpandedSubjectToken64bit{
+				TokenID:                token.TokenID,
+				AuditID:                token.AuditID,
+				EffectiveUserID:        token.EffectiveUserID,
+				EffectiveGroupID:       token.EffectiveGroupID,
+				RealUserID:             token.RealUserID,
+				RealGroupID:            token.RealGroupID,
+				ProcessID:              token.ProcessID,
+				SessionID:              token.SessionID,
+				TerminalPortID:         token.TerminalPortID,
+				TerminalAddressLength:  uint8(token.TerminalAddressLength), // validated by parseMachineAddress
+				TerminalMachineAddress: token.TerminalMachineAddress,
+			}, nil
+		}
+		return token, nil
+
+	case 0x7e: // expanded in_addr token
+		token := ExpandedInAddrToken{
+			TokenID:       tokenBuffer[0],
+			IpAddressType: tokenBuffer[1],
+		}
+		length, ok := DialectUnknown.addressLength(uint32(token.IpAddressType))
+		if !ok {
+			return nil, fmt.Errorf("invalid value (%d) for 'address type' field in expanded in_addr token", token.IpAddressType)
+		}
+		addr, err := parseMachineAddress(tokenBuffer[2:], uint32(length))
+		if err != nil {
+			return nil, err
+		}
+		token.IpAddress = addr
+		return token, nil
+
+	case 0x7f: // expanded socket token
+		token := ExpandedSocketToken{
+			TokenID: tokenBuffer[0],
+		}
+		val, err := bytesToUint16(tokenBuffer[1:3])
+		if err != nil {
+			return nil, err
+		}
+		token.SocketDomain = val
+		val, err = bytesToUint16(tokenBuffer[3:5])
+		if err != nil {
+			return nil, err
+		}
+		token.SocketType = val
+		val, err = bytesToUint16(tokenBuffer[5:7])
+		if err != nil {
+			return nil, err
+		}
+		token.AddressType = val
+		val, err = bytesToUint16(tokenBuffer[7:9])
+		if err != nil {
+			return nil, err
+		}
+		token.LocalPort = val
+		addrlen := int(token.AddressType)
+		token.LocalIpAddress, err = parseMachineAddress(tokenBuffer[9:], uint32(addrlen))
+		if err != nil {
+			return nil, err
+		}
+		val, err = bytesToUint16(tokenBuffer[9+addrlen : 11+addrlen])
+		if err != nil {
+			return nil, err
+		}
+		token.RemotePort = val
+		token.RemoteIpAddress, err = parseMachineAddress(tokenBuffer[11+addrlen:], uint32(addrlen))
+		if err != nil {
+			return nil, err
 		}
 		return token, nil
 
@@ -1470,96 +1971,6 @@ func TokenFromByteInput(input io.Reader) (empty, error) {
 		return token, nil
 
 	default:
-		return nil, fmt.Errorf("new token ID found: 0x%x", tokenBuffer[0])
-	}
-	return nil, nil
-}
-
-// BsmRecord represents a BSM record.
-type BsmRecord struct {
-	Seconds     uint64  // record time stamp (8 bytes)
-	NanoSeconds uint64  // record time stamp (8 bytes)
-	Tokens      []empty // generic list of all tokens
-}
-
-// ParsingResult encapsulates the result of the parsing
-// process to be used in conjunction with channels.
-type ParsingResult struct {
-	Record BsmRecord
-	Error  error
-}
-
-// ReadBsmRecord read a complete BSM record from the given byte source.
-// TODO: support potential file token at the beginning of a stream
-// TODO: check record size for consistency
-func ReadBsmRecord(input io.Reader) (BsmRecord, error) {
-	rec := BsmRecord{}
-
-	// start: header token
-	header, err := TokenFromByteInput(input)
-	if err != nil {
-		return rec, err
-	}
-
-	switch v := header.(type) {
-	case HeaderToken32bit:
-		rec.Seconds = uint64(v.Seconds)
-		rec.NanoSeconds = uint64(v.NanoSeconds)
-	case HeaderToken64bit:
-		rec.Seconds = v.Seconds
-		rec.NanoSeconds = v.NanoSeconds
-	case ExpandedHeaderToken32bit:
-		rec.Seconds = uint64(v.Seconds)
-		rec.NanoSeconds = uint64(v.NanoSeconds)
-	case ExpandedHeaderToken64bit:
-		rec.Seconds = v.Seconds
-		rec.NanoSeconds = v.NanoSeconds
-	default:
-		return rec, errors.New("no header token found")
-	}
-
-	nextToken, err := TokenFromByteInput(input)
-	if err != nil {
-		return rec, err
+		return nil, &UnknownTokenError{ID: tokenBuffer[0]}
 	}
-
-	_, isEnd := nextToken.(TrailerToken) // assert next token to be trailer and check success
-	for !isEnd {
-		// append the current token to list (in record)
-		rec.Tokens = append(rec.Tokens, nextToken)
-
-		// check if the next (trailer) token indicates the end of record
-		nextToken, err = TokenFromByteInput(input)
-		if err != nil {
-			return rec, err
-		}
-		_, isEnd = nextToken.(TrailerToken) // assert next token to be trailer and check success
-	}
-
-	return rec, nil
-}
-
-// RecordGenerator yields a continous stream of BSM records
-// until the source is exhausted.
-func RecordGenerator(input io.Reader) chan ParsingResult {
-	resChan := make(chan ParsingResult)
-
-	// cookie-cutter iterator
-	go func() {
-		for { // extraction loop
-			rec, err := ReadBsmRecord(input)
-			res := ParsingResult{
-				Record: rec,
-				Error:  err,
-			}
-			resChan <- res
-			// leave source is exhausted
-			if res.Error == io.EOF {
-				break
-			}
-		}
-		close(resChan)
-	}()
-
-	return resChan
 }
@@ -1,11 +1,7 @@
-// test parsing of BSM files
-package bsm
+package token
 
 import (
-	"bytes"
-	"os"
 	"strconv"
-	"strings"
 	"testing"
 )
 
@@ -36,31 +32,6 @@ func Test_bytesToUint32(t *testing.T) {
 	}
 }
 
-func TestTokenFromByteInput(t *testing.T) {
-	data := []byte{0x00}
-	_, err := TokenFromByteInput(bytes.NewBuffer(data))
-	if err == nil {
-		t.Error("one byte record should yield an error")
-	}
-	if !strings.Contains(err.Error(), "can't determine the size of the given token (type)") {
-		t.Error("unexpected error message:", err.Error())
-	}
-	// iport token as minimal test case
-	data = []byte{0x2c, 0x23, 0x42}
-	token, err := TokenFromByteInput(bytes.NewBuffer(data))
-	if err != nil {
-		t.Error(err)
-	}
-	switch v := token.(type) {
-	case IPortToken:
-		if v.PortNumber != 9026 {
-			t.Error("wrong port number in IPortToken")
-		}
-	default:
-		t.Error("expected IPortToken, but got", v)
-	}
-}
-
 // fixed sized tokens
 func Test_determineTokenSize_fixed(t *testing.T) {
 	testData := map[byte]int{
@@ -134,8 +105,8 @@ func Test_determineTokenSize_file_token(t *testing.T) {
 	if more != 0 {
 		t.Error("expected 0 bytes more to read, but only " + strconv.Itoa(more) + " were requested")
 	}
-	if size != (11 + 9208 + 1) { // 11 inital bytes + file name length (from hex) + NUL
-		t.Error("wrong size: expected " + strconv.Itoa(11+9208+1) + ", got " + strconv.Itoa(size))
+	if size != (11 + 9208) { // 11 inital bytes + file name length (from hex, incl. NUL)
+		t.Error("wrong size: expected " + strconv.Itoa(11+9208) + ", got " + strconv.Itoa(size))
 	}
 
 }
@@ -212,7 +183,7 @@ func Test_determineTokenSize_expanded_64bit_subject_token(t *testing.T) {
 	if err != nil {
 		t.Error(err)
 	}
-	moreBytes := 37
+	moreBytes := 40
 	if more != moreBytes {
 		t.Error("expected " + strconv.Itoa(moreBytes) + " bytes more to read, but only " + strconv.Itoa(more) + " were requested")
 	}
@@ -227,14 +198,69 @@ func Test_determineTokenSize_expanded_64bit_subject_token(t *testing.T) {
 		0x00, 0x01, 0x02, 0x03, // process ID
 		0x00, 0x01, 0x02, 0x03, // audit session ID
 		0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, // terminal port ID
-		0x00,                   // length of address
+		0x00, 0x00, 0x00, 0x00, // length of address
+		0x00, 0x01, 0x02, 0x03, // IPv4
+	}
+	size, more, err := determineTokenSize(testData)
+	if err == nil {
+		t.Error("expected an error on invalid address length")
+	}
+	testData[40] = 4 // IPv4
+	size, more, err = determineTokenSize(testData)
+	if err != nil {
+		t.Error(err)
+	}
+	if more != 0 {
+		t.Error("expected 0 bytes more to read, but only " + strconv.Itoa(more) + " were requested")
+	}
+	expSize := 45
+	if size != expSize {
+		t.Error("wrong size: expected " + strconv.Itoa(expSize) + ", got " + strconv.Itoa(size))
+	}
+
+}
+
+func Test_determineTokenSize_expanded_64bit_process_token(t *testing.T) {
+	testData := []byte{}
+
+	// missing token ID
+	_, more, err := determineTokenSize(testData)
+	if err != nil {
+		t.Error(err)
+	}
+	if more != 1 {
+		t.Error("expected 1 bytes more to read, but only " + strconv.Itoa(more) + " were requested")
+	}
+
+	// correct token ID, bot no more
+	testData = []byte{0x7d}
+	_, more, err = determineTokenSize(testData)
+	if err != nil {
+		t.Error(err)
+	}
+	moreBytes := 40
+	if more != moreBytes {
+		t.Error("expected " + strconv.Itoa(moreBytes) + " bytes more to read, but only " + strconv.Itoa(more) + " were requested")
+	}
+
+	// correct token (in terms of size)
+	testData = []byte{0x7d, // token ID
+		0x00, 0x01, 0x02, 0x03, // audit user ID
+		0x00, 0x01, 0x02, 0x03, // effective user ID
+		0x00, 0x01, 0x02, 0x03, // effective group ID
+		0x00, 0x01, 0x02, 0x03, // real user ID
+		0x00, 0x01, 0x02, 0x03, // real group ID
+		0x00, 0x01, 0x02, 0x03, // process ID
+		0x00, 0x01, 0x02, 0x03, // session ID
+		0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, // terminal port ID
+		0x00, 0x00, 0x00, 0x00, // length of address
 		0x00, 0x01, 0x02, 0x03, // IPv4
 	}
 	size, more, err := determineTokenSize(testData)
 	if err == nil {
 		t.Error("expected an error on invalid address length")
 	}
-	testData[37] = 4 // IPv4
+	testData[40] = 4 // IPv4
 	size, more, err = determineTokenSize(testData)
 	if err != nil {
 		t.Error(err)
@@ -242,7 +268,7 @@ func Test_determineTokenSize_expanded_64bit_subject_token(t *testing.T) {
 	if more != 0 {
 		t.Error("expected 0 bytes more to read, but only " + strconv.Itoa(more) + " were requested")
 	}
-	expSize := 42
+	expSize := 45
 	if size != expSize {
 		t.Error("wrong size: expected " + strconv.Itoa(expSize) + ", got " + strconv.Itoa(size))
 	}
@@ -331,7 +357,7 @@ func Test_determineTokenSize_expanded_64bit_header_token(t *testing.T) {
 	// correct token (in terms of size)
 	testData = []byte{0x79, // token ID
 		0x00, 0x01, 0x02, 0x03, // number of bytes in record
-		0x00, 0x01, // record version number
+		0x0b,       // record version number
 		0x00, 0x01, // event type
 		0x00, 0x01, // event modifier / sub-type
 		0x00, 0x01, 0x02, 0x03, // host address type/length
@@ -354,7 +380,7 @@ func Test_determineTokenSize_expanded_64bit_header_token(t *testing.T) {
 	if more != 0 {
 		t.Error("expected 0 bytes more to read, but only " + strconv.Itoa(more) + " were requested")
 	}
-	expSize := 35
+	expSize := 34
 	if size != expSize {
 		t.Error("wrong size: expected " + strconv.Itoa(expSize) + ", got " + strconv.Itoa(size))
 	}
@@ -812,334 +838,51 @@ func TestParseHeaderToken32bit(t *testing.T) {
 
 }
 
-func Test_parsing_ExpandedProcessToken32bit(t *testing.T) {
-	data := []byte{
-		0x7b,                   // token ID
-		0x00, 0x01, 0x02, 0x03, // audit ID
-		0x00, 0x01, 0x02, 0x03, // effective user ID
-		0x00, 0x01, 0x02, 0x03, // effective group ID
-		0x00, 0x01, 0x02, 0x03, // real user ID
-		0x00, 0x01, 0x02, 0x03, // real group ID
-		0x00, 0x01, 0x02, 0x03, // process ID
-		0x00, 0x01, 0x02, 0x03, // session ID
-		0x00, 0x01, 0x02, 0x03, // terminal port ID
-		0x00, 0x00, 0x00, 0x04, // address length -> IPv4
-		0x00, 0x01, 0x02, 0x03, // actual IP
-	}
-
-	token, err := TokenFromByteInput(bytes.NewBuffer(data))
-	if err != nil {
-		t.Error(err.Error())
-	}
-	switch v := token.(type) {
-	case ExpandedProcessToken32bit:
-		if v.TerminalAddressLength != 0x04 {
-			t.Error("invalid address length on 32 bit expanded process token")
-		}
-	default:
-		t.Error("unexpected token found")
-	}
-
-	data = []byte{
-		0x7b,                   // token ID
-		0x00, 0x01, 0x02, 0x03, // audit ID
-		0x00, 0x01, 0x02, 0x03, // effective user ID
-		0x00, 0x01, 0x02, 0x03, // effective group ID
-		0x00, 0x01, 0x02, 0x03, // real user ID
-		0x00, 0x01, 0x02, 0x03, // real group ID
-		0x00, 0x01, 0x02, 0x03, // process ID
-		0x00, 0x01, 0x02, 0x03, // session ID
-		0x00, 0x01, 0x02, 0x03, // terminal port ID
-		0x00, 0x00, 0x00, 0x10, // address length -> IPv6
-		0x00, 0x01, 0x02, 0x03, // actual IP
-		0x04, 0x05, 0x06, 0x07,
-		0x08, 0x09, 0x0a, 0x0b,
-		0x0c, 0x0d, 0x0e, 0x0f,
-	}
-	token, err = TokenFromByteInput(bytes.NewBuffer(data))
-	if err != nil {
-		t.Error(err.Error())
-	}
-	switch token.(type) {
-	case ExpandedProcessToken32bit:
-	default:
-		t.Error("unexpected token found")
-	}
-
-	data = []byte{
-		0x7b,                   // token ID
-		0x00, 0x01, 0x02, 0x03, // audit ID
-		0x00, 0x01, 0x02, 0x03, // effective user ID
-		0x00, 0x01, 0x02, 0x03, // effective group ID
-		0x00, 0x01, 0x02, 0x03, // real user ID
-		0x00, 0x01, 0x02, 0x03, // real group ID
-		0x00, 0x01, 0x02, 0x03, // process ID
-		0x00, 0x01, 0x02, 0x03, // session ID
-		0x00, 0x01, 0x02, 0x03, // terminal port ID
-		0x00, 0x00, 0x00, 0x11, // invalid address length
-		0x00, 0x01, 0x02, 0x03, // actual IP
-		0x04, 0x05, 0x06, 0x07,
-		0x08, 0x09, 0x0a, 0x0b,
-		0x0c, 0x0d, 0x0e, 0x0f,
-		0x41,
-	}
-	_, err = TokenFromByteInput(bytes.NewBuffer(data))
-	if err == nil {
-		t.Error("expected an error on invalid length")
-	}
-
-}
-
-func Test_small_example_token(t *testing.T) {
-	data := []byte{
-		0x14,                   // --- 32bit header token ID
-		0x00, 0x00, 0x00, 0x38, // 56 bytes in record
-		0x0b,       // version number (2991)
-		0xaf, 0xc8, // event type
-		0x00, 0x00, // event modifier / sub-type
-		0x5a, 0x9a, 0xc2, 0xe6, // timestamp seconds
-		0x00, 0x00, 0x03, 0x01, // timestamp nanoseconds
-		0x28,       // --- text token ID
-		0x00, 0x16, // string length (22 bytes)
-		0x61, 0x75, 0x64, 0x69, // actual string
-		0x74, 0x64, 0x3a, 0x3a,
-		0x41, 0x75, 0x64, 0x69,
-		0x74, 0x20, 0x73, 0x74,
-		0x61, 0x72, 0x74, 0x75,
-		0x70, 0x00,
-		0x27,                   // --- return token ID
-		0x00,                   // error number
-		0x00, 0x00, 0x00, 0x00, // return value
-		0x13,       // --- trailer token ID
-		0xb1, 0x05, // trailer magic
-		0x00, 0x00, 0x00, 0x38, // record byte count (56 bytes)
-	}
-	input := bytes.NewBuffer(data)
-
-	// --- parse tokens single handed ---
-	// parse first token
-	token, err := TokenFromByteInput(input)
-	if err != nil {
-		t.Error(err.Error())
-	}
-	switch token.(type) {
-	case HeaderToken32bit:
-	default:
-		t.Error("unexpected token found")
-	}
-
-	// parse second token
-	token, err = TokenFromByteInput(input)
-	if err != nil {
-		t.Error(err.Error())
-	}
-	switch v := token.(type) {
-	case TextToken:
-		if v.TextLength != 22 {
-			t.Error("wrong text length")
-		}
-		if v.Text != "auditd::Audit startup" {
-			t.Error("unexpected text")
-		}
-	default:
-		t.Error("unexpected token found")
-	}
-
-	// parse third token
-	token, err = TokenFromByteInput(input)
-	if err != nil {
-		t.Error(err.Error())
-	}
-	switch v := token.(type) {
-	case ReturnToken32bit:
-		if v.ErrorNumber != 0 {
-			t.Error("unexpected error number")
-		}
-		if v.ReturnValue != 0 {
-			t.Error("unexpected return value")
-		}
-	default:
-		t.Error("unexpected token found")
-	}
-
-	// parse fourth (last) token
-	token, err = TokenFromByteInput(input)
-	if err != nil {
-		t.Error(err.Error())
-	}
-	switch v := token.(type) {
-	case TrailerToken:
-		if v.RecordByteCount != 0 {
-			t.Error("unexpected record byte count")
-		}
-	default:
-		t.Error("unexpected token found")
-	}
-
-	// --- try to parse complete record ---
-	input = bytes.NewBuffer(data)
-	rec, err := ReadBsmRecord(input)
-	if err != nil {
-		t.Error(err.Error())
-	}
-	if 2 != len(rec.Tokens) {
-		t.Error("unexpected number od tokens in BSM record")
-	}
-
-	// --- try the generator ---
-	input = bytes.NewBuffer(data)
-	rcount := 0
-	for _ = range RecordGenerator(input) {
-		rcount += 1
-		if rcount > 2 { // original + EOF
-			t.Error("more records than expected")
-		}
-	}
-}
-
-func Test_parsing_root_login(t *testing.T) {
-	data := []byte{
-		0x14, // --- 32bit header token
-		0x00, 0x00, 0x00, 0x61,
-		0x0b,
-		0x18, 0x0f,
-		0x00, 0x00,
-		0x5a, 0x9a, 0xc2, 0x1f,
-		0x00, 0x00, 0x03, 0x63,
-		0x24,                   // --- 32bit subject token
-		0xff, 0xff, 0xff, 0xff, // audit ID
+func TestParseExpandedToken64bit(t *testing.T) {
+	data := []byte{0x7c, // token ID
+		0x00, 0x00, 0x03, 0xe8, // audit user ID
 		0x00, 0x00, 0x00, 0x00, // effective user ID
 		0x00, 0x00, 0x00, 0x00, // effective group ID
-		0x00, 0x00, 0x00, 0x00, // real user ID
-		0x00, 0x00, 0x00, 0x00, // real group ID
-		0x00, 0x00, 0x02, 0xf2, // process ID
-		0x00, 0x00, 0x02, 0xf2, // audit session ID
-		0x00, 0x00, 0x00, 0x00, // terminal port ID
-		0x00, 0x00, 0x00, 0x00, // machine IP address
-		0x28,       // --- text token
-		0x00, 0x1a, // test length (26)
-		0x73, 0x75, 0x63, 0x63, // text
-		0x65, 0x73, 0x73, 0x66,
-		0x75, 0x6c, 0x20, 0x61,
-		0x75, 0x74, 0x68, 0x65,
-		0x6e, 0x74, 0x69, 0x63,
-		0x61, 0x74, 0x69, 0x6f,
-		0x6e, 0x00,
-		0x27, // --- return token
-		0x00,
-		0x00, 0x00, 0x00, 0x00,
-		0x13, // --- trailer token
-		0xb1, 0x05,
-		0x00, 0x00, 0x00, 0x61,
-		0x14, // --- 32bit subject token
-		0x00, 0x00, 0x00, 0x61,
-		0x0b,
-		0x80, 0x20,
-		0x00, 0x00,
-		0x5a, 0x9a, 0xc2, 0x27,
-		0x00, 0x00, 0x01, 0xf9,
-		0x7a, // expanded 32bit subject token
-		0x00, 0x00, 0x00, 0x00,
-		0x00, 0x00, 0x00, 0x00,
-		0x00, 0x00, 0x00, 0x00,
-		0x00, 0x00, 0x00, 0x00,
-		0x00, 0x00, 0x00, 0x00,
-		0x00, 0x00, 0x03, 0x35,
-		0x00, 0x00, 0x03, 0x35,
-		0x00, 0x00, 0x1c, 0x65,
-		0x00, 0x00, 0x00, 0x04,
-		0x5d, 0xb8, 0xd8, 0x26,
-		0x28, // --- text token
-		0x00, 0x16,
-		0x73, 0x75, 0x63, 0x63,
-		0x65, 0x73, 0x73, 0x66,
-		0x75, 0x6c, 0x20, 0x6c,
-		0x6f, 0x67, 0x69, 0x6e,
-		0x20, 0x72, 0x6f, 0x6f,
-		0x74, 0x00,
-		0x27, // --- return token
-		0x00,
-		0x00, 0x00, 0x00, 0x00,
-		0x13, // --- trailer token
-		0xb1, 0x05,
-		0x00, 0x00, 0x00, 0x61,
-		0x14, // 32 bit header
-		0x00, 0x00, 0x00, 0x39,
-		0x0b,
-		0xaf, 0xc9,
-		0x00, 0x00,
-		0x5a, 0x9a, 0xc2, 0x43,
-		0x00, 0x00, 0x03, 0xa1,
-		0x28, // --- text token
-		0x00, 0x17,
-		0x61, 0x75, 0x64, 0x69,
-		0x74, 0x64, 0x3a, 0x3a,
-		0x41, 0x75, 0x64, 0x69,
-		0x74, 0x20, 0x73, 0x68,
-		0x75, 0x74, 0x64, 0x6f,
-		0x77, 0x6e, 0x00,
-		0x27, // --- return token
-		0x00, 0x00, 0x00, 0x00, 0x00,
-		0x13, // --- trailer token
-		0xb1, 0x05, 0x00, 0x00, 0x00, 0x39,
-	}
-	input := bytes.NewBuffer(data)
-	rec, err := ReadBsmRecord(input)
-	if err != nil {
-		t.Error(err.Error())
-	}
-	if 3 != len(rec.Tokens) { // subject + text + return
-		t.Error("unexpected number of tokens in BSM record")
+		0x00, 0x00, 0x03, 0xe8, // real user ID
+		0x00, 0x00, 0x03, 0xe8, // real group ID
+		0x00, 0x00, 0x00, 0x2a, // process ID
+		0x00, 0x00, 0x00, 0x07, // session ID
+		0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x05, // terminal port ID
+		0x00, 0x00, 0x00, 0x10, // length of address
+		0x20, 0x01, 0x0d, 0xb8, 0x00, 0x00, 0x00, 0x00, // IPv6
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01,
 	}
-
-	// record with expanded 32 bit subject token
-	rec, err = ReadBsmRecord(input)
+	tok, err := Parse(data)
 	if err != nil {
-		t.Error(err.Error())
-	}
-	if 3 != len(rec.Tokens) { // subject + text + return
-		t.Error("unexpected number of tokens in BSM record")
+		t.Fatal(err)
 	}
-
-	subjectToken, ok := rec.Tokens[0].(ExpandedSubjectToken32bit)
+	subject, ok := tok.(ExpandedSubjectToken64bit)
 	if !ok {
-		t.Error("asserting ExpandedSubjectToken32bit type failed")
+		t.Fatalf("unexpected token %T", tok)
 	}
-	if subjectToken.EffectiveUserID != 0 {
-		t.Error("wrong effective user ID")
+	if subject.AuditID != 1000 || subject.ProcessID != 42 || subject.SessionID != 7 || subject.TerminalPortID != 1<<40+5 {
+		t.Errorf("unexpected subject %+v", subject)
 	}
-
-	textToken, ok := rec.Tokens[1].(TextToken)
-	if !ok {
-		t.Error("asserting TextToken type failed")
-	}
-	if textToken.Text != "successful login root" {
-		t.Error("unexpected string in text token")
+	if subject.TerminalAddressLength != 16 || subject.TerminalMachineAddress.String() != "2001:db8::1" {
+		t.Error("wrong terminal address:", subject.TerminalMachineAddress)
 	}
 
-	// record with plain text token
-	rec, err = ReadBsmRecord(input)
+	data[0] = 0x7d
+	tok, err = Parse(data)
 	if err != nil {
-		t.Error(err.Error())
+		t.Fatal(err)
 	}
-	if 2 != len(rec.Tokens) { // text + return
-		t.Error("unexpected number of tokens in BSM record")
+	process, ok := tok.(ExpandedProcessToken64bit)
+	if !ok || process.TokenID != 0x7d || process.TerminalPortID != 1<<40+5 || process.TerminalMachineAddress.String() != "2001:db8::1" {
+		t.Errorf("unexpected process %+v", tok)
 	}
 
-}
-
-func Test_reading_from_file(t *testing.T) {
-	file, err := os.Open("start_stop.bsm")
-	if err != nil {
-		t.Error(err)
+	// AU_IPv4 address type (Linux)
+	v4 := append(data[:37:37], 0x00, 0x00, 0x00, 0x01, 192, 0, 2, 1)
+	if size, _, err := Size(v4, DialectLinux); err != nil || size != 45 {
+		t.Errorf("unexpected size %d (%v)", size, err)
 	}
-	defer file.Close()
-
-	rcount := 0
-	for _ = range RecordGenerator(file) {
-		rcount += 1
-		if rcount > 3 { // start + stop + EOF
-			t.Error("more records than expected")
-		}
+	if _, _, err := Size(v4, DialectDarwin); err == nil {
+		t.Error("expected an error for AU_IPv4 address type")
 	}
 }
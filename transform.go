@@ -0,0 +1,69 @@
+package bsm
+
+// Transform applies fn to every record of the given stream and yields
+// the results on the returned channel. Parsing errors are passed on
+// unchanged, an error returned by fn is attached to the affected record.
+func Transform(in chan ParsingResult, fn func(*BsmRecord) error) chan ParsingResult {
+	out := make(chan ParsingResult)
+
+	go func() {
+		for res := range in {
+			if res.Error == nil {
+				res.Error = fn(&res.Record)
+			}
+			out <- res
+		}
+		close(out)
+	}()
+
+	return out
+}
+
+// ParallelTransform works like Transform, but applies fn on n records
+// concurrently. The results are yielded in the order of the input
+// stream. This pays off for expensive transformations (e.g. DNS or
+// GeoIP lookups), fn has to be safe for concurrent use.
+func ParallelTransform(in chan ParsingResult, n int, fn func(*BsmRecord) error) chan ParsingResult {
+	if n < 1 {
+		n = 1
+	}
+	type job struct {
+		res  ParsingResult
+		done chan ParsingResult
+	}
+	jobs := make(chan job)
+	pending := make(chan chan ParsingResult, n)
+	out := make(chan ParsingResult)
+
+	for i := 0; i < n; i++ {
+		go func() {
+			for j := range jobs {
+				if j.res.Error == nil {
+					j.res.Error = fn(&j.res.Record)
+				}
+				j.done <- j.res
+			}
+		}()
+	}
+
+	// dispatch records to the workers, remembering their order
+	go func() {
+		for res := range in {
+			done := make(chan ParsingResult, 1)
+			pending <- done
+			jobs <- job{res: res, done: done}
+		}
+		close(jobs)
+		close(pending)
+	}()
+
+	// collect the results in order
+	go func() {
+		for done := range pending {
+			out <- <-done
+		}
+		close(out)
+	}()
+
+	return out
+}
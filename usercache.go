@@ -0,0 +1,100 @@
+package bsm
+
+import (
+	"container/list"
+	"sync"
+)
+
+// DefaultUserCacheSize is the size of a UserCache without one.
+const DefaultUserCacheSize = 1024
+
+// userEntryBytes is the estimated memory used per cached user: the
+// list element, the map entry and a name of up to 32 bytes.
+const userEntryBytes = 160
+
+// CacheStats describes the state of a bounded cache.
+type CacheStats struct {
+	Entries   int    // entries held
+	Capacity  int    // entries held at most
+	Hits      uint64 // lookups answered by the cache
+	Misses    uint64 // lookups passed on
+	Evictions uint64 // entries dropped to make room
+}
+
+// userEntry is a resolved user.
+type userEntry struct {
+	uid  uint32
+	name string
+	err  error
+}
+
+// UserCache remembers the names of the most recently resolved users,
+// including failed lookups, so transformations like
+// FlagPrivilegeTransitions don't query the user database for every
+// record. It is safe for concurrent use.
+type UserCache struct {
+	resolve UserResolver
+	size    int
+
+	mutex   sync.Mutex
+	entries map[uint32]*list.Element
+	lru     list.List // most recently used first
+	stats   CacheStats
+}
+
+// NewUserCache returns a cache of up to size users (DefaultUserCacheSize
+// if zero) resolved with the given resolver (LookupUser if nil).
+func NewUserCache(resolve UserResolver, size int) *UserCache {
+	if resolve == nil {
+		resolve = LookupUser
+	}
+	if size <= 0 {
+		size = DefaultUserCacheSize
+	}
+	return &UserCache{resolve: resolve, size: size, entries: map[uint32]*list.Element{}}
+}
+
+// Lookup resolves a user ID. It is a UserResolver.
+func (c *UserCache) Lookup(uid uint32) (string, error) {
+	c.mutex.Lock()
+	if elem, ok := c.entries[uid]; ok {
+		c.lru.MoveToFront(elem)
+		c.stats.Hits += 1
+		entry := elem.Value.(userEntry)
+		c.mutex.Unlock()
+		return entry.name, entry.err
+	}
+	c.stats.Misses += 1
+	c.mutex.Unlock()
+
+	name, err := c.resolve(uid)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if _, ok := c.entries[uid]; !ok {
+		if c.lru.Len() >= c.size {
+			oldest := c.lru.Back()
+			c.lru.Remove(oldest)
+			delete(c.entries, oldest.Value.(userEntry).uid)
+			c.stats.Evictions += 1
+		}
+		c.entries[uid] = c.lru.PushFront(userEntry{uid: uid, name: name, err: err})
+	}
+	return name, err
+}
+
+// Stats returns the state of the cache.
+func (c *UserCache) Stats() CacheStats {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	stats := c.stats
+	stats.Entries = c.lru.Len()
+	stats.Capacity = c.size
+	return stats
+}
+
+// MaxBytes returns an estimate of the memory the cache holds once it
+// is full.
+func (c *UserCache) MaxBytes() int64 {
+	return int64(c.size) * userEntryBytes
+}
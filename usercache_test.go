@@ -0,0 +1,38 @@
+package bsm
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestUserCache(t *testing.T) {
+	lookups := 0
+	cache := NewUserCache(func(uid uint32) (string, error) {
+		lookups += 1
+		if uid == 1001 {
+			return "alice", nil
+		}
+		return "", errors.New("unknown user")
+	}, 2)
+
+	for _, uid := range []uint32{1001, 1001, 1002, 1002, 1003, 1001} {
+		cache.Lookup(uid)
+	}
+	if name, err := cache.Lookup(1001); name != "alice" || err != nil {
+		t.Errorf("got %q (%v)", name, err)
+	}
+	if _, err := cache.Lookup(1003); err == nil {
+		t.Error("failed lookup not cached")
+	}
+	// 1001 was evicted by 1003 and looked up again
+	if lookups != 4 {
+		t.Error("unexpected number of lookups:", lookups)
+	}
+	stats := cache.Stats()
+	if stats.Entries != 2 || stats.Capacity != 2 || stats.Hits != 4 || stats.Misses != 4 || stats.Evictions != 2 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+	if cache.MaxBytes() != 2*userEntryBytes {
+		t.Error("unexpected memory bound:", cache.MaxBytes())
+	}
+}
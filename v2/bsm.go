@@ -0,0 +1,53 @@
+// Package bsm is the second major version of the BSM audit trail API.
+// Records hold tokens implementing the Token interface, decoding errors
+// are typed (see RecordError) and the functions of the first version
+// are kept as deprecated wrappers to ease the migration.
+package bsm
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/tpltnt/go-bsm/decode"
+	"github.com/tpltnt/go-bsm/token"
+)
+
+// Token is a single BSM token, i.e. one of the token types of package
+// token (e.g. token.PathToken).
+type Token interface {
+	Type() byte // token ID, e.g. 0x23 for a path token
+}
+
+// Record is a complete BSM audit record without its header and
+// trailer tokens.
+type Record struct {
+	Version       byte      // BSM record version number
+	EventType     uint16    // event type
+	EventModifier uint16    // event sub-type
+	Time          time.Time // time of the event
+	Tokens        []Token   // all tokens between header and trailer
+}
+
+// newRecord converts a record returned by package decode.
+func newRecord(rec *decode.BsmRecord) (*Record, error) {
+	result := &Record{
+		Version:       rec.Version,
+		EventType:     rec.EventType,
+		EventModifier: rec.EventModifier,
+		Time:          rec.Time(),
+		Tokens:        make([]Token, 0, len(rec.Tokens)),
+	}
+	for _, tok := range rec.Tokens {
+		t, ok := tok.(Token)
+		if !ok {
+			return nil, fmt.Errorf("unexpected token type %T", tok)
+		}
+		result.Tokens = append(result.Tokens, t)
+	}
+	return result, nil
+}
+
+// Name returns the name of the given token, e.g. "path".
+func Name(tok Token) string {
+	return token.Name(tok)
+}
@@ -0,0 +1,78 @@
+package bsm
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/tpltnt/go-bsm/token"
+)
+
+func TestDecoder(t *testing.T) {
+	data, err := os.ReadFile("../start_stop.bsm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoder := NewDecoder(bytes.NewReader(data))
+	count := 0
+	for {
+		rec, err := decoder.Decode()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, tok := range rec.Tokens {
+			if tok.Type() == 0 {
+				t.Errorf("token %s without ID", Name(tok))
+			}
+		}
+		count++
+	}
+	if count == 0 {
+		t.Error("no records decoded")
+	}
+}
+
+func TestDecoderErrors(t *testing.T) {
+	data, err := os.ReadFile("../start_stop.bsm")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// truncated second record
+	first, err := ReadBsmRecord(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoder := NewDecoder(bytes.NewReader(data[:first.ByteCount+20]))
+	if _, err := decoder.Decode(); err != nil {
+		t.Fatal(err)
+	}
+	_, err = decoder.Decode()
+	var recErr *RecordError
+	if !errors.As(err, &recErr) || recErr.Offset != uint64(first.ByteCount) {
+		t.Fatal("unexpected error:", err)
+	}
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Error("expected io.ErrUnexpectedEOF, got", err)
+	}
+
+	// unknown token ID
+	decoder = NewDecoder(bytes.NewReader([]byte{0xff}))
+	_, err = decoder.Decode()
+	var tokErr *UnknownTokenError
+	if !errors.As(err, &tokErr) || tokErr.ID != 0xff {
+		t.Error("unexpected error:", err)
+	}
+}
+
+func TestTokenInterface(t *testing.T) {
+	var tok Token = token.PathToken{TokenID: 0x23, Path: "/etc"}
+	if tok.Type() != 0x23 || Name(tok) != "path" {
+		t.Error("unexpected token:", tok.Type(), Name(tok))
+	}
+}
@@ -0,0 +1,47 @@
+package bsm
+
+import (
+	"io"
+
+	"github.com/tpltnt/go-bsm/decode"
+	"github.com/tpltnt/go-bsm/token"
+)
+
+// Decoder reads BSM records from an input stream.
+type Decoder struct {
+	// Dialect is the operating system which wrote the trail, see
+	// package token.
+	Dialect token.Dialect
+
+	// Strict makes the decoder reject records with an unknown version
+	// with an *UnknownVersionError.
+	Strict bool
+
+	decoder *decode.Decoder
+}
+
+// NewDecoder returns a decoder reading from the given input.
+func NewDecoder(input io.Reader) *Decoder {
+	return &Decoder{decoder: decode.NewDecoder(input)}
+}
+
+// Decode reads the next record. It returns io.EOF if the input is
+// exhausted, any other error is a *RecordError.
+func (d *Decoder) Decode() (*Record, error) {
+	d.decoder.Dialect = d.Dialect
+	d.decoder.Strict = d.Strict
+
+	offset := d.decoder.Stats().BytesRead
+	rec, err := d.decoder.Decode()
+	if err == io.EOF {
+		return nil, err
+	}
+	if err != nil {
+		return nil, &RecordError{Offset: offset, Err: err}
+	}
+	result, err := newRecord(&rec)
+	if err != nil {
+		return nil, &RecordError{Offset: offset, Err: err}
+	}
+	return result, nil
+}
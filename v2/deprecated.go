@@ -0,0 +1,46 @@
+package bsm
+
+import (
+	"io"
+
+	"github.com/tpltnt/go-bsm/decode"
+)
+
+// BsmRecord is the record type of the first version.
+//
+// Deprecated: use Record.
+type BsmRecord = decode.BsmRecord
+
+// ParsingResult is the result type of RecordGenerator.
+//
+// Deprecated: use Decoder.
+type ParsingResult = decode.ParsingResult
+
+// TokenFromByteInput converts bytes read from a given input
+// to a BSM token.
+//
+// Deprecated: use package token or a Decoder.
+func TokenFromByteInput(input io.Reader) (interface{}, error) {
+	return decode.ReadToken(input)
+}
+
+// ReadBsmRecord reads a complete BSM record from the given input.
+//
+// Deprecated: use Decoder.
+func ReadBsmRecord(input io.Reader) (BsmRecord, error) {
+	return decode.ReadBsmRecord(input)
+}
+
+// RecordGenerator yields the records of the given input on a channel.
+//
+// Deprecated: use Decoder.
+func RecordGenerator(input io.Reader) chan ParsingResult {
+	return decode.RecordGenerator(input)
+}
+
+// ForEachRecord calls fn for every record of the given input.
+//
+// Deprecated: use Decoder.
+func ForEachRecord(input io.Reader, fn func(*BsmRecord) error) error {
+	return decode.ForEachRecord(input, fn)
+}
@@ -0,0 +1,30 @@
+package bsm
+
+import (
+	"fmt"
+
+	"github.com/tpltnt/go-bsm/decode"
+	"github.com/tpltnt/go-bsm/token"
+)
+
+// UnknownTokenError is returned for a token with an unknown ID.
+type UnknownTokenError = token.UnknownTokenError
+
+// UnknownVersionError is returned by a strict Decoder for a record with
+// an unexpected version.
+type UnknownVersionError = decode.UnknownVersionError
+
+// RecordError is returned by the Decoder for a record which could not be
+// decoded. A truncated record wraps io.ErrUnexpectedEOF.
+type RecordError struct {
+	Offset uint64 // offset of the record in the input
+	Err    error  // the underlying error
+}
+
+func (e *RecordError) Error() string {
+	return fmt.Sprintf("record at offset %d: %v", e.Offset, e.Err)
+}
+
+func (e *RecordError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,125 @@
+package bsm
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// AlertKind identifies the problem reported by a Watchdog.
+type AlertKind string
+
+// Alerts raised by the Watchdog.
+const (
+	AlertStalled       AlertKind = "stalled"        // no records for too long
+	AlertLagging       AlertKind = "lagging"        // records are late compared to the wall clock
+	AlertNotTerminated AlertKind = "not_terminated" // unterminated trails accumulate
+)
+
+// Alert is a problem of the audit subsystem found by a Watchdog.
+type Alert struct {
+	Kind    AlertKind
+	Message string
+	Time    time.Time
+}
+
+// Watchdog monitors the audit subsystem from the consumer side: it
+// alerts when the record stream of a source stalls, when the records
+// are late compared to the wall clock (i.e. the time stamps of their
+// headers lag behind the time they are read) or when trails which were
+// never terminated, e.g. because auditd crashed, accumulate.
+type Watchdog struct {
+	Source Source
+	// StallAfter is the time without records after which the source is
+	// considered stalled (0 disables the check). Quiet hosts may
+	// legitimately produce few records.
+	StallAfter time.Duration
+	// MaxLag is the delay of records tolerated (0 disables the check).
+	MaxLag time.Duration
+	// TrailDir (if not empty) is checked for trails ending in
+	// ".not_terminated". More than MaxNotTerminated of them are an
+	// alert. The default is 1, the trail being written.
+	TrailDir         string
+	MaxNotTerminated int
+	// Interval is the time between checks of Run (default 10s).
+	Interval time.Duration
+	// Alert is called by Run for every new alert, i.e. alerts are not
+	// repeated while the problem persists.
+	Alert func(Alert)
+
+	started time.Time
+}
+
+// Check returns the current alerts.
+func (w *Watchdog) Check() []Alert {
+	now := time.Now()
+	if w.started.IsZero() {
+		w.started = now
+	}
+	var alerts []Alert
+	if w.Source != nil {
+		health := w.Source.Health()
+		lastRead := health.LastRead
+		if lastRead.IsZero() {
+			lastRead = w.started
+		}
+		if w.StallAfter > 0 && now.Sub(lastRead) > w.StallAfter {
+			alerts = append(alerts, Alert{Kind: AlertStalled, Time: now,
+				Message: fmt.Sprintf("no records for %v", now.Sub(lastRead).Round(time.Second))})
+		}
+		if lag := health.LastRead.Sub(health.LastRecord); w.MaxLag > 0 && !health.LastRead.IsZero() && lag > w.MaxLag {
+			alerts = append(alerts, Alert{Kind: AlertLagging, Time: now,
+				Message: fmt.Sprintf("records %v behind the wall clock", lag.Round(time.Second))})
+		}
+	}
+	if w.TrailDir != "" {
+		max := w.MaxNotTerminated
+		if max <= 0 {
+			max = 1
+		}
+		entries, err := os.ReadDir(w.TrailDir)
+		if err != nil {
+			alerts = append(alerts, Alert{Kind: AlertNotTerminated, Time: now, Message: fmt.Sprintf("trail directory: %v", err)})
+		}
+		var names []string
+		for _, entry := range entries {
+			if strings.HasSuffix(entry.Name(), ".not_terminated") {
+				names = append(names, entry.Name())
+			}
+		}
+		if len(names) > max {
+			alerts = append(alerts, Alert{Kind: AlertNotTerminated, Time: now,
+				Message: fmt.Sprintf("%d unterminated trails: %s", len(names), strings.Join(names, ", "))})
+		}
+	}
+	return alerts
+}
+
+// Run checks every Interval until the context is cancelled and passes
+// new alerts to Alert.
+func (w *Watchdog) Run(ctx context.Context) error {
+	interval := w.Interval
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	active := map[AlertKind]bool{}
+	for {
+		current := map[AlertKind]bool{}
+		for _, alert := range w.Check() {
+			current[alert.Kind] = true
+			if !active[alert.Kind] && w.Alert != nil {
+				w.Alert(alert)
+			}
+		}
+		active = current
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
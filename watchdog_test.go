@@ -0,0 +1,55 @@
+package bsm
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// healthSource is a Source reporting a fixed health.
+type healthSource struct {
+	health Health
+}
+
+func (src *healthSource) Next() (BsmRecord, error) { return BsmRecord{}, nil }
+func (src *healthSource) Health() Health           { return src.health }
+
+func TestWatchdog(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"20240101000000.20240102000000", "20240102000000.not_terminated", "20240103000000.not_terminated"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	now := time.Now()
+	src := &healthSource{health: Health{LastRead: now.Add(-time.Hour), LastRecord: now.Add(-2 * time.Hour)}}
+	w := &Watchdog{Source: src, StallAfter: time.Minute, MaxLag: time.Minute, TrailDir: dir}
+	kinds := map[AlertKind]bool{}
+	for _, alert := range w.Check() {
+		kinds[alert.Kind] = true
+	}
+	if len(kinds) != 3 || !kinds[AlertStalled] || !kinds[AlertLagging] || !kinds[AlertNotTerminated] {
+		t.Errorf("unexpected alerts %v", kinds)
+	}
+
+	src.health = Health{LastRead: now, LastRecord: now}
+	w.MaxNotTerminated = 2
+	if alerts := w.Check(); len(alerts) != 0 {
+		t.Errorf("unexpected alerts %v", alerts)
+	}
+}
+
+func TestWatchdogRun(t *testing.T) {
+	src := &healthSource{} // nothing read yet
+	var alerts []Alert
+	w := &Watchdog{Source: src, StallAfter: time.Millisecond, Interval: 5 * time.Millisecond,
+		Alert: func(a Alert) { alerts = append(alerts, a) }}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	w.Run(ctx)
+	if len(alerts) != 1 || alerts[0].Kind != AlertStalled {
+		t.Errorf("expected a single stall alert, got %v", alerts)
+	}
+}